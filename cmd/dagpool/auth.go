@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/filedag-project/filedag-storage/dag/pool/client"
 	"github.com/filedag-project/filedag-storage/dag/pool/poolservice/dpuser/upolicy"
+	"github.com/filedag-project/filedag-storage/dag/utils/rpcauth"
 	"github.com/urfave/cli/v2"
 	"golang.org/x/xerrors"
 )
@@ -50,7 +51,7 @@ var createUser = &cli.Command{
 		},
 		&cli.Uint64Flag{
 			Name:  "capacity",
-			Usage: "set the capacity",
+			Usage: "set the max bytes stored by the user, 0 is unlimited",
 		},
 		&cli.StringFlag{
 			Name:  "policy",
@@ -80,7 +81,11 @@ var createUser = &cli.Command{
 		if !upolicy.CheckValid(policy) {
 			return xerrors.Errorf("the policy is invalid")
 		}
-		poolClient, err := client.NewPoolClient(addr, rootUser, rootPassword, false)
+		opts, err := rpcauth.ConfigFromCLI(cctx).DialOptions()
+		if err != nil {
+			return err
+		}
+		poolClient, err := client.NewPoolClient(addr, rootUser, rootPassword, false, opts...)
 		if err != nil {
 			log.Errorf("NewPoolClient err:%v", err)
 			return err
@@ -132,7 +137,11 @@ var queryUser = &cli.Command{
 			return xerrors.Errorf("you must give the username")
 		}
 
-		poolClient, err := client.NewPoolClient(addr, rootUser, rootPassword, false)
+		opts, err := rpcauth.ConfigFromCLI(cctx).DialOptions()
+		if err != nil {
+			return err
+		}
+		poolClient, err := client.NewPoolClient(addr, rootUser, rootPassword, false, opts...)
 		if err != nil {
 			log.Errorf("NewPoolClient err:%v", err)
 			return err
@@ -142,7 +151,7 @@ var queryUser = &cli.Command{
 			log.Errorf("get user err:%v", err)
 			return err
 		}
-		fmt.Printf("username:%v policy:%v capacity:%v\n", reply.Username, reply.Policy, reply.Capacity)
+		fmt.Printf("username:%v policy:%v capacity:%v used:%v\n", reply.Username, reply.Policy, reply.Capacity, reply.Used)
 		return nil
 	},
 }
@@ -208,7 +217,11 @@ var updateUser = &cli.Command{
 			return xerrors.Errorf("the policy is invalid")
 		}
 
-		poolClient, err := client.NewPoolClient(addr, rootUser, rootPassword, false)
+		opts, err := rpcauth.ConfigFromCLI(cctx).DialOptions()
+		if err != nil {
+			return err
+		}
+		poolClient, err := client.NewPoolClient(addr, rootUser, rootPassword, false, opts...)
 		if err != nil {
 			log.Errorf("NewPoolClient err:%v", err)
 			return err
@@ -260,7 +273,11 @@ var removeUser = &cli.Command{
 			return xerrors.Errorf("you must give the username")
 		}
 
-		poolClient, err := client.NewPoolClient(addr, rootUser, rootPassword, false)
+		opts, err := rpcauth.ConfigFromCLI(cctx).DialOptions()
+		if err != nil {
+			return err
+		}
+		poolClient, err := client.NewPoolClient(addr, rootUser, rootPassword, false, opts...)
 		if err != nil {
 			log.Errorf("NewPoolClient err:%v", err)
 			return err
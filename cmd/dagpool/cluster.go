@@ -4,14 +4,18 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/dustin/go-humanize"
 	"github.com/filedag-project/filedag-storage/dag/config"
 	"github.com/filedag-project/filedag-storage/dag/pool/client"
+	"github.com/filedag-project/filedag-storage/dag/proto"
 	"github.com/filedag-project/filedag-storage/dag/slotsmgr"
 	"github.com/filedag-project/filedag-storage/dag/utils"
+	"github.com/filedag-project/filedag-storage/dag/utils/rpcauth"
 	"github.com/urfave/cli/v2"
 	"io/ioutil"
 	"strconv"
 	"strings"
+	"time"
 )
 
 var clusterCmd = &cli.Command{
@@ -23,8 +27,13 @@ var clusterCmd = &cli.Command{
 		getDagNode,
 		removeDagNode,
 		balanceSlots,
+		rebalance,
 		migrateSlots,
+		migration,
+		restripe,
 		repair,
+		replace,
+		repairTasks,
 	},
 }
 
@@ -45,7 +54,11 @@ var status = &cli.Command{
 	Action: func(cctx *cli.Context) error {
 		addr := cctx.String("address")
 
-		cli, err := client.NewPoolClusterClient(addr)
+		opts, err := rpcauth.ConfigFromCLI(cctx).DialOptions()
+		if err != nil {
+			return err
+		}
+		cli, err := client.NewPoolClusterClient(addr, opts...)
 		if err != nil {
 			return err
 		}
@@ -55,8 +68,22 @@ var status = &cli.Command{
 			return err
 		}
 
-		fmt.Printf("cluster_state: %s\ncluster_dagnodes: %d\ncluster_dagnodes_info:\n",
-			reply.State, len(reply.Statuses))
+		detail := cctx.Bool("detail")
+		fmt.Printf("cluster_state: %s\ncluster_dagnodes: %d\n", reply.State, len(reply.Statuses))
+		if reply.Description != "" {
+			fmt.Printf("cluster_description: %s\n", reply.Description)
+		}
+		if rb := reply.Rebalance; rb != nil && rb.Enabled {
+			fmt.Printf("rebalance: paused: %v, pending: %v\n", rb.Paused, rb.Pending)
+			if rb.LastError != "" {
+				fmt.Printf("rebalance_last_error: %s\n", rb.LastError)
+			}
+		}
+		if bc := reply.BlockCache; bc != nil {
+			fmt.Printf("block_cache: memory_hits: %d, disk_hits: %d, misses: %d, memory_blocks: %d, disk_blocks: %d, disk_bytes: %d\n",
+				bc.MemoryHits, bc.DiskHits, bc.Misses, bc.MemoryBlocks, bc.DiskBlocks, bc.DiskBytes)
+		}
+		fmt.Printf("cluster_dagnodes_info:\n")
 
 		for _, status := range reply.Statuses {
 			pairs := utils.ToSlotPairs(status.Pairs)
@@ -71,7 +98,17 @@ var status = &cli.Command{
 			}
 			fmt.Printf("  name: %s\n  slots: %s (%d slots)\n",
 				status.Node.Name, slotsInfo, slots)
-			if cctx.Bool("detail") {
+			if detail {
+				keys := "counting"
+				if reply.KeysCountedAt != 0 {
+					keys = strconv.FormatUint(status.Keys, 10)
+				}
+				repairQueue := "unknown"
+				if status.RepairQueue >= 0 {
+					repairQueue = strconv.FormatInt(status.RepairQueue, 10)
+				}
+				fmt.Printf("  keys: %s\n  repair_queue: %s\n", keys, repairQueue)
+				fmt.Printf("  weight: %d\n  expected_slots: %d\n  draining: %v\n", status.Weight, status.ExpectedSlots, status.Draining)
 				fmt.Printf("  erasure_set:\n    nodes:\n")
 				for idx, nd := range status.Node.Nodes {
 					st := "fail"
@@ -79,16 +116,65 @@ var status = &cli.Command{
 						st = "ok"
 					}
 					fmt.Printf("      set_index: %d, rpc_address: %s, state: %s\n", idx, nd.RpcAddress, st)
+					if idx >= len(status.Health) {
+						continue
+					}
+					h := status.Health[idx]
+					fmt.Printf("        last_heartbeat: %s, keys: %d, read_only: %v, rebuilding: %v\n",
+						formatUnixTime(h.LastHeartbeat), h.Keys, h.ReadOnly, h.Rebuilding)
+					if h.LastError != "" {
+						fmt.Printf("        last_error: %s (%s)\n", h.LastError, formatUnixTime(h.LastErrorAt))
+					}
 				}
 				fmt.Printf("    data_blocks: %d\n    parity_blocks: %d\n",
 					status.Node.DataBlocks, status.Node.ParityBlocks)
 			}
 			fmt.Println()
 		}
+		if !detail {
+			return nil
+		}
+
+		fmt.Printf("cluster_migrations:\n")
+		for _, m := range reply.Migrations {
+			pair := utils.ToSlotPairs([]*proto.SlotPair{m.Pair})[0]
+			fmt.Printf("  from: %s, to: %s, slots: [%s], done_slots: %d/%d, migrated_keys: %d, failed_keys: %d\n",
+				m.FromDagNode, m.ToDagNode, pair, m.DoneSlots, pair.Count(), m.MigratedKeys, m.FailedKeys)
+		}
+		if mc := reply.MigrationControl; mc != nil {
+			bytesRate, keysRate := "unlimited", "unlimited"
+			if mc.BytesPerSecond != 0 {
+				bytesRate = humanize.IBytes(mc.BytesPerSecond) + "/s"
+			}
+			if mc.KeysPerSecond != 0 {
+				keysRate = fmt.Sprintf("%d keys/s", mc.KeysPerSecond)
+			}
+			fmt.Printf("cluster_migration_control:\n  paused: %v\n  aborting: %v\n  bytes_rate: %s\n  keys_rate: %s\n",
+				mc.Paused, mc.Aborting, bytesRate, keysRate)
+		}
+		if gc := reply.Gc; gc != nil {
+			fmt.Printf("cluster_gc:\n  running: %v\n  last_start: %s\n  last_finish: %s\n  checked: %d, removed: %d, failed: %d\n",
+				gc.Running, formatUnixTime(gc.LastStart), formatUnixTime(gc.LastFinish), gc.Checked, gc.Removed, gc.Failed)
+			if gc.LastError != "" {
+				fmt.Printf("  last_error: %s\n", gc.LastError)
+			}
+		}
+		fmt.Printf("cluster_state_transitions:\n")
+		for _, t := range reply.Transitions {
+			fmt.Printf("  %s: %s -> %s\n", formatUnixTime(t.Time), t.From, t.To)
+		}
 		return nil
 	},
 }
 
+// formatUnixTime formats the unix time in seconds reported by the dag pool, 0 means never
+func formatUnixTime(sec int64) string {
+	if sec == 0 {
+		return "never"
+	}
+	return time.Unix(sec, 0).Format(time.RFC3339)
+}
+
 var addDagNode = &cli.Command{
 	Name:      "add",
 	Usage:     "Add a dagnode to the dag pool cluster",
@@ -114,7 +200,11 @@ var addDagNode = &cli.Command{
 			return errors.New("at least one dagnode configuration file path is required")
 		}
 
-		cli, err := client.NewPoolClusterClient(addr)
+		opts, err := rpcauth.ConfigFromCLI(cctx).DialOptions()
+		if err != nil {
+			return err
+		}
+		cli, err := client.NewPoolClusterClient(addr, opts...)
 		if err != nil {
 			return err
 		}
@@ -163,7 +253,11 @@ var getDagNode = &cli.Command{
 			return errors.New("a dagnode name is required")
 		}
 
-		cli, err := client.NewPoolClusterClient(addr)
+		opts, err := rpcauth.ConfigFromCLI(cctx).DialOptions()
+		if err != nil {
+			return err
+		}
+		cli, err := client.NewPoolClusterClient(addr, opts...)
 		if err != nil {
 			return err
 		}
@@ -202,7 +296,11 @@ var removeDagNode = &cli.Command{
 			return errors.New("a dagnode name is required")
 		}
 
-		cli, err := client.NewPoolClusterClient(addr)
+		opts, err := rpcauth.ConfigFromCLI(cctx).DialOptions()
+		if err != nil {
+			return err
+		}
+		cli, err := client.NewPoolClusterClient(addr, opts...)
 		if err != nil {
 			return err
 		}
@@ -212,6 +310,11 @@ var removeDagNode = &cli.Command{
 		if err != nil {
 			return err
 		}
+		if _, err = cli.GetDagNode(cctx.Context, dagnode.Name); err == nil {
+			// the automatic rebalancing removes the dagnode after migrating its slots
+			fmt.Printf("the dagnode is draining, it will be removed when its slots are migrated\n")
+			return nil
+		}
 
 		fmt.Printf("the dagnode is removed successfully\n")
 		fmt.Printf("removed_dagnode_name: %s\n", dagnode.Name)
@@ -238,7 +341,11 @@ var balanceSlots = &cli.Command{
 	Action: func(cctx *cli.Context) error {
 		addr := cctx.String("address")
 
-		cli, err := client.NewPoolClusterClient(addr)
+		opts, err := rpcauth.ConfigFromCLI(cctx).DialOptions()
+		if err != nil {
+			return err
+		}
+		cli, err := client.NewPoolClusterClient(addr, opts...)
 		if err != nil {
 			return err
 		}
@@ -247,6 +354,195 @@ var balanceSlots = &cli.Command{
 	},
 }
 
+var rebalance = &cli.Command{
+	Name:  "rebalance",
+	Usage: "Pause or resume the automatic rebalancing and the data migration of the slots",
+	Subcommands: []*cli.Command{
+		pauseRebalance,
+		resumeRebalance,
+	},
+}
+
+var pauseRebalance = &cli.Command{
+	Name:  "pause",
+	Usage: "Pause the automatic rebalancing and the data migration of the slots",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "address",
+			Usage: "the address of dagpool server",
+			Value: "127.0.0.1:50001",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		addr := cctx.String("address")
+
+		opts, err := rpcauth.ConfigFromCLI(cctx).DialOptions()
+		if err != nil {
+			return err
+		}
+		cli, err := client.NewPoolClusterClient(addr, opts...)
+		if err != nil {
+			return err
+		}
+		defer cli.Close(cctx.Context)
+		return cli.PauseRebalance(cctx.Context)
+	},
+}
+
+var resumeRebalance = &cli.Command{
+	Name:  "resume",
+	Usage: "Resume the automatic rebalancing and the data migration of the slots",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "address",
+			Usage: "the address of dagpool server",
+			Value: "127.0.0.1:50001",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		addr := cctx.String("address")
+
+		opts, err := rpcauth.ConfigFromCLI(cctx).DialOptions()
+		if err != nil {
+			return err
+		}
+		cli, err := client.NewPoolClusterClient(addr, opts...)
+		if err != nil {
+			return err
+		}
+		defer cli.Close(cctx.Context)
+		return cli.ResumeRebalance(cctx.Context)
+	},
+}
+
+var migration = &cli.Command{
+	Name:  "migration",
+	Usage: "Throttle, pause, resume or abort the data migration of the slots",
+	Subcommands: []*cli.Command{
+		migrationRate,
+		pauseMigration,
+		resumeMigration,
+		abortMigration,
+	},
+}
+
+var migrationRate = &cli.Command{
+	Name:  "rate",
+	Usage: "Limit the data migration of the slots, 0 is unlimited",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "address",
+			Usage: "the address of dagpool server",
+			Value: "127.0.0.1:50001",
+		},
+		&cli.StringFlag{
+			Name:  "bytes",
+			Usage: "the max bytes migrated per second, such as 50MiB",
+			Value: "0",
+		},
+		&cli.Uint64Flag{
+			Name:  "keys",
+			Usage: "the max keys migrated per second",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		addr := cctx.String("address")
+		bytes, err := humanize.ParseBytes(cctx.String("bytes"))
+		if err != nil {
+			return err
+		}
+
+		opts, err := rpcauth.ConfigFromCLI(cctx).DialOptions()
+		if err != nil {
+			return err
+		}
+		cli, err := client.NewPoolClusterClient(addr, opts...)
+		if err != nil {
+			return err
+		}
+		defer cli.Close(cctx.Context)
+		return cli.SetMigrationRate(cctx.Context, bytes, cctx.Uint64("keys"))
+	},
+}
+
+var pauseMigration = &cli.Command{
+	Name:  "pause",
+	Usage: "Pause the data migration of the slots",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "address",
+			Usage: "the address of dagpool server",
+			Value: "127.0.0.1:50001",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		addr := cctx.String("address")
+
+		opts, err := rpcauth.ConfigFromCLI(cctx).DialOptions()
+		if err != nil {
+			return err
+		}
+		cli, err := client.NewPoolClusterClient(addr, opts...)
+		if err != nil {
+			return err
+		}
+		defer cli.Close(cctx.Context)
+		return cli.PauseMigration(cctx.Context)
+	},
+}
+
+var resumeMigration = &cli.Command{
+	Name:  "resume",
+	Usage: "Resume the data migration of the slots",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "address",
+			Usage: "the address of dagpool server",
+			Value: "127.0.0.1:50001",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		addr := cctx.String("address")
+
+		opts, err := rpcauth.ConfigFromCLI(cctx).DialOptions()
+		if err != nil {
+			return err
+		}
+		cli, err := client.NewPoolClusterClient(addr, opts...)
+		if err != nil {
+			return err
+		}
+		defer cli.Close(cctx.Context)
+		return cli.ResumeMigration(cctx.Context)
+	},
+}
+
+var abortMigration = &cli.Command{
+	Name:  "abort",
+	Usage: "Abort the migration, the migrating slots and their keys are migrated back to the source dagnodes",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "address",
+			Usage: "the address of dagpool server",
+			Value: "127.0.0.1:50001",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		addr := cctx.String("address")
+
+		opts, err := rpcauth.ConfigFromCLI(cctx).DialOptions()
+		if err != nil {
+			return err
+		}
+		cli, err := client.NewPoolClusterClient(addr, opts...)
+		if err != nil {
+			return err
+		}
+		defer cli.Close(cctx.Context)
+		return cli.AbortMigration(cctx.Context)
+	},
+}
+
 var migrateSlots = &cli.Command{
 	Name:      "migrate",
 	Usage:     "Migrate slots from a dagnode to another dagnode",
@@ -300,7 +596,11 @@ var migrateSlots = &cli.Command{
 			}
 		}
 
-		cli, err := client.NewPoolClusterClient(addr)
+		opts, err := rpcauth.ConfigFromCLI(cctx).DialOptions()
+		if err != nil {
+			return err
+		}
+		cli, err := client.NewPoolClusterClient(addr, opts...)
 		if err != nil {
 			return err
 		}
@@ -319,6 +619,10 @@ var repair = &cli.Command{
 			Usage: "the address of dagpool server",
 			Value: "127.0.0.1:50001",
 		},
+		&cli.StringFlag{
+			Name:  "start-after",
+			Usage: "resume an interrupted repair after the key reported by its error",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
 		addr := cctx.String("address")
@@ -339,12 +643,196 @@ var repair = &cli.Command{
 			return err
 		}
 
-		cli, err := client.NewPoolClusterClient(addr)
+		opts, err := rpcauth.ConfigFromCLI(cctx).DialOptions()
+		if err != nil {
+			return err
+		}
+		cli, err := client.NewPoolClusterClient(addr, opts...)
+		if err != nil {
+			return err
+		}
+		defer cli.Close(cctx.Context)
+
+		return cli.RepairDataNode(cctx.Context, dagNodeName, int(fromIndex), int(repairIndex), cctx.String("start-after"))
+	},
+}
+
+var restripe = &cli.Command{
+	Name:      "restripe",
+	Usage:     "Re-encode all the data of a dagnode into a new dagnode with another erasure layout, 1+N is N+1 replicas",
+	ArgsUsage: "from_dagnode_name new_dagnode_config_path",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "address",
+			Usage: "the address of dagpool server",
+			Value: "127.0.0.1:50001",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		addr := cctx.String("address")
+		if cctx.NArg() < 2 {
+			return errors.New("at least input two parameters")
+		}
+		fromDagNodeName := cctx.Args().Get(0)
+		var nc config.DagNodeConfig
+		cfgBytes, err := ioutil.ReadFile(cctx.Args().Get(1))
+		if err != nil {
+			return err
+		}
+		if err = json.Unmarshal(cfgBytes, &nc); err != nil {
+			return err
+		}
+
+		opts, err := rpcauth.ConfigFromCLI(cctx).DialOptions()
+		if err != nil {
+			return err
+		}
+		cli, err := client.NewPoolClusterClient(addr, opts...)
+		if err != nil {
+			return err
+		}
+		defer cli.Close(cctx.Context)
+
+		if err = cli.RestripeDagNode(cctx.Context, fromDagNodeName, &nc); err != nil {
+			return err
+		}
+		fmt.Printf("restriping %s to %s (%d+%d) in the background, %s will be removed when finished\n",
+			fromDagNodeName, nc.Name, nc.DataBlocks, nc.ParityBlocks, fromDagNodeName)
+		return nil
+	},
+}
+
+var replace = &cli.Command{
+	Name:      "replace",
+	Usage:     "Replace a datanode of the dagnode with a new one, the shards are rebuilt in the background",
+	ArgsUsage: "dagnode_name node_index new_rpc_address",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "address",
+			Usage: "the address of dagpool server",
+			Value: "127.0.0.1:50001",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		addr := cctx.String("address")
+		if cctx.NArg() < 3 {
+			return errors.New("at least input three parameters")
+		}
+		dagNodeName := cctx.Args().Get(0)
+		index, err := strconv.ParseInt(cctx.Args().Get(1), 10, 32)
+		if err != nil {
+			return err
+		}
+		rpcAddress := cctx.Args().Get(2)
+
+		opts, err := rpcauth.ConfigFromCLI(cctx).DialOptions()
+		if err != nil {
+			return err
+		}
+		cli, err := client.NewPoolClusterClient(addr, opts...)
+		if err != nil {
+			return err
+		}
+		defer cli.Close(cctx.Context)
+
+		if err = cli.ReplaceDataNode(cctx.Context, dagNodeName, int(index), rpcAddress); err != nil {
+			return err
+		}
+		fmt.Printf("the datanode %d of %s is replaced with %s, rebuilding in the background\n", index, dagNodeName, rpcAddress)
+		return nil
+	},
+}
+
+var repairTasks = &cli.Command{
+	Name:  "repair-tasks",
+	Usage: "Inspect and drain the pending block repair tasks",
+	Subcommands: []*cli.Command{
+		listRepairTasks,
+		drainRepairTasks,
+	},
+}
+
+var listRepairTasks = &cli.Command{
+	Name:      "list",
+	Usage:     "List the pending repair tasks, the tasks which lost more shards come first",
+	ArgsUsage: "[dagnode_name]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "address",
+			Usage: "the address of dagpool server",
+			Value: "127.0.0.1:50001",
+		},
+		&cli.IntFlag{
+			Name:  "limit",
+			Usage: "the max number of listed tasks, 0 means no limit",
+			Value: 100,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		addr := cctx.String("address")
+		opts, err := rpcauth.ConfigFromCLI(cctx).DialOptions()
+		if err != nil {
+			return err
+		}
+		cli, err := client.NewPoolClusterClient(addr, opts...)
+		if err != nil {
+			return err
+		}
+		defer cli.Close(cctx.Context)
+
+		reply, err := cli.ListRepairTasks(cctx.Context, cctx.Args().First(), cctx.Int("limit"))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("total: %d\n", reply.Total)
+		for _, task := range reply.Tasks {
+			next := "now"
+			if task.NextAttempt > 0 {
+				next = time.Unix(task.NextAttempt, 0).Format(time.RFC3339)
+			}
+			fmt.Printf("  dagnode: %s, key: %s, lost_shards: %d, attempts: %d, next_attempt: %s",
+				task.DagNodeName, task.Key, task.LostShards, task.Attempts, next)
+			if task.LastError != "" {
+				fmt.Printf(", last_error: %s", task.LastError)
+			}
+			fmt.Println()
+		}
+		return nil
+	},
+}
+
+var drainRepairTasks = &cli.Command{
+	Name:      "drain",
+	Usage:     "Run all the pending repair tasks at once, ignoring the retry backoff",
+	ArgsUsage: "[dagnode_name]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "address",
+			Usage: "the address of dagpool server",
+			Value: "127.0.0.1:50001",
+		},
+		&cli.BoolFlag{
+			Name:  "discard",
+			Usage: "remove the tasks instead of repairing them",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		addr := cctx.String("address")
+		opts, err := rpcauth.ConfigFromCLI(cctx).DialOptions()
+		if err != nil {
+			return err
+		}
+		cli, err := client.NewPoolClusterClient(addr, opts...)
 		if err != nil {
 			return err
 		}
 		defer cli.Close(cctx.Context)
 
-		return cli.RepairDataNode(cctx.Context, dagNodeName, int(fromIndex), int(repairIndex))
+		reply, err := cli.DrainRepairTasks(cctx.Context, cctx.Args().First(), cctx.Bool("discard"))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("repaired: %d failed: %d discarded: %d\n", reply.Repaired, reply.Failed, reply.Discarded)
+		return nil
 	},
 }
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"github.com/filedag-project/filedag-storage/dag/utils/rpcauth"
 	"github.com/filedag-project/filedag-storage/objectservice/utils"
 	"github.com/urfave/cli/v2"
 	"os"
@@ -14,6 +15,10 @@ func main() {
 		authCmd,
 		clusterCmd,
 	}
+	// the clients of the auth and cluster commands connect the dagpool server with the same security config
+	for _, cmd := range append(authCmd.Subcommands, clusterCmd.Subcommands...) {
+		cmd.Flags = append(cmd.Flags, rpcauth.Flags()...)
+	}
 	app := &cli.App{
 		Name:                 "dagpool",
 		Usage:                "dag pool cluster",
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/filedag-project/filedag-storage/dag/config"
+	"github.com/filedag-project/filedag-storage/dag/pool/ha"
+	"github.com/filedag-project/filedag-storage/dag/pool/poolservice"
+	"github.com/filedag-project/filedag-storage/dag/pool/server"
+	"github.com/filedag-project/filedag-storage/dag/proto"
+	"google.golang.org/grpc"
+)
+
+// gracefulStopTimeout is how long a deposed leader waits for the running requests before it stops serving
+const gracefulStopTimeout = 10 * time.Second
+
+// leaderServer is the dag pool server run by the leader replica
+type leaderServer struct {
+	s       *grpc.Server
+	service interface{ Close() error }
+	cancel  context.CancelFunc
+}
+
+func startLeaderServer(ctx context.Context, cfg config.PoolConfig, node *ha.Node, opts []grpc.ServerOption) (*leaderServer, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	service, err := poolservice.NewDagPoolServiceWithDB(ctx, cfg, node.DB())
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	lis, err := net.Listen("tcp", cfg.Listen)
+	if err != nil {
+		cancel()
+		service.Close()
+		return nil, err
+	}
+	s := grpc.NewServer(opts...)
+	proto.RegisterDagPoolServer(s, &server.DagPoolServer{DagPool: service})
+	proto.RegisterDagPoolClusterServer(s, &server.DagPoolClusterServer{Cluster: service})
+	go func() {
+		if err := s.Serve(lis); err != nil {
+			log.Errorf("failed to serve: %v", err)
+		}
+	}()
+	go service.GC(ctx)
+	return &leaderServer{s: s, service: service, cancel: cancel}, nil
+}
+
+// stop cancels the background tasks of the service first, so that they stop writing as soon as
+// the replica is deposed, then it waits for the running requests and closes the service
+func (l *leaderServer) stop() {
+	l.cancel()
+	stopped := make(chan struct{})
+	go func() {
+		l.s.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(gracefulStopTimeout):
+		l.s.Stop()
+	}
+	l.service.Close()
+}
+
+// startDagPoolReplica runs the dag pool as one of the replicas, the dag pool server is only run while
+// the replica is the leader, so the clients fail over to the new leader
+func startDagPoolReplica(ctx context.Context, cfg config.PoolConfig) {
+	log.Infof("dagpool replica %s start...", cfg.HA.ID)
+	log.Infof("replication listen %s", cfg.HA.Listen)
+	opts, err := cfg.Security.ServerOptions()
+	if err != nil {
+		log.Fatalf("failed to load security config: %v", err)
+	}
+	dialOpts, err := cfg.Security.DialOptions()
+	if err != nil {
+		log.Fatalf("failed to load security config: %v", err)
+	}
+	node, err := ha.NewNode(cfg.HA, cfg.LeveldbPath, dialOpts...)
+	if err != nil {
+		log.Fatalf("NewNode err:%v", err)
+	}
+	defer node.Close()
+
+	lis, err := net.Listen("tcp", cfg.HA.Listen)
+	if err != nil {
+		log.Fatalf("failed to listen: %v", err)
+	}
+	rs := grpc.NewServer(opts...)
+	node.Register(rs)
+	go func() {
+		if err := rs.Serve(lis); err != nil {
+			log.Fatalf("failed to serve: %v", err)
+		}
+	}()
+	if err = node.Start(); err != nil {
+		log.Fatalf("failed to start the replica: %v", err)
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	// the role is checked from time to time as well, in case the dag pool server failed to start
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	var leading *leaderServer
+	for {
+		select {
+		case <-quit:
+			log.Info("Shutdown Server ...")
+			if leading != nil {
+				leading.stop()
+			}
+			rs.GracefulStop()
+			log.Info("Server exit")
+			return
+		case <-node.Notify():
+		case <-ticker.C:
+		}
+		isLeader := node.IsLeader()
+		if isLeader && leading == nil {
+			log.Infof("replica %s is the leader, listen %s", cfg.HA.ID, cfg.Listen)
+			if leading, err = startLeaderServer(ctx, cfg, node, opts); err != nil {
+				log.Errorf("start dag pool server error: %v", err)
+			}
+		} else if !isLeader && leading != nil {
+			log.Infof("replica %s is no longer the leader, stop the dag pool server", cfg.HA.ID)
+			leading.stop()
+			leading = nil
+		}
+	}
+}
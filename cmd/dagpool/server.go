@@ -3,10 +3,12 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/filedag-project/filedag-storage/dag/config"
 	"github.com/filedag-project/filedag-storage/dag/pool/poolservice"
 	"github.com/filedag-project/filedag-storage/dag/pool/server"
 	"github.com/filedag-project/filedag-storage/dag/proto"
+	"github.com/filedag-project/filedag-storage/dag/utils/rpcauth"
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/urfave/cli/v2"
 	"google.golang.org/grpc"
@@ -14,6 +16,7 @@ import (
 	"os"
 	"os/signal"
 	"path"
+	"strings"
 	"syscall"
 	"time"
 )
@@ -27,7 +30,7 @@ var log = logging.Logger("pool-main")
 var startCmd = &cli.Command{
 	Name:  "daemon",
 	Usage: "Start a dag pool process",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.StringFlag{
 			Name:  "listen",
 			Usage: "set server listen",
@@ -55,7 +58,63 @@ var startCmd = &cli.Command{
 			Usage: "set GC period, such as 1.5h or 2h45m",
 			Value: "1h",
 		},
-	},
+		&cli.IntFlag{
+			Name:  "scrub-rate",
+			Usage: "set the max number of blocks scrubbed per second by each dag node, 0 disables the scrubber",
+			Value: 10,
+		},
+		&cli.StringFlag{
+			Name:  "scrub-interval",
+			Usage: "set the interval between two scrub rounds, such as 12h or 24h",
+			Value: "24h",
+		},
+		&cli.StringFlag{
+			Name:  "cache-policy",
+			Usage: "set the eviction policy of the memory block cache, lru or arc",
+			Value: "lru",
+		},
+		&cli.IntFlag{
+			Name:  "cache-blocks",
+			Usage: "set the max number of blocks cached in memory, 0 disables the memory block cache",
+			Value: 1024,
+		},
+		&cli.IntFlag{
+			Name:  "cache-max-block-size",
+			Usage: "set the max size of the blocks to cache, 0 means no limit",
+			Value: 1 << 20,
+		},
+		&cli.StringFlag{
+			Name:  "cache-disk-path",
+			Usage: "set the directory of the disk block cache, such as a local ssd, empty disables the disk block cache",
+		},
+		&cli.Int64Flag{
+			Name:  "cache-disk-bytes",
+			Usage: "set the max total size of the blocks cached on the disk",
+			Value: 10 << 30,
+		},
+		&cli.BoolFlag{
+			Name:  "auto-balance",
+			Usage: "rebalance the slots by the weights of the dagnodes once a dagnode is added, and drain the slots of a dagnode before removing it",
+		},
+		&cli.StringFlag{
+			Name:  "ha-id",
+			Usage: "set the id of this dag pool replica, empty disables the high availability",
+		},
+		&cli.StringFlag{
+			Name:  "ha-listen",
+			Usage: "set the listen address of the replication rpc",
+			Value: ":50101",
+		},
+		&cli.StringSliceFlag{
+			Name:  "ha-peers",
+			Usage: "set the replication rpc addresses of all the dag pool replicas including this one, such as r1=127.0.0.1:50101",
+		},
+		&cli.StringFlag{
+			Name:  "ha-election-timeout",
+			Usage: "set how long a replica waits for the leader before it starts an election",
+			Value: "1s",
+		},
+	}, rpcauth.Flags()...),
 	Action: func(cctx *cli.Context) error {
 		cfg, err := loadPoolConfig(cctx)
 		if err != nil {
@@ -67,6 +126,10 @@ var startCmd = &cli.Command{
 }
 
 func startDagPoolServer(ctx context.Context, cfg config.PoolConfig) {
+	if cfg.HA.ID != "" {
+		startDagPoolReplica(ctx, cfg)
+		return
+	}
 	log.Infof("dagpool start...")
 	log.Infof("listen %s", cfg.Listen)
 	// listen port
@@ -75,7 +138,11 @@ func startDagPoolServer(ctx context.Context, cfg config.PoolConfig) {
 		log.Fatalf("failed to listen: %v", err)
 	}
 	// new server
-	s := grpc.NewServer()
+	opts, err := cfg.Security.ServerOptions()
+	if err != nil {
+		log.Fatalf("failed to load security config: %v", err)
+	}
+	s := grpc.NewServer(opts...)
 	service, err := poolservice.NewDagPoolService(ctx, cfg)
 	if err != nil {
 		log.Fatalf("NewDagPoolService err:%v", err)
@@ -126,5 +193,42 @@ func loadPoolConfig(cctx *cli.Context) (config.PoolConfig, error) {
 		return config.PoolConfig{}, err
 	}
 	cfg.GcPeriod = gcPer
+	cfg.ScrubRate = cctx.Int("scrub-rate")
+	scrubInterval, err := time.ParseDuration(cctx.String("scrub-interval"))
+	if err != nil {
+		return config.PoolConfig{}, err
+	}
+	cfg.ScrubInterval = scrubInterval
+	cfg.BlockCache = config.BlockCacheConfig{
+		Policy:       cctx.String("cache-policy"),
+		MemoryBlocks: cctx.Int("cache-blocks"),
+		MaxBlockSize: cctx.Int("cache-max-block-size"),
+		DiskPath:     cctx.String("cache-disk-path"),
+		DiskBytes:    cctx.Int64("cache-disk-bytes"),
+	}
+	cfg.Security = rpcauth.ConfigFromCLI(cctx)
+	cfg.AutoBalance = cctx.Bool("auto-balance")
+	if id := cctx.String("ha-id"); id != "" {
+		electionTimeout, err := time.ParseDuration(cctx.String("ha-election-timeout"))
+		if err != nil {
+			return config.PoolConfig{}, err
+		}
+		cfg.HA = config.HAConfig{
+			ID:              id,
+			Listen:          cctx.String("ha-listen"),
+			Peers:           make(map[string]string),
+			ElectionTimeout: electionTimeout,
+		}
+		for _, peer := range cctx.StringSlice("ha-peers") {
+			kv := strings.SplitN(peer, "=", 2)
+			if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+				return config.PoolConfig{}, fmt.Errorf("invalid dag pool replica %q, it should be id=address", peer)
+			}
+			cfg.HA.Peers[kv[0]] = kv[1]
+		}
+		if _, ok := cfg.HA.Peers[id]; !ok {
+			return config.PoolConfig{}, fmt.Errorf("dag pool replica %s is missing in ha-peers", id)
+		}
+	}
 	return cfg, nil
 }
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/filedag-project/filedag-storage/dag/node/datanode"
+	"github.com/filedag-project/filedag-storage/dag/utils/rpcauth"
 	"github.com/filedag-project/filedag-storage/objectservice/utils"
 	"github.com/urfave/cli/v2"
 	"os"
@@ -13,6 +14,8 @@ func main() {
 	utils.SetupLogLevels()
 	local := []*cli.Command{
 		startCmd,
+		verifyCmd,
+		migrateCmd,
 	}
 	app := &cli.App{
 		Name:     "datanode",
@@ -30,32 +33,199 @@ func main() {
 var startCmd = &cli.Command{
 	Name:  "daemon",
 	Usage: "Start a data node process",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.StringFlag{
 			Name:  "listen",
 			Usage: "set server listen",
 			Value: ":9010",
 		},
-		&cli.StringFlag{
+		&cli.StringSliceFlag{
 			Name:  "datadir",
-			Usage: "directory to store data in",
-			Value: "./dn-data",
+			Usage: "directories to store data in, usually one per disk, the keys are placed across them by hash, so they must be given in the same order every time",
+			Value: cli.NewStringSlice("./dn-data"),
 		},
 		&cli.StringFlag{
 			Name:  "kvdb",
-			Usage: "choose kvdb, badger or mutcask",
+			Usage: "choose kvdb, badger, mutcask or diskv",
 			Value: "badger",
 		},
-	},
+		&cli.StringFlag{
+			Name:  "checksum",
+			Usage: "choose the checksum of the written entries, crc32c or xxhash64",
+			Value: "crc32c",
+		},
+		&cli.Float64Flag{
+			Name:  "high-water-mark",
+			Usage: "the disk usage ratio at which the data node turns read-only, 0 disables it",
+			Value: 0.95,
+		},
+	}, append(append(diskvFlags, mutcaskFlags...), rpcauth.Flags()...)...),
 	Action: func(c *cli.Context) error {
-		kvType := datanode.KVType(c.String("kvdb"))
-		switch kvType {
-		case datanode.KVBadge:
-		case datanode.KVMutcask:
-		default:
-			return errors.New(fmt.Sprintf("not support this kvdb %s", kvType))
+		kvType, err := parseKVType(c)
+		if err != nil {
+			return err
+		}
+		checksumType, err := datanode.ParseChecksumType(c.String("checksum"))
+		if err != nil {
+			return err
+		}
+		hwm := c.Float64("high-water-mark")
+		if hwm < 0 || hwm > 1 {
+			return errors.New("the high-water mark must be between 0 and 1")
 		}
-		datanode.StartDataNodeServer(c.String("listen"), kvType, c.String("datadir"))
+		datanode.StartDataNodeServer(datanode.ServerConfig{
+			Listen:        c.String("listen"),
+			KVType:        kvType,
+			KVOptions:     kvOptions(c),
+			DataDirs:      c.StringSlice("datadir"),
+			ChecksumType:  checksumType,
+			HighWaterMark: hwm,
+			Security:      rpcauth.ConfigFromCLI(c),
+		})
 		return nil
 	},
 }
+
+// diskvFlags are the options of the diskv kvdb, the verify and migrate commands must use the same ones as the daemon
+var diskvFlags = []cli.Flag{
+	&cli.IntFlag{
+		Name:  "diskv-shard-bytes",
+		Usage: "the bytes of the key from the tail naming each level of the diskv directories, 0 uses the default",
+	},
+	&cli.IntFlag{
+		Name:  "diskv-shard-levels",
+		Usage: "the levels of the diskv directories, 0 uses the default",
+	},
+	&cli.IntFlag{
+		Name:  "diskv-cache-size",
+		Usage: "the max number of values in the diskv read cache, 0 uses the default",
+	},
+	&cli.IntFlag{
+		Name:  "diskv-max-read",
+		Usage: "the max number of concurrent diskv reads, 0 uses the default",
+	},
+	&cli.IntFlag{
+		Name:  "diskv-max-write",
+		Usage: "the max number of concurrent diskv writes, 0 uses the default",
+	},
+}
+
+// mutcaskFlags are the options of the mutcask kvdb
+var mutcaskFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "mutcask-sync",
+		Usage: "when the mutcask writes are synced to the disk, always, interval or none, empty uses the default",
+	},
+	&cli.DurationFlag{
+		Name:  "mutcask-sync-interval",
+		Usage: "how often the mutcask writes are synced in interval mode, 0 uses the default",
+	},
+	&cli.StringFlag{
+		Name:  "mutcask-recover",
+		Usage: "how the mutcask casks are checked when opened, tail, full or rebuild, empty uses the default",
+	},
+	&cli.StringFlag{
+		Name:  "mutcask-index",
+		Usage: "where the mutcask keeps the hints of the keys, memory or disk to bound the memory used by large casks, empty uses the default",
+	},
+	&cli.IntFlag{
+		Name:  "mutcask-hot-keys",
+		Usage: "how many hints of each cask are cached in memory in disk index mode, 0 uses the default",
+	},
+}
+
+var dataFlags = append([]cli.Flag{
+	&cli.StringSliceFlag{
+		Name:  "datadir",
+		Usage: "directories to store data in, in the same order as the data node daemon",
+		Value: cli.NewStringSlice("./dn-data"),
+	},
+	&cli.StringFlag{
+		Name:  "kvdb",
+		Usage: "choose kvdb, badger, mutcask or diskv",
+		Value: "badger",
+	},
+}, append(diskvFlags, mutcaskFlags...)...)
+
+var verifyCmd = &cli.Command{
+	Name:  "verify",
+	Usage: "Verify the checksums of all the entries in the data directory, the data node must be stopped",
+	Flags: dataFlags,
+	Action: func(c *cli.Context) error {
+		return checkEntries(c, false, 0)
+	},
+}
+
+var migrateCmd = &cli.Command{
+	Name:  "migrate",
+	Usage: "Rewrite the legacy entries in the data directory with the new checksum, the data node must be stopped",
+	Flags: append([]cli.Flag{
+		&cli.StringFlag{
+			Name:  "checksum",
+			Usage: "choose the checksum of the rewritten entries, crc32c or xxhash64",
+			Value: "crc32c",
+		},
+	}, dataFlags...),
+	Action: func(c *cli.Context) error {
+		checksumType, err := datanode.ParseChecksumType(c.String("checksum"))
+		if err != nil {
+			return err
+		}
+		return checkEntries(c, true, checksumType)
+	},
+}
+
+func parseKVType(c *cli.Context) (datanode.KVType, error) {
+	kvType := datanode.KVType(c.String("kvdb"))
+	switch kvType {
+	case datanode.KVBadge:
+	case datanode.KVMutcask:
+	case datanode.KVDiskv:
+	default:
+		return "", errors.New(fmt.Sprintf("not support this kvdb %s", kvType))
+	}
+	return kvType, nil
+}
+
+func kvOptions(c *cli.Context) datanode.KVOptions {
+	return datanode.KVOptions{
+		Diskv: datanode.DiskvOptions{
+			ShardBytes:  c.Int("diskv-shard-bytes"),
+			ShardLevels: c.Int("diskv-shard-levels"),
+			CacheSize:   c.Int("diskv-cache-size"),
+			MaxRead:     c.Int("diskv-max-read"),
+			MaxWrite:    c.Int("diskv-max-write"),
+		},
+		Mutcask: datanode.MutcaskOptions{
+			SyncMode:     c.String("mutcask-sync"),
+			SyncInterval: c.Duration("mutcask-sync-interval"),
+			RecoverMode:  c.String("mutcask-recover"),
+			IndexMode:    c.String("mutcask-index"),
+			HotKeys:      c.Int("mutcask-hot-keys"),
+		},
+	}
+}
+
+func checkEntries(c *cli.Context, migrate bool, checksumType datanode.ChecksumType) error {
+	kvType, err := parseKVType(c)
+	if err != nil {
+		return err
+	}
+	kvdb, err := datanode.OpenDataDisks(kvType, c.StringSlice("datadir"), kvOptions(c))
+	if err != nil {
+		return err
+	}
+	defer kvdb.Close()
+
+	res, err := datanode.CheckEntries(c.Context, kvdb, checksumType, migrate, func(key string, err error) {
+		fmt.Printf("corrupted entry %s: %v\n", key, err)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("checked: %d, legacy: %d, corrupted: %d, migrated: %d\n", res.Total, res.Legacy, res.Corrupted, res.Migrated)
+	if res.Corrupted > 0 {
+		return fmt.Errorf("found %d corrupted entries", res.Corrupted)
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	dagpoolcli "github.com/filedag-project/filedag-storage/dag/pool/client"
+	"github.com/filedag-project/filedag-storage/dag/utils/rpcauth"
 	"github.com/filedag-project/filedag-storage/objectservice/iam"
 	"github.com/filedag-project/filedag-storage/objectservice/iamapi"
 	"github.com/filedag-project/filedag-storage/objectservice/objmetadb"
@@ -62,7 +63,11 @@ func startServer(cctx *cli.Context) {
 	}
 	defer db.Close()
 	router := mux.NewRouter()
-	poolClient, err := dagpoolcli.NewPoolClient(poolAddr, poolUser, poolPassword, true)
+	dialOpts, err := rpcauth.ConfigFromCLI(cctx).DialOptions()
+	if err != nil {
+		log.Fatalf("load security config err: %v", err)
+	}
+	poolClient, err := dagpoolcli.NewPoolClient(poolAddr, poolUser, poolPassword, true, dialOpts...)
 	if err != nil {
 		log.Fatalf("connect dagpool server err: %v", err)
 	}
@@ -147,7 +152,7 @@ func startServer(cctx *cli.Context) {
 var startCmd = &cli.Command{
 	Name:  "daemon",
 	Usage: "Start a filedag storage process",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.StringFlag{
 			Name:  "listen",
 			Usage: "set server listen",
@@ -182,7 +187,7 @@ var startCmd = &cli.Command{
 			EnvVars: []string{EnvRootPassword},
 			Value:   auth.DefaultSecretKey,
 		},
-	},
+	}, rpcauth.Flags()...),
 	Action: func(cctx *cli.Context) error {
 		startServer(cctx)
 		return nil
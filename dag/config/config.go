@@ -2,6 +2,7 @@ package config
 
 import (
 	"github.com/filedag-project/filedag-storage/dag/slotsmgr"
+	"github.com/filedag-project/filedag-storage/dag/utils/rpcauth"
 	"time"
 )
 
@@ -12,6 +13,46 @@ type PoolConfig struct {
 	RootUser     string        `json:"root_user"`
 	RootPassword string        `json:"root_password"`
 	GcPeriod     time.Duration `json:"gc_period"`
+	// ScrubRate is the max number of blocks scrubbed per second by each dag node, 0 disables the scrubber
+	ScrubRate int `json:"scrub_rate"`
+	// ScrubInterval is the interval between two scrub rounds
+	ScrubInterval time.Duration `json:"scrub_interval"`
+	// Security secures the dag pool server and the connections to the data nodes
+	Security rpcauth.Config `json:"security"`
+	// BlockCache caches the blocks read from the dag nodes
+	BlockCache BlockCacheConfig `json:"block_cache"`
+	// HA runs the dag pool as one of several replicas, only the elected leader serves the clients
+	HA HAConfig `json:"ha"`
+	// AutoBalance rebalances the slots by the weights of the dag nodes once a dag node is added,
+	// and drains the slots of a dag node before removing it
+	AutoBalance bool `json:"auto_balance"`
+}
+
+// HAConfig is the configuration for the replicas of the dag pool
+type HAConfig struct {
+	// ID is the id of this replica, HA is disabled if it is empty
+	ID string `json:"id,omitempty"`
+	// Listen is the address of the replication rpc
+	Listen string `json:"listen,omitempty"`
+	// Peers maps the ids of all the replicas, including this one, to their replication rpc addresses
+	Peers map[string]string `json:"peers,omitempty"`
+	// ElectionTimeout is how long a replica waits for the leader before it starts an election,
+	// the leader contacts the other replicas about ten times within it
+	ElectionTimeout time.Duration `json:"election_timeout,omitempty"`
+}
+
+// BlockCacheConfig is the configuration for the read-through block cache of the dag pool
+type BlockCacheConfig struct {
+	// Policy is the eviction policy of the memory cache, lru or arc
+	Policy string `json:"policy"`
+	// MemoryBlocks is the max number of blocks cached in memory, 0 disables the memory cache
+	MemoryBlocks int `json:"memory_blocks"`
+	// MaxBlockSize is the max size of the blocks to cache, 0 means no limit
+	MaxBlockSize int `json:"max_block_size"`
+	// DiskPath is the directory of the disk cache, empty disables the disk cache
+	DiskPath string `json:"disk_path,omitempty"`
+	// DiskBytes is the max total size of the blocks cached on the disk
+	DiskBytes int64 `json:"disk_bytes,omitempty"`
 }
 
 // ClusterConfig is the configuration for a cluster
@@ -24,8 +65,13 @@ type ClusterConfig struct {
 type DagNodeConfig struct {
 	Name         string   `json:"name"`
 	Nodes        []string `json:"nodes"`         // rpc address list of datanodes
-	DataBlocks   int      `json:"data_blocks"`   // Number of data shards
-	ParityBlocks int      `json:"parity_blocks"` // Number of parity shards
+	DataBlocks   int      `json:"data_blocks"`   // Number of data shards, 1 stores full replicas of the blocks
+	ParityBlocks int      `json:"parity_blocks"` // Number of parity shards, or the extra replicas with 1 data shard
+	// ReplicaThreshold is the max size of the blocks stored as full replicas instead of erasure-coded shards, 0 disables it
+	ReplicaThreshold int `json:"replica_threshold,omitempty"`
+	// Weight is the share of the slots owned by the dag node. If it is 0, the raw capacity of its data nodes in GiB
+	// is used when the automatic rebalancing is enabled, or else the dag nodes share the slots equally
+	Weight uint64 `json:"weight,omitempty"`
 }
 
 type DagNodeInfo struct {
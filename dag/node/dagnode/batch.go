@@ -0,0 +1,294 @@
+package dagnode
+
+import (
+	"context"
+	"errors"
+	"github.com/filedag-project/filedag-storage/dag/node/datanode"
+	"github.com/filedag-project/filedag-storage/dag/proto"
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sync"
+)
+
+const (
+	// maxBatchSize is the max size of the shards sent to or received from a data node in one batch request
+	maxBatchSize = 2 << 20
+	// maxBatchKeys is the max number of keys in one batch request
+	maxBatchKeys = 1024
+)
+
+type encodedBlock struct {
+	key    string
+	meta   []byte
+	shards [][]byte
+}
+
+// PutMany adds the given blocks to the DagNode.
+// The shards are sent to each data node in batches, and every block must meet the write quorum.
+func (d *DagNode) PutMany(ctx context.Context, blks []blocks.Block) error {
+	if err := d.checkWritable(); err != nil {
+		return err
+	}
+	var batch []*encodedBlock
+	batchSize := 0
+	for _, block := range blks {
+		meta, shards, err := d.encodeBlock(block)
+		if err != nil {
+			return err
+		}
+		shardSize := len(shards[0])
+		// the large shards are sent by stream
+		if shardSize > datanode.StreamChunkSize {
+			if err = d.Put(ctx, block); err != nil {
+				return err
+			}
+			continue
+		}
+		if len(batch) > 0 && (batchSize+shardSize > maxBatchSize || len(batch) >= maxBatchKeys) {
+			if err = d.putBatch(ctx, batch); err != nil {
+				return err
+			}
+			batch, batchSize = nil, 0
+		}
+		batch = append(batch, &encodedBlock{
+			key:    block.Cid().String(),
+			meta:   meta,
+			shards: shards,
+		})
+		batchSize += shardSize
+	}
+	if len(batch) > 0 {
+		return d.putBatch(ctx, batch)
+	}
+	return nil
+}
+
+func (d *DagNode) putBatch(ctx context.Context, batch []*encodedBlock) error {
+	nodes, release := d.acquireNodes()
+	defer release()
+	errs := make([][]error, len(batch))
+	for i := range errs {
+		errs[i] = make([]error, len(nodes))
+	}
+	var wg sync.WaitGroup
+	wg.Add(len(nodes))
+	for i, snode := range nodes {
+		if snode.IsReadOnly() {
+			for j := range batch {
+				errs[j][i] = errDataNodeReadOnly
+			}
+			wg.Done()
+			continue
+		}
+		go func(index int, node *datanode.Client) {
+			defer wg.Done()
+			entries := make([]*proto.AddRequest, len(batch))
+			for j, eb := range batch {
+				entries[j] = &proto.AddRequest{
+					Key:  eb.key,
+					Meta: eb.meta,
+					Data: eb.shards[index],
+				}
+			}
+			resp, err := node.DataClient.PutMany(ctx, &proto.PutManyRequest{Entries: entries})
+			if err == nil && len(resp.Errors) != len(batch) {
+				err = errors.New("the number of results is not equal to the number of entries")
+			}
+			if err != nil {
+				log.Errorw("put many error", "datanode", node.RpcAddress, "error", err)
+			}
+			for j := range batch {
+				if err != nil {
+					errs[j][index] = err
+				} else if resp.Errors[j] != "" {
+					errs[j][index] = errors.New(resp.Errors[j])
+				}
+			}
+		}(i, snode.Client)
+	}
+	wg.Wait()
+
+	_, entryWriteQuorum := d.entryQuorum()
+	for j, eb := range batch {
+		if err := reduceQuorumErrs(ctx, errs[j], entryOpIgnoredErrs, entryWriteQuorum, errErasureWriteQuorum); err != nil {
+			log.Errorw("put block failed", "key", eb.key, "error", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// GetMany returns the blocks with the given cids in the same order.
+// The shards are read from each data node in batches, the block which fails to be read is nil,
+// and its error is recorded in the returned errors at the same index.
+func (d *DagNode) GetMany(ctx context.Context, cids []cid.Cid) ([]blocks.Block, []error) {
+	blks := make([]blocks.Block, len(cids))
+	errs := make([]error, len(cids))
+	keys := make([]string, len(cids))
+	for i, c := range cids {
+		keys[i] = c.String()
+	}
+	nodes, release := d.acquireNodes()
+	defer release()
+	metas, metaErrs := readAllMetaMany(ctx, nodes, keys)
+
+	blockMetas := make([]Meta, len(cids))
+	codecs := make([]blockCodec, len(cids))
+	var batch []int
+	batchSize := 0
+	for i, c := range cids {
+		meta, err := d.findBlockMeta(ctx, metas[i], metaErrs[i])
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		blockMetas[i] = meta
+		if codecs[i], err = d.newCodec(meta); err != nil {
+			errs[i] = err
+			continue
+		}
+		shardSize := int(codecs[i].ShardSize())
+		// the large shards are received by stream
+		if shardSize > datanode.StreamChunkSize {
+			blks[i], errs[i] = d.Get(ctx, c)
+			continue
+		}
+		if len(batch) > 0 && (batchSize+shardSize > maxBatchSize || len(batch) >= maxBatchKeys) {
+			d.getBatch(ctx, nodes, cids, batch, blockMetas, codecs, metas, blks, errs)
+			batch, batchSize = nil, 0
+		}
+		batch = append(batch, i)
+		batchSize += shardSize
+	}
+	if len(batch) > 0 {
+		d.getBatch(ctx, nodes, cids, batch, blockMetas, codecs, metas, blks, errs)
+	}
+	return blks, errs
+}
+
+// getBatch reads the shards of the blocks at the batch indexes and assembles them
+func (d *DagNode) getBatch(ctx context.Context, nodes []*StorageNode, cids []cid.Cid, batch []int, blockMetas []Meta, codecs []blockCodec, metas [][]Meta, blks []blocks.Block, errs []error) {
+	shards := make([][][]byte, len(batch))
+	repairIndexes := make([][]bool, len(batch))
+	for j := range batch {
+		shards[j] = make([][]byte, len(nodes))
+		repairIndexes[j] = make([]bool, len(nodes))
+	}
+	var wg sync.WaitGroup
+	wg.Add(len(nodes))
+	for i, snode := range nodes {
+		go func(index int, snode *StorageNode) {
+			defer wg.Done()
+			// the offline data node is not asked, its shards are read from the other data nodes
+			if !snode.State {
+				return
+			}
+			// only read the shards whose meta is consistent with the quorum
+			var keys []string
+			var positions []int
+			for j, k := range batch {
+				if metas[k][index] == blockMetas[k] {
+					keys = append(keys, cids[k].String())
+					positions = append(positions, j)
+				} else {
+					repairIndexes[j][index] = true
+				}
+			}
+			if len(keys) == 0 {
+				return
+			}
+			node := snode.Client
+			resp, err := node.DataClient.GetMany(ctx, &proto.GetManyRequest{Keys: keys})
+			if err == nil && len(resp.Entries) != len(keys) {
+				err = errors.New("the number of results is not equal to the number of keys")
+			}
+			if err != nil {
+				log.Errorw("get many error", "datanode", node.RpcAddress, "error", err)
+				if st, ok := status.FromError(err); ok && st.Code() != codes.Canceled {
+					for _, j := range positions {
+						repairIndexes[j][index] = true
+					}
+				}
+				return
+			}
+			for n, j := range positions {
+				if resp.Entries[n].Error != "" {
+					log.Errorw("get error", "datanode", node.RpcAddress, "key", keys[n], "error", resp.Entries[n].Error)
+					repairIndexes[j][index] = true
+					continue
+				}
+				shards[j][index] = resp.Entries[n].Data
+			}
+		}(i, snode)
+	}
+	wg.Wait()
+
+	for j, k := range batch {
+		available := 0
+		for _, shard := range shards[j] {
+			if shard != nil {
+				available++
+			}
+		}
+		if available < d.readQuorum(blockMetas[k]) {
+			errs[k] = errErasureReadQuorum
+			continue
+		}
+		blks[k], errs[k] = d.assembleBlock(cids[k], blockMetas[k], codecs[k], shards[j], repairIndexes[j])
+	}
+}
+
+// readAllMetaMany reads the metadata of the keys from all nodes in batches, the offline nodes are skipped.
+// Returns the metadata and the errors of each key indexed by node.
+func readAllMetaMany(ctx context.Context, nodes []*StorageNode, keys []string) ([][]Meta, [][]error) {
+	metas := make([][]Meta, len(keys))
+	errs := make([][]error, len(keys))
+	for i := range keys {
+		metas[i] = make([]Meta, len(nodes))
+		errs[i] = make([]error, len(nodes))
+	}
+	var wg sync.WaitGroup
+	wg.Add(len(nodes))
+	for index := range nodes {
+		go func(index int) {
+			defer wg.Done()
+			for start := 0; start < len(keys); start += maxBatchKeys {
+				end := start + maxBatchKeys
+				if end > len(keys) {
+					end = len(keys)
+				}
+				var err error
+				var resp *proto.GetMetaManyResponse
+				if nodes[index] == nil || !nodes[index].State {
+					err = errNodeNotFound
+				} else {
+					resp, err = nodes[index].Client.DataClient.GetMetaMany(ctx, &proto.GetManyRequest{Keys: keys[start:end]})
+					if err == nil && len(resp.Entries) != end-start {
+						err = errors.New("the number of results is not equal to the number of keys")
+					}
+				}
+				for i := start; i < end; i++ {
+					if err != nil {
+						errs[i][index] = err
+						continue
+					}
+					en := resp.Entries[i-start]
+					if en.Error != "" {
+						errs[i][index] = errors.New(en.Error)
+						continue
+					}
+					meta, err := decodeMeta(en.Meta)
+					if err != nil {
+						errs[i][index] = err
+						continue
+					}
+					metas[i][index] = meta
+				}
+			}
+		}(index)
+	}
+	wg.Wait()
+	return metas, errs
+}
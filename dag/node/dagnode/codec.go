@@ -0,0 +1,147 @@
+package dagnode
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	// ModeErasure stores the block as erasure-coded shards
+	ModeErasure uint8 = iota
+	// ModeReplica stores a full replica of the block on every data node
+	ModeReplica
+)
+
+// metaSize is the size of the meta of the erasure-coded blocks, which is compatible with the legacy meta
+const metaSize = 4
+
+var errInvalidMeta = errors.New("invalid meta")
+
+// Meta is the metadata stored with each shard of a block
+type Meta struct {
+	BlockSize int32
+	// Mode is how the block is stored, ModeErasure or ModeReplica
+	Mode uint8
+}
+
+// Encode returns the binary form of the meta, the mode is only written for the replicated blocks
+func (m Meta) Encode() []byte {
+	if m.Mode == ModeErasure {
+		buf := make([]byte, metaSize)
+		binary.LittleEndian.PutUint32(buf, uint32(m.BlockSize))
+		return buf
+	}
+	buf := make([]byte, metaSize+1)
+	binary.LittleEndian.PutUint32(buf, uint32(m.BlockSize))
+	buf[metaSize] = m.Mode
+	return buf
+}
+
+// decodeMeta parses the meta written by Meta.Encode
+func decodeMeta(buf []byte) (Meta, error) {
+	if len(buf) < metaSize {
+		return Meta{}, errInvalidMeta
+	}
+	meta := Meta{BlockSize: int32(binary.LittleEndian.Uint32(buf))}
+	if len(buf) > metaSize {
+		meta.Mode = buf[metaSize]
+	}
+	if meta.BlockSize < 0 || meta.Mode > ModeReplica {
+		return Meta{}, errInvalidMeta
+	}
+	return meta, nil
+}
+
+// blockCodec splits a block into the shards stored on the data nodes and rebuilds it
+type blockCodec interface {
+	ShardSize() int64
+	EncodeData(data []byte) ([][]byte, error)
+	// DecodeDataBlocks rebuilds the missing shards needed by Join
+	DecodeDataBlocks(shards [][]byte) error
+	// DecodeDataAndParityBlocks rebuilds all the missing shards
+	DecodeDataAndParityBlocks(shards [][]byte) error
+	// Join merges the decoded shards into the block data
+	Join(shards [][]byte, size int) []byte
+}
+
+// newCodec returns the codec of the block with the meta
+func (d *DagNode) newCodec(meta Meta) (blockCodec, error) {
+	if meta.Mode == ModeReplica {
+		return &replicaCodec{blockSize: int64(meta.BlockSize), replicas: len(d.Nodes())}, nil
+	}
+	enc, err := NewErasure(d.config.DataBlocks, d.config.ParityBlocks, int64(meta.BlockSize))
+	if err != nil {
+		log.Errorf("new erasure fail :%v", err)
+		return nil, err
+	}
+	return &enc, nil
+}
+
+// blockMeta returns the meta of a new block, the blocks not larger than the replica threshold are replicated.
+// A layout with one data block is plain replication, all its blocks are replicated.
+func (d *DagNode) blockMeta(size int) Meta {
+	meta := Meta{BlockSize: int32(size)}
+	if d.config.DataBlocks == 1 || (d.config.ReplicaThreshold > 0 && size <= d.config.ReplicaThreshold) {
+		meta.Mode = ModeReplica
+	}
+	return meta
+}
+
+// readQuorum returns the number of shards needed to rebuild the block
+func (d *DagNode) readQuorum(meta Meta) int {
+	if meta.Mode == ModeReplica {
+		return 1
+	}
+	entryReadQuorum, _ := d.entryQuorum()
+	return entryReadQuorum
+}
+
+// replicaCodec stores a full copy of the block in every shard
+type replicaCodec struct {
+	blockSize int64
+	replicas  int
+}
+
+func (r *replicaCodec) ShardSize() int64 {
+	return r.blockSize
+}
+
+func (r *replicaCodec) EncodeData(data []byte) ([][]byte, error) {
+	shards := make([][]byte, r.replicas)
+	for i := range shards {
+		shards[i] = data
+	}
+	return shards, nil
+}
+
+func (r *replicaCodec) DecodeDataBlocks(shards [][]byte) error {
+	return r.DecodeDataAndParityBlocks(shards)
+}
+
+func (r *replicaCodec) DecodeDataAndParityBlocks(shards [][]byte) error {
+	var replica []byte
+	for _, shard := range shards {
+		if shard != nil {
+			replica = shard
+			break
+		}
+	}
+	if replica == nil {
+		return errErasureReadQuorum
+	}
+	for i := range shards {
+		if shards[i] == nil {
+			shards[i] = replica
+		}
+	}
+	return nil
+}
+
+func (r *replicaCodec) Join(shards [][]byte, size int) []byte {
+	for _, shard := range shards {
+		if len(shard) >= size {
+			return shard[:size]
+		}
+	}
+	return nil
+}
@@ -1,125 +1,247 @@
 package dagnode
 
 import (
-	"bytes"
 	"context"
-	"encoding/binary"
 	"errors"
+	"fmt"
+	"github.com/filedag-project/filedag-storage/dag/node/datanode"
 	"github.com/filedag-project/filedag-storage/dag/proto"
 	"github.com/filedag-project/filedag-storage/dag/utils/paralleltask"
 	"github.com/ipfs/go-cid"
-	"google.golang.org/protobuf/types/known/emptypb"
 	"io"
+	"sync/atomic"
 )
 
-// RepairDataNode prepare node repair
-func (d *DagNode) RepairDataNode(ctx context.Context, fromNodeIndex int, repairNodeIndex int) error {
-	if fromNodeIndex >= len(d.Nodes) {
+// repairProgressInterval is the number of keys between two progress reports of RepairDataNode
+const repairProgressInterval = 100
+
+// errKeyNotRepaired is returned by repairKey if the shard of the key can not be repaired now,
+// the repair goes on with the next keys
+var errKeyNotRepaired = errors.New("the key is not repaired")
+
+// RepairDataNode repairs the shards of the data node at repairNodeIndex in the ascending order of the keys listed
+// from the data node at fromNodeIndex. It starts after the key startAfter, and reports the cursor of the repaired keys
+// by onProgress if it is set, so that an interrupted repair can be resumed from the last cursor.
+// The cursor never passes a key which failed to be repaired, the repair fails at the end if any key failed,
+// and the failed keys are retried when the repair is resumed.
+func (d *DagNode) RepairDataNode(ctx context.Context, fromNodeIndex int, repairNodeIndex int, startAfter string, onProgress func(cursor string)) error {
+	nodes, release := d.acquireNodes()
+	defer release()
+	if fromNodeIndex >= len(nodes) {
 		return errors.New("index greater than max index of nodes")
 	}
-	if repairNodeIndex >= len(d.Nodes) {
+	if repairNodeIndex >= len(nodes) {
 		return errors.New("repair index greater than max index of nodes")
 	}
 
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
-	stream, err := d.Nodes[fromNodeIndex].Client.DataClient.AllKeysChan(ctx, &emptypb.Empty{})
+	stream, err := nodes[fromNodeIndex].Client.DataClient.AllKeysChan(ctx, &proto.AllKeysChanRequest{StartAfter: startAfter})
 	if err != nil {
 		return err
 	}
-	repairNode := d.Nodes[repairNodeIndex]
-	for {
-		resp, err := stream.Recv()
-		if err != nil {
-			if err == io.EOF {
-				return nil
-			}
-			return err
-		}
-		key := resp.Key
+	return d.repairKeys(ctx, nodes, newKeyMerger([]proto.DataNode_AllKeysChanClient{stream}), repairNodeIndex, startAfter, onProgress)
+}
 
-		if _, err := repairNode.Client.DataClient.GetMeta(ctx, &proto.GetMetaRequest{Key: key}); err == nil {
-			continue
+// repairKeys repairs the shards of the keys received in the ascending order on the data node at repairNodeIndex
+func (d *DagNode) repairKeys(ctx context.Context, nodes []*StorageNode, keys *keyMerger, repairNodeIndex int, startAfter string, onProgress func(cursor string)) error {
+	cursor := startAfter
+	report := func() {
+		if onProgress != nil && cursor != startAfter {
+			onProgress(cursor)
 		}
-		dataCid, err := cid.Decode(key)
-		if err != nil {
-			log.Errorw("decode cid error", "key", key, "error", err)
-			continue
+	}
+	// the keys after the first failed key are still repaired, but the cursor stays before it
+	failed := 0
+	for repaired := 1; ; repaired++ {
+		key, err := keys.Recv()
+		if err == nil {
+			err = d.repairKey(ctx, nodes, key, repairNodeIndex)
 		}
-		size, err := d.GetSize(ctx, dataCid)
-		if err != nil {
-			log.Errorw("get block size error", "key", key, "error", err)
-			continue
+		if err == io.EOF {
+			report()
+			if failed > 0 {
+				return fmt.Errorf("%d keys after key %q are not repaired: %w", failed, cursor, errKeyNotRepaired)
+			}
+			return nil
 		}
-
-		shards := make([][]byte, len(d.Nodes))
-		entryReadQuorum, _ := d.entryQuorum()
-		task := paralleltask.NewParallelTask(ctx, entryReadQuorum, len(d.Nodes)-entryReadQuorum+1, true)
-		for i, snode := range d.Nodes {
-			index := i
-			tnode := snode
-			task.Goroutine(func(ctx context.Context) error {
-				if index == repairNodeIndex {
-					return errors.New("there is no data in this node")
-				}
-				res, err := tnode.Client.DataClient.Get(ctx, &proto.GetRequest{Key: key})
-				if err != nil {
-					log.Errorf("this node[%s] get key err: %v", tnode.RpcAddress, err)
-					return err
-				}
-				if len(res.Data) == 0 {
-					err = errors.New("there is no data in this node")
-					return err
-				}
-				shards[index] = res.Data
-				return nil
-			})
-		}
-		if err = task.Wait(); err != nil {
-			log.Errorw("task error, missing shards", "key", key, "error", err)
+		if errors.Is(err, errKeyNotRepaired) {
+			failed++
 			continue
 		}
-
-		enc, err := NewErasure(d.config.DataBlocks, d.config.ParityBlocks, int64(size))
 		if err != nil {
-			log.Errorf("new erasure fail :%v", err)
-			return err
+			report()
+			if cursor == "" {
+				return err
+			}
+			return fmt.Errorf("repair interrupted after key %s: %w", cursor, err)
 		}
-		err = enc.DecodeDataAndParityBlocks(shards)
-		if err != nil {
-			log.Errorf("decode data blocks failed: %v", err)
-			return err
+		if failed == 0 {
+			cursor = key
+		}
+		if repaired%repairProgressInterval == 0 {
+			report()
 		}
+	}
+}
 
-		meta := Meta{
-			BlockSize: int32(size),
+// repairKey repairs the shard of the key on the data node at repairNodeIndex. It returns errKeyNotRepaired
+// if the shard can not be repaired now, the other errors stop the repair
+func (d *DagNode) repairKey(ctx context.Context, nodes []*StorageNode, key string, repairNodeIndex int) error {
+	repairNode := nodes[repairNodeIndex]
+	if _, err := repairNode.Client.DataClient.GetMeta(ctx, &proto.GetMetaRequest{Key: key}); err == nil {
+		return nil
+	}
+	dataCid, err := cid.Decode(key)
+	if err != nil {
+		log.Errorw("decode cid error", "key", key, "error", err)
+		return nil
+	}
+	meta, _, _, err := d.getMetaInfo(ctx, nodes, dataCid)
+	if err != nil {
+		log.Errorw("get block meta error", "key", key, "error", err)
+		return fmt.Errorf("%w: %v", errKeyNotRepaired, err)
+	}
+
+	codec, err := d.newCodec(meta)
+	if err != nil {
+		return err
+	}
+	shards := make([][]byte, len(nodes))
+	entryReadQuorum := d.readQuorum(meta)
+	task := paralleltask.NewParallelTask(ctx, entryReadQuorum, len(nodes)-entryReadQuorum+1, true)
+	for i, snode := range nodes {
+		index := i
+		tnode := snode
+		task.Goroutine(func(ctx context.Context) error {
+			if index == repairNodeIndex {
+				return errors.New("there is no data in this node")
+			}
+			shard, err := getShard(ctx, tnode.Client, key, codec.ShardSize())
+			if err != nil {
+				log.Errorf("this node[%s] get key err: %v", tnode.RpcAddress, err)
+				return err
+			}
+			if len(shard) == 0 {
+				err = errors.New("there is no data in this node")
+				return err
+			}
+			shards[index] = shard
+			return nil
+		})
+	}
+	if err = task.Wait(); err != nil {
+		log.Errorw("task error, missing shards", "key", key, "error", err)
+		return fmt.Errorf("%w: %v", errKeyNotRepaired, err)
+	}
+
+	err = codec.DecodeDataAndParityBlocks(shards)
+	if err != nil {
+		log.Errorf("decode data blocks failed: %v", err)
+		return err
+	}
+
+	if err = putShard(ctx, repairNode.Client, key, meta.Encode(), shards[repairNodeIndex]); err != nil {
+		log.Errorf("data node put failed: %v", err)
+		return err
+	}
+	log.Infow("repair entry success", "key", key)
+	return nil
+}
+
+// ReplaceDataNode replaces the data node at the index with a new one,
+// the new data node must be rebuilt by RebuildDataNode, the reads are served by the other data nodes meanwhile.
+// The connection of the old data node is closed once the reads and writes in flight on it are done.
+func (d *DagNode) ReplaceDataNode(ctx context.Context, index int, rpcAddress string) error {
+	cfg := d.GetConfig()
+	if index < 0 || index >= len(cfg.Nodes) {
+		return errors.New("index greater than max index of nodes")
+	}
+	for _, addr := range cfg.Nodes {
+		if addr == rpcAddress {
+			return errors.New("the data node already exists")
 		}
-		var metaBuf bytes.Buffer
-		if err = binary.Write(&metaBuf, binary.LittleEndian, meta); err != nil {
-			log.Errorf("binary.Write failed: %v", err)
+	}
+	cli, err := datanode.NewClient(rpcAddress, d.dialOpts...)
+	if err != nil {
+		return err
+	}
+	if err = d.healthCheck(ctx, cli); err != nil {
+		cli.Conn.Close()
+		return fmt.Errorf("the data node %s is unavailable: %v", rpcAddress, err)
+	}
+
+	d.nodesLk.Lock()
+	oldSet := d.nodeSet
+	nodes := make([]*StorageNode, len(oldSet.nodes))
+	copy(nodes, oldSet.nodes)
+	old := nodes[index]
+	nodes[index] = &StorageNode{Client: cli, State: true, rebuilding: 1}
+	addrs := make([]string, len(d.config.Nodes))
+	copy(addrs, d.config.Nodes)
+	addrs[index] = rpcAddress
+	d.nodeSet = &nodeSet{nodes: nodes}
+	d.config.Nodes = addrs
+	d.nodesLk.Unlock()
+
+	go func() {
+		oldSet.users.Wait()
+		old.Conn.Close()
+	}()
+	return nil
+}
+
+// RebuildDataNode rebuilds all the shards of the data node at the index from the other data nodes,
+// the keys are listed from all the healthy data nodes, so a key missing on some of them is still rebuilt.
+// startAfter and onProgress resume an interrupted rebuilding as RepairDataNode does
+func (d *DagNode) RebuildDataNode(ctx context.Context, index int, startAfter string, onProgress func(cursor string)) error {
+	nodes, release := d.acquireNodes()
+	defer release()
+	if index < 0 || index >= len(nodes) {
+		return errors.New("index greater than max index of nodes")
+	}
+	sn := nodes[index]
+	// the node keeps rebuilding until success
+	atomic.StoreInt32(&sn.rebuilding, 1)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var from []int
+	var streams []proto.DataNode_AllKeysChanClient
+	for i, node := range nodes {
+		if i == index || !node.State {
 			continue
 		}
-		if _, err = repairNode.Client.DataClient.Put(ctx, &proto.AddRequest{
-			Key:  key,
-			Meta: metaBuf.Bytes(),
-			Data: shards[repairNodeIndex],
-		}); err != nil {
-			log.Errorf("data node put failed: %v", err)
-			return err
+		stream, err := node.Client.DataClient.AllKeysChan(ctx, &proto.AllKeysChanRequest{StartAfter: startAfter})
+		if err != nil {
+			return fmt.Errorf("list the keys of the data node %s: %w", node.RpcAddress, err)
 		}
-		log.Infow("repair entry success", "key", key)
+		from = append(from, i)
+		streams = append(streams, stream)
+	}
+	if len(streams) == 0 {
+		return errors.New("no available data node to rebuild from")
+	}
+	log.Infow("rebuild data node start", "dagnode", d.config.Name, "index", index, "from", from)
+	if err := d.repairKeys(ctx, nodes, newKeyMerger(streams), index, startAfter, onProgress); err != nil {
+		return err
 	}
+	atomic.StoreInt32(&sn.rebuilding, 0)
+	log.Infow("rebuild data node finished", "dagnode", d.config.Name, "index", index)
+	return nil
 }
 
 // repairBlock repairs shards of one erasure set
-func (d *DagNode) repairBlock(ctx context.Context, key string, blockSize int32, shards [][]byte, repairIndexes []int) error {
+func (d *DagNode) repairBlock(ctx context.Context, key string, meta Meta, shards [][]byte, repairIndexes []int) error {
+	nodes, release := d.acquireNodes()
+	defer release()
 	for _, repairNodeIndex := range repairIndexes {
-		if repairNodeIndex >= len(d.Nodes) {
+		if repairNodeIndex >= len(nodes) {
 			return errors.New("repair index greater than max index of nodes")
 		}
 	}
 
-	entryReadQuorum, _ := d.entryQuorum()
+	entryReadQuorum := d.readQuorum(meta)
 	availableShards := 0
 	for _, shard := range shards {
 		if shard != nil {
@@ -133,31 +255,19 @@ func (d *DagNode) repairBlock(ctx context.Context, key string, blockSize int32,
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	enc, err := NewErasure(d.config.DataBlocks, d.config.ParityBlocks, int64(blockSize))
+	codec, err := d.newCodec(meta)
 	if err != nil {
-		log.Errorf("new erasure fail :%v", err)
 		return err
 	}
-	err = enc.DecodeDataAndParityBlocks(shards)
+	err = codec.DecodeDataAndParityBlocks(shards)
 	if err != nil {
 		log.Errorf("decode data blocks failed: %v", err)
 		return err
 	}
 
-	meta := Meta{
-		BlockSize: blockSize,
-	}
+	metaBuf := meta.Encode()
 	for _, index := range repairIndexes {
-		var metaBuf bytes.Buffer
-		if err = binary.Write(&metaBuf, binary.LittleEndian, meta); err != nil {
-			log.Errorf("binary.Write failed: %v", err)
-			continue
-		}
-		if _, err = d.Nodes[index].Client.DataClient.Put(ctx, &proto.AddRequest{
-			Key:  key,
-			Meta: metaBuf.Bytes(),
-			Data: shards[index],
-		}); err != nil {
+		if err = putShard(ctx, nodes[index].Client, key, metaBuf, shards[index]); err != nil {
 			log.Errorf("data node put failed: %v", err)
 			return err
 		}
@@ -165,3 +275,88 @@ func (d *DagNode) repairBlock(ctx context.Context, key string, blockSize int32,
 	}
 	return nil
 }
+
+// keyMerger merges the keys listed by the data nodes in the ascending order, each key is received once
+type keyMerger struct {
+	streams []proto.DataNode_AllKeysChanClient
+	heads   []string
+	// started is set once the first key of every stream is received
+	started bool
+	ended   []bool
+	// quorum is the number of the streams which must list all their keys,
+	// the failed streams are dropped as long as the others keep the quorum
+	quorum int
+	failed int
+}
+
+func newKeyMerger(streams []proto.DataNode_AllKeysChanClient) *keyMerger {
+	return newQuorumKeyMerger(streams, len(streams))
+}
+
+func newQuorumKeyMerger(streams []proto.DataNode_AllKeysChanClient, quorum int) *keyMerger {
+	return &keyMerger{
+		streams: streams,
+		heads:   make([]string, len(streams)),
+		ended:   make([]bool, len(streams)),
+		quorum:  quorum,
+	}
+}
+
+// start receives the first key of every stream
+func (m *keyMerger) start() error {
+	if m.started {
+		return nil
+	}
+	for i := range m.streams {
+		if err := m.next(i); err != nil {
+			return err
+		}
+	}
+	m.started = true
+	return nil
+}
+
+// Recv returns the next key, io.EOF is returned after all the keys are received.
+// It fails once fewer data nodes than the quorum can list their keys, so that no key is missed.
+func (m *keyMerger) Recv() (string, error) {
+	if err := m.start(); err != nil {
+		return "", err
+	}
+	min := -1
+	for i, head := range m.heads {
+		if !m.ended[i] && (min < 0 || head < m.heads[min]) {
+			min = i
+		}
+	}
+	if min < 0 {
+		return "", io.EOF
+	}
+	key := m.heads[min]
+	for i, head := range m.heads {
+		if !m.ended[i] && head == key {
+			if err := m.next(i); err != nil {
+				return "", err
+			}
+		}
+	}
+	return key, nil
+}
+
+func (m *keyMerger) next(i int) error {
+	resp, err := m.streams[i].Recv()
+	if err == io.EOF {
+		m.ended[i] = true
+		return nil
+	}
+	if err != nil {
+		m.ended[i] = true
+		m.failed++
+		if len(m.streams)-m.failed < m.quorum {
+			return err
+		}
+		log.Warnw("list keys error, the keys are listed by the other data nodes", "error", err)
+		return nil
+	}
+	m.heads[i] = resp.Key
+	return nil
+}
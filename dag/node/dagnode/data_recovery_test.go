@@ -17,7 +17,7 @@ func TestRecovery_host(t *testing.T) {
 	}
 	err = json.Unmarshal(file, &nc)
 	dagNode, err := NewDagNode(nc)
-	err = dagNode.RepairDataNode(context.TODO(), 1, 2)
+	err = dagNode.RepairDataNode(context.TODO(), 1, 2, "", nil)
 	if err != nil {
 		fmt.Println(err)
 	}
@@ -122,3 +122,13 @@ func (e *Erasure) ShardFileOffset(startOffset, length, totalLength int64) int64
 	}
 	return tillOffset
 }
+
+// Join merges the data shards into the original data of the given size.
+func (e *Erasure) Join(shards [][]byte, size int) []byte {
+	shardSize := int(e.ShardSize())
+	data := make([]byte, e.dataBlocks*shardSize)
+	for i, shard := range shards[:e.dataBlocks] {
+		copy(data[i*shardSize:], shard)
+	}
+	return data[:size]
+}
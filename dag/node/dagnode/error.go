@@ -14,6 +14,15 @@ var errErasureReadQuorum = errors.New("Read failed. Insufficient number of nodes
 // errErasureWriteQuorum - did not meet write quorum.
 var errErasureWriteQuorum = errors.New("Write failed. Insufficient number of nodes online")
 
+// errErasureListQuorum - too few data nodes listed their keys to yield every key.
+var errErasureListQuorum = errors.New("List failed. Insufficient number of nodes online")
+
+// errNotEnoughWritableNodes - too many data nodes are read-only to meet write quorum.
+var errNotEnoughWritableNodes = errors.New("Write failed. Insufficient number of writable nodes")
+
+// errDataNodeReadOnly - the data node rejects the writes because its disk is nearly full.
+var errDataNodeReadOnly = errors.New("the data node is read-only")
+
 // errNodeAccessDenied - we don't have write permissions on node.
 var errNodeAccessDenied = errors.New("node access denied")
 
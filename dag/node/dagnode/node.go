@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"context"
 	"crypto/sha256"
-	"encoding/binary"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -17,10 +16,13 @@ import (
 	"github.com/ipfs/go-cid"
 	blockstore "github.com/ipfs/go-ipfs-blockstore"
 	logging "github.com/ipfs/go-log/v2"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/status"
+	"io"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -33,54 +35,106 @@ var log = logging.Logger("dag-node")
 type StorageNode struct {
 	*datanode.Client
 	State bool // true: means the data node is health
+	// rebuilding is set while the shards of a replaced data node are being rebuilt
+	rebuilding int32
+	// stat is the last disk usage reported by the data node
+	statLk sync.RWMutex
+	stat   DiskStat
+	// heartbeat is the result of the last health checks
+	heartbeat HeartbeatStat
+}
+
+// IsRebuilding returns whether the shards of the data node are being rebuilt
+func (sn *StorageNode) IsRebuilding() bool {
+	return atomic.LoadInt32(&sn.rebuilding) == 1
+}
+
+// nodeSet is a snapshot of the data nodes, the connections of the data nodes replaced
+// in it are closed once all of its users are done
+type nodeSet struct {
+	nodes []*StorageNode
+	users sync.WaitGroup
 }
 
 // DagNode Implemented the Blockstore interface
 type DagNode struct {
-	Nodes       []*StorageNode
+	// nodeSet is swapped as a whole when a data node is replaced, it is guarded by nodesLk
+	nodesLk     sync.RWMutex
+	nodeSet     *nodeSet
 	slots       *slotsmgr.SlotsManager
 	numSlots    int
 	config      config.DagNodeConfig
 	repairQueue chan func(ctx context.Context)
 	stopCh      chan struct{}
+	// hashOnRead is set to verify the hash of blocks when reading
+	hashOnRead     int32
+	hashMismatches uint64
+	scrubStats     scrubStatsHolder
+	// repairStore persists the repair tasks if it is set, otherwise the tasks are kept in repairQueue
+	repairStore  RepairStore
+	repairNotify chan struct{}
+	// repairRunning is held by a run of the repair tasks, so that the background worker and a drain
+	// do not repair or remove the same task at once
+	repairRunning chan struct{}
+	// dialOpts are the options to connect the data nodes
+	dialOpts []grpc.DialOption
 }
 
-type Meta struct {
-	BlockSize int32
-}
-
-// NewDagNode creates a new DagNode
-func NewDagNode(cfg config.DagNodeConfig) (*DagNode, error) {
+// NewDagNode creates a new DagNode, opts are used to connect the data nodes
+func NewDagNode(cfg config.DagNodeConfig, opts ...grpc.DialOption) (*DagNode, error) {
 	numNodes := len(cfg.Nodes)
 	if numNodes != cfg.DataBlocks+cfg.ParityBlocks || numNodes == 0 {
 		return nil, errors.New("dag node config is incorrect")
 	}
 	clients := make([]*StorageNode, 0, cfg.DataBlocks+cfg.ParityBlocks)
 	for _, c := range cfg.Nodes {
-		dateNode, err := datanode.NewClient(c)
+		dateNode, err := datanode.NewClient(c, opts...)
 		if err != nil {
 			return nil, err
 		}
 		clients = append(clients, &StorageNode{Client: dateNode})
 	}
 	return &DagNode{
-		Nodes:       clients,
+		nodeSet:     &nodeSet{nodes: clients},
 		slots:       slotsmgr.NewSlotsManager(),
 		config:      cfg,
 		repairQueue: make(chan func(ctx context.Context), 10000),
 		stopCh:      make(chan struct{}),
+		dialOpts:    opts,
 	}, nil
 }
 
+// GetConfig returns a copy of the config of the dag node
 func (d *DagNode) GetConfig() *config.DagNodeConfig {
-	return &d.config
+	d.nodesLk.RLock()
+	defer d.nodesLk.RUnlock()
+	cfg := d.config
+	return &cfg
+}
+
+// Nodes returns the data nodes, the returned slice is never modified.
+// Use acquireNodes instead if the connections of the data nodes are used.
+func (d *DagNode) Nodes() []*StorageNode {
+	d.nodesLk.RLock()
+	defer d.nodesLk.RUnlock()
+	return d.nodeSet.nodes
+}
+
+// acquireNodes returns the data nodes, their connections stay open until release is called
+func (d *DagNode) acquireNodes() (nodes []*StorageNode, release func()) {
+	d.nodesLk.RLock()
+	set := d.nodeSet
+	set.users.Add(1)
+	d.nodesLk.RUnlock()
+	return set.nodes, set.users.Done
 }
 
 func (d *DagNode) GetDataNodeState(setIndex int) bool {
-	if setIndex < 0 || setIndex >= len(d.Nodes) {
-		log.Fatalf("input setIndex %v is illegal, size of set is %v", setIndex, len(d.Nodes))
+	nodes := d.Nodes()
+	if setIndex < 0 || setIndex >= len(nodes) {
+		log.Fatalf("input setIndex %v is illegal, size of set is %v", setIndex, len(nodes))
 	}
-	return d.Nodes[setIndex].State
+	return nodes[setIndex].State
 }
 
 // AddSlot Set the slot bit and return the old value
@@ -133,11 +187,21 @@ func (d *DagNode) RunHeartbeatCheck(ctx context.Context) {
 		wg := sync.WaitGroup{}
 		checkCtx, checkCancel := context.WithTimeout(ctx, 15*time.Second)
 		defer checkCancel()
-		for _, node := range d.Nodes {
+		nodes, release := d.acquireNodes()
+		defer release()
+		for _, node := range nodes {
 			wg.Add(1)
 			go func(sn *StorageNode) {
 				defer wg.Done()
-				sn.State = d.healthCheck(checkCtx, sn.Client)
+				err := d.healthCheck(checkCtx, sn.Client)
+				sn.State = err == nil
+				sn.setHeartbeat(err)
+				if !sn.State {
+					return
+				}
+				if err := sn.refreshStat(checkCtx); err != nil {
+					log.Warnw("get disk usage error", "datanode", sn.RpcAddress, "error", err)
+				}
 			}(node)
 		}
 		wg.Wait()
@@ -159,6 +223,9 @@ func (d *DagNode) RunHeartbeatCheck(ctx context.Context) {
 func (d *DagNode) RunRepairTask(ctx context.Context) {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
+	if d.repairStore != nil {
+		go d.runRepairStoreTask(ctx)
+	}
 	for {
 		select {
 		case task := <-d.repairQueue:
@@ -172,29 +239,31 @@ func (d *DagNode) RunRepairTask(ctx context.Context) {
 	}
 }
 
-func (d *DagNode) healthCheck(ctx context.Context, cli *datanode.Client) bool {
+func (d *DagNode) healthCheck(ctx context.Context, cli *datanode.Client) error {
 	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 	check, err := cli.HeartClient.Check(ctx, &healthpb.HealthCheckRequest{Service: healthCheckService})
 	if err != nil {
 		log.Errorf("Check the rpc address:%v err:%v", cli.RpcAddress, err)
-		return false
+		return err
 	}
 	if check.Status != healthpb.HealthCheckResponse_SERVING {
 		log.Errorf("the rpc server[%v] status: %v", cli.RpcAddress, check.Status)
-		return false
+		return fmt.Errorf("the data node status is %v", check.Status)
 	}
-	return true
+	return nil
 }
 
 // DeleteBlock deletes a block from the DagNode
 func (d *DagNode) DeleteBlock(ctx context.Context, cid cid.Cid) (err error) {
 	log.Warnf("delete block, cid: %v", cid)
 	keyCode := cid.String()
+	nodes, release := d.acquireNodes()
+	defer release()
 	_, entryWriteQuorum := d.entryQuorum()
 	taskCtx := context.Background()
-	task := paralleltask.NewParallelTask(taskCtx, entryWriteQuorum, len(d.Nodes)-entryWriteQuorum+1, false)
-	for _, snode := range d.Nodes {
+	task := paralleltask.NewParallelTask(taskCtx, entryWriteQuorum, len(nodes)-entryWriteQuorum+1, false)
+	for _, snode := range nodes {
 		node := snode.Client
 		task.Goroutine(func(ctx context.Context) error {
 			var err error
@@ -220,17 +289,25 @@ func (d *DagNode) Has(ctx context.Context, cid cid.Cid) (bool, error) {
 func (d *DagNode) Get(ctx context.Context, cid cid.Cid) (blocks.Block, error) {
 	log.Debugf("get block, cid :%v", cid)
 	keyCode := cid.String()
-	meta, _, onlineNodes, err := d.getMetaInfo(ctx, cid)
+	nodes, release := d.acquireNodes()
+	defer release()
+	meta, _, onlineNodes, err := d.getMetaInfo(ctx, nodes, cid)
 	if err != nil {
 		return nil, err
 	}
-	size := meta.BlockSize
-	entryReadQuorum, _ := d.entryQuorum()
+	entryReadQuorum := d.readQuorum(meta)
+	codec, err := d.newCodec(meta)
+	if err != nil {
+		return nil, err
+	}
+	shardSize := codec.ShardSize()
 
+	// the results are guarded by lk, because the goroutines may still be running after the task returns
+	var lk sync.Mutex
 	shardsTmp := make([][]byte, len(onlineNodes))
 	shards := make([][]byte, len(onlineNodes))
+	repairIndexesTmp := make([]bool, len(onlineNodes))
 	repairIndexes := make([]bool, len(onlineNodes))
-	needRepair := false
 	task := paralleltask.NewParallelTask(ctx, entryReadQuorum, len(onlineNodes)-entryReadQuorum+1, true)
 	for i, snode := range onlineNodes {
 		index := i
@@ -239,25 +316,29 @@ func (d *DagNode) Get(ctx context.Context, cid cid.Cid) (blocks.Block, error) {
 			// is offline node or have no block?
 			if tnode == nil {
 				// is it online?
-				if d.Nodes[index].State {
+				if nodes[index].State {
 					// repair shard
-					repairIndexes[index] = true
-					needRepair = true
+					lk.Lock()
+					repairIndexesTmp[index] = true
+					lk.Unlock()
 				}
 				return errors.New("offline node")
 			}
 			node := tnode.Client
 			var err error
-			res, err := node.DataClient.Get(ctx, &proto.GetRequest{Key: keyCode})
+			shard, err := getShard(ctx, node, keyCode, shardSize)
 			if err != nil {
 				log.Errorw("get error", "datanode", node.RpcAddress, "key", keyCode, "error", err)
 				if st, ok := status.FromError(err); ok && st.Code() != codes.Canceled {
 					// repair shard
-					repairIndexes[index] = true
-					needRepair = true
+					lk.Lock()
+					repairIndexesTmp[index] = true
+					lk.Unlock()
 				}
 			} else {
-				shardsTmp[index] = res.Data
+				lk.Lock()
+				shardsTmp[index] = shard
+				lk.Unlock()
 				return nil
 			}
 			return err
@@ -270,53 +351,40 @@ func (d *DagNode) Get(ctx context.Context, cid cid.Cid) (blocks.Block, error) {
 	}
 
 	// clone a copy
-	for i, v := range shardsTmp {
-		shards[i] = v
-	}
+	lk.Lock()
+	copy(shards, shardsTmp)
+	copy(repairIndexes, repairIndexesTmp)
+	lk.Unlock()
 
-	enc, err := NewErasure(d.config.DataBlocks, d.config.ParityBlocks, int64(size))
-	if err != nil {
-		log.Errorf("new erasure fail :%v", err)
-		return nil, err
-	}
-	err = enc.DecodeDataBlocks(shards)
+	return d.assembleBlock(cid, meta, codec, shards, repairIndexes)
+}
+
+// assembleBlock decodes the shards to the block, and queues a repair task if some shards need to be repaired
+func (d *DagNode) assembleBlock(cid cid.Cid, meta Meta, codec blockCodec, shards [][]byte, repairIndexes []bool) (blocks.Block, error) {
+	keyCode := cid.String()
+	err := codec.DecodeDataBlocks(shards)
 	if err != nil {
 		log.Errorf("decode data blocks fail :%v", err)
 		return nil, err
 	}
 
 	// need repair shards?
-	if needRepair {
-		indexes := make([]int, 0)
-		for i, ok := range repairIndexes {
-			if ok {
-				indexes = append(indexes, i)
-			}
-		}
-		repairFunc := func(ctx context.Context) {
-			repairCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
-			defer cancel()
-			if err := d.repairBlock(repairCtx, keyCode, size, shards, indexes); err != nil {
-				log.Errorw("repair block failed", "key", keyCode, "blockSize", size, "indexes", indexes)
-			}
-		}
-		select {
-		case d.repairQueue <- repairFunc:
-		default:
-			log.Warn("repair queue is full, discard this task")
-		}
-	}
+	d.queueRepair(keyCode, meta, shards, repairIndexes)
 
 	// merge to block raw data
-	shardSize := int(enc.ShardSize())
-	data := make([]byte, d.config.DataBlocks*shardSize)
-	for i, shard := range shards {
-		if i == d.config.DataBlocks {
-			break
+	data := codec.Join(shards, int(meta.BlockSize))
+
+	if atomic.LoadInt32(&d.hashOnRead) == 1 {
+		sum, err := cid.Prefix().Sum(data)
+		if err != nil {
+			return nil, err
+		}
+		if !sum.Equals(cid) {
+			atomic.AddUint64(&d.hashMismatches, 1)
+			log.Errorw("block hash mismatch", "key", keyCode)
+			return nil, blockstore.ErrHashMismatch
 		}
-		copy(data[i*shardSize:], shard)
 	}
-	data = data[:size]
 
 	b, err := blocks.NewBlockWithCid(data, cid)
 	if err == blocks.ErrWrongHash {
@@ -325,28 +393,58 @@ func (d *DagNode) Get(ctx context.Context, cid cid.Cid) (blocks.Block, error) {
 	return b, err
 }
 
+// queueRepair queues a task to repair the shards at the repair indexes
+func (d *DagNode) queueRepair(key string, meta Meta, shards [][]byte, repairIndexes []bool) bool {
+	nodes := d.Nodes()
+	indexes := make([]int, 0)
+	for i, ok := range repairIndexes {
+		// the shards of the rebuilding node are repaired by the rebuilding task
+		if ok && !nodes[i].IsRebuilding() {
+			indexes = append(indexes, i)
+		}
+	}
+	if len(indexes) == 0 {
+		return false
+	}
+	if d.repairStore != nil {
+		return d.pushRepairTask(key, len(indexes))
+	}
+	repairFunc := func(ctx context.Context) {
+		repairCtx, cancel := context.WithTimeout(ctx, repairTaskTimeout)
+		defer cancel()
+		if err := d.repairBlock(repairCtx, key, meta, shards, indexes); err != nil {
+			log.Errorw("repair block failed", "key", key, "blockSize", meta.BlockSize, "mode", meta.Mode, "indexes", indexes)
+		}
+	}
+	select {
+	case d.repairQueue <- repairFunc:
+		return true
+	default:
+		log.Warn("repair queue is full, discard this task")
+		return false
+	}
+}
+
 // GetSize returns the size of the block with the given cid
 func (d *DagNode) GetSize(ctx context.Context, cid cid.Cid) (int, error) {
-	meta, _, _, err := d.getMetaInfo(ctx, cid)
+	nodes, release := d.acquireNodes()
+	defer release()
+	meta, _, _, err := d.getMetaInfo(ctx, nodes, cid)
 	return int(meta.BlockSize), err
 }
 
-func (d *DagNode) getMetaInfo(ctx context.Context, cid cid.Cid) (meta Meta, metas []Meta, onlineNodes []*StorageNode, err error) {
+// getMetaInfo reads the meta of the block from the nodes, the nodes must be acquired by the caller
+func (d *DagNode) getMetaInfo(ctx context.Context, nodes []*StorageNode, cid cid.Cid) (meta Meta, metas []Meta, onlineNodes []*StorageNode, err error) {
 	var errs []error
-	metas, errs = readAllMeta(ctx, d.Nodes, cid.String())
-	entryReadQuorum, _ := d.entryQuorum()
-	reducedErr := reduceQuorumErrs(ctx, errs, entryOpIgnoredErrs, entryReadQuorum, errErasureReadQuorum)
-	if reducedErr != nil {
-		return meta, nil, nil, reducedErr
-	}
-	meta, err = findMetaInQuorum(ctx, metas, entryReadQuorum)
+	metas, errs = readAllMeta(ctx, nodes, cid.String())
+	meta, err = d.findBlockMeta(ctx, metas, errs)
 	if err != nil {
 		return meta, nil, nil, err
 	}
 	onlineNodes = make([]*StorageNode, len(metas))
 	for i, m := range metas {
-		if m.BlockSize == meta.BlockSize {
-			onlineNodes[i] = d.Nodes[i]
+		if errs[i] == nil && m == meta {
+			onlineNodes[i] = nodes[i]
 		} else {
 			onlineNodes[i] = nil
 		}
@@ -357,46 +455,30 @@ func (d *DagNode) getMetaInfo(ctx context.Context, cid cid.Cid) (meta Meta, meta
 // Put adds the given block to the DagNode
 func (d *DagNode) Put(ctx context.Context, block blocks.Block) (err error) {
 	log.Debugf("put block, cid :%v", block.Cid())
-	// copy data from block, because reedsolomon may modify data
-	buf := bytes.NewBuffer(nil)
-	buf.Write(block.RawData())
-	blockData := buf.Bytes()
-	blockDataSize := len(blockData)
 	keyCode := block.Cid().String()
-
-	meta := Meta{
-		BlockSize: int32(blockDataSize),
-	}
-	var metaBuf bytes.Buffer
-	err = binary.Write(&metaBuf, binary.LittleEndian, meta)
-	if err != nil {
+	if err = d.checkWritable(); err != nil {
 		return err
 	}
-
-	enc, err := NewErasure(d.config.DataBlocks, d.config.ParityBlocks, int64(blockDataSize))
-	if err != nil {
-		log.Errorf("newErasure fail :%v", err)
-		return err
-	}
-	shards, err := enc.EncodeData(blockData)
+	meta, shards, err := d.encodeBlock(block)
 	if err != nil {
-		log.Errorf("encodeData fail :%v", err)
 		return err
 	}
 
+	nodes, release := d.acquireNodes()
+	defer release()
 	_, entryWriteQuorum := d.entryQuorum()
 	taskCtx := context.Background()
-	task := paralleltask.NewParallelTask(taskCtx, entryWriteQuorum, len(d.Nodes)-entryWriteQuorum+1, false)
-	for i, snode := range d.Nodes {
+	task := paralleltask.NewParallelTask(taskCtx, entryWriteQuorum, len(nodes)-entryWriteQuorum+1, false)
+	for i, snode := range nodes {
 		index := i
 		node := snode.Client
+		readOnly := snode.IsReadOnly()
 		task.Goroutine(func(ctx context.Context) error {
+			if readOnly {
+				return errDataNodeReadOnly
+			}
 			var err error
-			if _, err = node.DataClient.Put(ctx, &proto.AddRequest{
-				Key:  keyCode,
-				Meta: metaBuf.Bytes(),
-				Data: shards[index],
-			}); err != nil {
+			if err = putShard(ctx, node, keyCode, meta, shards[index]); err != nil {
 				log.Errorw("put error", "datanode", node.RpcAddress, "key", keyCode, "error", err)
 			}
 			return err
@@ -407,36 +489,163 @@ func (d *DagNode) Put(ctx context.Context, block blocks.Block) (err error) {
 	return task.Wait()
 }
 
-// PutMany adds the given blocks to the DagNode
-func (d *DagNode) PutMany(ctx context.Context, blocks []blocks.Block) (err error) {
-	for _, block := range blocks {
-		err = d.Put(ctx, block)
+// encodeBlock returns the encoded meta and the shards of the block,
+// the block is erasure-coded or replicated according to the replica threshold
+func (d *DagNode) encodeBlock(block blocks.Block) (meta []byte, shards [][]byte, err error) {
+	// copy data from block, because reedsolomon may modify data
+	buf := bytes.NewBuffer(nil)
+	buf.Write(block.RawData())
+	blockData := buf.Bytes()
+
+	blockMeta := d.blockMeta(len(blockData))
+	codec, err := d.newCodec(blockMeta)
+	if err != nil {
+		return nil, nil, err
 	}
-	return err
+	shards, err = codec.EncodeData(blockData)
+	if err != nil {
+		log.Errorf("encodeData fail :%v", err)
+		return nil, nil, err
+	}
+	return blockMeta.Encode(), shards, nil
 }
 
-// AllKeysChan returns a channel that will yield every key in the dag
+// AllKeysChan returns a channel that will yield every key in the dag.
+// The sorted keys of all data nodes are merged, and each key is yielded only once.
+// A key is written to at least the write quorum of the data nodes, so the listing fails
+// once fewer data nodes than the rest of the data nodes plus one can list their keys.
 func (d *DagNode) AllKeysChan(ctx context.Context) (<-chan cid.Cid, error) {
-	panic("implement me")
+	ctx, cancel := context.WithCancel(ctx)
+	nodes, release := d.acquireNodes()
+	_, entryWriteQuorum := d.entryQuorum()
+	listQuorum := len(nodes) - entryWriteQuorum + 1
+
+	// the cached heartbeat state may be stale, so every data node is asked for its keys
+	var streams []proto.DataNode_AllKeysChanClient
+	for _, snode := range nodes {
+		stream, err := snode.Client.DataClient.AllKeysChan(ctx, &proto.AllKeysChanRequest{})
+		if err != nil {
+			log.Errorw("all keys chan error", "datanode", snode.RpcAddress, "error", err)
+			continue
+		}
+		streams = append(streams, stream)
+	}
+	keys := newQuorumKeyMerger(streams, listQuorum)
+	if len(streams) < listQuorum {
+		cancel()
+		release()
+		return nil, errErasureListQuorum
+	}
+	if err := keys.start(); err != nil {
+		log.Errorw("list keys error", "dagnode", d.config.Name, "error", err)
+		cancel()
+		release()
+		return nil, errErasureListQuorum
+	}
+
+	out := make(chan cid.Cid)
+	go func() {
+		defer release()
+		defer cancel()
+		defer close(out)
+		for {
+			key, err := keys.Recv()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				if !contextCanceled(ctx) {
+					log.Errorw("list keys error", "dagnode", d.config.Name, "error", errErasureListQuorum, "cause", err)
+				}
+				return
+			}
+			c, err := cid.Decode(key)
+			if err != nil {
+				log.Errorw("decode cid error", "key", key, "error", err)
+				continue
+			}
+			select {
+			case out <- c:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
 }
 
 // HashOnRead tells the dag node to calculate the hash of the block
+// and verify it against the cid on every Get
 func (d *DagNode) HashOnRead(enabled bool) {
-	panic("implement me")
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&d.hashOnRead, v)
+}
+
+// HashMismatches returns the number of blocks whose hash mismatched the cid when reading
+func (d *DagNode) HashMismatches() uint64 {
+	return atomic.LoadUint64(&d.hashMismatches)
 }
 
 func (d *DagNode) Close() {
-	for _, nd := range d.Nodes {
+	for _, nd := range d.Nodes() {
 		nd.Conn.Close()
 	}
 	close(d.stopCh)
 	close(d.repairQueue)
 }
 
+// putShard puts the shard to the data node, the shard larger than one message is sent by stream
+func putShard(ctx context.Context, node *datanode.Client, key string, meta []byte, shard []byte) error {
+	if len(shard) > datanode.StreamChunkSize {
+		return node.PutStream(ctx, key, meta, shard)
+	}
+	_, err := node.DataClient.Put(ctx, &proto.AddRequest{
+		Key:  key,
+		Meta: meta,
+		Data: shard,
+	})
+	return err
+}
+
+// getShard gets the shard from the data node, the shard larger than one message is received by stream
+func getShard(ctx context.Context, node *datanode.Client, key string, shardSize int64) ([]byte, error) {
+	if shardSize > datanode.StreamChunkSize {
+		_, data, err := node.GetStream(ctx, key)
+		return data, err
+	}
+	res, err := node.DataClient.Get(ctx, &proto.GetRequest{Key: key})
+	if err != nil {
+		return nil, err
+	}
+	return res.Data, nil
+}
+
+// getShardRange gets the data in [offset, offset+length) of the shard from the data node
+func getShardRange(ctx context.Context, node *datanode.Client, key string, offset, length int64) ([]byte, error) {
+	if length > datanode.StreamChunkSize {
+		_, data, err := node.GetRangeStream(ctx, key, offset, length)
+		return data, err
+	}
+	res, err := node.DataClient.Get(ctx, &proto.GetRequest{Key: key, Offset: offset, Length: length})
+	if err != nil {
+		return nil, err
+	}
+	return res.Data, nil
+}
+
 // Returns per entry readQuorum and writeQuorum
 // readQuorum is the min required nodes to read data.
 // writeQuorum is the min required nodes to write data.
 func (d *DagNode) entryQuorum() (entryReadQuorum, entryWriteQuorum int) {
+	// the layout with one data block is plain replication, the majority of the replicas is read and written,
+	// so that the replicas read always overlap the written ones
+	if d.config.DataBlocks == 1 {
+		quorum := (d.config.DataBlocks+d.config.ParityBlocks)/2 + 1
+		return quorum, quorum
+	}
 	writeQuorum := d.config.DataBlocks
 	if d.config.DataBlocks == d.config.ParityBlocks {
 		writeQuorum++
@@ -471,9 +680,7 @@ func readAllMeta(ctx context.Context, nodes []*StorageNode, key string) ([]Meta,
 				}
 				return
 			}
-			meta := Meta{}
-			metaBuf := bytes.NewBuffer(resp.Meta)
-			err = binary.Read(metaBuf, binary.LittleEndian, &meta)
+			meta, err := decodeMeta(resp.Meta)
 			if err != nil {
 				errs[index] = err
 				return
@@ -488,6 +695,16 @@ func readAllMeta(ctx context.Context, nodes []*StorageNode, key string) ([]Meta,
 	return metadataArray, errs
 }
 
+// findBlockMeta returns the meta of the block agreed by the read quorum, the meta of a replicated block
+// must be agreed by the quorum as well, so that a stale data node can not change the mode of the block
+func (d *DagNode) findBlockMeta(ctx context.Context, metas []Meta, errs []error) (Meta, error) {
+	entryReadQuorum, _ := d.entryQuorum()
+	if err := reduceQuorumErrs(ctx, errs, entryOpIgnoredErrs, entryReadQuorum, errErasureReadQuorum); err != nil {
+		return Meta{}, err
+	}
+	return findMetaInQuorum(ctx, metas, entryReadQuorum)
+}
+
 func findMetaInQuorum(ctx context.Context, metaArr []Meta, quorum int) (Meta, error) {
 	// with less quorum return error.
 	if quorum < 2 {
@@ -496,7 +713,7 @@ func findMetaInQuorum(ctx context.Context, metaArr []Meta, quorum int) (Meta, er
 	metaHashes := make([]string, len(metaArr))
 	h := sha256.New()
 	for i, meta := range metaArr {
-		fmt.Fprint(h, meta.BlockSize)
+		fmt.Fprint(h, meta.BlockSize, meta.Mode)
 
 		metaHashes[i] = hex.EncodeToString(h.Sum(nil))
 		h.Reset()
@@ -4,15 +4,29 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"github.com/filedag-project/filedag-storage/dag/config"
 	"github.com/filedag-project/filedag-storage/dag/node/datanode"
 	"github.com/filedag-project/filedag-storage/dag/node/datanode/mocks"
 	"github.com/filedag-project/filedag-storage/dag/proto"
+	"github.com/filedag-project/filedag-storage/kv"
 	"github.com/golang/mock/gomock"
 	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	blockstore "github.com/ipfs/go-ipfs-blockstore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"io"
+	"math/rand"
 	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestDagNode(t *testing.T) {
@@ -24,7 +38,7 @@ func TestDagNode(t *testing.T) {
 		clients = append(clients, &StorageNode{Client: cli})
 	}
 	var d = DagNode{
-		Nodes: clients,
+		nodeSet: &nodeSet{nodes: clients},
 		config: config.DagNodeConfig{
 			DataBlocks:   2,
 			ParityBlocks: 1,
@@ -95,3 +109,785 @@ func newDatanode(t *testing.T, dataBlocks, parityBlocks int, index int) *mocks.M
 		Return(&proto.SizeResponse{Size: int64(datanode.HeaderSize + 4 + len(block.RawData()))}, nil)
 	return m
 }
+
+func TestDagNode_PutManyGetMany(t *testing.T) {
+	d, _ := newMemDagNode(t)
+	ctx := context.TODO()
+	var blks []blocks.Block
+	var cids []cid.Cid
+	for i := 0; i < 10; i++ {
+		block := blocks.NewBlock([]byte(fmt.Sprintf("block content %d", i)))
+		blks = append(blks, block)
+		cids = append(cids, block.Cid())
+	}
+	if err := d.PutMany(ctx, blks); err != nil {
+		t.Fatal(err)
+	}
+	missing := blocks.NewBlock([]byte("missing block"))
+	got, errs := d.GetMany(ctx, append(cids, missing.Cid()))
+	for i, block := range blks {
+		if errs[i] != nil {
+			t.Fatalf("get block %v failed: %v", block.Cid(), errs[i])
+		}
+		if !bytes.Equal(block.RawData(), got[i].RawData()) {
+			t.Fatal("the block from dagnode is not equal the origin block")
+		}
+	}
+	if got[len(blks)] != nil || errs[len(blks)] == nil {
+		t.Fatal("the missing block should not be found")
+	}
+}
+
+func TestDagNode_GetManyOffline(t *testing.T) {
+	d, _ := newMemDagNode(t)
+	ctx := context.TODO()
+	blks := []blocks.Block{blocks.NewBlock([]byte("block 1")), blocks.NewBlock([]byte("block 2"))}
+	if err := d.PutMany(ctx, blks); err != nil {
+		t.Fatal(err)
+	}
+	// the offline data node is not asked, the mock without expectations fails on any call
+	offline := d.Nodes()[0]
+	offline.State = false
+	offline.Client = &datanode.Client{DataClient: mocks.NewMockDataNodeClient(gomock.NewController(t))}
+	got, errs := d.GetMany(ctx, []cid.Cid{blks[0].Cid(), blks[1].Cid()})
+	for i, blk := range blks {
+		if errs[i] != nil || !bytes.Equal(got[i].RawData(), blk.RawData()) {
+			t.Fatalf("get the block %d error: %v", i, errs[i])
+		}
+	}
+}
+
+func TestDagNode_AllKeysChan(t *testing.T) {
+	d, stores := newMemDagNode(t)
+	ctx := context.TODO()
+	expected := make(map[cid.Cid]bool)
+	var blks []blocks.Block
+	for i := 0; i < 10; i++ {
+		block := blocks.NewBlock([]byte(fmt.Sprintf("block content %d", i)))
+		blks = append(blks, block)
+		expected[block.Cid()] = true
+	}
+	// PutMany returns after all the data nodes are written
+	if err := d.PutMany(ctx, blks); err != nil {
+		t.Fatal(err)
+	}
+	// the key only stored in one data node should be yielded as well
+	single := blocks.NewBlock([]byte("single"))
+	stores[2][single.Cid().String()] = &proto.AddRequest{Key: single.Cid().String()}
+	expected[single.Cid()] = true
+
+	ch, err := d.AllKeysChan(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[cid.Cid]bool)
+	for c := range ch {
+		if got[c] {
+			t.Fatalf("duplicate key %v", c)
+		}
+		got[c] = true
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %d keys, got %d keys", len(expected), len(got))
+	}
+
+	// the keys are listed before the first heartbeat
+	for _, sn := range d.Nodes() {
+		sn.State = false
+	}
+	ch, err = d.AllKeysChan(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for range ch {
+		n++
+	}
+	if n != len(expected) {
+		t.Fatalf("expected %d keys, got %d keys", len(expected), n)
+	}
+
+	// every block is kept by 2 of the 3 data nodes, the keys are listed without one data node,
+	// even if it fails in the middle of the listing
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+	d.Nodes()[0].Client = &datanode.Client{DataClient: newFailingKeysDatanode(t, stores[0], unavailable)}
+	ch, err = d.AllKeysChan(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n = 0
+	for range ch {
+		n++
+	}
+	if n != len(expected) {
+		t.Fatalf("expected %d keys, got %d keys", len(expected), n)
+	}
+	d.Nodes()[0].Client = &datanode.Client{DataClient: newFailingKeysDatanode(t, nil, unavailable)}
+	d.Nodes()[1].Client = &datanode.Client{DataClient: newFailingKeysDatanode(t, nil, unavailable)}
+	if _, err = d.AllKeysChan(ctx); err != errErasureListQuorum {
+		t.Fatalf("expected the list quorum error, got %v", err)
+	}
+}
+
+// newFailingKeysDatanode returns a data node client which lists the keys of the store and then fails with err
+func newFailingKeysDatanode(t *testing.T, store map[string]*proto.AddRequest, err error) *mocks.MockDataNodeClient {
+	m := mocks.NewMockDataNodeClient(gomock.NewController(t))
+	m.EXPECT().AllKeysChan(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(ctx context.Context, in *proto.AllKeysChanRequest, opts ...grpc.CallOption) (proto.DataNode_AllKeysChanClient, error) {
+			stream := &memKeysStream{err: err}
+			for key := range store {
+				stream.keys = append(stream.keys, key)
+			}
+			sort.Strings(stream.keys)
+			// half of the keys are listed before the failure
+			stream.keys = stream.keys[:len(stream.keys)/2]
+			return stream, nil
+		})
+	return m
+}
+
+func TestDagNode_HashOnRead(t *testing.T) {
+	d, stores := newMemDagNode(t)
+	ctx := context.TODO()
+	block := blocks.NewBlock([]byte("block content"))
+	if err := d.PutMany(ctx, []blocks.Block{block}); err != nil {
+		t.Fatal(err)
+	}
+	// corrupt the first data shard
+	en := stores[0][block.Cid().String()]
+	en.Data = append([]byte{en.Data[0] + 1}, en.Data[1:]...)
+
+	if _, err := d.Get(ctx, block.Cid()); err != nil {
+		t.Fatal("the hash should not be verified when hash on read is disabled")
+	}
+	d.HashOnRead(true)
+	if _, err := d.Get(ctx, block.Cid()); err != blockstore.ErrHashMismatch {
+		t.Fatalf("expected hash mismatch, got %v", err)
+	}
+	if d.HashMismatches() != 1 {
+		t.Fatalf("expected 1 hash mismatch, got %d", d.HashMismatches())
+	}
+}
+
+func TestDagNode_ScrubBlock(t *testing.T) {
+	d, stores := newMemDagNode(t)
+	ctx := context.TODO()
+	block := blocks.NewBlock([]byte("block content"))
+	if err := d.PutMany(ctx, []blocks.Block{block}); err != nil {
+		t.Fatal(err)
+	}
+	key := block.Cid().String()
+	if repairing, err := d.ScrubBlock(ctx, block.Cid()); err != nil || repairing {
+		t.Fatalf("the healthy block should not be repaired, repairing: %v, err: %v", repairing, err)
+	}
+
+	// lose the shard of the second data node
+	shard := stores[1][key].Data
+	delete(stores[1], key)
+	repairing, err := d.ScrubBlock(ctx, block.Cid())
+	if err != nil || !repairing {
+		t.Fatalf("the broken block should be repaired, repairing: %v, err: %v", repairing, err)
+	}
+	task := <-d.repairQueue
+	task(ctx)
+	en, ok := stores[1][key]
+	if !ok || !bytes.Equal(en.Data, shard) {
+		t.Fatal("the shard was not repaired")
+	}
+}
+
+func TestDagNode_ScrubRoundListError(t *testing.T) {
+	d, _ := newMemDagNode(t)
+	ctx := context.TODO()
+	block := blocks.NewBlock([]byte("block content"))
+	if err := d.PutMany(ctx, []blocks.Block{block}); err != nil {
+		t.Fatal(err)
+	}
+	listErr := errors.New("list keys error")
+	source := func(ctx context.Context, cursor string) (<-chan *ScrubEntry, <-chan error, error) {
+		ch := make(chan *ScrubEntry, 1)
+		errs := make(chan error, 1)
+		ch <- &ScrubEntry{Cursor: block.Cid().String(), Key: block.Cid().String()}
+		errs <- listErr
+		close(ch)
+		close(errs)
+		return ch, errs, nil
+	}
+	limiter := time.NewTicker(time.Millisecond)
+	defer limiter.Stop()
+	// the round is not finished, so the scrubber keeps the cursor and retries
+	if err := d.scrubRound(ctx, "", limiter, source, nil); err != listErr {
+		t.Fatalf("expected the list error, got %v", err)
+	}
+	if stats := d.ScrubStats(); stats.Scrubbed != 1 {
+		t.Fatalf("expected 1 scrubbed block, got %d", stats.Scrubbed)
+	}
+}
+
+// memRepairStore keeps the repair tasks in memory, ordered by the number of lost shards
+type memRepairStore struct {
+	lk    sync.Mutex
+	tasks []*RepairTask
+}
+
+func (m *memRepairStore) PushRepairTask(dagNodeName string, key string, lostShards int) error {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	for _, task := range m.tasks {
+		if task.Key == key {
+			if task.LostShards < lostShards {
+				task.LostShards = lostShards
+			}
+			return nil
+		}
+	}
+	m.tasks = append(m.tasks, &RepairTask{Key: key, LostShards: lostShards})
+	sort.SliceStable(m.tasks, func(i, j int) bool {
+		return m.tasks[i].LostShards > m.tasks[j].LostShards
+	})
+	return nil
+}
+
+func (m *memRepairStore) UpdateRepairTask(dagNodeName string, task *RepairTask) error {
+	return nil
+}
+
+func (m *memRepairStore) RemoveRepairTask(dagNodeName string, task *RepairTask) error {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	for i, t := range m.tasks {
+		if t.Key == task.Key {
+			m.tasks = append(m.tasks[:i], m.tasks[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (m *memRepairStore) RepairTasks(ctx context.Context, dagNodeName string) (<-chan *RepairTask, error) {
+	m.lk.Lock()
+	tasks := make([]*RepairTask, len(m.tasks))
+	for i, task := range m.tasks {
+		t := *task
+		tasks[i] = &t
+	}
+	m.lk.Unlock()
+	ch := make(chan *RepairTask, len(tasks))
+	for _, task := range tasks {
+		ch <- task
+	}
+	close(ch)
+	return ch, nil
+}
+
+func TestDagNode_RepairStore(t *testing.T) {
+	d, stores := newMemDagNode(t)
+	store := &memRepairStore{}
+	d.UseRepairStore(store)
+	ctx := context.TODO()
+	blks := []blocks.Block{blocks.NewBlock([]byte("block 1")), blocks.NewBlock([]byte("block 2"))}
+	if err := d.PutMany(ctx, blks); err != nil {
+		t.Fatal(err)
+	}
+	key1, key2 := blks[0].Cid().String(), blks[1].Cid().String()
+	delete(stores[0], key1)
+	delete(stores[1], key2)
+	delete(stores[2], key2)
+	// the second block can not be rebuilt with only one shard
+	for _, blk := range blks {
+		d.ScrubBlock(ctx, blk.Cid())
+	}
+	if len(store.tasks) != 1 || store.tasks[0].Key != key1 {
+		t.Fatalf("expected one task of %s, got %v", key1, store.tasks)
+	}
+	// queued twice but kept once
+	d.ScrubBlock(ctx, blks[0].Cid())
+	if len(store.tasks) != 1 {
+		t.Fatalf("expected one task, got %d", len(store.tasks))
+	}
+
+	// the drain waits for the run of the background worker
+	if err := d.lockRepair(ctx); err != nil {
+		t.Fatal(err)
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	_, _, err := d.DrainRepairTasks(waitCtx)
+	cancel()
+	if err != context.DeadlineExceeded || len(store.tasks) != 1 {
+		t.Fatalf("the drain should wait for the running repair, err: %v", err)
+	}
+	d.unlockRepair()
+
+	repaired, failed, err := d.DrainRepairTasks(ctx)
+	if err != nil || repaired != 1 || failed != 0 {
+		t.Fatalf("unexpected drain result, repaired: %d, failed: %d, err: %v", repaired, failed, err)
+	}
+	if _, ok := stores[0][key1]; !ok {
+		t.Fatal("the shard was not repaired")
+	}
+	if len(store.tasks) != 0 {
+		t.Fatalf("expected no task, got %d", len(store.tasks))
+	}
+
+	delete(stores[0], key1)
+	d.ScrubBlock(ctx, blks[0].Cid())
+	if discarded, err := d.DiscardRepairTasks(ctx); err != nil || discarded != 1 || len(store.tasks) != 0 {
+		t.Fatalf("unexpected discard result, discarded: %d, tasks: %d, err: %v", discarded, len(store.tasks), err)
+	}
+}
+
+func TestRepairBackoff(t *testing.T) {
+	if repairBackoff(1) != repairMinBackoff || repairBackoff(2) != 2*repairMinBackoff {
+		t.Fatal("unexpected backoff")
+	}
+	if repairBackoff(100) != repairMaxBackoff {
+		t.Fatal("the backoff should be capped")
+	}
+}
+
+func TestDagNode_RebuildDataNode(t *testing.T) {
+	d, stores := newMemDagNode(t)
+	ctx := context.TODO()
+	blks := []blocks.Block{blocks.NewBlock([]byte("block 1")), blocks.NewBlock([]byte("block 2"))}
+	if err := d.PutMany(ctx, blks); err != nil {
+		t.Fatal(err)
+	}
+	// the replaced data node is empty
+	for key := range stores[2] {
+		delete(stores[2], key)
+	}
+	atomic.StoreInt32(&d.Nodes()[2].rebuilding, 1)
+
+	// the degraded reads do not queue the repair of the rebuilding node
+	for _, blk := range blks {
+		if _, err := d.Get(ctx, blk.Cid()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(d.repairQueue) != 0 {
+		t.Fatalf("expected no repair task, got %d", len(d.repairQueue))
+	}
+
+	if err := d.RebuildDataNode(ctx, 2, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if d.Nodes()[2].IsRebuilding() {
+		t.Fatal("the data node should not be rebuilding")
+	}
+	for _, blk := range blks {
+		if _, ok := stores[2][blk.Cid().String()]; !ok {
+			t.Fatalf("the shard of %s was not rebuilt", blk.Cid())
+		}
+	}
+}
+
+func TestDagNode_RebuildDataNodeFromAllNodes(t *testing.T) {
+	// a 2+2 dag node can rebuild a block from any two data nodes
+	var clients []*StorageNode
+	var stores []map[string]*proto.AddRequest
+	for i := 0; i < 4; i++ {
+		store := make(map[string]*proto.AddRequest)
+		clients = append(clients, &StorageNode{Client: &datanode.Client{DataClient: newMemDatanode(t, store)}, State: true})
+		stores = append(stores, store)
+	}
+	d := &DagNode{
+		nodeSet:     &nodeSet{nodes: clients},
+		config:      config.DagNodeConfig{DataBlocks: 2, ParityBlocks: 2},
+		repairQueue: make(chan func(ctx context.Context), 10),
+	}
+	ctx := context.TODO()
+	blks := []blocks.Block{blocks.NewBlock([]byte("block 1")), blocks.NewBlock([]byte("block 2"))}
+	if err := d.PutMany(ctx, blks); err != nil {
+		t.Fatal(err)
+	}
+	for key := range stores[3] {
+		delete(stores[3], key)
+	}
+	// the first data node misses a block, it is listed by the others
+	delete(stores[0], blks[1].Cid().String())
+
+	if err := d.RebuildDataNode(ctx, 3, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, blk := range blks {
+		if _, ok := stores[3][blk.Cid().String()]; !ok {
+			t.Fatalf("the shard of %s was not rebuilt", blk.Cid())
+		}
+	}
+}
+
+func TestDagNode_RepairDataNodeResume(t *testing.T) {
+	d, stores := newMemDagNode(t)
+	ctx := context.TODO()
+	var blks []blocks.Block
+	for i := 0; i < 5; i++ {
+		blks = append(blks, blocks.NewBlock([]byte(fmt.Sprintf("block %d", i))))
+	}
+	if err := d.PutMany(ctx, blks); err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for key := range stores[2] {
+		keys = append(keys, key)
+		delete(stores[2], key)
+	}
+	sort.Strings(keys)
+
+	// resume after the second key, the first two keys are not repaired
+	var cursor string
+	if err := d.RepairDataNode(ctx, 0, 2, keys[1], func(c string) { cursor = c }); err != nil {
+		t.Fatal(err)
+	}
+	for i, key := range keys {
+		if _, ok := stores[2][key]; ok != (i > 1) {
+			t.Fatalf("the key %d is repaired: %v", i, ok)
+		}
+	}
+	if cursor != keys[len(keys)-1] {
+		t.Fatalf("the last cursor is %s, want %s", cursor, keys[len(keys)-1])
+	}
+}
+
+func TestDagNode_RepairDataNodeFailedKey(t *testing.T) {
+	d, stores := newMemDagNode(t)
+	ctx := context.TODO()
+	var blks []blocks.Block
+	for i := 0; i < 5; i++ {
+		blks = append(blks, blocks.NewBlock([]byte(fmt.Sprintf("block %d", i))))
+	}
+	if err := d.PutMany(ctx, blks); err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for key := range stores[2] {
+		keys = append(keys, key)
+		delete(stores[2], key)
+	}
+	sort.Strings(keys)
+	// the second key can not be repaired without the shard on the second data node
+	lost := stores[1][keys[1]]
+	delete(stores[1], keys[1])
+
+	var cursor string
+	err := d.RepairDataNode(ctx, 0, 2, "", func(c string) { cursor = c })
+	if !errors.Is(err, errKeyNotRepaired) {
+		t.Fatalf("expected the repair to fail, got %v", err)
+	}
+	if cursor != keys[0] {
+		t.Fatalf("the cursor should stay before the failed key, got %s", cursor)
+	}
+	for i, key := range keys {
+		if _, ok := stores[2][key]; ok != (i != 1) {
+			t.Fatalf("the key %d is repaired: %v", i, ok)
+		}
+	}
+
+	// the failed key is retried by resuming from the cursor
+	stores[1][keys[1]] = lost
+	if err = d.RepairDataNode(ctx, 0, 2, cursor, func(c string) { cursor = c }); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := stores[2][keys[1]]; !ok || cursor != keys[len(keys)-1] {
+		t.Fatalf("the failed key is not repaired, cursor %s", cursor)
+	}
+}
+
+func TestDagNode_ReplicaMode(t *testing.T) {
+	d, stores := newMemDagNode(t)
+	d.config.ReplicaThreshold = 16
+	ctx := context.TODO()
+	small := blocks.NewBlock([]byte("small block"))
+	large := blocks.NewBlock(bytes.Repeat([]byte("large block"), 4))
+	if err := d.PutMany(ctx, []blocks.Block{small, large}); err != nil {
+		t.Fatal(err)
+	}
+	key := small.Cid().String()
+	for i, store := range stores {
+		meta, err := decodeMeta(store[key].Meta)
+		if err != nil || meta.Mode != ModeReplica {
+			t.Fatalf("the block on data node %d should be replicated, meta: %v, err: %v", i, meta, err)
+		}
+		if !bytes.Equal(store[key].Data, small.RawData()) {
+			t.Fatalf("data node %d should keep a full replica", i)
+		}
+		if len(store[large.Cid().String()].Data) >= len(large.RawData()) {
+			t.Fatalf("the large block on data node %d should be erasure-coded", i)
+		}
+	}
+
+	// a broken data node claiming the erasure-coded block is replicated is outvoted by the quorum
+	largeKey := large.Cid().String()
+	origin := stores[0][largeKey]
+	stores[0][largeKey] = &proto.AddRequest{Key: largeKey, Meta: Meta{BlockSize: 4, Mode: ModeReplica}.Encode(), Data: []byte("bad!")}
+	if blk, err := d.Get(ctx, large.Cid()); err != nil || !bytes.Equal(blk.RawData(), large.RawData()) {
+		t.Fatalf("get erasure-coded block failed: %v", err)
+	}
+	stores[0][largeKey] = origin
+	for len(d.repairQueue) > 0 {
+		<-d.repairQueue
+	}
+
+	// the meta is agreed by the read quorum, and the block is read from one of the replicas
+	delete(stores[0], key)
+	blk, err := d.Get(ctx, small.Cid())
+	if err != nil || !bytes.Equal(blk.RawData(), small.RawData()) {
+		t.Fatalf("get replicated block failed: %v", err)
+	}
+	for len(d.repairQueue) > 0 {
+		<-d.repairQueue
+	}
+	// the read may return before the missing replica is noticed, the scrub always notices it
+	if queued, err := d.ScrubBlock(ctx, small.Cid()); err != nil || !queued {
+		t.Fatalf("the missing replica should be queued for repair, err: %v", err)
+	}
+	task := <-d.repairQueue
+	task(ctx)
+	for i, store := range stores {
+		if en, ok := store[key]; !ok || !bytes.Equal(en.Data, small.RawData()) {
+			t.Fatalf("the replica on data node %d was not repaired", i)
+		}
+	}
+
+	// a layout with one data block replicates all the blocks
+	d.config.DataBlocks, d.config.ParityBlocks, d.config.ReplicaThreshold = 1, 2, 0
+	larger := blocks.NewBlock(bytes.Repeat([]byte("larger block"), 4))
+	if err = d.PutMany(ctx, []blocks.Block{larger}); err != nil {
+		t.Fatal(err)
+	}
+	for i, store := range stores {
+		if en, ok := store[larger.Cid().String()]; !ok || !bytes.Equal(en.Data, larger.RawData()) {
+			t.Fatalf("data node %d should keep a full replica of the block", i)
+		}
+	}
+	delete(stores[0], larger.Cid().String())
+	if blk, err = d.Get(ctx, larger.Cid()); err != nil || !bytes.Equal(blk.RawData(), larger.RawData()) {
+		t.Fatalf("get block from the majority of the replicas failed: %v", err)
+	}
+}
+
+func TestDagNode_GetRange(t *testing.T) {
+	d, stores := newMemDagNode(t)
+	d.config.ReplicaThreshold = 16
+	ctx := context.TODO()
+	data := make([]byte, 100)
+	rand.Read(data)
+	large := blocks.NewBlock(data)
+	small := blocks.NewBlock([]byte("small block"))
+	if err := d.PutMany(ctx, []blocks.Block{large, small}); err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		name           string
+		block          blocks.Block
+		offset, length int64
+	}{
+		{"in one shard", large, 10, 20},
+		{"across shards", large, 40, 30},
+		{"truncated", large, 90, 50},
+		{"empty", large, 100, 10},
+		{"replica", small, 2, 5},
+	}
+	check := func(t *testing.T, blk blocks.Block, offset, length int64) {
+		got, err := d.GetRange(ctx, blk.Cid(), offset, length)
+		if err != nil {
+			t.Fatal(err)
+		}
+		end := offset + length
+		if end > int64(len(blk.RawData())) {
+			end = int64(len(blk.RawData()))
+		}
+		if !bytes.Equal(got, blk.RawData()[offset:end]) {
+			t.Fatalf("range [%d, %d) mismatch", offset, end)
+		}
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			check(t, tc.block, tc.offset, tc.length)
+		})
+	}
+	if _, err := d.GetRange(ctx, large.Cid(), 101, 1); err != kv.ErrInvalidRange {
+		t.Fatalf("expected invalid range, got %v", err)
+	}
+
+	// the whole block is read to verify its hash once hash on read is enabled
+	key := large.Cid().String()
+	parity := stores[2][key]
+	delete(stores[2], key)
+	en := stores[1][key]
+	shard := en.Data
+	en.Data = append([]byte{shard[0] + 1}, shard[1:]...)
+	if got, err := d.GetRange(ctx, large.Cid(), 40, 30); err != nil || bytes.Equal(got, data[40:70]) {
+		t.Fatalf("the corrupted shard should be read without hash on read, err: %v", err)
+	}
+	d.HashOnRead(true)
+	if _, err := d.GetRange(ctx, large.Cid(), 40, 30); err != blockstore.ErrHashMismatch {
+		t.Fatalf("expected hash mismatch, got %v", err)
+	}
+	d.HashOnRead(false)
+	en.Data = shard
+	stores[2][key] = parity
+
+	// the block is reconstructed if the data shard is lost
+	delete(stores[0], large.Cid().String())
+	delete(stores[0], small.Cid().String())
+	for _, tc := range testCases {
+		check(t, tc.block, tc.offset, tc.length)
+	}
+}
+
+func TestDagNode_ReadOnlyDataNode(t *testing.T) {
+	d, stores := newMemDagNode(t)
+	ctx := context.TODO()
+	d.Nodes()[2].setStat(&proto.StatResponse{ReadOnly: true})
+	block := blocks.NewBlock([]byte("block content"))
+	if err := d.Put(ctx, block); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := stores[2][block.Cid().String()]; ok {
+		t.Fatal("the shard should not be sent to the read-only data node")
+	}
+	if !d.DiskStats()[2].ReadOnly {
+		t.Fatal("the disk stat should be read-only")
+	}
+
+	// the writable data nodes can not meet the write quorum
+	d.Nodes()[1].setStat(&proto.StatResponse{ReadOnly: true})
+	if err := d.PutMany(ctx, []blocks.Block{blocks.NewBlock([]byte("other"))}); err != errNotEnoughWritableNodes {
+		t.Fatalf("expected %v, got %v", errNotEnoughWritableNodes, err)
+	}
+}
+
+// newMemDagNode returns a 2+1 dag node whose data nodes store the entries in memory
+func newMemDagNode(t *testing.T) (*DagNode, []map[string]*proto.AddRequest) {
+	var clients []*StorageNode
+	var stores []map[string]*proto.AddRequest
+	for i := 0; i < 3; i++ {
+		store := make(map[string]*proto.AddRequest)
+		cli := &datanode.Client{
+			DataClient: newMemDatanode(t, store),
+		}
+		clients = append(clients, &StorageNode{Client: cli, State: true})
+		stores = append(stores, store)
+	}
+	return &DagNode{
+		nodeSet: &nodeSet{nodes: clients},
+		config: config.DagNodeConfig{
+			DataBlocks:   2,
+			ParityBlocks: 1,
+		},
+		repairQueue: make(chan func(ctx context.Context), 10),
+	}, stores
+}
+
+type memKeysStream struct {
+	grpc.ClientStream
+	keys []string
+	// err is returned after the keys, io.EOF if nil
+	err error
+}
+
+func (s *memKeysStream) Recv() (*proto.AllKeysChanResponse, error) {
+	if len(s.keys) == 0 {
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, io.EOF
+	}
+	key := s.keys[0]
+	s.keys = s.keys[1:]
+	return &proto.AllKeysChanResponse{Key: key, Cursor: key}, nil
+}
+
+// newMemDatanode returns a data node client which stores the entries in the given map
+func newMemDatanode(t *testing.T, store map[string]*proto.AddRequest) *mocks.MockDataNodeClient {
+	var lk sync.Mutex
+	notFound := status.Error(codes.Unknown, kv.ErrNotFound.Error())
+	ctrl := gomock.NewController(t)
+	m := mocks.NewMockDataNodeClient(ctrl)
+	m.EXPECT().Put(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(ctx context.Context, in *proto.AddRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+			lk.Lock()
+			defer lk.Unlock()
+			store[in.Key] = in
+			return &emptypb.Empty{}, nil
+		})
+	m.EXPECT().Get(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(ctx context.Context, in *proto.GetRequest, opts ...grpc.CallOption) (*proto.GetResponse, error) {
+			lk.Lock()
+			defer lk.Unlock()
+			en, ok := store[in.Key]
+			if !ok {
+				return nil, notFound
+			}
+			data := en.Data
+			if in.Offset != 0 || in.Length != 0 {
+				end := in.Offset + in.Length
+				if in.Length == 0 || end > int64(len(data)) {
+					end = int64(len(data))
+				}
+				data = data[in.Offset:end]
+			}
+			return &proto.GetResponse{Meta: en.Meta, Data: data}, nil
+		})
+	m.EXPECT().GetMeta(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(ctx context.Context, in *proto.GetMetaRequest, opts ...grpc.CallOption) (*proto.GetMetaResponse, error) {
+			lk.Lock()
+			defer lk.Unlock()
+			en, ok := store[in.Key]
+			if !ok {
+				return nil, notFound
+			}
+			return &proto.GetMetaResponse{Meta: en.Meta}, nil
+		})
+	m.EXPECT().PutMany(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(ctx context.Context, in *proto.PutManyRequest, opts ...grpc.CallOption) (*proto.PutManyResponse, error) {
+			lk.Lock()
+			defer lk.Unlock()
+			for _, en := range in.Entries {
+				store[en.Key] = en
+			}
+			return &proto.PutManyResponse{Errors: make([]string, len(in.Entries))}, nil
+		})
+	m.EXPECT().GetMany(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(ctx context.Context, in *proto.GetManyRequest, opts ...grpc.CallOption) (*proto.GetManyResponse, error) {
+			lk.Lock()
+			defer lk.Unlock()
+			resp := &proto.GetManyResponse{}
+			for _, key := range in.Keys {
+				if en, ok := store[key]; ok {
+					resp.Entries = append(resp.Entries, &proto.GetManyEntry{Meta: en.Meta, Data: en.Data})
+				} else {
+					resp.Entries = append(resp.Entries, &proto.GetManyEntry{Error: kv.ErrNotFound.Error()})
+				}
+			}
+			return resp, nil
+		})
+	m.EXPECT().GetMetaMany(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(ctx context.Context, in *proto.GetManyRequest, opts ...grpc.CallOption) (*proto.GetMetaManyResponse, error) {
+			lk.Lock()
+			defer lk.Unlock()
+			resp := &proto.GetMetaManyResponse{}
+			for _, key := range in.Keys {
+				if en, ok := store[key]; ok {
+					resp.Entries = append(resp.Entries, &proto.GetMetaManyEntry{Meta: en.Meta})
+				} else {
+					resp.Entries = append(resp.Entries, &proto.GetMetaManyEntry{Error: kv.ErrNotFound.Error()})
+				}
+			}
+			return resp, nil
+		})
+	m.EXPECT().AllKeysChan(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(ctx context.Context, in *proto.AllKeysChanRequest, opts ...grpc.CallOption) (proto.DataNode_AllKeysChanClient, error) {
+			lk.Lock()
+			defer lk.Unlock()
+			stream := &memKeysStream{}
+			for key := range store {
+				if key > in.StartAfter {
+					stream.keys = append(stream.keys, key)
+				}
+			}
+			sort.Strings(stream.keys)
+			return stream, nil
+		})
+	return m
+}
@@ -0,0 +1,111 @@
+package dagnode
+
+import (
+	"context"
+	"errors"
+	"github.com/filedag-project/filedag-storage/kv"
+	"github.com/ipfs/go-cid"
+	"sync"
+	"sync/atomic"
+)
+
+// GetRange returns the data in [offset, offset+length) of the block, the range is truncated at the end of the block.
+// Only the byte ranges of the data shards covering the range are read, the data nodes verify the checksums
+// of the shards, and the block is reconstructed from the other shards when some of them can not be read or verified.
+// The whole block is read if HashOnRead is enabled, so that its hash is verified.
+func (d *DagNode) GetRange(ctx context.Context, cid cid.Cid, offset, length int64) ([]byte, error) {
+	log.Debugf("get block range, cid :%v, offset: %d, length: %d", cid, offset, length)
+	nodes, release := d.acquireNodes()
+	defer release()
+	meta, _, onlineNodes, err := d.getMetaInfo(ctx, nodes, cid)
+	if err != nil {
+		return nil, err
+	}
+	size := int64(meta.BlockSize)
+	if offset < 0 || length < 0 || offset > size {
+		return nil, kv.ErrInvalidRange
+	}
+	if offset+length > size {
+		length = size - offset
+	}
+	if length == 0 {
+		return []byte{}, nil
+	}
+	keyCode := cid.String()
+	if atomic.LoadInt32(&d.hashOnRead) == 0 {
+		data, err := d.readRange(ctx, keyCode, meta, onlineNodes, offset, length)
+		if err == nil {
+			return data, nil
+		}
+		log.Warnw("read range failed, reconstruct the block", "key", keyCode, "error", err)
+	}
+	blk, err := d.Get(ctx, cid)
+	if err != nil {
+		return nil, err
+	}
+	return blk.RawData()[offset : offset+length], nil
+}
+
+// readRange reads the range from the data shards holding it, or from any replica of the replicated block
+func (d *DagNode) readRange(ctx context.Context, key string, meta Meta, onlineNodes []*StorageNode, offset, length int64) ([]byte, error) {
+	if meta.Mode == ModeReplica {
+		err := errErasureReadQuorum
+		for _, snode := range onlineNodes {
+			if snode == nil {
+				continue
+			}
+			var data []byte
+			if data, err = getShardRange(ctx, snode.Client, key, offset, length); err == nil && int64(len(data)) == length {
+				return data, nil
+			}
+			log.Warnw("get range error", "datanode", snode.RpcAddress, "key", key, "error", err)
+		}
+		return nil, err
+	}
+
+	codec, err := d.newCodec(meta)
+	if err != nil {
+		return nil, err
+	}
+	shardSize := codec.ShardSize()
+	first, last := offset/shardSize, (offset+length-1)/shardSize
+	data := make([]byte, length)
+	errs := make([]error, last-first+1)
+	var wg sync.WaitGroup
+	for i := first; i <= last; i++ {
+		snode := onlineNodes[i]
+		if snode == nil {
+			errs[i-first] = errNodeNotFound
+			continue
+		}
+		// the part of the range in the i-th shard
+		start, end := i*shardSize, (i+1)*shardSize
+		if start < offset {
+			start = offset
+		}
+		if end > offset+length {
+			end = offset + length
+		}
+		wg.Add(1)
+		go func(index int64, snode *StorageNode, start, end int64) {
+			defer wg.Done()
+			shard, err := getShardRange(ctx, snode.Client, key, start-index*shardSize, end-start)
+			if err == nil && int64(len(shard)) != end-start {
+				err = errors.New("the shard range is incomplete")
+			}
+			if err != nil {
+				log.Warnw("get range error", "datanode", snode.RpcAddress, "key", key, "error", err)
+				errs[index-first] = err
+				return
+			}
+			copy(data[start-offset:], shard)
+		}(i, snode, start, end)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
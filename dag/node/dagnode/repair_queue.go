@@ -0,0 +1,218 @@
+package dagnode
+
+import (
+	"context"
+	"github.com/ipfs/go-cid"
+	"time"
+)
+
+const (
+	// repairTaskTimeout is the timeout of repairing one block
+	repairTaskTimeout = 30 * time.Second
+	// repairScanInterval is the interval to look for the due repair tasks
+	repairScanInterval = 10 * time.Second
+	// repairMinBackoff and repairMaxBackoff bound the delay before retrying a failed repair task
+	repairMinBackoff = 10 * time.Second
+	repairMaxBackoff = time.Hour
+)
+
+// RepairTask is a block waiting to be repaired
+type RepairTask struct {
+	Key string
+	// LostShards is the number of shards to be repaired, the tasks which lost more shards are repaired first
+	LostShards int
+	// Attempts is the number of failed repairs
+	Attempts int
+	// NextAttempt is the unix time in nanoseconds before which the task is not retried
+	NextAttempt int64
+	LastError   string
+}
+
+// RepairStore persists the repair tasks of the dag nodes
+type RepairStore interface {
+	// PushRepairTask adds a task, the task of the same key is merged and keeps the larger number of lost shards
+	PushRepairTask(dagNodeName string, key string, lostShards int) error
+	UpdateRepairTask(dagNodeName string, task *RepairTask) error
+	RemoveRepairTask(dagNodeName string, task *RepairTask) error
+	// RepairTasks lists the tasks of the dag node, the tasks which lost more shards come first
+	RepairTasks(ctx context.Context, dagNodeName string) (<-chan *RepairTask, error)
+}
+
+// UseRepairStore persists the repair tasks in the store instead of the in-memory queue,
+// it must be called before RunRepairTask
+func (d *DagNode) UseRepairStore(store RepairStore) {
+	d.repairStore = store
+	d.repairNotify = make(chan struct{}, 1)
+	d.repairRunning = make(chan struct{}, 1)
+}
+
+// lockRepair waits for the other run of the repair tasks to finish, unlockRepair must be called after the run
+func (d *DagNode) lockRepair(ctx context.Context) error {
+	select {
+	case d.repairRunning <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (d *DagNode) unlockRepair() {
+	<-d.repairRunning
+}
+
+// pushRepairTask persists a repair task and wakes up the repair worker
+func (d *DagNode) pushRepairTask(key string, lostShards int) bool {
+	if err := d.repairStore.PushRepairTask(d.config.Name, key, lostShards); err != nil {
+		log.Errorw("push repair task error", "key", key, "error", err)
+		return false
+	}
+	select {
+	case d.repairNotify <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// runRepairStoreTask runs the repair tasks in the store until the context is done
+func (d *DagNode) runRepairStoreTask(ctx context.Context) {
+	ticker := time.NewTicker(repairScanInterval)
+	defer ticker.Stop()
+	for {
+		if _, _, err := d.runRepairTasks(ctx, false); err != nil && ctx.Err() == nil {
+			log.Errorw("run repair tasks error", "dagnode", d.config.Name, "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.repairNotify:
+		case <-ticker.C:
+		}
+	}
+}
+
+// DrainRepairTasks runs all the repair tasks of the dag node at once, ignoring the backoff.
+// It waits for the background worker to finish its run first. Returns the number of repaired and failed tasks.
+func (d *DagNode) DrainRepairTasks(ctx context.Context) (repaired int, failed int, err error) {
+	if d.repairStore == nil {
+		return 0, 0, nil
+	}
+	return d.runRepairTasks(ctx, true)
+}
+
+// DiscardRepairTasks removes all the repair tasks of the dag node without repairing them,
+// it waits for the background worker to finish its run first. Returns the number of removed tasks.
+func (d *DagNode) DiscardRepairTasks(ctx context.Context) (discarded int, err error) {
+	if d.repairStore == nil {
+		return 0, nil
+	}
+	if err = d.lockRepair(ctx); err != nil {
+		return 0, err
+	}
+	defer d.unlockRepair()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	tasks, err := d.repairStore.RepairTasks(ctx, d.config.Name)
+	if err != nil {
+		return 0, err
+	}
+	for task := range tasks {
+		if err = d.repairStore.RemoveRepairTask(d.config.Name, task); err != nil {
+			return discarded, err
+		}
+		discarded++
+	}
+	return discarded, ctx.Err()
+}
+
+// runRepairTasks runs the repair tasks in priority order, the tasks not due are skipped unless force is set
+func (d *DagNode) runRepairTasks(ctx context.Context, force bool) (repaired int, failed int, err error) {
+	if err = d.lockRepair(ctx); err != nil {
+		return 0, 0, err
+	}
+	defer d.unlockRepair()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	tasks, err := d.repairStore.RepairTasks(ctx, d.config.Name)
+	if err != nil {
+		return 0, 0, err
+	}
+	for task := range tasks {
+		if !force && time.Now().UnixNano() < task.NextAttempt {
+			continue
+		}
+		repairCtx, repairCancel := context.WithTimeout(ctx, repairTaskTimeout)
+		err = d.RepairBlockByKey(repairCtx, task.Key)
+		repairCancel()
+		if ctx.Err() != nil {
+			return repaired, failed, ctx.Err()
+		}
+		if err == nil {
+			repaired++
+			if err = d.repairStore.RemoveRepairTask(d.config.Name, task); err != nil {
+				return repaired, failed, err
+			}
+			continue
+		}
+		failed++
+		log.Warnw("repair block failed", "key", task.Key, "attempts", task.Attempts+1, "error", err)
+		task.Attempts++
+		task.LastError = err.Error()
+		task.NextAttempt = time.Now().Add(repairBackoff(task.Attempts)).UnixNano()
+		if err = d.repairStore.UpdateRepairTask(d.config.Name, task); err != nil {
+			return repaired, failed, err
+		}
+	}
+	return repaired, failed, ctx.Err()
+}
+
+// repairBackoff returns the delay before the next attempt of a task which failed the given times
+func repairBackoff(attempts int) time.Duration {
+	backoff := repairMinBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= repairMaxBackoff {
+			return repairMaxBackoff
+		}
+	}
+	return backoff
+}
+
+// RepairBlockByKey reads the shards of the block and repairs the broken ones
+func (d *DagNode) RepairBlockByKey(ctx context.Context, key string) error {
+	c, err := cid.Decode(key)
+	if err != nil {
+		return err
+	}
+	meta, shards, repairIndexes, err := d.inspectBlock(ctx, c)
+	if err != nil {
+		return err
+	}
+	var indexes []int
+	for i, ok := range repairIndexes {
+		if ok {
+			indexes = append(indexes, i)
+		}
+	}
+	if len(indexes) == 0 {
+		return nil
+	}
+	return d.repairBlock(ctx, key, meta, shards, indexes)
+}
+
+// RepairQueueDepth returns the number of the blocks waiting to be repaired
+func (d *DagNode) RepairQueueDepth(ctx context.Context) (int, error) {
+	if d.repairStore == nil {
+		return len(d.repairQueue), nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	tasks, err := d.repairStore.RepairTasks(ctx, d.config.Name)
+	if err != nil {
+		return 0, err
+	}
+	depth := 0
+	for range tasks {
+		depth++
+	}
+	return depth, ctx.Err()
+}
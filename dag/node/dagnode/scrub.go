@@ -0,0 +1,234 @@
+package dagnode
+
+import (
+	"context"
+	"github.com/ipfs/go-cid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sync"
+	"time"
+)
+
+// scrubProgressBatch is the number of scrubbed keys after which the progress is saved
+const scrubProgressBatch = 100
+
+// ScrubConfig is the configuration for the scrubber
+type ScrubConfig struct {
+	// Rate is the max number of blocks scrubbed per second, 0 disables the scrubber
+	Rate int
+	// Interval is the time to wait before starting the next round after a whole round is finished
+	Interval time.Duration
+}
+
+// ScrubEntry is a key to be scrubbed, Cursor is used to resume scrubbing after the key
+type ScrubEntry struct {
+	Cursor string
+	Key    string
+}
+
+// ScrubKeySource yields the keys of the dag node in a stable order, starting after the given cursor.
+// The error channel is closed after the entries, it yields the error which stopped the listing early,
+// so the round is not taken as finished.
+type ScrubKeySource func(ctx context.Context, cursor string) (<-chan *ScrubEntry, <-chan error, error)
+
+// ScrubProgressStore persists the cursor of the scrubber
+type ScrubProgressStore interface {
+	LoadScrubProgress(dagNodeName string) (string, error)
+	SaveScrubProgress(dagNodeName string, cursor string) error
+}
+
+// ScrubStats is the statistics of the scrubber
+type ScrubStats struct {
+	// Scrubbed is the number of scrubbed blocks
+	Scrubbed uint64
+	// Repairs is the number of blocks queued for repair
+	Repairs uint64
+	// Failures is the number of blocks which could not be checked or repaired
+	Failures uint64
+	// Rounds is the number of finished rounds
+	Rounds uint64
+	// LastRoundAt is the time when the last round was finished
+	LastRoundAt time.Time
+}
+
+type scrubStatsHolder struct {
+	sync.Mutex
+	stats ScrubStats
+}
+
+// ScrubStats returns the statistics of the scrubber
+func (d *DagNode) ScrubStats() ScrubStats {
+	d.scrubStats.Lock()
+	defer d.scrubStats.Unlock()
+	return d.scrubStats.stats
+}
+
+// RunScrubTask walks all the keys of the dag node continuously, checks the shards of each block
+// and queues the repair of the broken ones
+func (d *DagNode) RunScrubTask(ctx context.Context, cfg ScrubConfig, source ScrubKeySource, store ScrubProgressStore) {
+	if cfg.Rate <= 0 {
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-d.stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	limiter := time.NewTicker(time.Second / time.Duration(cfg.Rate))
+	defer limiter.Stop()
+	name := d.config.Name
+	for {
+		cursor, err := store.LoadScrubProgress(name)
+		if err != nil {
+			log.Errorw("load scrub progress error", "dagnode", name, "error", err)
+		}
+		wait := time.Minute
+		if err = d.scrubRound(ctx, cursor, limiter, source, store); err == nil {
+			if err = store.SaveScrubProgress(name, ""); err != nil {
+				log.Errorw("save scrub progress error", "dagnode", name, "error", err)
+			}
+			d.scrubStats.Lock()
+			d.scrubStats.stats.Rounds++
+			d.scrubStats.stats.LastRoundAt = time.Now()
+			d.scrubStats.Unlock()
+			log.Infow("scrub round finished", "dagnode", name)
+			wait = cfg.Interval
+		} else if ctx.Err() == nil {
+			log.Errorw("scrub round error", "dagnode", name, "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// scrubRound scrubs the keys after the cursor, and saves the progress periodically
+func (d *DagNode) scrubRound(ctx context.Context, cursor string, limiter *time.Ticker, source ScrubKeySource, store ScrubProgressStore) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	entries, errs, err := source(ctx, cursor)
+	if err != nil {
+		return err
+	}
+	name := d.config.Name
+	count := 0
+	for entry := range entries {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-limiter.C:
+		}
+		c, err := cid.Decode(entry.Key)
+		if err != nil {
+			log.Warnw("decode cid error", "key", entry.Key, "error", err)
+			continue
+		}
+		repairing, err := d.ScrubBlock(ctx, c)
+		d.scrubStats.Lock()
+		d.scrubStats.stats.Scrubbed++
+		if err != nil {
+			d.scrubStats.stats.Failures++
+		} else if repairing {
+			d.scrubStats.stats.Repairs++
+		}
+		d.scrubStats.Unlock()
+		if err != nil {
+			log.Warnw("scrub block error", "dagnode", name, "key", entry.Key, "error", err)
+		}
+
+		count++
+		if count%scrubProgressBatch == 0 {
+			if err = store.SaveScrubProgress(name, entry.Cursor); err != nil {
+				log.Errorw("save scrub progress error", "dagnode", name, "error", err)
+			}
+		}
+	}
+	// the cursor is kept if the listing failed, the round is retried from it
+	if err = <-errs; err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// ScrubBlock checks that every data node has a shard of the block with valid crc and consistent meta,
+// and queues a repair task if some shards are broken. It returns whether a repair task was queued.
+func (d *DagNode) ScrubBlock(ctx context.Context, c cid.Cid) (bool, error) {
+	meta, shards, repairIndexes, err := d.inspectBlock(ctx, c)
+	if err != nil {
+		return false, err
+	}
+	needRepair := false
+	for _, ok := range repairIndexes {
+		needRepair = needRepair || ok
+	}
+	if !needRepair {
+		return false, nil
+	}
+	return d.queueRepair(c.String(), meta, shards, repairIndexes), nil
+}
+
+// inspectBlock reads all the shards of the block, and returns the shards read successfully
+// and the indexes of the online nodes whose shard is missing or broken.
+// It fails if the block can not be rebuilt from the shards.
+func (d *DagNode) inspectBlock(ctx context.Context, c cid.Cid) (meta Meta, shards [][]byte, repairIndexes []bool, err error) {
+	key := c.String()
+	nodes, release := d.acquireNodes()
+	defer release()
+	meta, metas, _, err := d.getMetaInfo(ctx, nodes, c)
+	if err != nil {
+		return meta, nil, nil, err
+	}
+	codec, err := d.newCodec(meta)
+	if err != nil {
+		return meta, nil, nil, err
+	}
+	shardSize := codec.ShardSize()
+
+	shards = make([][]byte, len(nodes))
+	repairIndexes = make([]bool, len(nodes))
+	var wg sync.WaitGroup
+	for i, snode := range nodes {
+		if metas[i] != meta {
+			// the offline node can not be repaired now, it will be checked later
+			repairIndexes[i] = snode.State
+			continue
+		}
+		wg.Add(1)
+		go func(index int, snode *StorageNode) {
+			defer wg.Done()
+			// the data node checks the crc when reading
+			shard, err := getShard(ctx, snode.Client, key, shardSize)
+			if err != nil {
+				log.Warnw("read shard error", "datanode", snode.RpcAddress, "key", key, "error", err)
+				if st, ok := status.FromError(err); ok && st.Code() != codes.Canceled {
+					repairIndexes[index] = snode.State
+				}
+				return
+			}
+			if int64(len(shard)) != shardSize {
+				log.Warnw("shard size mismatch", "datanode", snode.RpcAddress, "key", key, "size", len(shard))
+				repairIndexes[index] = snode.State
+				return
+			}
+			shards[index] = shard
+		}(i, snode)
+	}
+	wg.Wait()
+
+	available := 0
+	for _, shard := range shards {
+		if shard != nil {
+			available++
+		}
+	}
+	if available < d.readQuorum(meta) {
+		return meta, nil, nil, errErasureReadQuorum
+	}
+	return meta, shards, repairIndexes, nil
+}
@@ -0,0 +1,115 @@
+package dagnode
+
+import (
+	"context"
+	"github.com/filedag-project/filedag-storage/dag/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"time"
+)
+
+// DiskStat is the disk usage reported by a data node
+type DiskStat struct {
+	RpcAddress string
+	Total      uint64
+	Used       uint64
+	Free       uint64
+	Keys       uint64
+	// ReadOnly is set when the disk usage of the data node reaches the high-water mark
+	ReadOnly bool
+	// UpdatedAt is the time of the last report, zero if the data node never reported
+	UpdatedAt time.Time
+}
+
+// HeartbeatStat is the result of the health checks of a data node
+type HeartbeatStat struct {
+	// CheckedAt is the time of the last health check, zero if the data node was never checked
+	CheckedAt time.Time
+	// LastError is the error of the last failed health check, it is kept after the data node recovers
+	LastError string
+	// FailedAt is the time of the last failed health check
+	FailedAt time.Time
+}
+
+// Heartbeat returns the result of the health checks of the data node
+func (sn *StorageNode) Heartbeat() HeartbeatStat {
+	sn.statLk.RLock()
+	defer sn.statLk.RUnlock()
+	return sn.heartbeat
+}
+
+func (sn *StorageNode) setHeartbeat(err error) {
+	sn.statLk.Lock()
+	defer sn.statLk.Unlock()
+	sn.heartbeat.CheckedAt = time.Now()
+	if err != nil {
+		sn.heartbeat.LastError = err.Error()
+		sn.heartbeat.FailedAt = sn.heartbeat.CheckedAt
+	}
+}
+
+// DiskStat returns the last disk usage reported by the data node
+func (sn *StorageNode) DiskStat() DiskStat {
+	sn.statLk.RLock()
+	defer sn.statLk.RUnlock()
+	stat := sn.stat
+	stat.RpcAddress = sn.RpcAddress
+	return stat
+}
+
+// IsReadOnly returns whether the data node rejects the writes
+func (sn *StorageNode) IsReadOnly() bool {
+	sn.statLk.RLock()
+	defer sn.statLk.RUnlock()
+	return sn.stat.ReadOnly
+}
+
+// refreshStat asks the data node for its disk usage
+func (sn *StorageNode) refreshStat(ctx context.Context) error {
+	resp, err := sn.DataClient.Stat(ctx, &emptypb.Empty{})
+	if err != nil {
+		return err
+	}
+	sn.setStat(resp)
+	return nil
+}
+
+func (sn *StorageNode) setStat(resp *proto.StatResponse) {
+	sn.statLk.Lock()
+	defer sn.statLk.Unlock()
+	if resp.ReadOnly != sn.stat.ReadOnly {
+		log.Warnw("the data node changes the read-only state", "datanode", sn.RpcAddress, "readOnly", resp.ReadOnly)
+	}
+	sn.stat = DiskStat{
+		Total:     resp.Total,
+		Used:      resp.Used,
+		Free:      resp.Free,
+		Keys:      resp.Keys,
+		ReadOnly:  resp.ReadOnly,
+		UpdatedAt: time.Now(),
+	}
+}
+
+// DiskStats returns the disk usage of all the data nodes tracked by the heartbeat
+func (d *DagNode) DiskStats() []DiskStat {
+	nodes := d.Nodes()
+	stats := make([]DiskStat, len(nodes))
+	for i, sn := range nodes {
+		stats[i] = sn.DiskStat()
+	}
+	return stats
+}
+
+// checkWritable fails the writes early if the writable data nodes can not meet the write quorum
+func (d *DagNode) checkWritable() error {
+	_, entryWriteQuorum := d.entryQuorum()
+	writable := 0
+	for _, sn := range d.Nodes() {
+		if !sn.IsReadOnly() {
+			writable++
+		}
+	}
+	if writable < entryWriteQuorum {
+		return errNotEnoughWritableNodes
+	}
+	return nil
+}
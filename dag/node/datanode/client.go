@@ -1,10 +1,16 @@
 package datanode
 
 import (
+	"context"
+	"errors"
 	"github.com/filedag-project/filedag-storage/dag/proto"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"hash/crc32"
+	"io"
 )
 
 //go:generate go run github.com/golang/mock/mockgen -destination=mocks/mock_dnclient.go -package=mocks . DataNodeClient
@@ -21,9 +27,10 @@ type Client struct {
 	Conn        *grpc.ClientConn
 }
 
-// NewClient creates a grpc connection to a slice
-func NewClient(rpcAddress string) (datanode *Client, err error) {
-	conn, err := grpc.Dial(rpcAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+// NewClient creates a grpc connection to a slice, the connection is insecure unless opts override it
+func NewClient(rpcAddress string, opts ...grpc.DialOption) (datanode *Client, err error) {
+	opts = append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, opts...)
+	conn, err := grpc.Dial(rpcAddress, opts...)
 	if err != nil {
 		log.Errorf("did not connect: %v", err)
 		return nil, err
@@ -36,3 +43,90 @@ func NewClient(rpcAddress string) (datanode *Client, err error) {
 	}
 	return datanode, nil
 }
+
+// PutStream puts the shard to the data node in chunks
+func (c *Client) PutStream(ctx context.Context, key string, meta []byte, data []byte) error {
+	stream, err := c.DataClient.PutStream(ctx)
+	if err != nil {
+		return err
+	}
+	req := &proto.PutStreamRequest{
+		Key:      key,
+		Meta:     meta,
+		Size:     int64(len(data)),
+		Checksum: crc32.ChecksumIEEE(data),
+	}
+	for {
+		n := len(data)
+		if n > StreamChunkSize {
+			n = StreamChunkSize
+		}
+		req.Data = data[:n]
+		if err = stream.Send(req); err != nil {
+			if err == io.EOF {
+				// the real error will be returned by CloseAndRecv
+				break
+			}
+			return err
+		}
+		data = data[n:]
+		if len(data) == 0 {
+			break
+		}
+		req = &proto.PutStreamRequest{}
+	}
+	_, err = stream.CloseAndRecv()
+	return err
+}
+
+// GetStream gets the shard from the data node in chunks
+func (c *Client) GetStream(ctx context.Context, key string) (meta []byte, data []byte, err error) {
+	return c.GetRangeStream(ctx, key, 0, 0)
+}
+
+// GetRangeStream gets the data in [offset, offset+length) of the shard from the data node in chunks,
+// a zero length reads to the end of the shard
+func (c *Client) GetRangeStream(ctx context.Context, key string, offset, length int64) (meta []byte, data []byte, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.DataClient.GetStream(ctx, &proto.GetRequest{Key: key, Offset: offset, Length: length})
+	if err != nil {
+		return nil, nil, err
+	}
+	first, err := stream.Recv()
+	if err != nil {
+		return nil, nil, err
+	}
+	if first.Size < 0 || first.Size > MaxShardSize {
+		return nil, nil, errors.New("invalid shard size")
+	}
+	data = make([]byte, first.Size)
+	received := 0
+	checksum, want := uint32(0), uint32(0)
+	for resp := first; ; {
+		// the crc is set in the last message, or in the first one by the older data nodes
+		if resp.Checksum != 0 {
+			want = resp.Checksum
+		}
+		if received+len(resp.Data) > len(data) {
+			return nil, nil, errors.New("received data exceeds the shard size")
+		}
+		copy(data[received:], resp.Data)
+		received += len(resp.Data)
+		checksum = crc32.Update(checksum, crc32.IEEETable, resp.Data)
+
+		if resp, err = stream.Recv(); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, nil, err
+		}
+	}
+	if received != len(data) {
+		return nil, nil, errors.New("shard is incomplete")
+	}
+	if checksum != want {
+		return nil, nil, status.Error(codes.DataLoss, "checking crc failed")
+	}
+	return first.Meta, data, nil
+}
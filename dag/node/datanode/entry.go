@@ -0,0 +1,687 @@
+package datanode
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/cespare/xxhash"
+	"github.com/filedag-project/filedag-storage/kv"
+	"github.com/howeyc/crc16"
+	"hash"
+	"hash/crc32"
+)
+
+// entry item
+// version 2:
+// | reserved (1 byte) | chunk shift (1 byte) | checksum type (1 byte) | version (1 byte) | meta size (4 bytes) | data size (4 bytes) | checksum (8 bytes) | meta | data | chunk checksums (8 bytes each) |
+// the data is split in chunks of 1 << chunk shift bytes, and each chunk has its own checksum after the data.
+// The checksum in the header covers the sizes, meta and chunk checksums, so a range of the data is verified
+// by reading only the chunks it covers.
+//
+// version 1:
+// | reserved (2 bytes) | checksum type (1 byte) | version (1 byte) | meta size (4 bytes) | data size (4 bytes) | checksum (8 bytes) | meta | data |
+// the checksum covers the sizes, meta and data.
+//
+// legacy (version 0):
+// | crc16 (4 bytes) | meta size (4 bytes) | data size (4 bytes) | meta | data |
+// the crc16 is stored in a little endian uint32, so the high bytes are always zero,
+// which tells the legacy entries from the versioned ones.
+
+const (
+	// HeaderSize is size of entry header
+	HeaderSize = 20
+	// LegacyHeaderSize is size of the legacy entry header
+	LegacyHeaderSize = 12
+
+	// EntryVersion is the version of the entries written by the data node
+	EntryVersion = 2
+	// entryVersion1 is the version whose checksum covers the whole data
+	entryVersion1 = 1
+
+	// chunkSumSize is the size of the checksum of a data chunk
+	chunkSumSize = 8
+)
+
+// entryChunkShift is the log2 of the size of the data chunks checked separately in the written entries
+var entryChunkShift uint8 = 16
+
+// ChecksumType is the checksum algorithm of the entry
+type ChecksumType uint8
+
+const (
+	// ChecksumCRC32C is the crc32 with the Castagnoli polynomial
+	ChecksumCRC32C ChecksumType = 1
+	// ChecksumXXHash64 is the 64-bit xxhash
+	ChecksumXXHash64 ChecksumType = 2
+)
+
+var (
+	ErrChecksumMismatch = errors.New("checking crc failed")
+	ErrInvalidEntry     = errors.New("invalid entry size")
+)
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ParseChecksumType parses the name of the checksum algorithm
+func ParseChecksumType(name string) (ChecksumType, error) {
+	switch name {
+	case "crc32c":
+		return ChecksumCRC32C, nil
+	case "xxhash64":
+		return ChecksumXXHash64, nil
+	default:
+		return 0, fmt.Errorf("not support this checksum %s", name)
+	}
+}
+
+func (t ChecksumType) String() string {
+	switch t {
+	case ChecksumCRC32C:
+		return "crc32c"
+	case ChecksumXXHash64:
+		return "xxhash64"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(t))
+	}
+}
+
+func (t ChecksumType) newHash() (hash.Hash64, error) {
+	switch t {
+	case ChecksumCRC32C:
+		return &crc32Hash{Hash32: crc32.New(castagnoliTable)}, nil
+	case ChecksumXXHash64:
+		return xxhash.New(), nil
+	default:
+		return nil, fmt.Errorf("unknown checksum type %d", uint8(t))
+	}
+}
+
+// crc32Hash widens the crc32 to fit the checksum field
+type crc32Hash struct {
+	hash.Hash32
+}
+
+func (h *crc32Hash) Sum64() uint64 {
+	return uint64(h.Sum32())
+}
+
+// chunkCount returns the number of the chunks of the data
+func chunkCount(dataSize int64, chunkShift uint8) int64 {
+	return (dataSize + 1<<chunkShift - 1) >> chunkShift
+}
+
+// putEntryHead fills the header but the checksum, and the meta of the entry
+func putEntryHead(entry []byte, checksumType ChecksumType, meta []byte, dataSize int) {
+	entry[1] = entryChunkShift
+	entry[2] = byte(checksumType)
+	entry[3] = EntryVersion
+	binary.LittleEndian.PutUint32(entry[4:8], uint32(len(meta)))
+	binary.LittleEndian.PutUint32(entry[8:12], uint32(dataSize))
+	copy(entry[HeaderSize:], meta)
+}
+
+// entryWriter writes an entry to the kvdb in chunks, the header is sealed after all the data is written
+type entryWriter struct {
+	w    kv.ValueWriter
+	head []byte
+	// h is the checksum of the header, ch is the checksum of the data chunk being written
+	h, ch     hash.Hash64
+	chunkSize int
+	filled    int
+	dataSize  int
+	written   int
+	sums      []byte
+}
+
+func newEntryWriter(db kv.KVDB, key string, checksumType ChecksumType, meta []byte, dataSize int) (*entryWriter, error) {
+	h, err := checksumType.newHash()
+	if err != nil {
+		return nil, err
+	}
+	ch, _ := checksumType.newHash()
+	head := make([]byte, HeaderSize+len(meta))
+	putEntryHead(head, checksumType, meta, dataSize)
+	sums := make([]byte, 0, chunkCount(int64(dataSize), entryChunkShift)*chunkSumSize)
+	w, err := kv.NewValueWriter(db, key, len(head)+dataSize+cap(sums))
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(head); err != nil {
+		w.Abort()
+		return nil, err
+	}
+	h.Write(head[4:12])
+	h.Write(head[HeaderSize:])
+	return &entryWriter{w: w, head: head, h: h, ch: ch, chunkSize: 1 << entryChunkShift, dataSize: dataSize, sums: sums}, nil
+}
+
+// Write appends the bytes to the data of the entry
+func (e *entryWriter) Write(p []byte) (int, error) {
+	if e.written+len(p) > e.dataSize {
+		return 0, kv.ErrValueSize
+	}
+	n, err := e.w.Write(p)
+	e.written += n
+	for q := p[:n]; len(q) > 0; {
+		k := e.chunkSize - e.filled
+		if k > len(q) {
+			k = len(q)
+		}
+		e.ch.Write(q[:k])
+		e.filled += k
+		q = q[k:]
+		if e.filled == e.chunkSize {
+			e.sumChunk()
+		}
+	}
+	return n, err
+}
+
+// sumChunk records the checksum of the finished data chunk
+func (e *entryWriter) sumChunk() {
+	e.sums = e.sums[:len(e.sums)+chunkSumSize]
+	binary.LittleEndian.PutUint64(e.sums[len(e.sums)-chunkSumSize:], e.ch.Sum64())
+	e.ch.Reset()
+	e.filled = 0
+}
+
+// Commit appends the checksums of the chunks, seals the header and stores the entry
+func (e *entryWriter) Commit() error {
+	if e.written != e.dataSize {
+		e.w.Abort()
+		return kv.ErrValueSize
+	}
+	if e.filled > 0 {
+		e.sumChunk()
+	}
+	e.h.Write(e.sums)
+	binary.LittleEndian.PutUint64(e.head[12:20], e.h.Sum64())
+	if _, err := e.w.Write(e.sums); err != nil {
+		e.w.Abort()
+		return err
+	}
+	if _, err := e.w.WriteAt(e.head[:HeaderSize], 0); err != nil {
+		e.w.Abort()
+		return err
+	}
+	return e.w.Commit()
+}
+
+// Abort drops the entry, it does nothing after Commit
+func (e *entryWriter) Abort() {
+	e.w.Abort()
+}
+
+// encodeEntry builds a sealed entry of the meta and data
+func encodeEntry(checksumType ChecksumType, meta []byte, data []byte) ([]byte, error) {
+	h, err := checksumType.newHash()
+	if err != nil {
+		return nil, err
+	}
+	chunkSize := 1 << entryChunkShift
+	sums := make([]byte, chunkCount(int64(len(data)), entryChunkShift)*chunkSumSize)
+	for i := 0; i*chunkSize < len(data); i++ {
+		end := (i + 1) * chunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+		h.Reset()
+		h.Write(data[i*chunkSize : end])
+		binary.LittleEndian.PutUint64(sums[i*chunkSumSize:], h.Sum64())
+	}
+	entry := make([]byte, HeaderSize+len(meta)+len(data)+len(sums))
+	putEntryHead(entry, checksumType, meta, len(data))
+	copy(entry[HeaderSize+len(meta):], data)
+	copy(entry[HeaderSize+len(meta)+len(data):], sums)
+	h.Reset()
+	h.Write(entry[4:12])
+	h.Write(meta)
+	h.Write(sums)
+	binary.LittleEndian.PutUint64(entry[12:20], h.Sum64())
+	return entry, nil
+}
+
+// entryLayout is the position of the parts of an entry
+type entryLayout struct {
+	version      uint8
+	checksumType ChecksumType
+	chunkShift   uint8
+	headerSize   int64
+	metaSize     int64
+	dataSize     int64
+}
+
+// parseEntryHead reads the layout of the entry from its header
+func parseEntryHead(head []byte) (l entryLayout, err error) {
+	if len(head) < LegacyHeaderSize {
+		return l, ErrInvalidEntry
+	}
+	l.headerSize = LegacyHeaderSize
+	if !isLegacyEntry(head) {
+		l.version, l.checksumType, l.chunkShift = head[3], ChecksumType(head[2]), head[1]
+		if l.version != EntryVersion && l.version != entryVersion1 {
+			return l, fmt.Errorf("unsupported entry version %d", l.version)
+		}
+		if len(head) < HeaderSize || (l.version == EntryVersion && l.chunkShift > 30) {
+			return l, ErrInvalidEntry
+		}
+		l.headerSize = HeaderSize
+	}
+	l.metaSize = int64(int32(binary.LittleEndian.Uint32(head[4:8])))
+	l.dataSize = int64(int32(binary.LittleEndian.Uint32(head[8:12])))
+	if l.metaSize < 0 || l.dataSize < 0 {
+		return l, ErrInvalidEntry
+	}
+	return l, nil
+}
+
+// chunked reports whether each chunk of the data has its own checksum
+func (l entryLayout) chunked() bool {
+	return l.version == EntryVersion
+}
+
+func (l entryLayout) dataStart() int64 {
+	return l.headerSize + l.metaSize
+}
+
+func (l entryLayout) sumsStart() int64 {
+	return l.dataStart() + l.dataSize
+}
+
+func (l entryLayout) sumsSize() int64 {
+	if !l.chunked() {
+		return 0
+	}
+	return chunkCount(l.dataSize, l.chunkShift) * chunkSumSize
+}
+
+// dataRange checks the range of the data and returns its length, a zero length reads to the end of the data
+func (l entryLayout) dataRange(offset, length int64) (int64, error) {
+	if offset < 0 || length < 0 || offset > l.dataSize {
+		return 0, kv.ErrInvalidRange
+	}
+	if length == 0 || offset+length > l.dataSize {
+		length = l.dataSize - offset
+	}
+	return length, nil
+}
+
+// checkHead verifies the checksum in the header of a chunked entry, which covers the meta and the chunk checksums
+func (l entryLayout) checkHead(head, meta, sums []byte) error {
+	h, err := l.checksumType.newHash()
+	if err != nil {
+		return err
+	}
+	h.Write(head[4:12])
+	h.Write(meta)
+	h.Write(sums)
+	if binary.LittleEndian.Uint64(head[12:20]) != h.Sum64() {
+		return ErrChecksumMismatch
+	}
+	return nil
+}
+
+// checkChunk verifies the data chunk of the index with its checksum
+func (l entryLayout) checkChunk(h hash.Hash64, sums []byte, index int64, chunk []byte) error {
+	h.Reset()
+	h.Write(chunk)
+	if binary.LittleEndian.Uint64(sums[index*chunkSumSize:]) != h.Sum64() {
+		return ErrChecksumMismatch
+	}
+	return nil
+}
+
+// isLegacyEntry reports whether the entry was written in the crc16 format
+func isLegacyEntry(entry []byte) bool {
+	return len(entry) >= LegacyHeaderSize && entry[2] == 0 && entry[3] == 0
+}
+
+// decodeEntry checks the entry and returns its meta and data
+func decodeEntry(entry []byte) (meta []byte, data []byte, err error) {
+	if len(entry) < LegacyHeaderSize {
+		return nil, nil, ErrInvalidEntry
+	}
+	if isLegacyEntry(entry) {
+		return decodeLegacyEntry(entry)
+	}
+	l, err := parseEntryHead(entry)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !l.chunked() {
+		return decodeEntryV1(l, entry)
+	}
+	if l.sumsStart()+l.sumsSize() != int64(len(entry)) {
+		return nil, nil, ErrInvalidEntry
+	}
+	meta, data, err = splitEntry(entry, HeaderSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	sums := entry[l.sumsStart():]
+	if err = l.checkHead(entry, meta, sums); err != nil {
+		return nil, nil, err
+	}
+	h, _ := l.checksumType.newHash()
+	chunkSize := int64(1) << l.chunkShift
+	for i := int64(0); i*chunkSize < l.dataSize; i++ {
+		end := (i + 1) * chunkSize
+		if end > l.dataSize {
+			end = l.dataSize
+		}
+		if err = l.checkChunk(h, sums, i, data[i*chunkSize:end]); err != nil {
+			return nil, nil, err
+		}
+	}
+	return meta, data, nil
+}
+
+func decodeEntryV1(l entryLayout, entry []byte) (meta []byte, data []byte, err error) {
+	h, err := l.checksumType.newHash()
+	if err != nil {
+		return nil, nil, err
+	}
+	h.Write(entry[4:12])
+	h.Write(entry[HeaderSize:])
+	if binary.LittleEndian.Uint64(entry[12:20]) != h.Sum64() {
+		return nil, nil, ErrChecksumMismatch
+	}
+	return splitEntry(entry, HeaderSize)
+}
+
+func decodeLegacyEntry(entry []byte) (meta []byte, data []byte, err error) {
+	var checksum uint32
+	if err = binary.Read(bytes.NewReader(entry), binary.LittleEndian, &checksum); err != nil {
+		return nil, nil, err
+	}
+	// check crc
+	sum := crc16.Checksum(entry[binary.Size(checksum):], crc16.IBMTable)
+	if checksum != uint32(sum) {
+		return nil, nil, ErrChecksumMismatch
+	}
+	return splitEntry(entry, LegacyHeaderSize)
+}
+
+// splitEntry returns the meta and data of the entry after the header
+func splitEntry(entry []byte, headerSize int) (meta []byte, data []byte, err error) {
+	metaSize := int32(binary.LittleEndian.Uint32(entry[4:8]))
+	dataSize := int32(binary.LittleEndian.Uint32(entry[8:12]))
+	if metaSize < 0 || dataSize < 0 || headerSize+int(metaSize)+int(dataSize) > len(entry) {
+		return nil, nil, ErrInvalidEntry
+	}
+	meta = entry[headerSize : headerSize+int(metaSize)]
+	data = entry[headerSize+int(metaSize) : headerSize+int(metaSize+dataSize)]
+	return meta, data, nil
+}
+
+// rangeHeadSize is the size read at first to get the header of an entry
+const rangeHeadSize = HeaderSize + 64
+
+// rangeChunkSize is the size of the entry read at a time while verifying the checksum of an entry which is not chunked
+var rangeChunkSize int64 = 1 << 20
+
+// entryCheck verifies the checksum of an entry read in chunks
+type entryCheck struct {
+	legacy bool
+	want   uint64
+	h      hash.Hash64
+	crc    uint16
+}
+
+func newEntryCheck(header []byte) (*entryCheck, error) {
+	if isLegacyEntry(header) {
+		return &entryCheck{
+			legacy: true,
+			want:   uint64(binary.LittleEndian.Uint32(header)),
+			crc:    crc16.Update(0, crc16.IBMTable, header[4:LegacyHeaderSize]),
+		}, nil
+	}
+	h, err := ChecksumType(header[2]).newHash()
+	if err != nil {
+		return nil, err
+	}
+	h.Write(header[4:12])
+	return &entryCheck{want: binary.LittleEndian.Uint64(header[12:20]), h: h}, nil
+}
+
+// update adds the bytes after the header
+func (c *entryCheck) update(p []byte) {
+	if c.legacy {
+		c.crc = crc16.Update(c.crc, crc16.IBMTable, p)
+		return
+	}
+	c.h.Write(p)
+}
+
+func (c *entryCheck) ok() bool {
+	if c.legacy {
+		return uint64(c.crc) == c.want
+	}
+	return c.h.Sum64() == c.want
+}
+
+// copyOverlap copies the part of the chunk at chunkStart which overlaps dst at dstStart
+func copyOverlap(dst []byte, dstStart int64, chunk []byte, chunkStart int64) {
+	lo, hi := dstStart, dstStart+int64(len(dst))
+	if chunkStart > lo {
+		lo = chunkStart
+	}
+	if end := chunkStart + int64(len(chunk)); end < hi {
+		hi = end
+	}
+	if lo < hi {
+		copy(dst[lo-dstStart:hi-dstStart], chunk[lo-chunkStart:hi-chunkStart])
+	}
+}
+
+// readEntryRange reads the meta and the data in [offset, offset+length) of the entry, a zero length reads to
+// the end of the data. Only the chunks covering the range are read and verified in a chunked entry, the other
+// entries are read in pieces to verify their checksum, and only the range is kept in memory.
+func readEntryRange(kvdb kv.KVDB, key string, offset, length int64) (meta []byte, data []byte, err error) {
+	r, err := openEntry(kvdb, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if length, err = r.layout.dataRange(offset, length); err != nil {
+		return nil, nil, err
+	}
+	if r.layout.chunked() {
+		data, err = r.readChunks(offset, length)
+		if err != nil {
+			return nil, nil, err
+		}
+		return r.meta, data, nil
+	}
+	data = make([]byte, length)
+	err = r.scan(func(chunk []byte, pos int64) error {
+		copyOverlap(data, offset, chunk, pos)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return r.meta, data, nil
+}
+
+// entryReader reads the data of an entry in the kvdb piece by piece
+type entryReader struct {
+	kvdb   kv.KVDB
+	key    string
+	layout entryLayout
+	head   []byte
+	meta   []byte
+	// sums is the checksums of the chunks of a chunked entry
+	sums []byte
+}
+
+// openEntry reads the header and the meta of the entry. The chunk checksums of a chunked entry are read as well,
+// and verified with the header, the meta of the other entries is verified once the data is scanned.
+func openEntry(kvdb kv.KVDB, key string) (*entryReader, error) {
+	head, err := kvdb.GetRange(key, 0, rangeHeadSize)
+	if err != nil {
+		return nil, err
+	}
+	l, err := parseEntryHead(head)
+	if err != nil {
+		return nil, err
+	}
+	r := &entryReader{kvdb: kvdb, key: key, layout: l, head: head[:l.headerSize]}
+	if r.meta, err = r.read(l.headerSize, l.metaSize, head); err != nil {
+		return nil, err
+	}
+	if !l.chunked() {
+		return r, nil
+	}
+	if r.sums, err = r.read(l.sumsStart(), l.sumsSize(), head); err != nil {
+		return nil, err
+	}
+	if err = l.checkHead(r.head, r.meta, r.sums); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// read reads n bytes of the entry at pos, the bytes already in the head are not read again
+func (r *entryReader) read(pos, n int64, head []byte) ([]byte, error) {
+	if n == 0 {
+		return []byte{}, nil
+	}
+	if pos+n <= int64(len(head)) {
+		return head[pos : pos+n], nil
+	}
+	b, err := r.kvdb.GetRange(r.key, int(pos), int(n))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) != n {
+		return nil, ErrInvalidEntry
+	}
+	return b, nil
+}
+
+// readChunks reads the data in [offset, offset+length) of a chunked entry, and verifies the chunks covering it
+func (r *entryReader) readChunks(offset, length int64) ([]byte, error) {
+	l := r.layout
+	h, err := l.checksumType.newHash()
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, length)
+	chunkSize := int64(1) << l.chunkShift
+	for i := offset >> l.chunkShift; i*chunkSize < offset+length; i++ {
+		start := i * chunkSize
+		n := chunkSize
+		if start+n > l.dataSize {
+			n = l.dataSize - start
+		}
+		chunk, err := r.read(l.dataStart()+start, n, nil)
+		if err != nil {
+			return nil, err
+		}
+		if err = l.checkChunk(h, r.sums, i, chunk); err != nil {
+			return nil, err
+		}
+		copyOverlap(data, offset, chunk, start)
+	}
+	return data, nil
+}
+
+// scan reads the data of an entry which is not chunked in pieces of rangeChunkSize, fn is called with each piece
+// and its offset in the data. The checksum of the whole entry is verified after the last piece.
+func (r *entryReader) scan(fn func(chunk []byte, pos int64) error) error {
+	l := r.layout
+	check, err := newEntryCheck(r.head)
+	if err != nil {
+		return err
+	}
+	check.update(r.meta)
+	for pos := int64(0); pos < l.dataSize; {
+		n := l.dataSize - pos
+		if n > rangeChunkSize {
+			n = rangeChunkSize
+		}
+		chunk, err := r.read(l.dataStart()+pos, n, nil)
+		if err != nil {
+			return err
+		}
+		check.update(chunk)
+		if err = fn(chunk, pos); err != nil {
+			return err
+		}
+		pos += n
+	}
+	if !check.ok() {
+		return ErrChecksumMismatch
+	}
+	return nil
+}
+
+// CheckResult is the result of checking the entries of a data node
+type CheckResult struct {
+	// Total is the number of checked entries
+	Total int
+	// Legacy is the number of entries in the legacy format
+	Legacy int
+	// Corrupted is the number of entries which failed the check
+	Corrupted int
+	// Migrated is the number of legacy entries rewritten in the current format
+	Migrated int
+}
+
+// CheckEntries verifies the checksums of all the entries in the kvdb, onCorrupt is called for each corrupted entry.
+// If migrate is set, the valid legacy entries are rewritten in the current format with the given checksum.
+func CheckEntries(ctx context.Context, kvdb kv.KVDB, checksumType ChecksumType, migrate bool, onCorrupt func(key string, err error)) (CheckResult, error) {
+	var res CheckResult
+	ch, err := kvdb.AllKeysChan(ctx)
+	if err != nil {
+		return res, err
+	}
+	// the keys are collected first because some kvdbs do not support writing while iterating
+	var keys []string
+	for key := range ch {
+		keys = append(keys, key)
+	}
+	if err = ctx.Err(); err != nil {
+		return res, err
+	}
+	for _, key := range keys {
+		if err = ctx.Err(); err != nil {
+			return res, err
+		}
+		entry, err := kvdb.Get(key)
+		if err != nil {
+			if errors.Is(err, kv.ErrNotFound) {
+				// deleted after listing
+				continue
+			}
+			return res, err
+		}
+		res.Total++
+		meta, data, err := decodeEntry(entry)
+		if err != nil {
+			res.Corrupted++
+			if onCorrupt != nil {
+				onCorrupt(key, err)
+			}
+			continue
+		}
+		if !isLegacyEntry(entry) {
+			continue
+		}
+		res.Legacy++
+		if !migrate {
+			continue
+		}
+		newEntry, err := encodeEntry(checksumType, meta, data)
+		if err != nil {
+			return res, err
+		}
+		if err = kvdb.Put(key, newEntry); err != nil {
+			return res, err
+		}
+		res.Migrated++
+	}
+	return res, nil
+}
@@ -0,0 +1,108 @@
+package datanode
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"github.com/filedag-project/filedag-storage/kv/mutcask"
+	"github.com/howeyc/crc16"
+	"testing"
+)
+
+// legacyEntry builds an entry in the crc16 format
+func legacyEntry(meta []byte, data []byte) []byte {
+	entry := make([]byte, LegacyHeaderSize+len(meta)+len(data))
+	binary.LittleEndian.PutUint32(entry[4:8], uint32(len(meta)))
+	binary.LittleEndian.PutUint32(entry[8:12], uint32(len(data)))
+	copy(entry[LegacyHeaderSize:], meta)
+	copy(entry[LegacyHeaderSize+len(meta):], data)
+	binary.LittleEndian.PutUint32(entry[0:4], uint32(crc16.Checksum(entry[4:], crc16.IBMTable)))
+	return entry
+}
+
+// version1Entry builds an entry whose checksum covers the whole data
+func version1Entry(checksumType ChecksumType, meta []byte, data []byte) []byte {
+	entry := make([]byte, HeaderSize+len(meta)+len(data))
+	entry[2] = byte(checksumType)
+	entry[3] = entryVersion1
+	binary.LittleEndian.PutUint32(entry[4:8], uint32(len(meta)))
+	binary.LittleEndian.PutUint32(entry[8:12], uint32(len(data)))
+	copy(entry[HeaderSize:], meta)
+	copy(entry[HeaderSize+len(meta):], data)
+	h, _ := checksumType.newHash()
+	h.Write(entry[4:12])
+	h.Write(entry[HeaderSize:])
+	binary.LittleEndian.PutUint64(entry[12:20], h.Sum64())
+	return entry
+}
+
+func TestEntry(t *testing.T) {
+	meta, data := []byte("meta"), []byte("some data of the entry")
+	for _, typ := range []ChecksumType{ChecksumCRC32C, ChecksumXXHash64} {
+		entry, err := encodeEntry(typ, meta, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, d, err := decodeEntry(entry)
+		if err != nil || !bytes.Equal(m, meta) || !bytes.Equal(d, data) {
+			t.Fatalf("%v: decode entry failed, err: %v", typ, err)
+		}
+		for _, pos := range []int{HeaderSize, HeaderSize + len(meta), len(entry) - 1} {
+			entry[pos]++
+			if _, _, err = decodeEntry(entry); err != ErrChecksumMismatch {
+				t.Fatalf("%v: expected checksum mismatch at %d, got %v", typ, pos, err)
+			}
+			entry[pos]--
+		}
+	}
+
+	m, d, err := decodeEntry(legacyEntry(meta, data))
+	if err != nil || !bytes.Equal(m, meta) || !bytes.Equal(d, data) {
+		t.Fatalf("decode legacy entry failed, err: %v", err)
+	}
+	m, d, err = decodeEntry(version1Entry(ChecksumXXHash64, meta, data))
+	if err != nil || !bytes.Equal(m, meta) || !bytes.Equal(d, data) {
+		t.Fatalf("decode version 1 entry failed, err: %v", err)
+	}
+}
+
+func TestCheckEntries(t *testing.T) {
+	db, err := mutcask.NewMutcask(mutcask.PathConf(t.TempDir()), mutcask.CaskNumConf(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	entry, _ := encodeEntry(ChecksumCRC32C, []byte("meta"), []byte("new"))
+	corrupted := legacyEntry([]byte("meta"), []byte("corrupted"))
+	corrupted[len(corrupted)-1]++
+	for key, val := range map[string][]byte{
+		"new":       entry,
+		"legacy":    legacyEntry([]byte("meta"), []byte("legacy")),
+		"corrupted": corrupted,
+	} {
+		if err = db.Put(key, val); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var corruptedKeys []string
+	res, err := CheckEntries(context.TODO(), db, ChecksumXXHash64, true, func(key string, err error) {
+		corruptedKeys = append(corruptedKeys, key)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != (CheckResult{Total: 3, Legacy: 1, Corrupted: 1, Migrated: 1}) || len(corruptedKeys) != 1 || corruptedKeys[0] != "corrupted" {
+		t.Fatalf("unexpected result %+v, corrupted keys %v", res, corruptedKeys)
+	}
+	migrated, err := db.Get("legacy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if isLegacyEntry(migrated) || ChecksumType(migrated[2]) != ChecksumXXHash64 {
+		t.Fatal("the legacy entry was not migrated")
+	}
+	if _, d, err := decodeEntry(migrated); err != nil || string(d) != "legacy" {
+		t.Fatalf("decode migrated entry failed, err: %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package datanode
+
+import (
+	"fmt"
+	"github.com/filedag-project/filedag-storage/kv"
+	"github.com/filedag-project/filedag-storage/kv/badger"
+	"github.com/filedag-project/filedag-storage/kv/diskv"
+	"github.com/filedag-project/filedag-storage/kv/mutcask"
+	"os"
+	"time"
+)
+
+// KVOptions is the options of the kvdb, the zero value uses the defaults of every kvdb
+type KVOptions struct {
+	Diskv   DiskvOptions
+	Mutcask MutcaskOptions
+}
+
+// MutcaskOptions is the options of the mutcask kvdb, the zero fields use the defaults of mutcask
+type MutcaskOptions struct {
+	// SyncMode is always, interval or none
+	SyncMode     string
+	SyncInterval time.Duration
+	// RecoverMode is how the casks are checked when opened, tail, full or rebuild
+	RecoverMode string
+	// IndexMode is where the hints of the keys are kept, memory or disk
+	IndexMode string
+	// HotKeys is the number of hints of each cask cached in memory in disk index mode
+	HotKeys int
+}
+
+func (o MutcaskOptions) options(dataDir string) []mutcask.Option {
+	opts := []mutcask.Option{mutcask.PathConf(dataDir), mutcask.CaskNumConf(6)}
+	if o.SyncMode != "" {
+		opts = append(opts, mutcask.SyncModeConf(mutcask.SyncMode(o.SyncMode)))
+	}
+	if o.SyncInterval > 0 {
+		opts = append(opts, mutcask.SyncIntervalConf(o.SyncInterval))
+	}
+	if o.RecoverMode != "" {
+		opts = append(opts, mutcask.RecoverModeConf(mutcask.RecoverMode(o.RecoverMode)))
+	}
+	if o.IndexMode != "" {
+		opts = append(opts, mutcask.IndexModeConf(mutcask.IndexMode(o.IndexMode)))
+	}
+	if o.HotKeys > 0 {
+		opts = append(opts, mutcask.HotKeysConf(o.HotKeys))
+	}
+	return opts
+}
+
+// DiskvOptions is the options of the diskv kvdb, the zero fields use the defaults of diskv
+type DiskvOptions struct {
+	// ShardBytes and ShardLevels shard the data files into ShardLevels levels of directories,
+	// which are named by ShardBytes bytes of the key from the tail. They must not change once the data is written.
+	ShardBytes  int
+	ShardLevels int
+	// CacheSize is the max number of values in the read cache
+	CacheSize int
+	// MaxRead and MaxWrite are the max number of concurrent reads and writes
+	MaxRead  int
+	MaxWrite int
+}
+
+func (o DiskvOptions) options(dataDir string) []diskv.Option {
+	opts := []diskv.Option{diskv.DirConf(dataDir)}
+	if o.ShardBytes > 0 || o.ShardLevels > 0 {
+		opts = append(opts, diskv.ShardFunConf(diskv.NewShardFun(o.ShardBytes, o.ShardLevels)))
+	}
+	if o.CacheSize > 0 {
+		opts = append(opts, diskv.MaxCacheDagsConf(o.CacheSize))
+	}
+	if o.MaxRead > 0 {
+		opts = append(opts, diskv.MaxReadConf(o.MaxRead))
+	}
+	if o.MaxWrite > 0 {
+		opts = append(opts, diskv.MaxWriteConf(o.MaxWrite))
+	}
+	return opts
+}
+
+// OpenKVDB opens the kvdb of the data node in the data directory
+func OpenKVDB(kvType KVType, dataDir string, opts KVOptions) (kv.KVDB, error) {
+	if err := os.MkdirAll(dataDir, 0777); err != nil {
+		return nil, err
+	}
+	switch kvType {
+	case KVBadge:
+		return badger.NewBadger(dataDir)
+	case KVMutcask:
+		return mutcask.NewMutcask(opts.Mutcask.options(dataDir)...)
+	case KVDiskv:
+		return diskv.NewDisKV(opts.Diskv.options(dataDir)...)
+	default:
+		return nil, fmt.Errorf("not support this kvdb %s", kvType)
+	}
+}
@@ -38,7 +38,7 @@ func (m *MockDataNodeClient) EXPECT() *MockDataNodeClientMockRecorder {
 }
 
 // AllKeysChan mocks base method.
-func (m *MockDataNodeClient) AllKeysChan(arg0 context.Context, arg1 *emptypb.Empty, arg2 ...grpc.CallOption) (proto.DataNode_AllKeysChanClient, error) {
+func (m *MockDataNodeClient) AllKeysChan(arg0 context.Context, arg1 *proto.AllKeysChanRequest, arg2 ...grpc.CallOption) (proto.DataNode_AllKeysChanClient, error) {
 	m.ctrl.T.Helper()
 	varargs := []interface{}{arg0, arg1}
 	for _, a := range arg2 {
@@ -117,6 +117,26 @@ func (mr *MockDataNodeClientMockRecorder) Get(arg0, arg1 interface{}, arg2 ...in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockDataNodeClient)(nil).Get), varargs...)
 }
 
+// GetMany mocks base method.
+func (m *MockDataNodeClient) GetMany(arg0 context.Context, arg1 *proto.GetManyRequest, arg2 ...grpc.CallOption) (*proto.GetManyResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetMany", varargs...)
+	ret0, _ := ret[0].(*proto.GetManyResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMany indicates an expected call of GetMany.
+func (mr *MockDataNodeClientMockRecorder) GetMany(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMany", reflect.TypeOf((*MockDataNodeClient)(nil).GetMany), varargs...)
+}
+
 // GetMeta mocks base method.
 func (m *MockDataNodeClient) GetMeta(arg0 context.Context, arg1 *proto.GetMetaRequest, arg2 ...grpc.CallOption) (*proto.GetMetaResponse, error) {
 	m.ctrl.T.Helper()
@@ -137,6 +157,46 @@ func (mr *MockDataNodeClientMockRecorder) GetMeta(arg0, arg1 interface{}, arg2 .
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMeta", reflect.TypeOf((*MockDataNodeClient)(nil).GetMeta), varargs...)
 }
 
+// GetMetaMany mocks base method.
+func (m *MockDataNodeClient) GetMetaMany(arg0 context.Context, arg1 *proto.GetManyRequest, arg2 ...grpc.CallOption) (*proto.GetMetaManyResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetMetaMany", varargs...)
+	ret0, _ := ret[0].(*proto.GetMetaManyResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMetaMany indicates an expected call of GetMetaMany.
+func (mr *MockDataNodeClientMockRecorder) GetMetaMany(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMetaMany", reflect.TypeOf((*MockDataNodeClient)(nil).GetMetaMany), varargs...)
+}
+
+// GetStream mocks base method.
+func (m *MockDataNodeClient) GetStream(arg0 context.Context, arg1 *proto.GetRequest, arg2 ...grpc.CallOption) (proto.DataNode_GetStreamClient, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetStream", varargs...)
+	ret0, _ := ret[0].(proto.DataNode_GetStreamClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStream indicates an expected call of GetStream.
+func (mr *MockDataNodeClientMockRecorder) GetStream(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStream", reflect.TypeOf((*MockDataNodeClient)(nil).GetStream), varargs...)
+}
+
 // Put mocks base method.
 func (m *MockDataNodeClient) Put(arg0 context.Context, arg1 *proto.AddRequest, arg2 ...grpc.CallOption) (*emptypb.Empty, error) {
 	m.ctrl.T.Helper()
@@ -157,6 +217,46 @@ func (mr *MockDataNodeClientMockRecorder) Put(arg0, arg1 interface{}, arg2 ...in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockDataNodeClient)(nil).Put), varargs...)
 }
 
+// PutMany mocks base method.
+func (m *MockDataNodeClient) PutMany(arg0 context.Context, arg1 *proto.PutManyRequest, arg2 ...grpc.CallOption) (*proto.PutManyResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PutMany", varargs...)
+	ret0, _ := ret[0].(*proto.PutManyResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PutMany indicates an expected call of PutMany.
+func (mr *MockDataNodeClientMockRecorder) PutMany(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutMany", reflect.TypeOf((*MockDataNodeClient)(nil).PutMany), varargs...)
+}
+
+// PutStream mocks base method.
+func (m *MockDataNodeClient) PutStream(arg0 context.Context, arg1 ...grpc.CallOption) (proto.DataNode_PutStreamClient, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PutStream", varargs...)
+	ret0, _ := ret[0].(proto.DataNode_PutStreamClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PutStream indicates an expected call of PutStream.
+func (mr *MockDataNodeClientMockRecorder) PutStream(arg0 interface{}, arg1 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutStream", reflect.TypeOf((*MockDataNodeClient)(nil).PutStream), varargs...)
+}
+
 // Size mocks base method.
 func (m *MockDataNodeClient) Size(arg0 context.Context, arg1 *proto.SizeRequest, arg2 ...grpc.CallOption) (*proto.SizeResponse, error) {
 	m.ctrl.T.Helper()
@@ -176,3 +276,23 @@ func (mr *MockDataNodeClientMockRecorder) Size(arg0, arg1 interface{}, arg2 ...i
 	varargs := append([]interface{}{arg0, arg1}, arg2...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Size", reflect.TypeOf((*MockDataNodeClient)(nil).Size), varargs...)
 }
+
+// Stat mocks base method.
+func (m *MockDataNodeClient) Stat(arg0 context.Context, arg1 *emptypb.Empty, arg2 ...grpc.CallOption) (*proto.StatResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Stat", varargs...)
+	ret0, _ := ret[0].(*proto.StatResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Stat indicates an expected call of Stat.
+func (mr *MockDataNodeClientMockRecorder) Stat(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stat", reflect.TypeOf((*MockDataNodeClient)(nil).Stat), varargs...)
+}
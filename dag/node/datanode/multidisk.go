@@ -0,0 +1,411 @@
+package datanode
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/cespare/xxhash"
+	"github.com/filedag-project/filedag-storage/kv"
+	"github.com/google/uuid"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"syscall"
+)
+
+const (
+	// diskSetFile records the disk set a data directory belongs to and its position in the set
+	diskSetFile = "disk-set.json"
+	// diskProbeFile is written and removed to check whether an offline disk works again
+	diskProbeFile = ".disk-probe"
+)
+
+var (
+	// ErrDiskOffline is returned for the keys placed on an offline disk
+	ErrDiskOffline = errors.New("the disk is offline")
+	// ErrDiskReadOnly is returned for the writes of the keys placed on a read-only disk
+	ErrDiskReadOnly = errors.New("the disk is read-only")
+	// ErrDiskSetMismatch is returned if the data directories are not given as the disk set was created
+	ErrDiskSetMismatch = errors.New("the data directories do not match the disk set")
+)
+
+// diskSetFormat is the content of the disk set file
+type diskSetFormat struct {
+	SetID string `json:"set_id"`
+	Index int    `json:"index"`
+	Disks int    `json:"disks"`
+}
+
+// dataDisk is a data directory with its own kvdb
+type dataDisk struct {
+	index int
+	dir   string
+	kvdb  kv.KVDB
+	// online is cleared on the first I/O error, the disk is back online once it passes the probe
+	online int32
+	// readOnly is set when the disk usage reaches the high-water mark
+	readOnly int32
+}
+
+func (d *dataDisk) isOnline() bool {
+	return atomic.LoadInt32(&d.online) == 1
+}
+
+func (d *dataDisk) isReadOnly() bool {
+	return atomic.LoadInt32(&d.readOnly) == 1
+}
+
+// multiDisk places the keys across the data disks by the hash of the key.
+// A disk is marked offline on I/O errors, then the keys on it fail without affecting the other disks.
+type multiDisk struct {
+	disks []*dataDisk
+	// onOffline is called when a disk goes offline
+	onOffline func(d *dataDisk)
+}
+
+var _ kv.KVDB = (*multiDisk)(nil)
+
+// OpenDataDisks opens a kvdb in every data directory, and places the keys across them by hash.
+// The directories must be given in the same order every time, which is checked by the disk set file
+// written in every directory. A directory which fails to open is marked offline, and it fails only
+// if none of them can be opened.
+func OpenDataDisks(kvType KVType, dataDirs []string, opts KVOptions) (kv.KVDB, error) {
+	return openMultiDisk(kvType, dataDirs, opts)
+}
+
+func openMultiDisk(kvType KVType, dataDirs []string, opts KVOptions) (*multiDisk, error) {
+	if len(dataDirs) == 0 {
+		return nil, errors.New("no data directory")
+	}
+	usable, err := checkDiskSet(dataDirs)
+	if err != nil {
+		return nil, err
+	}
+	m := &multiDisk{}
+	online := 0
+	for i, dir := range dataDirs {
+		d := &dataDisk{index: i, dir: dir}
+		if !usable[i] {
+			m.disks = append(m.disks, d)
+			continue
+		}
+		kvdb, err := OpenKVDB(kvType, dir, opts)
+		if err != nil {
+			log.Errorw("open data disk error, mark it offline", "dir", dir, "error", err)
+		} else {
+			d.kvdb = kvdb
+			d.online = 1
+			online++
+		}
+		m.disks = append(m.disks, d)
+	}
+	if online == 0 {
+		return nil, fmt.Errorf("none of the %d data directories can be opened", len(dataDirs))
+	}
+	return m, nil
+}
+
+// checkDiskSet checks that the data directories are given in the same order as the disk set was created,
+// and writes the disk set file into the new directories. It returns whether each directory is usable,
+// the directories whose disk set file can not be read or written are offline.
+func checkDiskSet(dataDirs []string) ([]bool, error) {
+	formats := make([]*diskSetFormat, len(dataDirs))
+	usable := make([]bool, len(dataDirs))
+	setID := ""
+	for i, dir := range dataDirs {
+		buf, err := ioutil.ReadFile(filepath.Join(dir, diskSetFile))
+		if os.IsNotExist(err) {
+			usable[i] = true
+			continue
+		}
+		if err != nil {
+			log.Errorw("read disk set file error, mark the disk offline", "dir", dir, "error", err)
+			continue
+		}
+		var f diskSetFormat
+		if err = json.Unmarshal(buf, &f); err != nil {
+			return nil, fmt.Errorf("the disk set file of %s is broken: %v", dir, err)
+		}
+		if setID == "" {
+			setID = f.SetID
+		}
+		if f.SetID != setID || f.Disks != len(dataDirs) || f.Index != i {
+			return nil, fmt.Errorf("%w: %s is disk %d of %d disks in set %s, but it is given as disk %d of %d disks in set %s",
+				ErrDiskSetMismatch, dir, f.Index, f.Disks, f.SetID, i, len(dataDirs), setID)
+		}
+		formats[i] = &f
+		usable[i] = true
+	}
+	if setID == "" {
+		setID = uuid.New().String()
+	}
+	for i, dir := range dataDirs {
+		if formats[i] != nil || !usable[i] {
+			continue
+		}
+		// a new or replaced directory joins the set
+		buf, _ := json.Marshal(&diskSetFormat{SetID: setID, Index: i, Disks: len(dataDirs)})
+		if err := writeFileSync(filepath.Join(dir, diskSetFile), buf); err != nil {
+			log.Errorw("write disk set file error, mark the disk offline", "dir", dir, "error", err)
+			usable[i] = false
+		}
+	}
+	return usable, nil
+}
+
+// writeFileSync writes the file and syncs it to the disk
+func writeFileSync(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(data); err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// disk returns the online disk of the key
+func (m *multiDisk) disk(key string) (*dataDisk, error) {
+	d := m.disks[xxhash.Sum64String(key)%uint64(len(m.disks))]
+	if !d.isOnline() {
+		return nil, ErrDiskOffline
+	}
+	return d, nil
+}
+
+// check marks the disk offline if the error is an I/O error
+func (m *multiDisk) check(d *dataDisk, err error) error {
+	if err == nil || !isIOError(err) {
+		return err
+	}
+	if atomic.CompareAndSwapInt32(&d.online, 1, 0) {
+		log.Errorw("I/O error on data disk, mark it offline", "dir", d.dir, "error", err)
+		if m.onOffline != nil {
+			m.onOffline(d)
+		}
+	}
+	return err
+}
+
+// isIOError reports whether the error is caused by a failing disk. The other errors of the file operations,
+// such as a missing file, are not, and the full disk is protected by the high-water mark
+func isIOError(err error) bool {
+	return errors.Is(err, syscall.EIO) || errors.Is(err, syscall.EROFS) ||
+		errors.Is(err, syscall.ENODEV) || errors.Is(err, syscall.ENXIO)
+}
+
+// recheck probes the offline disks whose kvdb is opened, and brings them back online if the probe succeeds.
+// It returns the disks back online
+func (m *multiDisk) recheck() []*dataDisk {
+	var recovered []*dataDisk
+	for _, d := range m.disks {
+		if d.isOnline() || d.kvdb == nil {
+			continue
+		}
+		if err := probeDisk(d.dir); err != nil {
+			log.Debugw("the offline disk is still failing", "dir", d.dir, "error", err)
+			continue
+		}
+		if atomic.CompareAndSwapInt32(&d.online, 0, 1) {
+			log.Infow("the disk passes the probe, mark it online", "dir", d.dir)
+			recovered = append(recovered, d)
+		}
+	}
+	return recovered
+}
+
+// probeDisk writes, reads and removes a file in the directory
+func probeDisk(dir string) error {
+	path := filepath.Join(dir, diskProbeFile)
+	data := []byte(uuid.New().String())
+	if err := writeFileSync(path, data); err != nil {
+		return err
+	}
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(buf, data) {
+		return errors.New("the probe file is corrupted")
+	}
+	return os.Remove(path)
+}
+
+// offlineDisk returns an error if any disk is offline, the keys on it can not be listed
+func (m *multiDisk) offlineDisk() error {
+	for _, d := range m.disks {
+		if !d.isOnline() {
+			return fmt.Errorf("%w: the keys of %s can not be listed", ErrDiskOffline, d.dir)
+		}
+	}
+	return nil
+}
+
+func (m *multiDisk) Put(key string, value []byte) error {
+	d, err := m.disk(key)
+	if err != nil {
+		return err
+	}
+	if d.isReadOnly() {
+		return ErrDiskReadOnly
+	}
+	return m.check(d, d.kvdb.Put(key, value))
+}
+
+// PutStream writes the value in chunks to the disk of the key, the value is buffered
+// if the kvdb of the disk can not write it in chunks
+func (m *multiDisk) PutStream(key string, size int) (kv.ValueWriter, error) {
+	d, err := m.disk(key)
+	if err != nil {
+		return nil, err
+	}
+	if d.isReadOnly() {
+		return nil, ErrDiskReadOnly
+	}
+	w, err := kv.NewValueWriter(d.kvdb, key, size)
+	if err != nil {
+		return nil, m.check(d, err)
+	}
+	return &diskValueWriter{ValueWriter: w, m: m, d: d}, nil
+}
+
+// diskValueWriter checks the errors of the writes to take the failed disk offline
+type diskValueWriter struct {
+	kv.ValueWriter
+	m *multiDisk
+	d *dataDisk
+}
+
+func (w *diskValueWriter) Write(p []byte) (int, error) {
+	n, err := w.ValueWriter.Write(p)
+	return n, w.m.check(w.d, err)
+}
+
+func (w *diskValueWriter) WriteAt(p []byte, off int64) (int, error) {
+	n, err := w.ValueWriter.WriteAt(p, off)
+	return n, w.m.check(w.d, err)
+}
+
+func (w *diskValueWriter) Commit() error {
+	return w.m.check(w.d, w.ValueWriter.Commit())
+}
+
+func (m *multiDisk) Delete(key string) error {
+	d, err := m.disk(key)
+	if err != nil {
+		return err
+	}
+	return m.check(d, d.kvdb.Delete(key))
+}
+
+func (m *multiDisk) Get(key string) ([]byte, error) {
+	d, err := m.disk(key)
+	if err != nil {
+		return nil, err
+	}
+	value, err := d.kvdb.Get(key)
+	return value, m.check(d, err)
+}
+
+func (m *multiDisk) GetRange(key string, offset, length int) ([]byte, error) {
+	d, err := m.disk(key)
+	if err != nil {
+		return nil, err
+	}
+	value, err := d.kvdb.GetRange(key, offset, length)
+	return value, m.check(d, err)
+}
+
+func (m *multiDisk) Size(key string) (int, error) {
+	d, err := m.disk(key)
+	if err != nil {
+		return -1, err
+	}
+	size, err := d.kvdb.Size(key)
+	return size, m.check(d, err)
+}
+
+// AllKeysChan merges the keys of all the disks, it fails if any disk is offline
+func (m *multiDisk) AllKeysChan(ctx context.Context) (<-chan string, error) {
+	if err := m.offlineDisk(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	var chs []<-chan string
+	for _, d := range m.disks {
+		ch, err := d.kvdb.AllKeysChan(ctx)
+		if err = m.check(d, err); err != nil {
+			cancel()
+			return nil, err
+		}
+		chs = append(chs, ch)
+	}
+	out := make(chan string)
+	var wg sync.WaitGroup
+	for _, ch := range chs {
+		wg.Add(1)
+		go func(ch <-chan string) {
+			defer wg.Done()
+			for key := range ch {
+				select {
+				case out <- key:
+				case <-ctx.Done():
+					// drain the keys so that the kvdb can exit
+					for range ch {
+					}
+					return
+				}
+			}
+		}(ch)
+	}
+	go func() {
+		wg.Wait()
+		cancel()
+		close(out)
+	}()
+	return out, nil
+}
+
+// ListKeys merges the pages of all the disks. Every disk lists the first page of the limit,
+// so the first page of the merged keys is among them. It fails if any disk is offline.
+func (m *multiDisk) ListKeys(ctx context.Context, opts kv.ListOptions) ([]string, string, error) {
+	if err := m.offlineDisk(); err != nil {
+		return nil, "", err
+	}
+	var keys []string
+	more := false
+	for _, d := range m.disks {
+		dkeys, cursor, err := d.kvdb.ListKeys(ctx, opts)
+		if err = m.check(d, err); err != nil {
+			return nil, "", err
+		}
+		keys = append(keys, dkeys...)
+		more = more || cursor != ""
+	}
+	keys, cursor := kv.SortPage(keys, opts.Limit, more)
+	return keys, cursor, nil
+}
+
+func (m *multiDisk) Close() error {
+	var lastErr error
+	for _, d := range m.disks {
+		if d.kvdb == nil {
+			continue
+		}
+		if err := d.kvdb.Close(); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
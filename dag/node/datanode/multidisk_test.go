@@ -0,0 +1,172 @@
+package datanode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/filedag-project/filedag-storage/kv"
+	"github.com/filedag-project/filedag-storage/kv/kvtest"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"os"
+	"syscall"
+	"testing"
+)
+
+// brokenKV fails every operation with an I/O error
+type brokenKV struct {
+	kv.KVDB
+}
+
+func (b *brokenKV) Get(key string) ([]byte, error) {
+	return nil, &os.PathError{Op: "read", Path: key, Err: syscall.EIO}
+}
+
+func TestMultiDisk(t *testing.T) {
+	disks, err := openMultiDisk(KVMutcask, []string{t.TempDir(), t.TempDir(), t.TempDir()}, KVOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer disks.Close()
+	hs := health.NewServer()
+	ser := &server{kvdb: disks, disks: disks, health: hs}
+	disks.onOffline = func(d *dataDisk) {
+		ser.updateHealth()
+	}
+	ser.updateHealth()
+
+	keys := make(map[string]int)
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		if err = disks.Put(key, []byte(key)); err != nil {
+			t.Fatal(err)
+		}
+		for _, d := range disks.disks {
+			if _, err := d.kvdb.Get(key); err == nil {
+				keys[key] = d.index
+			}
+		}
+	}
+	used := make(map[int]bool)
+	for _, index := range keys {
+		used[index] = true
+	}
+	if len(keys) != 100 || len(used) != 3 {
+		t.Fatalf("the keys should be placed across all disks, placed %d keys on %d disks", len(keys), len(used))
+	}
+
+	// an I/O error takes the disk offline, the keys on the other disks are still served
+	origin := disks.disks[1].kvdb
+	disks.disks[1].kvdb = &brokenKV{KVDB: origin}
+	defer func() { disks.disks[1].kvdb = origin }()
+	for key, index := range keys {
+		_, err := disks.Get(key)
+		if index != 1 && err != nil {
+			t.Fatalf("the key %s on the online disk failed: %v", key, err)
+		}
+		if index == 1 && err == nil {
+			t.Fatalf("the key %s on the broken disk should fail", key)
+		}
+	}
+	if disks.disks[1].isOnline() {
+		t.Fatal("the broken disk should be offline")
+	}
+	for key, index := range keys {
+		if index == 1 {
+			if err := disks.Put(key, []byte(key)); err != ErrDiskOffline {
+				t.Fatalf("expected %v, got %v", ErrDiskOffline, err)
+			}
+			break
+		}
+	}
+
+	ctx := context.Background()
+	check := func(service string, expected healthpb.HealthCheckResponse_ServingStatus) {
+		resp, err := hs.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Status != expected {
+			t.Fatalf("the status of %s should be %v, got %v", service, expected, resp.Status)
+		}
+	}
+	check(DiskHealthService(0), healthpb.HealthCheckResponse_SERVING)
+	check(DiskHealthService(1), healthpb.HealthCheckResponse_NOT_SERVING)
+	check(healthCheckService, healthpb.HealthCheckResponse_SERVING)
+
+	// the keys on the offline disk are missing, so the listing fails instead of returning a part of the keys
+	if _, _, err = disks.ListKeys(ctx, kv.ListOptions{}); !errors.Is(err, ErrDiskOffline) {
+		t.Fatalf("expected %v, got %v", ErrDiskOffline, err)
+	}
+	if _, err = disks.AllKeysChan(ctx); !errors.Is(err, ErrDiskOffline) {
+		t.Fatalf("expected %v, got %v", ErrDiskOffline, err)
+	}
+
+	// the disk is back online once it passes the probe
+	disks.disks[1].kvdb = origin
+	if recovered := disks.recheck(); len(recovered) != 1 || recovered[0].index != 1 {
+		t.Fatalf("the disk should be back online, recovered %v", recovered)
+	}
+	ser.updateHealth()
+	check(DiskHealthService(1), healthpb.HealthCheckResponse_SERVING)
+	listed, _, err := disks.ListKeys(ctx, kv.ListOptions{})
+	if err != nil || len(listed) != len(keys) {
+		t.Fatalf("expected %d keys, got %d, err: %v", len(keys), len(listed), err)
+	}
+}
+
+func TestIsIOError(t *testing.T) {
+	if isIOError(&os.PathError{Op: "open", Path: "key", Err: syscall.ENOENT}) {
+		t.Fatal("a missing file is not an I/O error")
+	}
+	if isIOError(&os.PathError{Op: "write", Path: "key", Err: syscall.ENOSPC}) {
+		t.Fatal("the full disk is not an I/O error")
+	}
+	if !isIOError(&os.PathError{Op: "read", Path: "key", Err: syscall.EIO}) {
+		t.Fatal("EIO is an I/O error")
+	}
+}
+
+func TestDiskSet(t *testing.T) {
+	dirs := []string{t.TempDir(), t.TempDir()}
+	disks, err := openMultiDisk(KVMutcask, dirs, KVOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	disks.Close()
+
+	// the keys would be looked up on the wrong disks if the directories are reordered or added
+	if _, err = openMultiDisk(KVMutcask, []string{dirs[1], dirs[0]}, KVOptions{}); !errors.Is(err, ErrDiskSetMismatch) {
+		t.Fatalf("expected %v, got %v", ErrDiskSetMismatch, err)
+	}
+	if _, err = openMultiDisk(KVMutcask, append(dirs, t.TempDir()), KVOptions{}); !errors.Is(err, ErrDiskSetMismatch) {
+		t.Fatalf("expected %v, got %v", ErrDiskSetMismatch, err)
+	}
+
+	// a replaced empty directory joins the set
+	dirs[1] = t.TempDir()
+	disks, err = openMultiDisk(KVMutcask, dirs, KVOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	disks.Close()
+	if disks, err = openMultiDisk(KVMutcask, dirs, KVOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	disks.Close()
+}
+
+func TestMultiDiskConformance(t *testing.T) {
+	for _, kvType := range []KVType{KVBadge, KVMutcask, KVDiskv} {
+		t.Run(string(kvType), func(t *testing.T) {
+			kvtest.Run(t, func(t *testing.T) kv.KVDB {
+				opts := KVOptions{Diskv: DiskvOptions{ShardLevels: 2, CacheSize: 16}}
+				disks, err := openMultiDisk(kvType, []string{t.TempDir(), t.TempDir()}, opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return disks
+			})
+		})
+	}
+}
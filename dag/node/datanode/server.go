@@ -1,14 +1,11 @@
 package datanode
 
 import (
-	"bytes"
 	"context"
-	"encoding/binary"
+	"fmt"
 	"github.com/filedag-project/filedag-storage/dag/proto"
+	"github.com/filedag-project/filedag-storage/dag/utils/rpcauth"
 	"github.com/filedag-project/filedag-storage/kv"
-	"github.com/filedag-project/filedag-storage/kv/badger"
-	"github.com/filedag-project/filedag-storage/kv/mutcask"
-	"github.com/howeyc/crc16"
 	logging "github.com/ipfs/go-log/v2"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -16,6 +13,8 @@ import (
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"hash/crc32"
+	"io"
 	"net"
 	"os"
 	"os/signal"
@@ -32,48 +31,89 @@ const (
 	KVBadge KVType = "badger"
 	//KVMutcask is the kv type of mutcask
 	KVMutcask KVType = "mutcask"
+	//KVDiskv is the kv type of diskv
+	KVDiskv KVType = "diskv"
 
-	// HeaderSize is size of entry header
-	HeaderSize = 12
+	// StreamChunkSize is the max size of data sent in one message of the stream rpc
+	StreamChunkSize = 1 << 20
+	// MaxShardSize is the max size of a shard received by stream
+	MaxShardSize = 1 << 30
+	// listPageSize is the number of keys listed from the kvdb at a time by AllKeysChan
+	listPageSize = 1000
 )
 
-// entry item
-// | crc (4 bytes) | meta size (4 bytes) | data size (4 bytes) | meta | data |
-
 type server struct {
 	proto.UnimplementedDataNodeServer
 	kvdb kv.KVDB
+	// checksumType is the checksum algorithm of the written entries
+	checksumType ChecksumType
+	// disks is the data disks of the kvdb
+	disks *multiDisk
+	// highWaterMark is the disk usage ratio at which the data node turns read-only, 0 disables it
+	highWaterMark float64
+	usage         diskUsage
+	health        *health.Server
+}
+
+// ServerConfig is the configuration of the data node server
+type ServerConfig struct {
+	Listen string
+	KVType KVType
+	// KVOptions is the options of the kvdb
+	KVOptions KVOptions
+	// DataDirs is the data directories, usually one per disk, the keys are placed across them by hash
+	DataDirs []string
+	// ChecksumType is the checksum algorithm of the written entries
+	ChecksumType ChecksumType
+	// HighWaterMark is the disk usage ratio at which the data node turns read-only, 0 disables it
+	HighWaterMark float64
+	// Security enables TLS and the token check of the grpc server
+	Security rpcauth.Config
 }
 
 const healthCheckService = "grpc.health.v1.Health"
 
-type Header struct {
-	Checksum uint32
-	MetaSize int32
-	DataSize int32
+// DiskHealthService returns the service name of the health of the data disk at the index
+func DiskHealthService(index int) string {
+	return fmt.Sprintf("disk/%d", index)
+}
+
+// updateHealth reports the health of every disk, the data node is serving while any disk is online
+func (s *server) updateHealth() {
+	online := false
+	for _, d := range s.disks.disks {
+		st := healthpb.HealthCheckResponse_NOT_SERVING
+		if d.isOnline() {
+			st = healthpb.HealthCheckResponse_SERVING
+			online = true
+		}
+		s.health.SetServingStatus(DiskHealthService(d.index), st)
+	}
+	if online {
+		s.health.SetServingStatus(healthCheckService, healthpb.HealthCheckResponse_SERVING)
+	} else {
+		s.health.SetServingStatus(healthCheckService, healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+}
+
+// checksum returns the checksum algorithm of the written entries, crc32c by default
+func (s *server) checksum() ChecksumType {
+	if s.checksumType == 0 {
+		return ChecksumCRC32C
+	}
+	return s.checksumType
 }
 
 //Put puts the data by key
 func (s *server) Put(ctx context.Context, in *proto.AddRequest) (*emptypb.Empty, error) {
-	header := Header{
-		MetaSize: int32(len(in.Meta)),
-		DataSize: int32(len(in.Data)),
+	if s.isReadOnly() {
+		return nil, errReadOnly
 	}
-	var buf bytes.Buffer
-	buf.Grow(binary.Size(header) + int(header.MetaSize+header.DataSize))
-	if err := binary.Write(&buf, binary.LittleEndian, header); err != nil {
-		return nil, status.Error(codes.Unknown, err.Error())
-	}
-	buf.Write(in.Meta)
-	buf.Write(in.Data)
-	data := buf.Bytes()
-	header.Checksum = uint32(crc16.Checksum(data[binary.Size(header.Checksum):], crc16.IBMTable))
-	var bufCrc bytes.Buffer
-	if err := binary.Write(&bufCrc, binary.LittleEndian, header.Checksum); err != nil {
+	entry, err := encodeEntry(s.checksum(), in.Meta, in.Data)
+	if err != nil {
 		return nil, status.Error(codes.Unknown, err.Error())
 	}
-	copy(data, bufCrc.Bytes())
-	if err := s.kvdb.Put(in.Key, data); err != nil {
+	if err = s.kvdb.Put(in.Key, entry); err != nil {
 		return nil, status.Error(codes.Unknown, err.Error())
 	}
 	return &emptypb.Empty{}, nil
@@ -81,48 +121,176 @@ func (s *server) Put(ctx context.Context, in *proto.AddRequest) (*emptypb.Empty,
 
 //Get gets the data by key
 func (s *server) Get(ctx context.Context, in *proto.GetRequest) (*proto.GetResponse, error) {
-	data, err := s.kvdb.Get(in.Key)
+	meta, data, err := s.readEntry(in)
 	if err != nil {
 		return nil, status.Error(codes.Unknown, err.Error())
 	}
-	buf := bytes.NewBuffer(data)
-	header := Header{}
-	if err = binary.Read(buf, binary.LittleEndian, &header); err != nil {
-		return nil, status.Error(codes.Unknown, err.Error())
-	}
-	// check crc
-	sum := crc16.Checksum(data[binary.Size(header.Checksum):], crc16.IBMTable)
-	if header.Checksum != uint32(sum) {
-		return nil, status.Error(codes.Unknown, "checking crc failed")
-	}
-	offset := binary.Size(header)
 	return &proto.GetResponse{
-		Meta: data[offset : offset+int(header.MetaSize)],
-		Data: data[offset+int(header.MetaSize) : offset+int(header.MetaSize+header.DataSize)],
+		Meta: meta,
+		Data: data,
 	}, nil
 }
 
+// readEntry reads the meta and data of the request, only the requested range of the data is loaded by the ranged reads
+func (s *server) readEntry(in *proto.GetRequest) (meta []byte, data []byte, err error) {
+	if in.Offset != 0 || in.Length != 0 {
+		return readEntryRange(s.kvdb, in.Key, in.Offset, in.Length)
+	}
+	entry, err := s.kvdb.Get(in.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return decodeEntry(entry)
+}
+
 func (s *server) GetMeta(ctx context.Context, in *proto.GetMetaRequest) (*proto.GetMetaResponse, error) {
-	data, err := s.kvdb.Get(in.Key)
+	entry, err := s.kvdb.Get(in.Key)
 	if err != nil {
 		return nil, status.Error(codes.Unknown, err.Error())
 	}
-	buf := bytes.NewBuffer(data)
-	header := Header{}
-	if err = binary.Read(buf, binary.LittleEndian, &header); err != nil {
+	meta, _, err := decodeEntry(entry)
+	if err != nil {
 		return nil, status.Error(codes.Unknown, err.Error())
 	}
-	// check crc
-	sum := crc16.Checksum(data[binary.Size(header.Checksum):], crc16.IBMTable)
-	if header.Checksum != uint32(sum) {
-		return nil, status.Error(codes.Unknown, "checking crc failed")
-	}
-	headerSize := binary.Size(header)
 	return &proto.GetMetaResponse{
-		Meta: data[headerSize : headerSize+int(header.MetaSize)],
+		Meta: meta,
 	}, nil
 }
 
+//PutStream puts the data received in chunks by key
+func (s *server) PutStream(stream proto.DataNode_PutStreamServer) error {
+	if s.isReadOnly() {
+		return errReadOnly
+	}
+	first, err := stream.Recv()
+	if err != nil {
+		return status.Error(codes.Unknown, err.Error())
+	}
+	if first.Size < 0 || first.Size > MaxShardSize {
+		return status.Errorf(codes.InvalidArgument, "invalid shard size %d", first.Size)
+	}
+	// the chunks are written to the kvdb as they come, the entry is stored once all of them are checked
+	w, err := newEntryWriter(s.kvdb, first.Key, s.checksum(), first.Meta, int(first.Size))
+	if err != nil {
+		return status.Error(codes.Unknown, err.Error())
+	}
+	defer w.Abort()
+	size := int(first.Size)
+	received := 0
+	checksum := uint32(0)
+	for req := first; ; {
+		if received+len(req.Data) > size {
+			return status.Error(codes.InvalidArgument, "received data exceeds the shard size")
+		}
+		if _, err = w.Write(req.Data); err != nil {
+			return status.Error(codes.Unknown, err.Error())
+		}
+		received += len(req.Data)
+		checksum = crc32.Update(checksum, crc32.IEEETable, req.Data)
+
+		if req, err = stream.Recv(); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return status.Error(codes.Unknown, err.Error())
+		}
+	}
+	if received != size {
+		return status.Errorf(codes.InvalidArgument, "expected %d bytes, received %d bytes", size, received)
+	}
+	if checksum != first.Checksum {
+		return status.Error(codes.DataLoss, "checking crc failed")
+	}
+	if err = w.Commit(); err != nil {
+		return status.Error(codes.Unknown, err.Error())
+	}
+	return stream.SendAndClose(&emptypb.Empty{})
+}
+
+//GetStream gets the data by key and sends it in chunks, the data is read from the kvdb chunk by chunk
+func (s *server) GetStream(in *proto.GetRequest, stream proto.DataNode_GetStreamServer) error {
+	r, err := openEntry(s.kvdb, in.Key)
+	if err != nil {
+		return status.Error(codes.Unknown, err.Error())
+	}
+	length, err := r.layout.dataRange(in.Offset, in.Length)
+	if err != nil {
+		return status.Error(codes.Unknown, err.Error())
+	}
+	sender := &streamSender{stream: stream, resp: &proto.GetStreamResponse{Meta: r.meta, Size: length}}
+	if r.layout.chunked() {
+		// each chunk is verified before it is sent
+		end := in.Offset + length
+		for pos := in.Offset; ; {
+			// the messages end at the multiples of StreamChunkSize, so no chunk is read twice
+			n := (pos/StreamChunkSize+1)*StreamChunkSize - pos
+			if n > end-pos {
+				n = end - pos
+			}
+			data, err := r.readChunks(pos, n)
+			if err != nil {
+				return status.Error(codes.Unknown, err.Error())
+			}
+			pos += n
+			if err = sender.send(data, pos == end); err != nil {
+				return err
+			}
+			if pos == end {
+				return nil
+			}
+		}
+	}
+	// the checksum covers the whole entry, so a chunk is held back until the next one is read,
+	// and the last one is sent once the checksum is verified
+	var pending []byte
+	err = r.scan(func(chunk []byte, pos int64) error {
+		lo, hi := in.Offset-pos, in.Offset+length-pos
+		if lo < 0 {
+			lo = 0
+		}
+		if hi > int64(len(chunk)) {
+			hi = int64(len(chunk))
+		}
+		if lo >= hi {
+			return nil
+		}
+		if pending != nil {
+			if err := sender.send(pending, false); err != nil {
+				return err
+			}
+		}
+		pending = chunk[lo:hi]
+		return nil
+	})
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		return status.Error(codes.Unknown, err.Error())
+	}
+	return sender.send(pending, true)
+}
+
+// streamSender sends the data in messages, the crc of all the sent data is set in the last message
+type streamSender struct {
+	stream proto.DataNode_GetStreamServer
+	resp   *proto.GetStreamResponse
+	crc    uint32
+}
+
+func (s *streamSender) send(data []byte, last bool) error {
+	s.crc = crc32.Update(s.crc, crc32.IEEETable, data)
+	s.resp.Data = data
+	if last {
+		s.resp.Checksum = s.crc
+	}
+	if err := s.stream.Send(s.resp); err != nil {
+		return status.Error(codes.Unknown, err.Error())
+	}
+	s.resp = &proto.GetStreamResponse{}
+	return nil
+}
+
 //Delete deletes the data by key
 func (s *server) Delete(ctx context.Context, in *proto.DeleteRequest) (*emptypb.Empty, error) {
 	err := s.kvdb.Delete(in.Key)
@@ -153,19 +321,89 @@ func (s *server) DeleteMany(ctx context.Context, in *proto.DeleteManyRequest) (*
 	return &emptypb.Empty{}, nil
 }
 
-func (s *server) AllKeysChan(_ *emptypb.Empty, server proto.DataNode_AllKeysChanServer) error {
-	ctx, cancel := context.WithCancel(server.Context())
-	defer cancel()
-	ch, err := s.kvdb.AllKeysChan(ctx)
-	if err != nil {
-		return status.Error(codes.Unknown, err.Error())
+//PutMany puts the entries by key, the error of each entry is returned in the response
+func (s *server) PutMany(ctx context.Context, in *proto.PutManyRequest) (*proto.PutManyResponse, error) {
+	if s.isReadOnly() {
+		return nil, errReadOnly
 	}
-	for key := range ch {
-		if err = server.Send(&proto.AllKeysChanResponse{Key: key}); err != nil {
+	errs := make([]string, len(in.Entries))
+	for i, en := range in.Entries {
+		entry, err := encodeEntry(s.checksum(), en.Meta, en.Data)
+		if err == nil {
+			err = s.kvdb.Put(en.Key, entry)
+		}
+		if err != nil {
+			errs[i] = err.Error()
+		}
+	}
+	return &proto.PutManyResponse{Errors: errs}, nil
+}
+
+//GetMany gets the data of the keys, the error of each key is returned in the response
+func (s *server) GetMany(ctx context.Context, in *proto.GetManyRequest) (*proto.GetManyResponse, error) {
+	entries := make([]*proto.GetManyEntry, len(in.Keys))
+	for i, key := range in.Keys {
+		en := &proto.GetManyEntry{}
+		entries[i] = en
+		entry, err := s.kvdb.Get(key)
+		if err != nil {
+			en.Error = err.Error()
+			continue
+		}
+		if en.Meta, en.Data, err = decodeEntry(entry); err != nil {
+			en.Error = err.Error()
+		}
+	}
+	return &proto.GetManyResponse{Entries: entries}, nil
+}
+
+//GetMetaMany gets the meta of the keys, the error of each key is returned in the response
+func (s *server) GetMetaMany(ctx context.Context, in *proto.GetManyRequest) (*proto.GetMetaManyResponse, error) {
+	entries := make([]*proto.GetMetaManyEntry, len(in.Keys))
+	for i, key := range in.Keys {
+		en := &proto.GetMetaManyEntry{}
+		entries[i] = en
+		entry, err := s.kvdb.Get(key)
+		if err != nil {
+			en.Error = err.Error()
+			continue
+		}
+		if en.Meta, _, err = decodeEntry(entry); err != nil {
+			en.Error = err.Error()
+		}
+	}
+	return &proto.GetMetaManyResponse{Entries: entries}, nil
+}
+
+// AllKeysChan streams the keys page by page, so that the kvdb is not held while the client is slow
+func (s *server) AllKeysChan(in *proto.AllKeysChanRequest, server proto.DataNode_AllKeysChanServer) error {
+	opts := kv.ListOptions{Prefix: in.Prefix, StartAfter: in.StartAfter}
+	remaining := in.Limit
+	for {
+		opts.Limit = listPageSize
+		if remaining > 0 && remaining < listPageSize {
+			opts.Limit = int(remaining)
+		}
+		keys, cursor, err := s.kvdb.ListKeys(server.Context(), opts)
+		if err != nil {
 			return status.Error(codes.Unknown, err.Error())
 		}
+		for _, key := range keys {
+			if err = server.Send(&proto.AllKeysChanResponse{Key: key, Cursor: key}); err != nil {
+				return status.Error(codes.Unknown, err.Error())
+			}
+		}
+		if in.Limit > 0 {
+			remaining -= uint64(len(keys))
+			if remaining == 0 {
+				return nil
+			}
+		}
+		if cursor == "" {
+			return nil
+		}
+		opts.StartAfter = cursor
 	}
-	return nil
 }
 
 //func (s *server) Check(ctx context.Context, in *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
@@ -180,40 +418,45 @@ func (s *server) AllKeysChan(_ *emptypb.Empty, server proto.DataNode_AllKeysChan
 //}
 
 //StartDataNodeServer is the gRPC server for the MutDataNode
-func StartDataNodeServer(listen string, kvType KVType, dataDir string) {
+func StartDataNodeServer(cfg ServerConfig) {
 	log.Infof("datanode start...")
-	log.Infof("listen %s", listen)
+	log.Infof("listen %s", cfg.Listen)
 	// listen port
-	lis, err := net.Listen("tcp", listen)
+	lis, err := net.Listen("tcp", cfg.Listen)
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
-	s := grpc.NewServer()
+	opts, err := cfg.Security.ServerOptions()
+	if err != nil {
+		log.Fatalf("failed to load security config: %v", err)
+	}
+	s := grpc.NewServer(opts...)
 
 	//HealthCheck
 	hs := health.NewServer()
-	hs.SetServingStatus(healthCheckService, healthpb.HealthCheckResponse_SERVING)
 	healthpb.RegisterHealthServer(s, hs)
 
-	if err := os.MkdirAll(dataDir, 0777); err != nil {
-		log.Fatalf("failed to create directory: %v", err)
-	}
-
-	var kvdb kv.KVDB
-	switch kvType {
-	case KVBadge:
-		kvdb, err = badger.NewBadger(dataDir)
-	case KVMutcask:
-		kvdb, err = mutcask.NewMutcask(mutcask.PathConf(dataDir), mutcask.CaskNumConf(6))
-	default:
-		log.Fatal("not handle this kv type")
-	}
+	disks, err := openMultiDisk(cfg.KVType, cfg.DataDirs, cfg.KVOptions)
 	if err != nil {
 		log.Fatalf("failed to load db: %v", err)
 	}
-	defer kvdb.Close()
+	defer disks.Close()
 
-	proto.RegisterDataNodeServer(s, &server{kvdb: kvdb})
+	ser := &server{
+		kvdb:          disks,
+		checksumType:  cfg.ChecksumType,
+		disks:         disks,
+		highWaterMark: cfg.HighWaterMark,
+		health:        hs,
+	}
+	disks.onOffline = func(d *dataDisk) {
+		ser.updateHealth()
+	}
+	ser.updateHealth()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go ser.runUsageCheck(ctx)
+	proto.RegisterDataNodeServer(s, ser)
 	if err != nil {
 		return
 	}
@@ -1,12 +1,21 @@
 package datanode
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"github.com/filedag-project/filedag-storage/dag/proto"
 	"github.com/filedag-project/filedag-storage/kv/badger"
 	"github.com/filedag-project/filedag-storage/kv/mutcask"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"io"
+	"math/rand"
+	"net"
 	"testing"
 )
 
@@ -144,10 +153,10 @@ func TestServer_Size(t *testing.T) {
 		dataBlock      []byte
 		expectResponse int64
 	}{
-		{"badge", KVBadge, "1234567", []byte("\b\u0002\u0012\a1234567\u0018\a"), HeaderSize + 13},
-		{"mutcask", KVMutcask, "1234567", []byte("\b\u0002\u0012\a1234567\u0018\a"), HeaderSize + 13},
-		{"badge", KVBadge, "122", []byte("1"), HeaderSize + 1},
-		{"mutcask", KVMutcask, "122", []byte("1"), HeaderSize + 1},
+		{"badge", KVBadge, "1234567", []byte("\b\u0002\u0012\a1234567\u0018\a"), HeaderSize + 13 + chunkSumSize},
+		{"mutcask", KVMutcask, "1234567", []byte("\b\u0002\u0012\a1234567\u0018\a"), HeaderSize + 13 + chunkSumSize},
+		{"badge", KVBadge, "122", []byte("1"), HeaderSize + 1 + chunkSumSize},
+		{"mutcask", KVMutcask, "122", []byte("1"), HeaderSize + 1 + chunkSumSize},
 		{"badge", KVBadge, "122", []byte(""), HeaderSize},
 		{"mutcask", KVMutcask, "122", []byte(""), HeaderSize},
 	}
@@ -178,3 +187,349 @@ func TestServer_Size(t *testing.T) {
 		})
 	}
 }
+
+func TestServer_Stream(t *testing.T) {
+	testcases := []struct {
+		name string
+		size int
+	}{
+		{"empty", 0},
+		{"one chunk", StreamChunkSize / 2},
+		{"many chunks", StreamChunkSize*3 + 100},
+	}
+	newMutcask, err := mutcask.NewMutcask(mutcask.PathConf(t.TempDir()), mutcask.CaskNumConf(6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer newMutcask.Close()
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	proto.RegisterDataNodeServer(s, &server{kvdb: newMutcask})
+	go s.Serve(lis)
+	defer s.Stop()
+	conn, err := grpc.Dial("bufnet", grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) {
+		return lis.Dial()
+	}), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	cli := &Client{DataClient: proto.NewDataNodeClient(conn)}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			data := make([]byte, tc.size)
+			rand.Read(data)
+			meta := []byte("meta")
+			if err := cli.PutStream(context.Background(), tc.name, meta, data); err != nil {
+				t.Fatal(err)
+			}
+			gotMeta, gotData, err := cli.GetStream(context.Background(), tc.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(gotMeta, meta) || !bytes.Equal(gotData, data) {
+				t.Errorf("the shard got by stream is not equal to the origin one")
+			}
+			// the shard put by stream can be read by the unary rpc as well
+			res, err := cli.DataClient.Get(context.Background(), &proto.GetRequest{Key: tc.name})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(res.Data, data) {
+				t.Errorf("the shard got by unary rpc is not equal to the origin one")
+			}
+		})
+	}
+
+	t.Run("bad checksum", func(t *testing.T) {
+		data := make([]byte, StreamChunkSize*2)
+		rand.Read(data)
+		if err := cli.PutStream(context.Background(), "bad checksum", nil, data[:10]); err != nil {
+			t.Fatal(err)
+		}
+		stream, err := cli.DataClient.PutStream(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := &proto.PutStreamRequest{Key: "bad checksum", Size: int64(len(data)), Checksum: 1}
+		for i := 0; i < len(data); i += StreamChunkSize {
+			req.Data = data[i : i+StreamChunkSize]
+			if err = stream.Send(req); err != nil {
+				t.Fatal(err)
+			}
+			req = &proto.PutStreamRequest{}
+		}
+		if _, err = stream.CloseAndRecv(); status.Code(err) != codes.DataLoss {
+			t.Fatalf("expected %v, got %v", codes.DataLoss, err)
+		}
+		// the shard stored before is kept
+		_, got, err := cli.GetStream(context.Background(), "bad checksum")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, data[:10]) {
+			t.Errorf("the shard is overwritten by the stream failed to check")
+		}
+	})
+
+	t.Run("range", func(t *testing.T) {
+		data := make([]byte, StreamChunkSize*2+100)
+		rand.Read(data)
+		meta := []byte("meta")
+		if err := cli.PutStream(context.Background(), "range", meta, data); err != nil {
+			t.Fatal(err)
+		}
+		if err = newMutcask.Put("range version 1", version1Entry(ChecksumCRC32C, meta, data)); err != nil {
+			t.Fatal(err)
+		}
+		if err = newMutcask.Put("range legacy", legacyEntry(meta, data)); err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range []string{"range", "range version 1", "range legacy"} {
+			for _, r := range [][2]int64{{0, 0}, {100, StreamChunkSize}, {StreamChunkSize - 1, 2}, {int64(len(data)), 0}} {
+				end := r[0] + r[1]
+				if r[1] == 0 {
+					end = int64(len(data))
+				}
+				gotMeta, got, err := cli.GetRangeStream(context.Background(), key, r[0], r[1])
+				if err != nil || !bytes.Equal(gotMeta, meta) || !bytes.Equal(got, data[r[0]:end]) {
+					t.Fatalf("%s: the range [%d, +%d) is not equal to the origin one, err: %v", key, r[0], r[1], err)
+				}
+			}
+		}
+
+		corrupted := version1Entry(ChecksumCRC32C, meta, data)
+		corrupted[len(corrupted)-1]++
+		if err = newMutcask.Put("corrupted", corrupted); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err = cli.GetStream(context.Background(), "corrupted"); err == nil {
+			t.Error("expected checksum mismatch for the corrupted entry")
+		}
+	})
+}
+
+func TestServer_GetRange(t *testing.T) {
+	newBadger, err := badger.NewBadger(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer newBadger.Close()
+	newMutcask, err := mutcask.NewMutcask(mutcask.PathConf(t.TempDir()), mutcask.CaskNumConf(6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer newMutcask.Close()
+	data := make([]byte, 1000)
+	rand.Read(data)
+	meta := []byte("meta")
+	testcases := []struct {
+		name           string
+		offset, length int64
+		expect         []byte
+	}{
+		{"head", 0, 10, data[:10]},
+		{"middle", 100, 200, data[100:300]},
+		{"to the end", 900, 0, data[900:]},
+		{"truncated", 990, 100, data[990:]},
+		{"empty", 1000, 10, []byte{}},
+	}
+	for _, ser := range []*server{{kvdb: newBadger}, {kvdb: newMutcask}} {
+		if _, err := ser.Put(context.Background(), &proto.AddRequest{Key: "key", Meta: meta, Data: data}); err != nil {
+			t.Fatal(err)
+		}
+		for _, tc := range testcases {
+			t.Run(tc.name, func(t *testing.T) {
+				res, err := ser.Get(context.Background(), &proto.GetRequest{Key: "key", Offset: tc.offset, Length: tc.length})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(res.Meta, meta) || !bytes.Equal(res.Data, tc.expect) {
+					t.Errorf("the range [%d, +%d) is not equal to the origin one", tc.offset, tc.length)
+				}
+			})
+		}
+		if _, err := ser.Get(context.Background(), &proto.GetRequest{Key: "key", Offset: 1001}); err == nil {
+			t.Error("expected error for the offset beyond the shard")
+		}
+
+		// the whole entry is verified even if the range does not cover the corrupted byte
+		entry, err := encodeEntry(ChecksumCRC32C, meta, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry[len(entry)-1]++
+		legacy := legacyEntry(meta, data)
+		if err = ser.kvdb.Put("corrupted", entry); err != nil {
+			t.Fatal(err)
+		}
+		if err = ser.kvdb.Put("legacy", legacy); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = ser.Get(context.Background(), &proto.GetRequest{Key: "corrupted", Length: 10}); err == nil {
+			t.Error("expected checksum mismatch for the corrupted entry")
+		}
+		legacy[len(legacy)-1]++
+		if err = ser.kvdb.Put("legacy corrupted", legacy); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = ser.Get(context.Background(), &proto.GetRequest{Key: "legacy corrupted", Length: 10}); err == nil {
+			t.Error("expected checksum mismatch for the corrupted legacy entry")
+		}
+	}
+}
+
+func TestReadEntryRangeChunks(t *testing.T) {
+	chunkSize, chunkShift := rangeChunkSize, entryChunkShift
+	rangeChunkSize, entryChunkShift = 64, 6
+	defer func() { rangeChunkSize, entryChunkShift = chunkSize, chunkShift }()
+	db, err := mutcask.NewMutcask(mutcask.PathConf(t.TempDir()), mutcask.CaskNumConf(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	data := make([]byte, 1000)
+	rand.Read(data)
+	meta := []byte("meta")
+	entry, err := encodeEntry(ChecksumXXHash64, meta, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.Put("key", entry); err != nil {
+		t.Fatal(err)
+	}
+	if err = db.Put("legacy", legacyEntry(meta, data)); err != nil {
+		t.Fatal(err)
+	}
+	if err = db.Put("version 1", version1Entry(ChecksumCRC32C, meta, data)); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"key", "legacy", "version 1"} {
+		for _, r := range [][2]int64{{0, 10}, {60, 200}, {500, 0}, {999, 1}} {
+			m, d, err := readEntryRange(db, key, r[0], r[1])
+			end := r[0] + r[1]
+			if r[1] == 0 {
+				end = int64(len(data))
+			}
+			if err != nil || !bytes.Equal(m, meta) || !bytes.Equal(d, data[r[0]:end]) {
+				t.Fatalf("%s: the range [%d, +%d) is not equal to the origin one, err: %v", key, r[0], r[1], err)
+			}
+		}
+	}
+
+	// only the chunks covering the range are verified
+	entry[HeaderSize+len(meta)+200]++
+	if err = db.Put("key", entry); err != nil {
+		t.Fatal(err)
+	}
+	if _, d, err := readEntryRange(db, "key", 0, 100); err != nil || !bytes.Equal(d, data[:100]) {
+		t.Fatalf("read the range before the corrupted chunk failed, err: %v", err)
+	}
+	if _, _, err = readEntryRange(db, "key", 150, 100); err != ErrChecksumMismatch {
+		t.Fatalf("expected checksum mismatch for the corrupted chunk, got %v", err)
+	}
+}
+
+func TestServer_Stat(t *testing.T) {
+	disks, err := openMultiDisk(KVMutcask, []string{t.TempDir(), t.TempDir()}, KVOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer disks.Close()
+	ser := &server{kvdb: disks, disks: disks}
+	ctx := context.Background()
+	for _, key := range []string{"key1", "key2"} {
+		if _, err = ser.Put(ctx, &proto.AddRequest{Key: key, Data: []byte(key)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = ser.refreshUsage(); err != nil {
+		t.Fatal(err)
+	}
+	if err = ser.countKeys(ctx); err != nil {
+		t.Fatal(err)
+	}
+	stat, err := ser.Stat(ctx, &emptypb.Empty{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stat.Total == 0 || stat.Keys != 2 || stat.ReadOnly || len(stat.Disks) != 2 {
+		t.Fatalf("unexpected stat %v", stat)
+	}
+
+	// any used disk reaches the tiny high-water mark
+	ser.highWaterMark = 1e-9
+	if err = ser.refreshUsage(); err != nil {
+		t.Fatal(err)
+	}
+	if stat, _ = ser.Stat(ctx, &emptypb.Empty{}); !stat.ReadOnly {
+		t.Fatal("the data node should be read-only")
+	}
+	if _, err = ser.Put(ctx, &proto.AddRequest{Key: "key3", Data: []byte("key3")}); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected the write rejected, got %v", err)
+	}
+	if _, err = ser.Get(ctx, &proto.GetRequest{Key: "key1"}); err != nil {
+		t.Fatalf("the read-only data node should serve reads: %v", err)
+	}
+}
+
+func TestServer_AllKeysChan(t *testing.T) {
+	disks, err := openMultiDisk(KVBadge, []string{t.TempDir(), t.TempDir()}, KVOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer disks.Close()
+	// more keys than a page of the kvdb listing
+	var want []string
+	for i := 0; i < listPageSize*2+10; i++ {
+		key := fmt.Sprintf("key%05d", i)
+		if err = disks.Put(key, []byte(key)); err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, key)
+	}
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	proto.RegisterDataNodeServer(s, &server{kvdb: disks})
+	go s.Serve(lis)
+	defer s.Stop()
+	conn, err := grpc.Dial("bufnet", grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) {
+		return lis.Dial()
+	}), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	cli := proto.NewDataNodeClient(conn)
+	list := func(in *proto.AllKeysChanRequest) (keys []string, cursor string) {
+		stream, err := cli.AllKeysChan(context.Background(), in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for {
+			resp, err := stream.Recv()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			keys = append(keys, resp.Key)
+			cursor = resp.Cursor
+		}
+	}
+
+	if keys, _ := list(&proto.AllKeysChanRequest{}); fmt.Sprint(keys) != fmt.Sprint(want) {
+		t.Fatalf("got %d keys, want %d keys in order", len(keys), len(want))
+	}
+	// resume a limited stream from its cursor
+	first, cursor := list(&proto.AllKeysChanRequest{Limit: listPageSize + 5})
+	rest, _ := list(&proto.AllKeysChanRequest{StartAfter: cursor})
+	if fmt.Sprint(append(first, rest...)) != fmt.Sprint(want) {
+		t.Fatalf("got %d+%d keys by resuming, want %d keys", len(first), len(rest), len(want))
+	}
+	if keys, _ := list(&proto.AllKeysChanRequest{Prefix: "key0001"}); fmt.Sprint(keys) != fmt.Sprint(want[10:20]) {
+		t.Fatalf("got %v by prefix", keys)
+	}
+}
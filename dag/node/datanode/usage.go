@@ -0,0 +1,175 @@
+package datanode
+
+import (
+	"context"
+	"github.com/filedag-project/filedag-storage/dag/proto"
+	"github.com/shirou/gopsutil/disk"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// usageCheckInterval is the interval to refresh the disk usage
+	usageCheckInterval = 10 * time.Second
+	// keyCountInterval is the interval to count the keys, which walks all the keys of the kvdb
+	keyCountInterval = 10 * time.Minute
+)
+
+// errReadOnly is returned for the writes when the data node is read-only
+var errReadOnly = status.Error(codes.ResourceExhausted, "the data node is read-only, the disk usage reaches the high-water mark")
+
+// diskUsage is the last disk usage of the data node
+type diskUsage struct {
+	sync.RWMutex
+	total, used, free uint64
+	keys              uint64
+	// disks is the usage of each data disk
+	disks []diskSpace
+	// readOnly is set when none of the disks is writable
+	readOnly int32
+}
+
+type diskSpace struct {
+	total, used, free uint64
+}
+
+// Stat returns the disk usage and the number of keys of the data node
+func (s *server) Stat(ctx context.Context, _ *emptypb.Empty) (*proto.StatResponse, error) {
+	s.usage.RLock()
+	defer s.usage.RUnlock()
+	resp := &proto.StatResponse{
+		Total:    s.usage.total,
+		Used:     s.usage.used,
+		Free:     s.usage.free,
+		Keys:     s.usage.keys,
+		ReadOnly: s.isReadOnly(),
+	}
+	if s.disks != nil {
+		for i, d := range s.disks.disks {
+			ds := &proto.DiskStat{
+				Path:     d.dir,
+				Online:   d.isOnline(),
+				ReadOnly: d.isReadOnly(),
+			}
+			if i < len(s.usage.disks) {
+				ds.Total = s.usage.disks[i].total
+				ds.Used = s.usage.disks[i].used
+				ds.Free = s.usage.disks[i].free
+			}
+			resp.Disks = append(resp.Disks, ds)
+		}
+	}
+	return resp, nil
+}
+
+func (s *server) isReadOnly() bool {
+	return atomic.LoadInt32(&s.usage.readOnly) == 1
+}
+
+// refreshUsage updates the disk usage, a disk turns read-only when its usage reaches the high-water mark,
+// and the data node turns read-only when none of the disks is writable
+func (s *server) refreshUsage() error {
+	if s.disks == nil {
+		return nil
+	}
+	spaces := make([]diskSpace, len(s.disks.disks))
+	var total, used, free uint64
+	writable := 0
+	for i, d := range s.disks.disks {
+		if !d.isOnline() {
+			continue
+		}
+		st, err := disk.Usage(d.dir)
+		if err != nil {
+			log.Errorw("get disk usage error", "dir", d.dir, "error", err)
+			continue
+		}
+		spaces[i] = diskSpace{total: st.Total, used: st.Used, free: st.Free}
+		total += st.Total
+		used += st.Used
+		free += st.Free
+
+		var readOnly int32
+		if s.highWaterMark > 0 && st.Total > 0 && float64(st.Used)/float64(st.Total) >= s.highWaterMark {
+			readOnly = 1
+		} else {
+			writable++
+		}
+		if atomic.SwapInt32(&d.readOnly, readOnly) != readOnly {
+			if readOnly == 1 {
+				log.Warnw("the disk usage reaches the high-water mark, turn read-only", "dir", d.dir, "used", st.Used, "total", st.Total)
+			} else {
+				log.Infow("the disk usage falls below the high-water mark, turn writable", "dir", d.dir, "used", st.Used, "total", st.Total)
+			}
+		}
+	}
+	s.usage.Lock()
+	s.usage.total = total
+	s.usage.used = used
+	s.usage.free = free
+	s.usage.disks = spaces
+	s.usage.Unlock()
+
+	var readOnly int32
+	if writable == 0 {
+		readOnly = 1
+	}
+	if atomic.SwapInt32(&s.usage.readOnly, readOnly) != readOnly {
+		if readOnly == 1 {
+			log.Warn("none of the disks is writable, the data node turns read-only")
+		} else {
+			log.Info("the data node turns writable")
+		}
+	}
+	return nil
+}
+
+// countKeys counts the keys of the kvdb
+func (s *server) countKeys(ctx context.Context) error {
+	ch, err := s.kvdb.AllKeysChan(ctx)
+	if err != nil {
+		return err
+	}
+	var keys uint64
+	for range ch {
+		keys++
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	s.usage.Lock()
+	s.usage.keys = keys
+	s.usage.Unlock()
+	return nil
+}
+
+// runUsageCheck refreshes the disk usage and the number of keys periodically until the context is done,
+// and brings the offline disks back online once they work again
+func (s *server) runUsageCheck(ctx context.Context) {
+	ticker := time.NewTicker(usageCheckInterval)
+	defer ticker.Stop()
+	var lastCount time.Time
+	for {
+		if s.disks != nil && len(s.disks.recheck()) > 0 {
+			s.updateHealth()
+		}
+		if err := s.refreshUsage(); err != nil {
+			log.Errorw("refresh disk usage error", "error", err)
+		}
+		if time.Since(lastCount) >= keyCountInterval {
+			if err := s.countKeys(ctx); err != nil && ctx.Err() == nil {
+				log.Errorw("count keys error", "error", err)
+			}
+			lastCount = time.Now()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
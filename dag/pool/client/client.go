@@ -40,9 +40,11 @@ func NewBlockService(blkstore blockstore.Blockstore) blockservice.BlockService {
 	return blockservice.NewWriteThrough(blkstore, offline.Exchange(blkstore))
 }
 
-//NewPoolClient new a dagPoolClient
-func NewPoolClient(addr, user, password string, enablePin bool) (*dagPoolClient, error) {
-	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+//NewPoolClient new a dagPoolClient, the connection is insecure unless opts override it.
+// The addr may list the addresses of the dag pool replicas separated by commas
+func NewPoolClient(addr, user, password string, enablePin bool, opts ...grpc.DialOption) (*dagPoolClient, error) {
+	opts = append([]grpc.DialOption{grpc.WithInsecure()}, opts...)
+	conn, err := dial(addr, opts...)
 	if err != nil {
 		log.Errorf("did not connect: %v", err)
 		return nil, err
@@ -18,9 +18,11 @@ type dagPoolClusterClient struct {
 	Conn            *grpc.ClientConn
 }
 
-// NewPoolClusterClient new a dagPoolClusterClient
-func NewPoolClusterClient(addr string) (*dagPoolClusterClient, error) {
-	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+// NewPoolClusterClient new a dagPoolClusterClient, the connection is insecure unless opts override it.
+// The addr may list the addresses of the dag pool replicas separated by commas
+func NewPoolClusterClient(addr string, opts ...grpc.DialOption) (*dagPoolClusterClient, error) {
+	opts = append([]grpc.DialOption{grpc.WithInsecure()}, opts...)
+	conn, err := dial(addr, opts...)
 	if err != nil {
 		log.Errorf("did not connect: %v", err)
 		return nil, err
@@ -86,6 +88,20 @@ func (cli *dagPoolClusterClient) MigrateSlots(ctx context.Context, fromDagNodeNa
 	return nil
 }
 
+func (cli *dagPoolClusterClient) RestripeDagNode(ctx context.Context, fromDagNodeName string, toConfig *config.DagNodeConfig) error {
+	_, err := cli.DPClusterClient.RestripeDagNode(ctx, &proto.RestripeDagNodeReq{
+		FromDagNodeName: fromDagNodeName,
+		ToDagNode:       utils.ToProtoDagNodeInfo(toConfig),
+	})
+	if err != nil {
+		if st, ok := status.FromError(err); ok && st.Code() == codes.Unknown {
+			return errors.New(st.Message())
+		}
+		return err
+	}
+	return nil
+}
+
 func (cli *dagPoolClusterClient) BalanceSlots(ctx context.Context) error {
 	_, err := cli.DPClusterClient.BalanceSlots(ctx, &emptypb.Empty{})
 	if err != nil {
@@ -97,6 +113,75 @@ func (cli *dagPoolClusterClient) BalanceSlots(ctx context.Context) error {
 	return nil
 }
 
+func (cli *dagPoolClusterClient) PauseRebalance(ctx context.Context) error {
+	_, err := cli.DPClusterClient.PauseRebalance(ctx, &emptypb.Empty{})
+	if err != nil {
+		if st, ok := status.FromError(err); ok && st.Code() == codes.Unknown {
+			return errors.New(st.Message())
+		}
+		return err
+	}
+	return nil
+}
+
+func (cli *dagPoolClusterClient) ResumeRebalance(ctx context.Context) error {
+	_, err := cli.DPClusterClient.ResumeRebalance(ctx, &emptypb.Empty{})
+	if err != nil {
+		if st, ok := status.FromError(err); ok && st.Code() == codes.Unknown {
+			return errors.New(st.Message())
+		}
+		return err
+	}
+	return nil
+}
+
+func (cli *dagPoolClusterClient) SetMigrationRate(ctx context.Context, bytesPerSecond, keysPerSecond uint64) error {
+	_, err := cli.DPClusterClient.SetMigrationRate(ctx, &proto.MigrationRateReq{
+		BytesPerSecond: bytesPerSecond,
+		KeysPerSecond:  keysPerSecond,
+	})
+	if err != nil {
+		if st, ok := status.FromError(err); ok && st.Code() == codes.Unknown {
+			return errors.New(st.Message())
+		}
+		return err
+	}
+	return nil
+}
+
+func (cli *dagPoolClusterClient) PauseMigration(ctx context.Context) error {
+	_, err := cli.DPClusterClient.PauseMigration(ctx, &emptypb.Empty{})
+	if err != nil {
+		if st, ok := status.FromError(err); ok && st.Code() == codes.Unknown {
+			return errors.New(st.Message())
+		}
+		return err
+	}
+	return nil
+}
+
+func (cli *dagPoolClusterClient) ResumeMigration(ctx context.Context) error {
+	_, err := cli.DPClusterClient.ResumeMigration(ctx, &emptypb.Empty{})
+	if err != nil {
+		if st, ok := status.FromError(err); ok && st.Code() == codes.Unknown {
+			return errors.New(st.Message())
+		}
+		return err
+	}
+	return nil
+}
+
+func (cli *dagPoolClusterClient) AbortMigration(ctx context.Context) error {
+	_, err := cli.DPClusterClient.AbortMigration(ctx, &emptypb.Empty{})
+	if err != nil {
+		if st, ok := status.FromError(err); ok && st.Code() == codes.Unknown {
+			return errors.New(st.Message())
+		}
+		return err
+	}
+	return nil
+}
+
 func (cli *dagPoolClusterClient) Status(ctx context.Context) (*proto.StatusReply, error) {
 	reply, err := cli.DPClusterClient.Status(ctx, &emptypb.Empty{})
 	if err != nil {
@@ -108,11 +193,27 @@ func (cli *dagPoolClusterClient) Status(ctx context.Context) (*proto.StatusReply
 	return reply, nil
 }
 
-func (cli *dagPoolClusterClient) RepairDataNode(ctx context.Context, dagNodeName string, fromIndex, repairIndex int) error {
+func (cli *dagPoolClusterClient) RepairDataNode(ctx context.Context, dagNodeName string, fromIndex, repairIndex int, startAfter string) error {
 	_, err := cli.DPClusterClient.RepairDataNode(ctx, &proto.RepairDataNodeReq{
 		DagNodeName:     dagNodeName,
 		FromNodeIndex:   int32(fromIndex),
 		RepairNodeIndex: int32(repairIndex),
+		StartAfter:      startAfter,
+	})
+	if err != nil {
+		if st, ok := status.FromError(err); ok && st.Code() == codes.Unknown {
+			return errors.New(st.Message())
+		}
+		return err
+	}
+	return nil
+}
+
+func (cli *dagPoolClusterClient) ReplaceDataNode(ctx context.Context, dagNodeName string, index int, rpcAddress string) error {
+	_, err := cli.DPClusterClient.ReplaceDataNode(ctx, &proto.ReplaceDataNodeReq{
+		DagNodeName: dagNodeName,
+		NodeIndex:   int32(index),
+		RpcAddress:  rpcAddress,
 	})
 	if err != nil {
 		if st, ok := status.FromError(err); ok && st.Code() == codes.Unknown {
@@ -122,3 +223,31 @@ func (cli *dagPoolClusterClient) RepairDataNode(ctx context.Context, dagNodeName
 	}
 	return nil
 }
+
+func (cli *dagPoolClusterClient) ListRepairTasks(ctx context.Context, dagNodeName string, limit int) (*proto.ListRepairTasksReply, error) {
+	reply, err := cli.DPClusterClient.ListRepairTasks(ctx, &proto.ListRepairTasksReq{
+		DagNodeName: dagNodeName,
+		Limit:       int32(limit),
+	})
+	if err != nil {
+		if st, ok := status.FromError(err); ok && st.Code() == codes.Unknown {
+			return nil, errors.New(st.Message())
+		}
+		return nil, err
+	}
+	return reply, nil
+}
+
+func (cli *dagPoolClusterClient) DrainRepairTasks(ctx context.Context, dagNodeName string, discard bool) (*proto.DrainRepairTasksReply, error) {
+	reply, err := cli.DPClusterClient.DrainRepairTasks(ctx, &proto.DrainRepairTasksReq{
+		DagNodeName: dagNodeName,
+		Discard:     discard,
+	})
+	if err != nil {
+		if st, ok := status.FromError(err); ok && st.Code() == codes.Unknown {
+			return nil, errors.New(st.Message())
+		}
+		return nil, err
+	}
+	return reply, nil
+}
@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/filedag-project/filedag-storage/dag/pool/ha"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	// failoverTimeout is how long a request waits for a new leader of the dag pool replicas
+	failoverTimeout = 30 * time.Second
+	// failoverInterval is the interval between the retries of a request
+	failoverInterval = 200 * time.Millisecond
+)
+
+var resolverSeq uint64
+
+// repeatSafeMethods are the methods sent again if the connection is lost during the request,
+// they read the state or set it to the same value, so applying them twice does no harm
+var repeatSafeMethods = map[string]bool{
+	"/proto.DagPool/Get":                     true,
+	"/proto.DagPool/GetSize":                 true,
+	"/proto.DagPool/QueryUser":               true,
+	"/proto.DagPoolCluster/GetDagNode":       true,
+	"/proto.DagPoolCluster/Status":           true,
+	"/proto.DagPoolCluster/ListRepairTasks":  true,
+	"/proto.DagPoolCluster/PauseRebalance":   true,
+	"/proto.DagPoolCluster/ResumeRebalance":  true,
+	"/proto.DagPoolCluster/SetMigrationRate": true,
+	"/proto.DagPoolCluster/PauseMigration":   true,
+	"/proto.DagPoolCluster/ResumeMigration":  true,
+}
+
+// dial connects the dag pool. The addr may list the addresses of the dag pool replicas separated by commas,
+// only the leader serves the clients, so the requests go to whichever replica accepts the connection.
+func dial(addr string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	var addrs []resolver.Address
+	for _, a := range strings.Split(addr, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			addrs = append(addrs, resolver.Address{Addr: a})
+		}
+	}
+	if len(addrs) <= 1 {
+		return grpc.Dial(addr, opts...)
+	}
+	r := manual.NewBuilderWithScheme(fmt.Sprintf("dagpool%d", atomic.AddUint64(&resolverSeq, 1)))
+	r.InitialState(resolver.State{Addresses: addrs})
+	bc := backoff.DefaultConfig
+	bc.MaxDelay = time.Second
+	opts = append(opts,
+		grpc.WithResolvers(r),
+		grpc.WithDefaultServiceConfig(`{"loadBalancingPolicy":"round_robin"}`),
+		grpc.WithConnectParams(grpc.ConnectParams{Backoff: bc, MinConnectTimeout: time.Second}),
+		grpc.WithChainUnaryInterceptor(failoverInterceptor),
+	)
+	return grpc.Dial(r.Scheme()+":///dagpool", opts...)
+}
+
+// failoverInterceptor retries the requests which are not served because there is no leader, while the replicas elect one.
+// The other requests wait for the connection to the leader instead, since they may have been applied
+// if the connection is lost during the request
+func failoverInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	deadline := time.Now().Add(failoverTimeout)
+	for {
+		if !repeatSafeMethods[method] {
+			waitReady(ctx, cc, deadline)
+		}
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if err == nil || !retryable(method, err) || time.Now().After(deadline) {
+			return err
+		}
+		log.Debugf("dag pool leader is unavailable, retry %s: %v", method, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(failoverInterval):
+		}
+	}
+}
+
+// waitReady waits until a replica accepts the connection, only the leader does
+func waitReady(ctx context.Context, cc *grpc.ClientConn, deadline time.Time) {
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+	for state := cc.GetState(); state != connectivity.Ready; state = cc.GetState() {
+		if state == connectivity.Idle {
+			cc.Connect()
+		}
+		if !cc.WaitForStateChange(ctx, state) {
+			return
+		}
+	}
+}
+
+// retryable reports whether the request failed because no replica was serving as the leader. The replica which is
+// not the leader applies nothing, while the lost connection may have applied the request, so the request is sent
+// again only if it is safe to repeat
+func retryable(method string, err error) bool {
+	st, ok := status.FromError(err)
+	if !ok {
+		return false
+	}
+	switch st.Code() {
+	case codes.Unavailable:
+		return repeatSafeMethods[method]
+	case codes.Unknown:
+		return strings.Contains(st.Message(), ha.ErrNotLeader.Error())
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package client
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/filedag-project/filedag-storage/dag/pool/ha"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRetryable(t *testing.T) {
+	testCases := []struct {
+		name   string
+		method string
+		err    error
+		expect bool
+	}{
+		{"read on unavailable", "/proto.DagPool/Get", status.Error(codes.Unavailable, "connection closed"), true},
+		{"add on unavailable", "/proto.DagPool/Add", status.Error(codes.Unavailable, "connection closed"), false},
+		{"remove on unavailable", "/proto.DagPool/Remove", status.Error(codes.Unavailable, "connection closed"), false},
+		{"add on not leader", "/proto.DagPool/Add", status.Error(codes.Unknown, ha.ErrNotLeader.Error()), true},
+		{"add on leadership lost", "/proto.DagPool/Add", status.Error(codes.Unknown, ha.ErrLeadershipLost.Error()), false},
+		{"read on other error", "/proto.DagPool/Get", status.Error(codes.NotFound, "not found"), false},
+		{"not a status", "/proto.DagPool/Get", errors.New("error"), false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := retryable(tc.method, tc.err); got != tc.expect {
+				t.Fatalf("expected %v, got %v", tc.expect, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package ha
+
+import (
+	"github.com/filedag-project/filedag-storage/objectservice/objmetadb"
+)
+
+// replicatedDB writes the metadata through the replicated log, and reads the local db which holds the applied writes
+type replicatedDB struct {
+	objmetadb.ObjStoreMetaDBAPI
+	n *Node
+}
+
+func (r *replicatedDB) Put(key string, value interface{}) error {
+	var batch objmetadb.Batch
+	if err := batch.Put(key, value); err != nil {
+		return err
+	}
+	return r.n.apply(&batch)
+}
+
+func (r *replicatedDB) Delete(key string) error {
+	var batch objmetadb.Batch
+	batch.Delete(key)
+	return r.n.apply(&batch)
+}
+
+// Write replicates the batch as one log entry, so the writes of the batch are applied together
+func (r *replicatedDB) Write(batch *objmetadb.Batch) error {
+	if batch.Len() == 0 {
+		return nil
+	}
+	return r.n.apply(batch)
+}
+
+// Close leaves the local db open, it is closed with the node
+func (r *replicatedDB) Close() error {
+	return nil
+}
@@ -0,0 +1,127 @@
+package ha
+
+import (
+	"bufio"
+	"io"
+
+	"github.com/filedag-project/filedag-storage/objectservice/objmetadb"
+	"github.com/hashicorp/raft"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// restoreBatchSize is the number of bytes written to the metadata at once while restoring a snapshot
+const restoreBatchSize = 4 << 20
+
+// fsm applies the replicated batches to the local metadata, the raft keys are left to the raft
+type fsm struct {
+	local objmetadb.ObjStoreMetaDBAPI
+	db    *leveldb.DB
+}
+
+// Apply writes a committed batch, the error of the write is the response of the batch
+func (f *fsm) Apply(l *raft.Log) interface{} {
+	var batch objmetadb.Batch
+	if err := msgpack.Unmarshal(l.Data, &batch); err != nil {
+		log.Errorf("decode the batch of log %d error: %v", l.Index, err)
+		return err
+	}
+	if err := f.local.Write(&batch); err != nil {
+		log.Errorf("apply the batch of log %d error: %v", l.Index, err)
+		return err
+	}
+	return nil
+}
+
+// Snapshot takes a leveldb snapshot, it is written out by Persist while the new batches are applied
+func (f *fsm) Snapshot() (raft.FSMSnapshot, error) {
+	snap, err := f.db.GetSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &fsmSnapshot{snap: snap}, nil
+}
+
+// Restore replaces the metadata with the key values of the snapshot
+func (f *fsm) Restore(rc io.ReadCloser) error {
+	defer rc.Close()
+	iter := f.db.NewIterator(nil, nil)
+	batch := new(leveldb.Batch)
+	for iter.Next() {
+		if isRaftKey(iter.Key()) {
+			continue
+		}
+		batch.Delete(iter.Key())
+		if len(batch.Dump()) >= restoreBatchSize {
+			if err := f.db.Write(batch, nil); err != nil {
+				iter.Release()
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return err
+	}
+
+	dec := msgpack.NewDecoder(bufio.NewReader(rc))
+	for {
+		var key, value []byte
+		if err := dec.Decode(&key); err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+		batch.Put(key, value)
+		if len(batch.Dump()) >= restoreBatchSize {
+			if err := f.db.Write(batch, nil); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	return f.db.Write(batch, syncWrite)
+}
+
+type fsmSnapshot struct {
+	snap *leveldb.Snapshot
+}
+
+// Persist writes the key values of the metadata as pairs of msgpack byte strings
+func (s *fsmSnapshot) Persist(sink raft.SnapshotSink) error {
+	if err := s.write(sink); err != nil {
+		sink.Cancel()
+		return err
+	}
+	return sink.Close()
+}
+
+func (s *fsmSnapshot) write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	enc := msgpack.NewEncoder(bw)
+	iter := s.snap.NewIterator(nil, nil)
+	defer iter.Release()
+	for iter.Next() {
+		if isRaftKey(iter.Key()) {
+			continue
+		}
+		if err := enc.Encode(iter.Key()); err != nil {
+			return err
+		}
+		if err := enc.Encode(iter.Value()); err != nil {
+			return err
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func (s *fsmSnapshot) Release() {
+	s.snap.Release()
+}
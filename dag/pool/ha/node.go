@@ -0,0 +1,288 @@
+package ha
+
+import (
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	transport "github.com/Jille/raft-grpc-transport"
+	"github.com/filedag-project/filedag-storage/dag/config"
+	"github.com/filedag-project/filedag-storage/objectservice/objmetadb"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/vmihailenco/msgpack/v5"
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+var log = logging.Logger("dag-pool-ha")
+
+var (
+	ErrNotLeader = xerrors.New("this dag pool replica is not the leader")
+	// ErrLeadershipLost is returned if the leadership is lost before a write is applied,
+	// the write may or may not be applied by the next leader
+	ErrLeadershipLost = xerrors.New("the dag pool replica lost the leadership before the write was applied")
+	ErrNodeClosed     = xerrors.New("the dag pool replica is closed")
+)
+
+const (
+	defaultElectionTimeout = time.Second
+	// applyTimeout is how long a write waits to be queued for the replication
+	applyTimeout = 10 * time.Second
+	// logCacheSize is the number of the latest log entries cached in memory
+	logCacheSize = 512
+	// retainSnapshots is the number of the snapshots kept on disk
+	retainSnapshots = 2
+)
+
+var (
+	// snapshotThreshold is the number of the new log entries which makes a snapshot taken
+	snapshotThreshold uint64 = 8192
+	// trailingLogs is the number of the log entries kept after a snapshot for the replicas falling behind
+	trailingLogs uint64 = 10240
+)
+
+// Node is a replica of the dag pool metadata. The writes are batched into the entries of a raft log replicated
+// to the other replicas, a batch is applied to the metadata at once after the majority of the replicas have it.
+type Node struct {
+	sync.Mutex
+
+	id      string
+	conf    *raft.Config
+	servers []raft.Server
+	local   objmetadb.ObjStoreMetaDBAPI
+	db      *leveldb.DB
+	store   *levelStore
+	snaps   raft.SnapshotStore
+	manager *transport.Manager
+	raft    *raft.Raft
+
+	// ready is set once the leader applied the entries of the former terms, epoch counts the leadership changes
+	ready bool
+	epoch uint64
+
+	notifyCh  chan struct{}
+	closeCh   chan struct{}
+	closeOnce sync.Once
+}
+
+// NewNode opens the metadata db of the replica, the other replicas are connected with the dial options.
+// The raft snapshots are kept in the directory next to the db
+func NewNode(cfg config.HAConfig, dbPath string, opts ...grpc.DialOption) (*Node, error) {
+	addr, ok := cfg.Peers[cfg.ID]
+	if !ok {
+		return nil, xerrors.Errorf("replica %s is not one of the peers", cfg.ID)
+	}
+	electionTimeout := cfg.ElectionTimeout
+	if electionTimeout <= 0 {
+		electionTimeout = defaultElectionTimeout
+	}
+	conf := raft.DefaultConfig()
+	conf.LocalID = raft.ServerID(cfg.ID)
+	conf.HeartbeatTimeout = electionTimeout
+	conf.ElectionTimeout = electionTimeout
+	conf.LeaderLeaseTimeout = electionTimeout / 2
+	conf.SnapshotThreshold = snapshotThreshold
+	conf.TrailingLogs = trailingLogs
+	conf.Logger = hclog.New(&hclog.LoggerOptions{Name: "dag-pool-raft", Level: hclog.Info})
+
+	n := &Node{
+		id:       cfg.ID,
+		conf:     conf,
+		notifyCh: make(chan struct{}, 1),
+		closeCh:  make(chan struct{}),
+	}
+	for id, peer := range cfg.Peers {
+		n.servers = append(n.servers, raft.Server{ID: raft.ServerID(id), Address: raft.ServerAddress(peer)})
+	}
+	sort.Slice(n.servers, func(i, j int) bool { return n.servers[i].ID < n.servers[j].ID })
+
+	local, err := objmetadb.OpenDb(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	n.local, n.db = local, local.DB
+	n.store = &levelStore{db: n.db}
+	if n.snaps, err = raft.NewFileSnapshotStoreWithLogger(filepath.Clean(dbPath)+"-raft", retainSnapshots, conf.Logger); err != nil {
+		local.Close()
+		return nil, err
+	}
+	if len(opts) == 0 {
+		opts = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	}
+	// a replica coming back is reconnected within an election timeout
+	bc := backoff.DefaultConfig
+	bc.BaseDelay, bc.MaxDelay = electionTimeout/10, electionTimeout
+	opts = append(opts, grpc.WithConnectParams(grpc.ConnectParams{Backoff: bc, MinConnectTimeout: electionTimeout}))
+	n.manager = transport.New(raft.ServerAddress(addr), opts)
+	return n, nil
+}
+
+// Register serves the raft requests of the other replicas on the grpc server
+func (n *Node) Register(s *grpc.Server) {
+	n.manager.Register(s)
+}
+
+// Start takes part in the elections, the cluster of all the peers is bootstrapped on the first start
+func (n *Node) Start() error {
+	logs, err := raft.NewLogCache(logCacheSize, n.store)
+	if err != nil {
+		return err
+	}
+	trans := n.manager.Transport()
+	existing, err := raft.HasExistingState(logs, n.store, n.snaps)
+	if err != nil {
+		return err
+	}
+	if !existing {
+		if err = raft.BootstrapCluster(n.conf, logs, n.store, n.snaps, trans,
+			raft.Configuration{Servers: n.servers}); err != nil {
+			return err
+		}
+	}
+	r, err := raft.NewRaft(n.conf, &fsm{local: n.local, db: n.db}, logs, n.store, n.snaps, trans)
+	if err != nil {
+		return err
+	}
+	n.Lock()
+	n.raft = r
+	n.Unlock()
+	go n.watchLeadership(r)
+	return nil
+}
+
+// watchLeadership marks the leader ready once it applied all the entries of the former terms
+func (n *Node) watchLeadership(r *raft.Raft) {
+	for {
+		select {
+		case <-n.closeCh:
+			return
+		case isLeader := <-r.LeaderCh():
+			n.Lock()
+			n.ready = false
+			n.epoch++
+			epoch := n.epoch
+			n.Unlock()
+			n.notify()
+			if isLeader {
+				go n.prepareLeader(r, epoch)
+			} else {
+				log.Infof("replica %s is no longer the leader", n.id)
+			}
+		}
+	}
+}
+
+func (n *Node) prepareLeader(r *raft.Raft, epoch uint64) {
+	if err := r.Barrier(0).Error(); err != nil {
+		log.Warnf("replica %s failed to apply the former entries as the leader: %v", n.id, err)
+		return
+	}
+	n.Lock()
+	defer n.Unlock()
+	if n.epoch != epoch || r.State() != raft.Leader {
+		return
+	}
+	n.ready = true
+	n.notify()
+	log.Infof("replica %s is ready to serve as the leader", n.id)
+}
+
+// Notify is signaled when the replica becomes the leader or stops being the leader, check IsLeader for the current role
+func (n *Node) Notify() <-chan struct{} {
+	return n.notifyCh
+}
+
+// IsLeader reports whether the replica is the leader and holds all the committed writes, so it can serve the clients
+func (n *Node) IsLeader() bool {
+	n.Lock()
+	defer n.Unlock()
+	return n.raft != nil && n.ready && n.raft.State() == raft.Leader
+}
+
+// Leader returns the id of the known leader
+func (n *Node) Leader() string {
+	n.Lock()
+	r := n.raft
+	n.Unlock()
+	if r == nil {
+		return ""
+	}
+	// the grpc transport only carries the address of the leader
+	addr, id := r.LeaderWithID()
+	if id != "" {
+		return string(id)
+	}
+	for _, s := range n.servers {
+		if s.Address == addr {
+			return string(s.ID)
+		}
+	}
+	return ""
+}
+
+// DB returns the metadata db whose writes are replicated, only the leader can write it
+func (n *Node) DB() objmetadb.ObjStoreMetaDBAPI {
+	return &replicatedDB{ObjStoreMetaDBAPI: n.local, n: n}
+}
+
+// Close stops the replica and closes the metadata db
+func (n *Node) Close() {
+	n.closeOnce.Do(func() {
+		close(n.closeCh)
+		n.Lock()
+		r := n.raft
+		n.ready = false
+		n.Unlock()
+		if r != nil {
+			if err := r.Shutdown().Error(); err != nil {
+				log.Errorf("shutdown raft error: %v", err)
+			}
+		}
+		n.local.Close()
+	})
+}
+
+func (n *Node) notify() {
+	select {
+	case n.notifyCh <- struct{}{}:
+	default:
+	}
+}
+
+// apply replicates the batch as a single log entry, and waits until it is applied
+func (n *Node) apply(batch *objmetadb.Batch) error {
+	select {
+	case <-n.closeCh:
+		return ErrNodeClosed
+	default:
+	}
+	if !n.IsLeader() {
+		return ErrNotLeader
+	}
+	data, err := msgpack.Marshal(batch)
+	if err != nil {
+		return err
+	}
+	f := n.raft.Apply(data, applyTimeout)
+	switch err = f.Error(); err {
+	case nil:
+	case raft.ErrNotLeader:
+		return ErrNotLeader
+	case raft.ErrLeadershipLost:
+		return ErrLeadershipLost
+	case raft.ErrRaftShutdown:
+		return ErrNodeClosed
+	default:
+		return err
+	}
+	if err, ok := f.Response().(error); ok {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package ha
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/filedag-project/filedag-storage/dag/config"
+	"github.com/filedag-project/filedag-storage/objectservice/objmetadb"
+	"google.golang.org/grpc"
+)
+
+type testReplica struct {
+	cfg  config.HAConfig
+	path string
+	node *Node
+	s    *grpc.Server
+}
+
+func (r *testReplica) start(t *testing.T) {
+	lis, err := net.Listen("tcp", r.cfg.Listen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.node, err = NewNode(r.cfg, r.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.s = grpc.NewServer()
+	r.node.Register(r.s)
+	go r.s.Serve(lis)
+	if err = r.node.Start(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (r *testReplica) stop() {
+	r.s.Stop()
+	r.node.Close()
+}
+
+func startReplicas(t *testing.T, n int) []*testReplica {
+	dir := t.TempDir()
+	peers := make(map[string]string)
+	for i := 0; i < n; i++ {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		peers[fmt.Sprintf("r%d", i)] = lis.Addr().String()
+		lis.Close()
+	}
+	var replicas []*testReplica
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("r%d", i)
+		r := &testReplica{
+			cfg: config.HAConfig{
+				ID:              id,
+				Listen:          peers[id],
+				Peers:           peers,
+				ElectionTimeout: 300 * time.Millisecond,
+			},
+			path: filepath.Join(dir, id),
+		}
+		r.start(t)
+		replicas = append(replicas, r)
+	}
+	t.Cleanup(func() {
+		for _, r := range replicas {
+			r.stop()
+		}
+	})
+	return replicas
+}
+
+func waitLeader(t *testing.T, replicas []*testReplica) *testReplica {
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, r := range replicas {
+			if r.node.IsLeader() {
+				return r
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("no leader elected")
+	return nil
+}
+
+func waitValue(t *testing.T, r *testReplica, key string, want int) {
+	deadline := time.Now().Add(10 * time.Second)
+	var v int
+	for time.Now().Before(deadline) {
+		if err := r.node.DB().Get(key, &v); err == nil && v == want {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("replica %s: %s is %d, want %d", r.cfg.ID, key, v, want)
+}
+
+func TestReplication(t *testing.T) {
+	replicas := startReplicas(t, 3)
+	l := waitLeader(t, replicas)
+	if err := l.node.DB().Put("ref/a", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.node.DB().Put("ref/b", 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.node.DB().Delete("ref/b"); err != nil {
+		t.Fatal(err)
+	}
+	// the writes of a batch are replicated as one entry
+	batch := new(objmetadb.Batch)
+	if err := batch.Put("ref/d", 4); err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.Put("ref/e", 5); err != nil {
+		t.Fatal(err)
+	}
+	batch.Delete("ref/a")
+	if err := batch.Put("ref/a", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.node.DB().Write(batch); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range replicas {
+		waitValue(t, r, "ref/a", 1)
+		waitValue(t, r, "ref/d", 4)
+		waitValue(t, r, "ref/e", 5)
+		if r != l {
+			if err := r.node.DB().Put("ref/c", 3); err != ErrNotLeader {
+				t.Fatalf("follower write should be rejected: %v", err)
+			}
+			if r.node.Leader() != l.cfg.ID {
+				t.Fatalf("replica %s follows %s, want %s", r.cfg.ID, r.node.Leader(), l.cfg.ID)
+			}
+		}
+	}
+	// the writes are applied in order, so the deletion is applied before the last put
+	var v int
+	for _, r := range replicas {
+		if err := r.node.DB().Get("ref/b", &v); err == nil {
+			t.Fatalf("replica %s should not have the deleted key", r.cfg.ID)
+		}
+	}
+}
+
+func TestFailover(t *testing.T) {
+	replicas := startReplicas(t, 3)
+	l := waitLeader(t, replicas)
+	if err := l.node.DB().Put("ref/a", 1); err != nil {
+		t.Fatal(err)
+	}
+	l.stop()
+	var rest []*testReplica
+	for _, r := range replicas {
+		if r != l {
+			rest = append(rest, r)
+		}
+	}
+	nl := waitLeader(t, rest)
+	// the committed writes survive the failover
+	waitValue(t, nl, "ref/a", 1)
+	if err := nl.node.DB().Put("ref/b", 2); err != nil {
+		t.Fatal(err)
+	}
+
+	// the former leader rejoins as a follower and catches up
+	l.start(t)
+	waitValue(t, l, "ref/b", 2)
+	if l.node.IsLeader() {
+		t.Fatal("the former leader should follow the new one")
+	}
+}
+
+func TestSnapshotCatchUp(t *testing.T) {
+	oldThreshold, oldTrailing := snapshotThreshold, trailingLogs
+	snapshotThreshold, trailingLogs = 20, 5
+	// restored after the replicas are stopped
+	t.Cleanup(func() {
+		snapshotThreshold, trailingLogs = oldThreshold, oldTrailing
+	})
+
+	replicas := startReplicas(t, 3)
+	l := waitLeader(t, replicas)
+	var lagging *testReplica
+	for _, r := range replicas {
+		if r != l {
+			lagging = r
+			break
+		}
+	}
+	lagging.stop()
+	for i := 0; i < 100; i++ {
+		if err := l.node.DB().Put(fmt.Sprintf("ref/%d", i), i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.node.raft.Snapshot().Error(); err != nil {
+		t.Fatal(err)
+	}
+	if first, err := l.node.store.FirstIndex(); err != nil || first <= 1 {
+		t.Fatalf("the log should be compacted, the first index is %d, err: %v", first, err)
+	}
+
+	// the entries missing on the lagging replica are compacted, so it installs a snapshot
+	lagging.start(t)
+	for i := 0; i < 100; i++ {
+		waitValue(t, lagging, fmt.Sprintf("ref/%d", i), i)
+	}
+	if err := l.node.DB().Put("ref/last", 1); err != nil {
+		t.Fatal(err)
+	}
+	waitValue(t, lagging, "ref/last", 1)
+}
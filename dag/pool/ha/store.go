@@ -0,0 +1,143 @@
+package ha
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/hashicorp/raft"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// the raft log and the raft state are kept beside the metadata in the same leveldb, under their own prefix
+const (
+	raftPrefix       = "raft/"
+	raftLogPrefix    = raftPrefix + "log/"
+	raftStablePrefix = raftPrefix + "stable/"
+)
+
+var syncWrite = &opt.WriteOptions{Sync: true}
+
+// levelStore is the raft.LogStore and raft.StableStore of a replica
+type levelStore struct {
+	db *leveldb.DB
+}
+
+var (
+	_ raft.LogStore    = (*levelStore)(nil)
+	_ raft.StableStore = (*levelStore)(nil)
+)
+
+func logKey(index uint64) []byte {
+	key := make([]byte, len(raftLogPrefix)+8)
+	copy(key, raftLogPrefix)
+	binary.BigEndian.PutUint64(key[len(raftLogPrefix):], index)
+	return key
+}
+
+func logIndex(key []byte) uint64 {
+	return binary.BigEndian.Uint64(key[len(raftLogPrefix):])
+}
+
+func isRaftKey(key []byte) bool {
+	return bytes.HasPrefix(key, []byte(raftPrefix))
+}
+
+// FirstIndex returns the first index of the log, 0 if the log is empty
+func (s *levelStore) FirstIndex() (uint64, error) {
+	iter := s.db.NewIterator(util.BytesPrefix([]byte(raftLogPrefix)), nil)
+	defer iter.Release()
+	if !iter.First() {
+		return 0, iter.Error()
+	}
+	return logIndex(iter.Key()), nil
+}
+
+// LastIndex returns the last index of the log, 0 if the log is empty
+func (s *levelStore) LastIndex() (uint64, error) {
+	iter := s.db.NewIterator(util.BytesPrefix([]byte(raftLogPrefix)), nil)
+	defer iter.Release()
+	if !iter.Last() {
+		return 0, iter.Error()
+	}
+	return logIndex(iter.Key()), nil
+}
+
+// GetLog gets the log entry of the index
+func (s *levelStore) GetLog(index uint64, log *raft.Log) error {
+	data, err := s.db.Get(logKey(index), nil)
+	if err == leveldb.ErrNotFound {
+		return raft.ErrLogNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return msgpack.Unmarshal(data, log)
+}
+
+// StoreLog stores a log entry
+func (s *levelStore) StoreLog(log *raft.Log) error {
+	return s.StoreLogs([]*raft.Log{log})
+}
+
+// StoreLogs stores the log entries at once
+func (s *levelStore) StoreLogs(logs []*raft.Log) error {
+	batch := new(leveldb.Batch)
+	for _, l := range logs {
+		data, err := msgpack.Marshal(l)
+		if err != nil {
+			return err
+		}
+		batch.Put(logKey(l.Index), data)
+	}
+	return s.db.Write(batch, syncWrite)
+}
+
+// DeleteRange deletes the log entries from min to max, both inclusive
+func (s *levelStore) DeleteRange(min, max uint64) error {
+	iter := s.db.NewIterator(&util.Range{Start: logKey(min), Limit: util.BytesPrefix([]byte(raftLogPrefix)).Limit}, nil)
+	defer iter.Release()
+	batch := new(leveldb.Batch)
+	for iter.Next() {
+		if logIndex(iter.Key()) > max {
+			break
+		}
+		batch.Delete(iter.Key())
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	return s.db.Write(batch, syncWrite)
+}
+
+// Set saves a raft state
+func (s *levelStore) Set(key []byte, val []byte) error {
+	return s.db.Put(append([]byte(raftStablePrefix), key...), val, syncWrite)
+}
+
+// Get returns a raft state, empty if it is not saved
+func (s *levelStore) Get(key []byte) ([]byte, error) {
+	val, err := s.db.Get(append([]byte(raftStablePrefix), key...), nil)
+	if err == leveldb.ErrNotFound {
+		return nil, nil
+	}
+	return val, err
+}
+
+// SetUint64 saves a raft state of uint64
+func (s *levelStore) SetUint64(key []byte, val uint64) error {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], val)
+	return s.Set(key, buf[:])
+}
+
+// GetUint64 returns a raft state of uint64, 0 if it is not saved
+func (s *levelStore) GetUint64(key []byte) (uint64, error) {
+	val, err := s.Get(key)
+	if err != nil || len(val) != 8 {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(val), nil
+}
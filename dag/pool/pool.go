@@ -33,7 +33,17 @@ type Cluster interface {
 	GetDagNode(dagNodeName string) (*config.DagNodeConfig, error)
 	RemoveDagNode(dagNodeName string) (*config.DagNodeConfig, error)
 	MigrateSlots(fromDagNodeName, toDagNodeName string, pairs []slotsmgr.SlotPair) error
+	RestripeDagNode(fromDagNodeName string, toConfig *config.DagNodeConfig) error
 	BalanceSlots() error
+	PauseRebalance() error
+	ResumeRebalance() error
+	SetMigrationRate(bytesPerSecond, keysPerSecond uint64) error
+	PauseMigration() error
+	ResumeMigration() error
+	AbortMigration() error
 	Status() (*proto.StatusReply, error)
-	RepairDataNode(ctx context.Context, dagNodeName string, fromNodeIndex int, repairNodeIndex int) error
+	RepairDataNode(ctx context.Context, dagNodeName string, fromNodeIndex int, repairNodeIndex int, startAfter string) error
+	ReplaceDataNode(ctx context.Context, dagNodeName string, index int, rpcAddress string) error
+	ListRepairTasks(ctx context.Context, dagNodeName string, limit int) (*proto.ListRepairTasksReply, error)
+	DrainRepairTasks(ctx context.Context, dagNodeName string, discard bool) (*proto.DrainRepairTasksReply, error)
 }
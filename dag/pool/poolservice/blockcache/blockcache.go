@@ -0,0 +1,138 @@
+package blockcache
+
+import (
+	"sync/atomic"
+
+	"github.com/bluele/gcache"
+	"github.com/filedag-project/filedag-storage/dag/config"
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+)
+
+var log = logging.Logger("block-cache")
+
+const (
+	PolicyLRU = "lru"
+	PolicyARC = "arc"
+)
+
+// Stats is the hit and miss counts of the block cache
+type Stats struct {
+	// MemoryHits is the number of blocks served by the memory cache
+	MemoryHits uint64
+	// DiskHits is the number of blocks served by the disk cache
+	DiskHits uint64
+	// Misses is the number of blocks read from the dag nodes
+	Misses uint64
+	// MemoryBlocks is the number of blocks in the memory cache
+	MemoryBlocks int
+	// DiskBlocks and DiskBytes are the number and the total size of the blocks in the disk cache
+	DiskBlocks int
+	DiskBytes  int64
+}
+
+// BlockCache caches the blocks read from the dag nodes in memory, and optionally on a local disk.
+// The blocks are addressed by their content, so a cached block never goes stale, it only has to be removed
+// once the block is deleted from the dag pool.
+type BlockCache struct {
+	maxBlockSize int
+	mem          gcache.Cache
+	disk         *diskCache
+
+	memoryHits uint64
+	diskHits   uint64
+	misses     uint64
+}
+
+// New returns the block cache of the config, the caches disabled by the config are skipped
+func New(cfg config.BlockCacheConfig) (*BlockCache, error) {
+	bc := &BlockCache{maxBlockSize: cfg.MaxBlockSize}
+	if cfg.MemoryBlocks > 0 {
+		builder := gcache.New(cfg.MemoryBlocks)
+		switch cfg.Policy {
+		case "", PolicyLRU:
+			builder = builder.LRU()
+		case PolicyARC:
+			builder = builder.ARC()
+		default:
+			return nil, xerrors.Errorf("unknown block cache policy %s", cfg.Policy)
+		}
+		bc.mem = builder.Build()
+	}
+	if cfg.DiskPath != "" {
+		if cfg.DiskBytes <= 0 {
+			return nil, xerrors.New("the size of the disk block cache must be positive")
+		}
+		disk, err := openDiskCache(cfg.DiskPath, cfg.DiskBytes)
+		if err != nil {
+			return nil, err
+		}
+		bc.disk = disk
+	}
+	return bc, nil
+}
+
+// Get returns the cached block, a block found on the disk is promoted to the memory cache
+func (bc *BlockCache) Get(c cid.Cid) (blocks.Block, bool) {
+	key := c.String()
+	if bc.mem != nil {
+		if v, err := bc.mem.Get(key); err == nil {
+			atomic.AddUint64(&bc.memoryHits, 1)
+			return v.(blocks.Block), true
+		}
+	}
+	if bc.disk != nil {
+		if b, ok := bc.disk.get(c); ok {
+			atomic.AddUint64(&bc.diskHits, 1)
+			if bc.mem != nil {
+				bc.mem.Set(key, b)
+			}
+			return b, true
+		}
+	}
+	atomic.AddUint64(&bc.misses, 1)
+	return nil, false
+}
+
+// Add caches a block read from the dag nodes
+func (bc *BlockCache) Add(b blocks.Block) {
+	if bc.maxBlockSize > 0 && len(b.RawData()) > bc.maxBlockSize {
+		return
+	}
+	if bc.mem != nil {
+		bc.mem.Set(b.Cid().String(), b)
+	}
+	if bc.disk != nil {
+		if err := bc.disk.add(b); err != nil {
+			log.Warnw("cache block on disk error", "cid", b.Cid(), "error", err)
+		}
+	}
+}
+
+// Remove drops the block from the caches
+func (bc *BlockCache) Remove(c cid.Cid) {
+	if bc.mem != nil {
+		bc.mem.Remove(c.String())
+	}
+	if bc.disk != nil {
+		bc.disk.remove(c.String())
+	}
+}
+
+// Stats returns the hit and miss counts since the cache was created
+func (bc *BlockCache) Stats() Stats {
+	st := Stats{
+		MemoryHits: atomic.LoadUint64(&bc.memoryHits),
+		DiskHits:   atomic.LoadUint64(&bc.diskHits),
+		Misses:     atomic.LoadUint64(&bc.misses),
+	}
+	if bc.mem != nil {
+		st.MemoryBlocks = bc.mem.Len(false)
+	}
+	if bc.disk != nil {
+		st.DiskBlocks, st.DiskBytes = bc.disk.usage()
+	}
+	return st
+}
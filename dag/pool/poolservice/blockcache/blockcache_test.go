@@ -0,0 +1,116 @@
+package blockcache
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/filedag-project/filedag-storage/dag/config"
+	blocks "github.com/ipfs/go-block-format"
+)
+
+func testBlocks(n, size int) []blocks.Block {
+	ret := make([]blocks.Block, 0, n)
+	for i := 0; i < n; i++ {
+		data := bytes.Repeat([]byte(fmt.Sprintf("%08d", i)), size/8)
+		ret = append(ret, blocks.NewBlock(data))
+	}
+	return ret
+}
+
+func TestMemoryCache(t *testing.T) {
+	for _, policy := range []string{PolicyLRU, PolicyARC} {
+		t.Run(policy, func(t *testing.T) {
+			bc, err := New(config.BlockCacheConfig{Policy: policy, MemoryBlocks: 2, MaxBlockSize: 64})
+			if err != nil {
+				t.Fatal(err)
+			}
+			bs := testBlocks(3, 64)
+			if _, ok := bc.Get(bs[0].Cid()); ok {
+				t.Fatal("empty cache should miss")
+			}
+			bc.Add(bs[0])
+			b, ok := bc.Get(bs[0].Cid())
+			if !ok || !bytes.Equal(b.RawData(), bs[0].RawData()) {
+				t.Fatal("cached block should hit")
+			}
+			bc.Remove(bs[0].Cid())
+			if _, ok = bc.Get(bs[0].Cid()); ok {
+				t.Fatal("removed block should miss")
+			}
+			// the blocks over the max size are not cached
+			large := testBlocks(1, 128)[0]
+			bc.Add(large)
+			if _, ok = bc.Get(large.Cid()); ok {
+				t.Fatal("large block should not be cached")
+			}
+			st := bc.Stats()
+			if st.MemoryHits != 1 || st.Misses != 3 || st.MemoryBlocks != 0 {
+				t.Fatalf("unexpected stats %+v", st)
+			}
+		})
+	}
+	if _, err := New(config.BlockCacheConfig{Policy: "lfu", MemoryBlocks: 1}); err == nil {
+		t.Fatal("unknown policy should be rejected")
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.BlockCacheConfig{DiskPath: dir, DiskBytes: 3 * 64}
+	bc, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bs := testBlocks(4, 64)
+	for _, b := range bs[:3] {
+		bc.Add(b)
+	}
+	// use the first block so that the second one is the least recently used
+	if _, ok := bc.Get(bs[0].Cid()); !ok {
+		t.Fatal("cached block should hit")
+	}
+	bc.Add(bs[3])
+	if _, ok := bc.Get(bs[1].Cid()); ok {
+		t.Fatal("least recently used block should be evicted")
+	}
+	if st := bc.Stats(); st.DiskBlocks != 3 || st.DiskBytes != 3*64 || st.DiskHits != 1 {
+		t.Fatalf("unexpected stats %+v", st)
+	}
+
+	// the cached blocks survive a restart, and the partial files are cleaned up
+	if err = ioutil.WriteFile(filepath.Join(dir, bs[1].Cid().String()+tmpSuffix), []byte("partial"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	bc, err = New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range []blocks.Block{bs[0], bs[2], bs[3]} {
+		cached, ok := bc.Get(b.Cid())
+		if !ok || !bytes.Equal(cached.RawData(), b.RawData()) {
+			t.Fatalf("block %s should be cached", b.Cid())
+		}
+	}
+	if _, err = os.Stat(filepath.Join(dir, bs[1].Cid().String()+tmpSuffix)); !os.IsNotExist(err) {
+		t.Fatalf("partial file should be removed: %v", err)
+	}
+
+	// a corrupted block file is dropped instead of served
+	if err = ioutil.WriteFile(filepath.Join(dir, bs[0].Cid().String()), []byte("corrupted"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := bc.Get(bs[0].Cid()); ok {
+		t.Fatal("corrupted block should miss")
+	}
+	bc.Remove(bs[2].Cid())
+	if _, ok := bc.Get(bs[2].Cid()); ok {
+		t.Fatal("removed block should miss")
+	}
+	if st := bc.Stats(); st.DiskBlocks != 1 || st.DiskBytes != 64 {
+		t.Fatalf("unexpected stats %+v", st)
+	}
+}
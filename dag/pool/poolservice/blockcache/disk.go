@@ -0,0 +1,171 @@
+package blockcache
+
+import (
+	"container/list"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+)
+
+// tmpSuffix marks the block files being written
+const tmpSuffix = ".tmp"
+
+// diskEntry is a block file of the disk cache
+type diskEntry struct {
+	key  string
+	size int64
+}
+
+// diskCache keeps the blocks in files named by their cids, the least recently used ones are removed
+// when the total size goes over the capacity
+type diskCache struct {
+	sync.Mutex
+	dir      string
+	capacity int64
+	size     int64
+	// lru holds the entries, the most recently used at the front
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+// openDiskCache loads the blocks left in the directory, the ones modified earlier are evicted first
+func openDiskCache(dir string, capacity int64) (*diskCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ModTime().After(infos[j].ModTime()) })
+	dc := &diskCache{
+		dir:      dir,
+		capacity: capacity,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+	for _, info := range infos {
+		if info.IsDir() {
+			continue
+		}
+		// the partial block files are left by a crash, the other files are not ours
+		if strings.HasSuffix(info.Name(), tmpSuffix) {
+			os.Remove(filepath.Join(dir, info.Name()))
+			continue
+		}
+		if _, err = cid.Decode(info.Name()); err != nil {
+			continue
+		}
+		dc.entries[info.Name()] = dc.lru.PushBack(&diskEntry{key: info.Name(), size: info.Size()})
+		dc.size += info.Size()
+	}
+	dc.Lock()
+	dc.evict()
+	dc.Unlock()
+	return dc, nil
+}
+
+// get reads the cached block, a block file which does not match its cid is dropped
+func (dc *diskCache) get(c cid.Cid) (blocks.Block, bool) {
+	key := c.String()
+	dc.Lock()
+	elem, ok := dc.entries[key]
+	if ok {
+		dc.lru.MoveToFront(elem)
+	}
+	dc.Unlock()
+	if !ok {
+		return nil, false
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dc.dir, key))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warnw("read cached block error", "cid", key, "error", err)
+		}
+		dc.remove(key)
+		return nil, false
+	}
+	sum, err := c.Prefix().Sum(data)
+	if err != nil || !sum.Equals(c) {
+		log.Warnw("cached block is corrupted", "cid", key)
+		dc.remove(key)
+		return nil, false
+	}
+	b, err := blocks.NewBlockWithCid(data, c)
+	if err != nil {
+		return nil, false
+	}
+	return b, true
+}
+
+// add writes the block file aside and moves it in place, so a crash never leaves a partial block
+func (dc *diskCache) add(b blocks.Block) error {
+	key := b.Cid().String()
+	dc.Lock()
+	_, ok := dc.entries[key]
+	dc.Unlock()
+	if ok {
+		return nil
+	}
+	data := b.RawData()
+	if int64(len(data)) > dc.capacity {
+		return nil
+	}
+	path := filepath.Join(dc.dir, key)
+	if err := ioutil.WriteFile(path+tmpSuffix, data, 0644); err != nil {
+		os.Remove(path + tmpSuffix)
+		return err
+	}
+	if err := os.Rename(path+tmpSuffix, path); err != nil {
+		os.Remove(path + tmpSuffix)
+		return err
+	}
+
+	dc.Lock()
+	defer dc.Unlock()
+	if _, ok = dc.entries[key]; ok {
+		return nil
+	}
+	dc.entries[key] = dc.lru.PushFront(&diskEntry{key: key, size: int64(len(data))})
+	dc.size += int64(len(data))
+	dc.evict()
+	return nil
+}
+
+func (dc *diskCache) remove(key string) {
+	dc.Lock()
+	defer dc.Unlock()
+	elem, ok := dc.entries[key]
+	if !ok {
+		return
+	}
+	dc.removeElement(elem)
+}
+
+// evict removes the least recently used blocks until the total size fits the capacity
+func (dc *diskCache) evict() {
+	for dc.size > dc.capacity {
+		dc.removeElement(dc.lru.Back())
+	}
+}
+
+func (dc *diskCache) removeElement(elem *list.Element) {
+	entry := dc.lru.Remove(elem).(*diskEntry)
+	delete(dc.entries, entry.key)
+	dc.size -= entry.size
+	if err := os.Remove(filepath.Join(dc.dir, entry.key)); err != nil && !os.IsNotExist(err) {
+		log.Warnw("remove cached block error", "cid", entry.key, "error", err)
+	}
+}
+
+func (dc *diskCache) usage() (int, int64) {
+	dc.Lock()
+	defer dc.Unlock()
+	return len(dc.entries), dc.size
+}
@@ -29,13 +29,15 @@ func (d *dagPoolService) startNewDagNode(nodeConfig *config.DagNodeConfig) (*dag
 	if _, ok := d.dagNodesMap[nodeConfig.Name]; ok {
 		return nil, ErrDagNodeAlreadyExist
 	}
-	dagNode, err := dagnode.NewDagNode(*nodeConfig)
+	dagNode, err := dagnode.NewDagNode(*nodeConfig, d.dialOpts...)
 	if err != nil {
 		log.Errorf("new dagnode err:%v", err)
 		return nil, err
 	}
+	dagNode.UseRepairStore(d.repairRepo)
 	go dagNode.RunHeartbeatCheck(d.parentCtx)
 	go dagNode.RunRepairTask(d.parentCtx)
+	go dagNode.RunScrubTask(d.parentCtx, d.scrubConfig, d.scrubKeySource(nodeConfig.Name), d.scrubRepo)
 	d.dagNodesMap[nodeConfig.Name] = dagNode
 	return dagNode, nil
 }
@@ -67,6 +69,9 @@ func (d *dagPoolService) AddDagNode(nodeConfig *config.DagNodeConfig) error {
 		dagNode.Close()
 		return err
 	}
+	if d.autoBalance {
+		d.rebalance.request()
+	}
 
 	return nil
 }
@@ -81,10 +86,21 @@ func (d *dagPoolService) GetDagNode(dagNodeName string) (*config.DagNodeConfig,
 	return nil, ErrDagNodeNotFound
 }
 
+// RemoveDagNode removes the dag node without slots, the dag node with slots is drained first
+// if the automatic rebalancing is enabled
 func (d *dagPoolService) RemoveDagNode(dagNodeName string) (*config.DagNodeConfig, error) {
 	d.dagNodesLock.Lock()
 	defer d.dagNodesLock.Unlock()
 
+	if d.autoBalance {
+		if nd, ok := d.dagNodesMap[dagNodeName]; ok && nd.GetNumSlots() != 0 {
+			return d.drainDagNode(dagNodeName)
+		}
+	}
+	return d.removeDagNode(dagNodeName)
+}
+
+func (d *dagPoolService) removeDagNode(dagNodeName string) (*config.DagNodeConfig, error) {
 	if d.state != StateOk {
 		if d.state == StateMigrating {
 			return nil, ErrClusterMigrating
@@ -197,7 +213,8 @@ func (d *dagPoolService) migrateSlotsByName(fromDagNodeName, toDagNodeName strin
 		rollback()
 		return err
 	}
-	d.state = StateMigrating
+	d.status.startMigration(fromDagNodeName, toDagNodeName, pairs, d.state != StateMigrating)
+	d.setState(StateMigrating)
 
 	return nil
 }
@@ -224,17 +241,22 @@ func (d *dagPoolService) migrateSlotsDataTask(ctx context.Context) {
 				return
 			}
 
+			// the pass is interrupted by an abort
+			passCtx := d.migrationCtl.startPass(ctx)
 			numSlotOk := 0
-			for slot, from := range d.importingSlotsFrom {
+			for slot := 0; slot < slotsmgr.ClusterSlots; slot++ {
+				if passCtx.Err() != nil {
+					break
+				}
+				_, to, from := d.slotNodes(uint16(slot))
 				if from == nil {
 					numSlotOk++
 					continue
 				}
-				to := d.slots[slot]
 				toName := to.GetConfig().Name
 
 				// slot data migrate from 'from' to 'to'
-				ch, err := d.slotKeyRepo.AllKeysChan(ctx, uint16(slot), "")
+				ch, err := d.slotKeyRepo.AllKeysChan(passCtx, uint16(slot), "")
 				if err != nil {
 					log.Fatal(err)
 				}
@@ -244,6 +266,9 @@ func (d *dagPoolService) migrateSlotsDataTask(ctx context.Context) {
 					if entry.Value == toName {
 						continue
 					}
+					if err = d.migrationGate.wait(passCtx); err != nil {
+						break
+					}
 					toMigrateSlots++
 					blkCid, err := cid.Parse(entry.Key)
 					if err != nil {
@@ -257,38 +282,76 @@ func (d *dagPoolService) migrateSlotsDataTask(ctx context.Context) {
 							continue
 						}
 						log.Errorw("migrating get block error", "from_node", from.GetConfig().Name, "slot", slot, "cid", blkCid, "err", err)
+						d.status.migrateKey(uint16(slot), false)
 						continue
 					}
+					if err = d.migrationCtl.throttle(passCtx, 1, uint64(len(bk.RawData()))); err != nil {
+						break
+					}
 					if err = to.Put(ctx, bk); err != nil {
 						log.Errorw("migrating put block error", "to_node", toName, "slot", slot, "cid", entry.Key, "err", err)
+						d.status.migrateKey(uint16(slot), false)
 						continue
 					}
 
 					if err = d.slotKeyRepo.Set(uint16(slot), entry.Key, toName); err != nil {
 						log.Errorw("slotKeyRepo set key error", "to_node", toName, "slot", slot, "cid", entry.Key, "err", err)
+						d.status.migrateKey(uint16(slot), false)
 						continue
 					}
+					d.status.migrateKey(uint16(slot), true)
 					if err = from.DeleteBlock(ctx, blkCid); err != nil {
 						log.Warnw("migrating delete block error", "from_node", from.GetConfig().Name, "slot", slot, "cid", blkCid, "err", err)
 					}
+					d.removeRepairTask(from, entry.Key)
 					successMigrateSlots++
 				}
+				if passCtx.Err() != nil {
+					break
+				}
 				if toMigrateSlots == successMigrateSlots {
 					// all migrated
 					if err = d.slotMigrateRepo.Remove(uint16(slot)); err == nil {
+						d.dagNodesLock.Lock()
 						d.importingSlotsFrom[slot] = nil
+						d.dagNodesLock.Unlock()
+						d.status.migrateSlotDone(uint16(slot))
 						numSlotOk++
 					} else {
 						log.Errorw("slotMigrateRepo.Remove failed", "slot", slot)
 					}
 				}
 			}
+			d.migrationCtl.endPass()
+			if ctx.Err() != nil {
+				return
+			}
+			if d.migrationCtl.takeAbort() {
+				if err := d.rollbackMigration(); err != nil {
+					log.Errorw("abort migration error", "error", err)
+					d.migrationCtl.finish()
+				}
+				// migrate the slots back
+				go func() {
+					d.migratingCh <- struct{}{}
+				}()
+				continue
+			}
 			// is migration done?
 			if numSlotOk == slotsmgr.ClusterSlots {
-				if d.checkAllSlots() {
-					d.state = StateOk
+				d.migrationCtl.finish()
+				d.dagNodesLock.Lock()
+				allSlots := d.checkAllSlots()
+				if allSlots {
+					d.setState(StateOk)
 				} else {
-					d.state = StateFail
+					d.setState(StateFail)
+				}
+				d.dagNodesLock.Unlock()
+				// they take the lock themselves
+				if allSlots {
+					d.finishRestripes()
+					d.finishDrains()
 				}
 			} else {
 				// try again
@@ -300,34 +363,18 @@ func (d *dagPoolService) migrateSlotsDataTask(ctx context.Context) {
 	}
 }
 
-// initSlots Perform the slots allocation for the first time
-func (d *dagPoolService) initSlots() error {
+// initSlots Perform the slots allocation for the first time, the dag nodes of the name list get the expected slots
+func (d *dagPoolService) initSlots(nameList []string, expected map[string]int) error {
 	cfg, err := d.loadConfig()
 	if err != nil {
 		return err
 	}
 
-	// calculate number of slots each dagnode
-	nodesNum := len(d.dagNodesMap)
-	if nodesNum == 0 {
-		return errors.New("please add the dagnodes first")
-	}
-	piece := slotsmgr.ClusterSlots / nodesNum
-	remind := slotsmgr.ClusterSlots - piece*nodesNum
-
-	var nameList []string
-	for name := range d.dagNodesMap {
-		nameList = append(nameList, name)
-	}
-	sort.Strings(nameList)
+	nodesNum := len(nameList)
 	curIndex := 0
 	cfg.Cluster = nil
 	for i := 0; i < nodesNum; i++ {
-		curPiece := piece
-		if remind > 0 {
-			curPiece += 1
-			remind--
-		}
+		curPiece := expected[nameList[i]]
 
 		node := d.dagNodesMap[nameList[i]]
 		for start := curIndex; start <= curIndex+curPiece-1; start++ {
@@ -346,6 +393,12 @@ func (d *dagPoolService) initSlots() error {
 
 		curIndex += curPiece
 	}
+	// keep the config of the dag nodes out of the name list
+	for name, node := range d.dagNodesMap {
+		if _, ok := expected[name]; !ok {
+			cfg.Cluster = append(cfg.Cluster, config.DagNodeInfo{Config: *node.GetConfig()})
+		}
+	}
 	// save config
 	cfg.Version += 1
 	if err = d.saveConfig(cfg); err != nil {
@@ -367,7 +420,7 @@ func (d *dagPoolService) initSlots() error {
 		return err
 	}
 	// initialization is complete
-	d.state = StateOk
+	d.setState(StateOk)
 
 	return nil
 }
@@ -379,21 +432,37 @@ func (d *dagPoolService) BalanceSlots() error {
 	if d.state == StateMigrating {
 		return ErrClusterMigrating
 	}
+	_, err := d.balanceSlots(0)
+	return err
+}
 
-	// calculate number of slots each dagnode
-	nodesNum := len(d.dagNodesMap)
-	if nodesNum == 0 {
-		return errors.New("please add the dagnodes first")
+// balanceSlots migrates the slots so that each dag node owns the slots expected by its weight and the leaving
+// dag nodes own none, nothing is migrated if no dag node deviates from its expected slots more than the threshold
+func (d *dagPoolService) balanceSlots(threshold int) (bool, error) {
+	leaving, err := d.leavingDagNodes()
+	if err != nil {
+		return false, err
 	}
-	piece := slotsmgr.ClusterSlots / nodesNum
-	remain := slotsmgr.ClusterSlots - piece*nodesNum
-
-	var nameList []string
+	var allNames, nameList []string
 	for name := range d.dagNodesMap {
-		nameList = append(nameList, name)
+		allNames = append(allNames, name)
+		if !leaving[name] {
+			nameList = append(nameList, name)
+		}
+	}
+	if len(nameList) == 0 {
+		return false, errors.New("please add the dagnodes first")
 	}
+	sort.Strings(allNames)
 	sort.Strings(nameList)
 
+	// calculate number of slots each dagnode
+	weights, err := d.slotWeights(nameList)
+	if err != nil {
+		return false, err
+	}
+	expected := expectedSlots(nameList, weights)
+
 	// check the slots
 	slotsTmp := slotsmgr.NewSlotsManager()
 	for slot, node := range d.slots {
@@ -405,7 +474,7 @@ func (d *dagPoolService) BalanceSlots() error {
 	if unAllocatedSlots > 0 {
 		if unAllocatedSlots == slotsmgr.ClusterSlots {
 			// init slots
-			return d.initSlots()
+			return false, d.initSlots(nameList, expected)
 		} else {
 			// slots will be assigned to the first node
 			pairs := slotsTmp.ToSlotPair()
@@ -413,11 +482,24 @@ func (d *dagPoolService) BalanceSlots() error {
 			for _, pair := range pairs {
 				for slot := pair.Start; slot <= pair.End; slot++ {
 					if err := d.addSlot(firstNode, slot); err != nil {
-						return err
+						return false, err
 					}
 				}
 			}
 		}
+	} else if threshold > 0 {
+		// Is it necessary to adjust?
+		balanced := true
+		for _, name := range allNames {
+			diff := expected[name] - d.dagNodesMap[name].GetNumSlots()
+			if diff > threshold || -diff > threshold || (leaving[name] && diff != 0) {
+				balanced = false
+				break
+			}
+		}
+		if balanced {
+			return false, nil
+		}
 	}
 
 	type MigrateInfo struct {
@@ -426,13 +508,9 @@ func (d *dagPoolService) BalanceSlots() error {
 	}
 	availableList := make([]MigrateInfo, 0)
 	requireList := make([]MigrateInfo, 0)
-	for i := 0; i < nodesNum; i++ {
-		expectedPiece := piece
-		if remain > 0 {
-			expectedPiece += 1
-			remain--
-		}
-		node := d.dagNodesMap[nameList[i]]
+	for _, name := range allNames {
+		expectedPiece := expected[name]
+		node := d.dagNodesMap[name]
 		numSlots := node.GetNumSlots()
 		// Is it necessary to adjust?
 		if expectedPiece == numSlots {
@@ -440,12 +518,12 @@ func (d *dagPoolService) BalanceSlots() error {
 		}
 		if expectedPiece < numSlots {
 			availableList = append(availableList, MigrateInfo{
-				DagNodeName: nameList[i],
+				DagNodeName: name,
 				NumSlots:    numSlots - expectedPiece,
 			})
 		} else {
 			requireList = append(requireList, MigrateInfo{
-				DagNodeName: nameList[i],
+				DagNodeName: name,
 				NumSlots:    expectedPiece - numSlots,
 			})
 		}
@@ -511,7 +589,6 @@ func (d *dagPoolService) BalanceSlots() error {
 		}
 	}
 
-	var err error
 	migrated := false
 	for _, migrate := range migrateSlots {
 		if err = d.migrateSlotsByName(migrate.From, migrate.To, migrate.SlotPairs); err != nil {
@@ -528,23 +605,36 @@ func (d *dagPoolService) BalanceSlots() error {
 		}
 	}
 
-	return err
+	return migrated, err
 }
 
 func (d *dagPoolService) Status() (*proto.StatusReply, error) {
+	// the cluster is copied under the lock, the data nodes are inspected after releasing it
 	d.dagNodesLock.RLock()
-	defer d.dagNodesLock.RUnlock()
-
-	list := make([]*proto.DagNodeStatus, 0, len(d.dagNodesMap))
+	state := d.state
+	description := d.genStatusDescription()
 	var nameList []string
 	for name := range d.dagNodesMap {
 		nameList = append(nameList, name)
 	}
 	sort.Strings(nameList)
-	nodesNum := len(nameList)
-	for i := 0; i < nodesNum; i++ {
-		node := d.dagNodesMap[nameList[i]]
-		pairs := node.GetSlotPairs()
+	weights, expected := d.statusWeights()
+	nodes := make([]*dagnode.DagNode, 0, len(nameList))
+	slotPairs := make([][]slotsmgr.SlotPair, 0, len(nameList))
+	draining := make(map[string]bool, len(d.draining))
+	for _, name := range nameList {
+		node := d.dagNodesMap[name]
+		nodes = append(nodes, node)
+		slotPairs = append(slotPairs, node.GetSlotPairs())
+		draining[name] = d.draining[name]
+	}
+	d.dagNodesLock.RUnlock()
+
+	keyCounts, countedAt := d.keyCounts()
+	repairDepths := d.repairQueueDepths(nodes)
+	list := make([]*proto.DagNodeStatus, 0, len(nodes))
+	for i, node := range nodes {
+		pairs := slotPairs[i]
 		newPairs := make([]*proto.SlotPair, 0, len(pairs))
 		for _, p := range pairs {
 			newPairs = append(newPairs, &proto.SlotPair{Start: uint32(p.Start), End: uint32(p.End)})
@@ -558,20 +648,47 @@ func (d *dagPoolService) Status() (*proto.StatusReply, error) {
 				State:      &state,
 			})
 		}
+		repairDepth, ok := repairDepths[cfg.Name]
+		if !ok {
+			repairDepth = -1
+		}
 		st := &proto.DagNodeStatus{
 			Node: &proto.DagNodeInfo{
 				Name:         cfg.Name,
 				Nodes:        dataNodes,
 				DataBlocks:   int32(cfg.DataBlocks),
 				ParityBlocks: int32(cfg.ParityBlocks),
+				Weight:       cfg.Weight,
 			},
-			Pairs: newPairs,
+			Pairs:         newPairs,
+			Health:        dataNodeHealth(node),
+			Keys:          keyCounts[cfg.Name],
+			RepairQueue:   repairDepth,
+			Weight:        weights[cfg.Name],
+			ExpectedSlots: uint32(expected[cfg.Name]),
+			Draining:      draining[cfg.Name],
 		}
 		list = append(list, st)
 	}
+	cacheStats := d.blockCache.Stats()
 	return &proto.StatusReply{
-		State:    d.state.String(),
-		Statuses: list,
+		State:            state.String(),
+		Description:      description,
+		Statuses:         list,
+		Migrations:       d.status.migrationStatus(),
+		Gc:               d.status.gcStatus(),
+		Transitions:      d.status.stateTransitions(),
+		KeysCountedAt:    unixTime(countedAt),
+		Rebalance:        d.rebalanceStatus(),
+		MigrationControl: d.migrationControlStatus(),
+		BlockCache: &proto.BlockCacheStats{
+			MemoryHits:   cacheStats.MemoryHits,
+			DiskHits:     cacheStats.DiskHits,
+			Misses:       cacheStats.Misses,
+			MemoryBlocks: int64(cacheStats.MemoryBlocks),
+			DiskBlocks:   int64(cacheStats.DiskBlocks),
+			DiskBytes:    cacheStats.DiskBytes,
+		},
 	}, nil
 }
 
@@ -0,0 +1,285 @@
+package dpuser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/filedag-project/filedag-storage/objectservice/objmetadb"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+const (
+	dagPoolUsageTotal = "dagPoolUsage/total/"
+	dagPoolUsageBlock = "dagPoolUsage/block/"
+	dagPoolUsageCache = "dagPoolUsage/cache/"
+	// dagPoolUsageBackfill is the progress of charging the blocks stored before the usage accounting
+	dagPoolUsageBackfill = "dagPoolUsage/backfill"
+)
+
+// ErrQuotaExceeded is returned when the stored bytes of a user would exceed its capacity
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+// blockUsage is the references to a block held by a user, the user is charged for the block once
+// however many times the user pins it
+type blockUsage struct {
+	Size   uint64
+	Pins   int64
+	Cached bool
+}
+
+func (b *blockUsage) held() bool {
+	return b.Pins > 0 || b.Cached
+}
+
+// backfillState is the progress of the backfill, the cursor is the last block charged
+type backfillState struct {
+	Cursor string
+	Done   bool
+}
+
+// UsageSys accounts the bytes stored by the dag pool users, the blocks pinned by a user and the blocks
+// cached by a user are charged to the user. The blocks stored before the accounting are charged to the admin user
+type UsageSys struct {
+	lk    sync.Mutex
+	DB    objmetadb.ObjStoreMetaDBAPI
+	admin string
+}
+
+// NewUsageSys new usage sys
+func NewUsageSys(db objmetadb.ObjStoreMetaDBAPI, admin string) *UsageSys {
+	return &UsageSys{DB: db, admin: admin}
+}
+
+// Usage returns the stored bytes of the user
+func (u *UsageSys) Usage(username string) (uint64, error) {
+	var total uint64
+	if err := u.DB.Get(dagPoolUsageTotal+username, &total); err != nil && err != leveldb.ErrNotFound {
+		return 0, err
+	}
+	return total, nil
+}
+
+// Charge records a pin or a cached block of the user, the size of the block is added to the stored bytes
+// if the user did not hold the block. ErrQuotaExceeded is returned if the stored bytes would exceed the capacity,
+// 0 capacity is unlimited. A cached block is charged to the first user caching it, false is returned if it is
+// charged to another user
+func (u *UsageSys) Charge(username, key string, size, capacity uint64, pin bool) (bool, error) {
+	u.lk.Lock()
+	defer u.lk.Unlock()
+
+	if !pin {
+		var owner string
+		err := u.DB.Get(dagPoolUsageCache+key, &owner)
+		if err == nil && owner != username {
+			return false, nil
+		} else if err != nil && err != leveldb.ErrNotFound {
+			return false, err
+		}
+	}
+	block, err := u.getBlock(username, key)
+	if err != nil {
+		return false, err
+	}
+	// the total, the cache owner and the block are written at once
+	batch := new(objmetadb.Batch)
+	if !block.held() {
+		total, err := u.Usage(username)
+		if err != nil {
+			return false, err
+		}
+		if capacity != 0 && total+size > capacity {
+			return false, fmt.Errorf("%w: user %s stores %d bytes of %d, can not add %d bytes",
+				ErrQuotaExceeded, username, total, capacity, size)
+		}
+		if err = batch.Put(dagPoolUsageTotal+username, total+size); err != nil {
+			return false, err
+		}
+		block.Size = size
+	}
+	if pin {
+		block.Pins++
+	} else {
+		if err = batch.Put(dagPoolUsageCache+key, username); err != nil {
+			return false, err
+		}
+		block.Cached = true
+	}
+	if err = batch.Put(dagPoolUsageBlock+username+"/"+key, block); err != nil {
+		return false, err
+	}
+	if err = u.DB.Write(batch); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Release drops a pin or the cached block charged to the user, the size of the block is subtracted from
+// the stored bytes once the user does not hold the block. A pin the user does not hold is dropped from
+// the admin user, who holds the pins stored before the accounting. The other blocks never charged are ignored
+func (u *UsageSys) Release(username, key string, pin bool) error {
+	u.lk.Lock()
+	defer u.lk.Unlock()
+
+	block, err := u.getBlock(username, key)
+	if err != nil {
+		return err
+	}
+	if pin && block.Pins == 0 && username != u.admin {
+		username = u.admin
+		if block, err = u.getBlock(username, key); err != nil {
+			return err
+		}
+	}
+	// the cache owner, the block and the total are written at once
+	batch := new(objmetadb.Batch)
+	if pin {
+		if block.Pins == 0 {
+			return nil
+		}
+		block.Pins--
+	} else {
+		if !block.Cached {
+			return nil
+		}
+		batch.Delete(dagPoolUsageCache + key)
+		block.Cached = false
+	}
+	if block.held() {
+		if err = batch.Put(dagPoolUsageBlock+username+"/"+key, block); err != nil {
+			return err
+		}
+		return u.DB.Write(batch)
+	}
+
+	total, err := u.Usage(username)
+	if err != nil {
+		return err
+	}
+	if total < block.Size {
+		total = block.Size
+	}
+	if err = batch.Put(dagPoolUsageTotal+username, total-block.Size); err != nil {
+		return err
+	}
+	batch.Delete(dagPoolUsageBlock + username + "/" + key)
+	return u.DB.Write(batch)
+}
+
+// BackfillState returns the last block charged by the backfill, done is set once all the blocks are charged
+func (u *UsageSys) BackfillState() (cursor string, done bool, err error) {
+	var state backfillState
+	if err = u.DB.Get(dagPoolUsageBackfill, &state); err != nil && err != leveldb.ErrNotFound {
+		return "", false, err
+	}
+	return state.Cursor, state.Done, nil
+}
+
+// BackfillBlock is a block stored before the usage accounting
+type BackfillBlock struct {
+	Key    string
+	Size   uint64
+	Pins   int64
+	Cached bool
+}
+
+// Backfill charges a batch of the blocks stored before the usage accounting to the admin user. The pins of
+// each block not charged to any user and the cached block without an owner are charged, together with the cursor,
+// so that an interrupted backfill resumes after the batch. The users are loaded once for the batch,
+// and the lock is held only while the batch is charged
+func (u *UsageSys) Backfill(ctx context.Context, blocks []BackfillBlock, cursor string) error {
+	u.lk.Lock()
+	defer u.lk.Unlock()
+
+	all, err := u.DB.ReadAllChan(ctx, dagPoolUsageTotal, "")
+	if err != nil {
+		return err
+	}
+	var users []string
+	for entry := range all {
+		users = append(users, strings.TrimPrefix(entry.GetKey(), dagPoolUsageTotal))
+	}
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	total, err := u.Usage(u.admin)
+	if err != nil {
+		return err
+	}
+	adminTotal := total
+
+	batch := new(objmetadb.Batch)
+	for _, b := range blocks {
+		pins, cached := b.Pins, b.Cached
+		for _, user := range users {
+			block, err := u.getBlock(user, b.Key)
+			if err != nil {
+				return err
+			}
+			pins -= block.Pins
+		}
+		if cached {
+			owner, err := u.CacheOwner(b.Key)
+			if err != nil {
+				return err
+			}
+			cached = owner == ""
+		}
+		if pins <= 0 && !cached {
+			continue
+		}
+		block, err := u.getBlock(u.admin, b.Key)
+		if err != nil {
+			return err
+		}
+		if !block.held() {
+			total += b.Size
+			block.Size = b.Size
+		}
+		if pins > 0 {
+			block.Pins += pins
+		}
+		if cached {
+			if err = batch.Put(dagPoolUsageCache+b.Key, u.admin); err != nil {
+				return err
+			}
+			block.Cached = true
+		}
+		if err = batch.Put(dagPoolUsageBlock+u.admin+"/"+b.Key, block); err != nil {
+			return err
+		}
+	}
+	if total != adminTotal {
+		if err = batch.Put(dagPoolUsageTotal+u.admin, total); err != nil {
+			return err
+		}
+	}
+	if err = batch.Put(dagPoolUsageBackfill, &backfillState{Cursor: cursor}); err != nil {
+		return err
+	}
+	return u.DB.Write(batch)
+}
+
+// FinishBackfill marks the blocks stored before the usage accounting are all charged
+func (u *UsageSys) FinishBackfill() error {
+	return u.DB.Put(dagPoolUsageBackfill, &backfillState{Done: true})
+}
+
+// CacheOwner returns the user charged for the cached block, empty if it is not charged
+func (u *UsageSys) CacheOwner(key string) (string, error) {
+	var owner string
+	if err := u.DB.Get(dagPoolUsageCache+key, &owner); err != nil && err != leveldb.ErrNotFound {
+		return "", err
+	}
+	return owner, nil
+}
+
+func (u *UsageSys) getBlock(username, key string) (*blockUsage, error) {
+	var block blockUsage
+	if err := u.DB.Get(dagPoolUsageBlock+username+"/"+key, &block); err != nil && err != leveldb.ErrNotFound {
+		return nil, err
+	}
+	return &block, nil
+}
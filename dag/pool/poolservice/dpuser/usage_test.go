@@ -0,0 +1,131 @@
+package dpuser
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filedag-project/filedag-storage/objectservice/objmetadb"
+	"golang.org/x/xerrors"
+)
+
+func TestUsageSys(t *testing.T) {
+	db, err := objmetadb.OpenDb(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	u := NewUsageSys(db, "root")
+	expectUsage := func(user string, expected uint64) {
+		t.Helper()
+		if used, err := u.Usage(user); err != nil || used != expected {
+			t.Fatalf("expect %s uses %d bytes, got %d, err: %v", user, expected, used, err)
+		}
+	}
+
+	// the pins of the same block are charged once
+	for i := 0; i < 2; i++ {
+		if _, err = u.Charge("a", "k1", 60, 100, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expectUsage("a", 60)
+	if _, err = u.Charge("a", "k2", 50, 100, true); !xerrors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expect quota exceeded, got %v", err)
+	}
+	expectUsage("a", 60)
+	// caching the pinned block costs nothing
+	if charged, err := u.Charge("a", "k1", 60, 100, false); err != nil || !charged {
+		t.Fatalf("the cached block should be charged, err: %v", err)
+	}
+	expectUsage("a", 60)
+
+	// the cached block is charged to the first user
+	if charged, err := u.Charge("b", "k1", 60, 0, false); err != nil || charged {
+		t.Fatalf("the cached block should not be charged twice, err: %v", err)
+	}
+	expectUsage("b", 0)
+	if owner, err := u.CacheOwner("k1"); err != nil || owner != "a" {
+		t.Fatalf("expect the cache owner a, got %s, err: %v", owner, err)
+	}
+
+	if err = u.Release("a", "k1", true); err != nil {
+		t.Fatal(err)
+	}
+	if err = u.Release("a", "k1", false); err != nil {
+		t.Fatal(err)
+	}
+	expectUsage("a", 60)
+	if err = u.Release("a", "k1", true); err != nil {
+		t.Fatal(err)
+	}
+	expectUsage("a", 0)
+	// releasing the blocks never charged is ignored
+	if err = u.Release("a", "k1", true); err != nil {
+		t.Fatal(err)
+	}
+	expectUsage("a", 0)
+	if _, err = u.Charge("a", "k2", 50, 100, true); err != nil {
+		t.Fatal(err)
+	}
+	expectUsage("a", 50)
+}
+
+func TestUsageBackfill(t *testing.T) {
+	db, err := objmetadb.OpenDb(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	u := NewUsageSys(db, "root")
+	expectUsage := func(user string, expected uint64) {
+		t.Helper()
+		if used, err := u.Usage(user); err != nil || used != expected {
+			t.Fatalf("expect %s uses %d bytes, got %d, err: %v", user, expected, used, err)
+		}
+	}
+
+	// k1 is pinned twice, once by a after the accounting
+	if _, err = u.Charge("a", "k1", 60, 0, true); err != nil {
+		t.Fatal(err)
+	}
+	// k2 is cached before the accounting, k3 is charged to a already
+	if _, err = u.Charge("a", "k3", 10, 0, true); err != nil {
+		t.Fatal(err)
+	}
+	if err = u.Backfill(ctx, []BackfillBlock{
+		{Key: "k1", Size: 60, Pins: 2},
+		{Key: "k2", Size: 40, Cached: true},
+		{Key: "k3", Size: 10, Pins: 1},
+	}, "slot/2/k2"); err != nil {
+		t.Fatal(err)
+	}
+	expectUsage("a", 70)
+	expectUsage("root", 100)
+	if owner, err := u.CacheOwner("k2"); err != nil || owner != "root" {
+		t.Fatalf("expect the cache owner root, got %s, err: %v", owner, err)
+	}
+	if cursor, done, err := u.BackfillState(); err != nil || cursor != "slot/2/k2" || done {
+		t.Fatalf("expect the cursor slot/2/k2, got %s, done: %v, err: %v", cursor, done, err)
+	}
+
+	// the pin stored before the accounting is released from root
+	for i := 0; i < 2; i++ {
+		if err = u.Release("b", "k1", true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expectUsage("root", 40)
+	expectUsage("a", 70)
+	if err = u.Release("root", "k2", false); err != nil {
+		t.Fatal(err)
+	}
+	expectUsage("root", 0)
+
+	if err = u.FinishBackfill(); err != nil {
+		t.Fatal(err)
+	}
+	if _, done, err := u.BackfillState(); err != nil || !done {
+		t.Fatalf("expect the backfill done, err: %v", err)
+	}
+}
@@ -20,6 +20,8 @@ type DagPoolUser struct {
 	Password string
 	Policy   upolicy.DagPoolPolicy
 	Capacity uint64
+	// Used is the stored bytes of the user reported by the dag pool, it is not persisted with the user
+	Used uint64 `msgpack:"-"`
 }
 
 //CheckAdmin check user admin policy
@@ -37,9 +37,12 @@ func (d *dagPoolService) GC(ctx context.Context) {
 					case <-taskCtx.Done():
 					}
 				}()
-				if err := d.runGC(taskCtx); err != nil {
+				d.status.gcStarted()
+				checked, removed, failed, err := d.runGC(taskCtx)
+				if err != nil {
 					log.Errorf("GC err: %v", err)
 				}
+				d.status.gcFinished(checked, removed, failed, err)
 			}()
 			log.Info("GC completed")
 			timer.Reset(d.gcPeriod)
@@ -49,16 +52,18 @@ func (d *dagPoolService) GC(ctx context.Context) {
 	}
 }
 
-func (d *dagPoolService) runGC(ctx context.Context) error {
+// runGC removes the cached blocks which are not pinned, it returns the numbers of the checked, removed and failed blocks
+func (d *dagPoolService) runGC(ctx context.Context) (checked, removed, failed uint64, err error) {
 	keys, err := d.cacheSet.AllKeysChan(ctx)
 	if err != nil {
-		return err
+		return 0, 0, 0, err
 	}
 
 	for key := range keys {
+		checked++
 		// is pinned?
 		if has, err := d.refCounter.Has(key); err != nil {
-			return err
+			return checked, removed, failed, err
 		} else if has {
 			continue
 		}
@@ -66,10 +71,12 @@ func (d *dagPoolService) runGC(ctx context.Context) error {
 		blkCid, err := cid.Decode(key)
 		if err != nil {
 			log.Warnw("decode cid error", "cid", key, "error", err)
+			failed++
 			continue
 		}
 		if err = d.cacheSet.Remove(key); err != nil {
 			log.Warnw("remove cache key error", "cid", key, "error", err)
+			failed++
 			continue
 		}
 		log.Infow("delete block", "cid", key)
@@ -79,10 +86,17 @@ func (d *dagPoolService) runGC(ctx context.Context) error {
 			}
 
 			log.Warnw("delete block data error", "cid", key, "error", err)
+			failed++
 			continue
 		}
+		removed++
+		if owner, err := d.usage.CacheOwner(key); err != nil {
+			log.Warnw("get cache owner error", "cid", key, "error", err)
+		} else if owner != "" {
+			d.releaseUser(owner, key, false)
+		}
 	}
-	return nil
+	return checked, removed, failed, nil
 }
 
 func (d *dagPoolService) InterruptGC() {
@@ -22,20 +22,45 @@ func keyHashSlot(key string) uint16 {
 }
 
 func (d *dagPoolService) clusterInit() error {
-	cfg, err := d.loadConfig()
+	state, err := d.loadCluster()
 	if err != nil {
 		return err
 	}
+	if state == StateOk {
+		// the restripes and the drains may be finished before restarting
+		d.finishRestripes()
+		d.finishDrains()
+	}
+	if state == StateMigrating {
+		// start to migrate data
+		select {
+		case d.migratingCh <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// loadCluster starts the dag nodes and restores their slots under dagNodesLock, it returns the cluster state
+func (d *dagPoolService) loadCluster() (ClusterState, error) {
+	d.dagNodesLock.Lock()
+	defer d.dagNodesLock.Unlock()
+
+	cfg, err := d.loadConfig()
+	if err != nil {
+		return d.state, err
+	}
 
 	for _, dagNodeConfig := range cfg.Cluster {
 		dagNode, err := d.startNewDagNode(&dagNodeConfig.Config)
 		if err != nil {
-			return err
+			return d.state, err
 		}
 		for _, pair := range dagNodeConfig.SlotPairs {
 			for idx := pair.Start; idx <= pair.End; idx++ {
 				if err := d.addSlot(dagNode, idx); err != nil {
-					return err
+					return d.state, err
 				}
 			}
 		}
@@ -44,30 +69,27 @@ func (d *dagPoolService) clusterInit() error {
 	// load migrating slot
 	ch, err := d.slotMigrateRepo.AllKeysChan(d.parentCtx)
 	if err != nil {
-		return err
+		return d.state, err
 	}
 	for entry := range ch {
 		if dagNode, ok := d.dagNodesMap[entry.Value]; ok {
 			d.importingSlotsFrom[entry.Slot] = dagNode
-			d.state = StateMigrating
+			d.status.resumeMigration(entry.Slot, entry.Value, d.slots[entry.Slot].GetConfig().Name)
+			d.setState(StateMigrating)
 		} else {
-			return fmt.Errorf("not exist the dag node, slot:%v dagNodeName:%v", entry.Slot, entry.Value)
+			return d.state, fmt.Errorf("not exist the dag node, slot:%v dagNodeName:%v", entry.Slot, entry.Value)
 		}
 	}
 
+	if err = d.resumeRebuilds(); err != nil {
+		return d.state, err
+	}
+
 	if !d.checkAllSlots() {
-		d.state = StateFail
+		d.setState(StateFail)
 		log.Warn("please allocate all the slots")
 	}
-	if d.state == StateMigrating {
-		// start to migrate data
-		select {
-		case d.migratingCh <- struct{}{}:
-		default:
-		}
-	}
-
-	return nil
+	return d.state, nil
 }
 
 func (d *dagPoolService) checkAllSlots() bool {
@@ -114,19 +136,27 @@ func (d *dagPoolService) delNodeSlots(node *dagnode.DagNode) int {
 	return deleted
 }
 
+// slotNodes returns the cluster state, the dag node owning the slot and the dag node the slot is imported from,
+// which are changed by the migration under dagNodesLock
+func (d *dagPoolService) slotNodes(slot uint16) (state ClusterState, owner, importingFrom *dagnode.DagNode) {
+	d.dagNodesLock.RLock()
+	defer d.dagNodesLock.RUnlock()
+	return d.state, d.slots[slot], d.importingSlotsFrom[slot]
+}
+
 // readBlock read block from dagnode
 func (d *dagPoolService) readBlock(ctx context.Context, c cid.Cid) (blocks.Block, error) {
-	if d.state == StateFail {
+	state, owner, from := d.slotNodes(keyHashSlot(c.String()))
+	if state == StateFail {
 		return nil, ErrClusterAvailable
 	}
-	slot := keyHashSlot(c.String())
-	if node := d.importingSlotsFrom[slot]; node != nil {
-		b, err := node.Get(ctx, c)
+	if from != nil {
+		b, err := from.Get(ctx, c)
 		if err == nil {
 			return b, nil
 		}
 	}
-	b, err := d.slots[slot].Get(ctx, c)
+	b, err := owner.Get(ctx, c)
 	if err != nil {
 		if format.IsNotFound(err) {
 			return nil, err
@@ -138,27 +168,27 @@ func (d *dagPoolService) readBlock(ctx context.Context, c cid.Cid) (blocks.Block
 
 // readBlockSize read block size from dagnode
 func (d *dagPoolService) readBlockSize(ctx context.Context, c cid.Cid) (int, error) {
-	if d.state == StateFail {
+	state, owner, from := d.slotNodes(keyHashSlot(c.String()))
+	if state == StateFail {
 		return 0, ErrClusterAvailable
 	}
-	slot := keyHashSlot(c.String())
-	if node := d.importingSlotsFrom[slot]; node != nil {
-		size, err := node.GetSize(ctx, c)
+	if from != nil {
+		size, err := from.GetSize(ctx, c)
 		if err == nil {
 			return size, nil
 		}
 	}
-	return d.slots[slot].GetSize(ctx, c)
+	return owner.GetSize(ctx, c)
 }
 
 // putBlock put block to dagnode
 func (d *dagPoolService) putBlock(ctx context.Context, block blocks.Block) error {
-	if d.state == StateFail {
-		return ErrClusterAvailable
-	}
 	blkCid := block.Cid()
 	slot := keyHashSlot(blkCid.String())
-	selNode := d.slots[slot]
+	state, selNode, _ := d.slotNodes(slot)
+	if state == StateFail {
+		return ErrClusterAvailable
+	}
 	if err := selNode.Put(ctx, block); err != nil {
 		return err
 	}
@@ -176,15 +206,16 @@ func (d *dagPoolService) putBlock(ctx context.Context, block blocks.Block) error
 
 // deleteBlock delete block from dagnode
 func (d *dagPoolService) deleteBlock(ctx context.Context, c cid.Cid) error {
-	if d.state == StateFail {
+	slot := keyHashSlot(c.String())
+	state, selNode, _ := d.slotNodes(slot)
+	if state == StateFail {
 		return ErrClusterAvailable
 	}
-	slot := keyHashSlot(c.String())
 	if err := d.slotKeyRepo.Remove(slot, c.String()); err != nil {
 		return err
 	}
+	d.blockCache.Remove(c)
 
-	selNode := d.slots[slot]
 	if err := selNode.DeleteBlock(ctx, c); err != nil {
 		// rollback
 		if dberr := d.slotKeyRepo.Set(slot, c.String(), selNode.GetConfig().Name); dberr != nil {
@@ -193,5 +224,6 @@ func (d *dagPoolService) deleteBlock(ctx context.Context, c cid.Cid) error {
 		}
 		return err
 	}
+	d.removeRepairTask(selNode, c.String())
 	return nil
 }
@@ -0,0 +1,308 @@
+package poolservice
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/filedag-project/filedag-storage/dag/config"
+	"github.com/filedag-project/filedag-storage/dag/node/dagnode"
+	"github.com/filedag-project/filedag-storage/dag/proto"
+	"github.com/filedag-project/filedag-storage/dag/slotsmgr"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+const (
+	// migrationRateKey keeps the rate limit of the data migration
+	migrationRateKey = "migration/rate"
+	// migrationPausedKey is set while the data migration is paused by PauseMigration
+	migrationPausedKey = "migration/paused"
+	// operatorPauseReason is the reason of pausing the migration gate by PauseMigration
+	operatorPauseReason = "operator"
+)
+
+var ErrClusterNotMigrating = errors.New("the cluster is not migrating")
+
+// migrationRate is the rate limit of the data migration, 0 is unlimited
+type migrationRate struct {
+	BytesPerSecond uint64
+	KeysPerSecond  uint64
+}
+
+// pacer spaces out the work so that it does not exceed the rate per second, 0 is unlimited
+type pacer struct {
+	rate uint64
+	next time.Time
+	// now is the clock of the pacer, time.Now if nil
+	now func() time.Time
+}
+
+// reserve returns how long to wait before doing n units of work
+func (p *pacer) reserve(n uint64) time.Duration {
+	if p.rate == 0 {
+		return 0
+	}
+	now := time.Now()
+	if p.now != nil {
+		now = p.now()
+	}
+	if p.next.Before(now) {
+		p.next = now
+	}
+	delay := p.next.Sub(now)
+	p.next = p.next.Add(time.Duration(n) * time.Second / time.Duration(p.rate))
+	return delay
+}
+
+// migrationControl throttles and aborts the data migration of the slots
+type migrationControl struct {
+	sync.Mutex
+	bytes, keys pacer
+	// abort asks the data migration to roll back after the pass in progress is interrupted
+	abort bool
+	// aborting is set from AbortMigration until the slots are migrated back to the source dag nodes
+	aborting   bool
+	cancelPass context.CancelFunc
+}
+
+func (c *migrationControl) setRate(rate migrationRate) {
+	c.Lock()
+	defer c.Unlock()
+	c.bytes.rate = rate.BytesPerSecond
+	c.keys.rate = rate.KeysPerSecond
+}
+
+// throttle waits until the keys and the bytes are allowed by the rate limit
+func (c *migrationControl) throttle(ctx context.Context, keys, bytes uint64) error {
+	c.Lock()
+	delay := c.keys.reserve(keys)
+	if d := c.bytes.reserve(bytes); d > delay {
+		delay = d
+	}
+	c.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// startPass returns the context of a pass of the data migration, it is cancelled by an abort
+func (c *migrationControl) startPass(ctx context.Context) context.Context {
+	c.Lock()
+	defer c.Unlock()
+	passCtx, cancel := context.WithCancel(ctx)
+	c.cancelPass = cancel
+	if c.abort {
+		cancel()
+	}
+	return passCtx
+}
+
+func (c *migrationControl) endPass() {
+	c.Lock()
+	defer c.Unlock()
+	if c.cancelPass != nil {
+		c.cancelPass()
+		c.cancelPass = nil
+	}
+}
+
+func (c *migrationControl) requestAbort() {
+	c.Lock()
+	defer c.Unlock()
+	c.abort = true
+	c.aborting = true
+	if c.cancelPass != nil {
+		c.cancelPass()
+	}
+}
+
+// takeAbort returns whether an abort is requested and clears the request
+func (c *migrationControl) takeAbort() bool {
+	c.Lock()
+	defer c.Unlock()
+	abort := c.abort
+	c.abort = false
+	return abort
+}
+
+func (c *migrationControl) finish() {
+	c.Lock()
+	defer c.Unlock()
+	c.aborting = false
+}
+
+// loadMigrationControl restores the rate limit and the pause of the data migration
+func (d *dagPoolService) loadMigrationControl() error {
+	var rate migrationRate
+	if err := d.db.Get(migrationRateKey, &rate); err != nil && err != leveldb.ErrNotFound {
+		return err
+	}
+	d.migrationCtl.setRate(rate)
+	var paused bool
+	if err := d.db.Get(migrationPausedKey, &paused); err != nil && err != leveldb.ErrNotFound {
+		return err
+	}
+	if paused {
+		d.migrationGate.pause(operatorPauseReason)
+	}
+	return nil
+}
+
+// SetMigrationRate limits the data migration of the slots, 0 is unlimited
+func (d *dagPoolService) SetMigrationRate(bytesPerSecond, keysPerSecond uint64) error {
+	rate := migrationRate{BytesPerSecond: bytesPerSecond, KeysPerSecond: keysPerSecond}
+	if err := d.db.Put(migrationRateKey, rate); err != nil {
+		return err
+	}
+	d.migrationCtl.setRate(rate)
+	log.Infow("migration rate changed", "bytesPerSecond", bytesPerSecond, "keysPerSecond", keysPerSecond)
+	return nil
+}
+
+// PauseMigration pauses the data migration of the slots until ResumeMigration
+func (d *dagPoolService) PauseMigration() error {
+	if err := d.db.Put(migrationPausedKey, true); err != nil {
+		return err
+	}
+	d.migrationGate.pause(operatorPauseReason)
+	log.Infow("migration paused")
+	return nil
+}
+
+// ResumeMigration resumes the data migration of the slots
+func (d *dagPoolService) ResumeMigration() error {
+	if err := d.db.Delete(migrationPausedKey); err != nil {
+		return err
+	}
+	d.migrationGate.resume(operatorPauseReason)
+	log.Infow("migration resumed")
+	return nil
+}
+
+// AbortMigration gives the migrating slots back to the source dag nodes, the keys migrated already are
+// migrated back at the same rate. The rollback waits for ResumeMigration if the migration is paused
+func (d *dagPoolService) AbortMigration() error {
+	d.dagNodesLock.RLock()
+	migrating := d.state == StateMigrating
+	d.dagNodesLock.RUnlock()
+	if !migrating {
+		return ErrClusterNotMigrating
+	}
+	d.migrationCtl.requestAbort()
+	// start the rollback if no pass is in progress
+	select {
+	case d.migratingCh <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (d *dagPoolService) migrationControlStatus() *proto.MigrationControl {
+	d.migrationCtl.Lock()
+	defer d.migrationCtl.Unlock()
+	return &proto.MigrationControl{
+		Paused:         d.migrationGate.pausedBy(operatorPauseReason),
+		Aborting:       d.migrationCtl.aborting,
+		BytesPerSecond: d.migrationCtl.bytes.rate,
+		KeysPerSecond:  d.migrationCtl.keys.rate,
+	}
+}
+
+// rollbackMigration reverses the migrating slots, so the data migration moves the keys back to the source
+// dag nodes. The drains and the restripes waiting for the migration are cancelled
+func (d *dagPoolService) rollbackMigration() error {
+	d.dagNodesLock.Lock()
+	defer d.dagNodesLock.Unlock()
+
+	type reverted struct {
+		slot     uint16
+		from, to *dagnode.DagNode
+	}
+	var slots []reverted
+	for slot, from := range d.importingSlotsFrom {
+		if from != nil {
+			slots = append(slots, reverted{slot: uint16(slot), from: from, to: d.slots[slot]})
+		}
+	}
+	if len(slots) == 0 {
+		return nil
+	}
+	cfg, err := d.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	rollback := func(n int) {
+		for _, r := range slots[:n] {
+			if errR := d.slotMigrateRepo.Set(r.slot, r.from.GetConfig().Name); errR != nil {
+				log.Warnw("slotMigrateRepo.Set error", "slot", r.slot)
+			}
+		}
+	}
+	for i, r := range slots {
+		if err = d.slotMigrateRepo.Set(r.slot, r.to.GetConfig().Name); err != nil {
+			rollback(i)
+			return err
+		}
+	}
+	for _, r := range slots {
+		d.migrateSlotsByNode(r.to, r.from, []slotsmgr.SlotPair{{Start: uint64(r.slot), End: uint64(r.slot)}})
+	}
+
+	// update local config
+	cfg.Version += 1
+	cfg.Cluster = nil
+	for _, node := range d.dagNodesMap {
+		dagNode := config.DagNodeInfo{}
+		dagNode.Config = *node.GetConfig()
+		dagNode.SlotPairs = node.GetSlotPairs()
+		cfg.Cluster = append(cfg.Cluster, dagNode)
+	}
+	if err = d.saveConfig(cfg); err != nil {
+		for _, r := range slots {
+			d.migrateSlotsByNode(r.from, r.to, []slotsmgr.SlotPair{{Start: uint64(r.slot), End: uint64(r.slot)}})
+		}
+		rollback(len(slots))
+		return err
+	}
+	for i, r := range slots {
+		pair := slotsmgr.SlotPair{Start: uint64(r.slot), End: uint64(r.slot)}
+		d.status.startMigration(r.to.GetConfig().Name, r.from.GetConfig().Name, []slotsmgr.SlotPair{pair}, i == 0)
+	}
+
+	// the aborted migration may be for the restripes and the drains
+	restripes, err := d.slotMigrateRepo.Restripes(d.parentCtx)
+	if err != nil {
+		log.Errorw("load restripes error", "error", err)
+	}
+	for from, to := range restripes {
+		if err = d.slotMigrateRepo.RemoveRestripe(from); err != nil {
+			log.Errorw("remove restripe error", "dagnode", from, "error", err)
+			continue
+		}
+		log.Warnw("restripe cancelled", "from", from, "to", to)
+	}
+	for name := range d.draining {
+		if err = d.slotMigrateRepo.RemoveDrain(name); err != nil {
+			log.Errorw("remove drain error", "dagnode", name, "error", err)
+			continue
+		}
+		delete(d.draining, name)
+		log.Warnw("drain cancelled", "dagnode", name)
+	}
+	d.rebalance.Lock()
+	d.rebalance.pending = false
+	d.rebalance.Unlock()
+
+	log.Warnw("migration aborted, migrating the slots back", "slots", len(slots))
+	return nil
+}
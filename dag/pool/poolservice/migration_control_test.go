@@ -0,0 +1,67 @@
+package poolservice
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPacer(t *testing.T) {
+	now := time.Now()
+	p := pacer{now: func() time.Time { return now }}
+	if delay := p.reserve(100); delay != 0 {
+		t.Fatalf("unlimited pacer should not wait, got %v", delay)
+	}
+	p.rate = 10
+	if delay := p.reserve(1); delay != 0 {
+		t.Fatalf("the first reservation should not wait, got %v", delay)
+	}
+	now = now.Add(10 * time.Millisecond)
+	if delay := p.reserve(5); delay != 90*time.Millisecond {
+		t.Fatalf("the second reservation should wait for the first one, got %v", delay)
+	}
+	if delay := p.reserve(1); delay != 590*time.Millisecond {
+		t.Fatalf("the third reservation should wait for the former ones, got %v", delay)
+	}
+	// the time passed is not reserved again
+	now = now.Add(time.Second)
+	if delay := p.reserve(1); delay != 0 {
+		t.Fatalf("the reservation after the former ones should not wait, got %v", delay)
+	}
+}
+
+func TestMigrationControlAbort(t *testing.T) {
+	var c migrationControl
+	passCtx := c.startPass(context.Background())
+	if c.takeAbort() {
+		t.Fatal("no abort is requested")
+	}
+	c.requestAbort()
+	if passCtx.Err() == nil {
+		t.Fatal("the pass in progress should be interrupted by the abort")
+	}
+	c.endPass()
+	// the abort requested between the passes interrupts the next pass
+	c.requestAbort()
+	if passCtx = c.startPass(context.Background()); passCtx.Err() == nil {
+		t.Fatal("the next pass should be interrupted by the abort")
+	}
+	c.endPass()
+	if !c.takeAbort() || c.takeAbort() {
+		t.Fatal("the abort should be taken once")
+	}
+	if !c.aborting {
+		t.Fatal("the control should be aborting until the rollback finishes")
+	}
+	c.finish()
+
+	c.setRate(migrationRate{KeysPerSecond: 20})
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := c.throttle(ctx, 1, 1<<20); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if err := c.throttle(ctx, 1, 1<<20); err == nil {
+		t.Fatal("the throttle should be interrupted by the context")
+	}
+}
@@ -146,11 +146,11 @@ func TestPinAndReference(t *testing.T) {
 }
 func startTestDagPoolServer(t *testing.T) *dagPoolService {
 	user, pass := "dagpool", "dagpool"
-	go datanode.StartDataNodeServer(":9021", datanode.KVBadge, t.TempDir())
+	go datanode.StartDataNodeServer(datanode.ServerConfig{Listen: ":9021", KVType: datanode.KVBadge, DataDirs: []string{t.TempDir()}})
 	time.Sleep(time.Second)
-	go datanode.StartDataNodeServer(":9022", datanode.KVBadge, t.TempDir())
+	go datanode.StartDataNodeServer(datanode.ServerConfig{Listen: ":9022", KVType: datanode.KVBadge, DataDirs: []string{t.TempDir()}})
 	time.Sleep(time.Second)
-	go datanode.StartDataNodeServer(":9023", datanode.KVBadge, t.TempDir())
+	go datanode.StartDataNodeServer(datanode.ServerConfig{Listen: ":9023", KVType: datanode.KVBadge, DataDirs: []string{t.TempDir()}})
 	time.Sleep(time.Second)
 	var (
 		dagdc = []string{
@@ -5,9 +5,12 @@ import (
 	"github.com/filedag-project/filedag-storage/dag/config"
 	"github.com/filedag-project/filedag-storage/dag/node/dagnode"
 	"github.com/filedag-project/filedag-storage/dag/pool"
+	"github.com/filedag-project/filedag-storage/dag/pool/poolservice/blockcache"
 	"github.com/filedag-project/filedag-storage/dag/pool/poolservice/dpuser"
 	"github.com/filedag-project/filedag-storage/dag/pool/poolservice/dpuser/upolicy"
 	"github.com/filedag-project/filedag-storage/dag/pool/poolservice/reference"
+	"github.com/filedag-project/filedag-storage/dag/pool/poolservice/repairrepo"
+	"github.com/filedag-project/filedag-storage/dag/pool/poolservice/scrubrepo"
 	"github.com/filedag-project/filedag-storage/dag/pool/poolservice/slotkeyrepo"
 	"github.com/filedag-project/filedag-storage/dag/pool/poolservice/slotmigraterepo"
 	"github.com/filedag-project/filedag-storage/dag/slotsmgr"
@@ -17,6 +20,7 @@ import (
 	format "github.com/ipfs/go-ipld-format"
 	logging "github.com/ipfs/go-log/v2"
 	"golang.org/x/xerrors"
+	"google.golang.org/grpc"
 	"sync"
 	"time"
 )
@@ -58,8 +62,9 @@ type dagPoolService struct {
 	parentCtx   context.Context
 	migratingCh chan struct{}
 
-	iam *dpuser.IdentityUserSys
-	db  objmetadb.ObjStoreMetaDBAPI
+	iam   *dpuser.IdentityUserSys
+	usage *dpuser.UsageSys
+	db    objmetadb.ObjStoreMetaDBAPI
 
 	refCounter      *reference.RefCounter
 	cacheSet        *reference.CacheSet
@@ -68,6 +73,29 @@ type dagPoolService struct {
 
 	gcControl *GcControl
 	gcPeriod  time.Duration
+
+	repairRepo  *repairrepo.RepairRepo
+	scrubRepo   *scrubrepo.ScrubRepo
+	scrubConfig dagnode.ScrubConfig
+
+	// dialOpts are the options to connect the data nodes
+	dialOpts []grpc.DialOption
+
+	// blockCache caches the blocks read from the dag nodes
+	blockCache *blockcache.BlockCache
+
+	// status tracks the GC runs, the migration progress, the state transitions and the key counts
+	status statusTracker
+
+	// autoBalance rebalances the slots once a dag node is added and drains the dag node before removing it
+	autoBalance bool
+	// draining is the dag nodes whose slots are migrating to the other dag nodes before removing them
+	draining  map[string]bool
+	rebalance rebalancer
+	// migrationGate pauses the data migration of the slots
+	migrationGate *migrationGate
+	// migrationCtl throttles and aborts the data migration of the slots
+	migrationCtl migrationControl
 }
 
 // NewDagPoolService constructs a new DAGPool (using the default implementation).
@@ -76,6 +104,20 @@ func NewDagPoolService(ctx context.Context, cfg config.PoolConfig) (*dagPoolServ
 	if err != nil {
 		return nil, err
 	}
+	return NewDagPoolServiceWithDB(ctx, cfg, db)
+}
+
+// NewDagPoolServiceWithDB constructs a dagPoolService keeping its metadata in the db,
+// such as the replicated db of the dag pool replicas
+func NewDagPoolServiceWithDB(ctx context.Context, cfg config.PoolConfig, db objmetadb.ObjStoreMetaDBAPI) (*dagPoolService, error) {
+	dialOpts, err := cfg.Security.DialOptions()
+	if err != nil {
+		return nil, err
+	}
+	blockCache, err := blockcache.New(cfg.BlockCache)
+	if err != nil {
+		return nil, err
+	}
 	i, err := dpuser.NewIdentityUserSys(db, cfg.RootUser, cfg.RootPassword)
 	if err != nil {
 		return nil, err
@@ -88,6 +130,7 @@ func NewDagPoolService(ctx context.Context, cfg config.PoolConfig) (*dagPoolServ
 		parentCtx:       ctx,
 		migratingCh:     make(chan struct{}),
 		iam:             i,
+		usage:           dpuser.NewUsageSys(db, cfg.RootUser),
 		db:              db,
 		refCounter:      refCounter,
 		cacheSet:        cacheSet,
@@ -95,6 +138,24 @@ func NewDagPoolService(ctx context.Context, cfg config.PoolConfig) (*dagPoolServ
 		slotMigrateRepo: slotmigraterepo.NewSlotMigrateRepo(db),
 		gcControl:       NewGcControl(),
 		gcPeriod:        cfg.GcPeriod,
+		repairRepo:      repairrepo.NewRepairRepo(db),
+		scrubRepo:       scrubrepo.NewScrubRepo(db),
+		scrubConfig: dagnode.ScrubConfig{
+			Rate:     cfg.ScrubRate,
+			Interval: cfg.ScrubInterval,
+		},
+		dialOpts:      dialOpts,
+		blockCache:    blockCache,
+		autoBalance:   cfg.AutoBalance,
+		draining:      make(map[string]bool),
+		rebalance:     rebalancer{pending: cfg.AutoBalance, notify: make(chan struct{}, 1)},
+		migrationGate: newMigrationGate(),
+	}
+	if err = serv.loadRebalance(); err != nil {
+		return nil, err
+	}
+	if err = serv.loadMigrationControl(); err != nil {
+		return nil, err
 	}
 	// process migrating task
 	go serv.migrateSlotsDataTask(ctx)
@@ -102,6 +163,8 @@ func NewDagPoolService(ctx context.Context, cfg config.PoolConfig) (*dagPoolServ
 	if err = serv.clusterInit(); err != nil {
 		return nil, err
 	}
+	go serv.rebalanceTask(ctx)
+	go serv.backfillUsageTask(ctx)
 
 	return serv, nil
 }
@@ -119,18 +182,53 @@ func (d *dagPoolService) Add(ctx context.Context, block blocks.Block, user strin
 
 	if pin {
 		d.InterruptGC()
-		return d.refCounter.IncrOrCreate(key, addBlock)
+		if _, err := d.chargeUser(user, key, len(block.RawData()), true); err != nil {
+			return err
+		}
+		if err := d.refCounter.IncrOrCreate(key, addBlock); err != nil {
+			d.releaseUser(user, key, true)
+			return err
+		}
+		return nil
 	}
 
 	if has, _ := d.Has(key); !has {
-		if err := addBlock(); err != nil {
+		charged, err := d.chargeUser(user, key, len(block.RawData()), false)
+		if err != nil {
 			return err
 		}
-		return d.cacheSet.Add(key)
+		if err = addBlock(); err == nil {
+			err = d.cacheSet.Add(key)
+		}
+		if err != nil && charged {
+			d.releaseUser(user, key, false)
+		}
+		return err
 	}
 	return nil
 }
 
+// chargeUser charges the user for the block before storing it, the capacity of the user limits its stored bytes
+func (d *dagPoolService) chargeUser(user, key string, size int, pin bool) (bool, error) {
+	var capacity uint64
+	// the admin user is unlimited
+	if !d.iam.IsAdmin(user) {
+		u, err := d.iam.QueryUser(user)
+		if err != nil {
+			return false, err
+		}
+		capacity = u.Capacity
+	}
+	return d.usage.Charge(user, key, uint64(size), capacity, pin)
+}
+
+// releaseUser releases the block charged to the user
+func (d *dagPoolService) releaseUser(user, key string, pin bool) {
+	if err := d.usage.Release(user, key, pin); err != nil {
+		log.Errorw("release user usage error", "user", user, "cid", key, "error", err)
+	}
+}
+
 // Get retrieves a node from the dagPoolService, fetching the block in the BlockService
 func (d *dagPoolService) Get(ctx context.Context, c cid.Cid, user string, password string) (blocks.Block, error) {
 	if !d.iam.CheckUserPolicy(user, password, upolicy.ReadOnly) {
@@ -147,7 +245,15 @@ func (d *dagPoolService) Get(ctx context.Context, c cid.Cid, user string, passwo
 		return nil, format.ErrNotFound{Cid: c}
 	}
 
-	return d.readBlock(ctx, c)
+	if b, ok := d.blockCache.Get(c); ok {
+		return b, nil
+	}
+	b, err := d.readBlock(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	d.blockCache.Add(b)
+	return b, nil
 }
 
 // Remove remove block from DAGPool
@@ -157,7 +263,10 @@ func (d *dagPoolService) Remove(ctx context.Context, c cid.Cid, user string, pas
 	}
 
 	if unpin {
-		return d.refCounter.Decr(c.String())
+		if err := d.refCounter.Decr(c.String()); err != nil {
+			return err
+		}
+		d.releaseUser(user, c.String(), true)
 	}
 	return nil
 }
@@ -174,6 +283,9 @@ func (d *dagPoolService) GetSize(ctx context.Context, c cid.Cid, user string, pa
 	} else if !has {
 		return 0, format.ErrNotFound{Cid: c}
 	}
+	if b, ok := d.blockCache.Get(c); ok {
+		return len(b.RawData()), nil
+	}
 
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -210,14 +322,18 @@ func (d *dagPoolService) QueryUser(qUser string, user string, password string) (
 	if !d.iam.CheckUser(user, password) {
 		return nil, upolicy.AccessDenied
 	}
-	if d.iam.IsAdmin(user) {
-		return d.iam.QueryUser(qUser)
-	}
 	// only query self config
-	if qUser != user {
+	if !d.iam.IsAdmin(user) && qUser != user {
 		return nil, upolicy.AccessDenied
 	}
-	return d.iam.QueryUser(qUser)
+	u, err := d.iam.QueryUser(qUser)
+	if err != nil {
+		return nil, err
+	}
+	if u.Used, err = d.usage.Usage(qUser); err != nil {
+		return nil, err
+	}
+	return u, nil
 }
 
 // UpdateUser update the user
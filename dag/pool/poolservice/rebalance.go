@@ -0,0 +1,410 @@
+package poolservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/filedag-project/filedag-storage/dag/config"
+	"github.com/filedag-project/filedag-storage/dag/node/dagnode"
+	"github.com/filedag-project/filedag-storage/dag/proto"
+	"github.com/filedag-project/filedag-storage/dag/slotsmgr"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+const (
+	// rebalanceThreshold is how many slots a dag node may deviate from its expected slots
+	// before the automatic rebalancing migrates the slots
+	rebalanceThreshold = slotsmgr.ClusterSlots / 100
+	// rebalanceRetryInterval is how long the automatic rebalancing waits before checking the slots again
+	rebalanceRetryInterval = 10 * time.Second
+	// rebalancePausedKey is set while the rebalancing is paused
+	rebalancePausedKey = "rebalance/paused"
+	// rebalancePauseReason is the reason of pausing the migration gate by PauseRebalance
+	rebalancePauseReason = "rebalance"
+	// maxSlotWeight bounds the configured weights so that the slots are calculated without overflow
+	maxSlotWeight = 1 << 32
+	gib           = 1 << 30
+)
+
+var ErrNoDagNodeToDrain = errors.New("the other dag nodes are draining, the slots of this dag node have nowhere to go")
+
+// rebalancer is the state of the automatic rebalancing
+type rebalancer struct {
+	sync.Mutex
+	paused bool
+	// pending is set until the slots are balanced by the weights
+	pending   bool
+	lastError string
+	notify    chan struct{}
+}
+
+// request asks the rebalance task to check the slots
+func (r *rebalancer) request() {
+	r.Lock()
+	r.pending = true
+	r.Unlock()
+	r.wake()
+}
+
+func (r *rebalancer) wake() {
+	select {
+	case r.notify <- struct{}{}:
+	default:
+	}
+}
+
+// migrationGate blocks the data migration of the slots while it is paused for any reason
+type migrationGate struct {
+	sync.Mutex
+	reasons map[string]struct{}
+	resumed chan struct{}
+}
+
+func newMigrationGate() *migrationGate {
+	resumed := make(chan struct{})
+	close(resumed)
+	return &migrationGate{reasons: make(map[string]struct{}), resumed: resumed}
+}
+
+func (g *migrationGate) pause(reason string) {
+	g.Lock()
+	defer g.Unlock()
+	if len(g.reasons) == 0 {
+		g.resumed = make(chan struct{})
+	}
+	g.reasons[reason] = struct{}{}
+}
+
+func (g *migrationGate) resume(reason string) {
+	g.Lock()
+	defer g.Unlock()
+	if _, ok := g.reasons[reason]; !ok {
+		return
+	}
+	delete(g.reasons, reason)
+	if len(g.reasons) == 0 {
+		close(g.resumed)
+	}
+}
+
+func (g *migrationGate) paused() bool {
+	g.Lock()
+	defer g.Unlock()
+	return len(g.reasons) != 0
+}
+
+// pausedBy returns whether the gate is paused for the reason
+func (g *migrationGate) pausedBy(reason string) bool {
+	g.Lock()
+	defer g.Unlock()
+	_, ok := g.reasons[reason]
+	return ok
+}
+
+// wait blocks until the gate is resumed or the context is done
+func (g *migrationGate) wait(ctx context.Context) error {
+	g.Lock()
+	resumed := g.resumed
+	g.Unlock()
+	select {
+	case <-resumed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// loadRebalance restores the paused rebalancing and the draining dag nodes
+func (d *dagPoolService) loadRebalance() error {
+	var paused bool
+	if err := d.db.Get(rebalancePausedKey, &paused); err != nil && err != leveldb.ErrNotFound {
+		return err
+	}
+	if paused {
+		d.rebalance.paused = true
+		d.migrationGate.pause(rebalancePauseReason)
+	}
+	drains, err := d.slotMigrateRepo.Drains(d.parentCtx)
+	if err != nil {
+		return err
+	}
+	for _, name := range drains {
+		d.draining[name] = true
+	}
+	return nil
+}
+
+// PauseRebalance stops the automatic rebalancing and the data migration of the slots until ResumeRebalance
+func (d *dagPoolService) PauseRebalance() error {
+	if err := d.db.Put(rebalancePausedKey, true); err != nil {
+		return err
+	}
+	d.rebalance.Lock()
+	d.rebalance.paused = true
+	d.rebalance.Unlock()
+	d.migrationGate.pause(rebalancePauseReason)
+	log.Infow("rebalance paused")
+	return nil
+}
+
+// ResumeRebalance resumes the automatic rebalancing and the data migration of the slots
+func (d *dagPoolService) ResumeRebalance() error {
+	if err := d.db.Delete(rebalancePausedKey); err != nil {
+		return err
+	}
+	d.rebalance.Lock()
+	d.rebalance.paused = false
+	d.rebalance.Unlock()
+	d.migrationGate.resume(rebalancePauseReason)
+	d.rebalance.wake()
+	log.Infow("rebalance resumed")
+	return nil
+}
+
+func (d *dagPoolService) rebalanceStatus() *proto.RebalanceStatus {
+	d.rebalance.Lock()
+	defer d.rebalance.Unlock()
+	return &proto.RebalanceStatus{
+		Enabled:   d.autoBalance,
+		Paused:    d.rebalance.paused,
+		Pending:   d.rebalance.pending,
+		LastError: d.rebalance.lastError,
+	}
+}
+
+// rebalanceTask balances the slots once a dag node is added or drained, if the automatic rebalancing is enabled
+func (d *dagPoolService) rebalanceTask(ctx context.Context) {
+	ticker := time.NewTicker(rebalanceRetryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.rebalance.notify:
+		case <-ticker.C:
+		}
+		d.tryRebalance()
+	}
+}
+
+func (d *dagPoolService) tryRebalance() {
+	if !d.autoBalance {
+		return
+	}
+	d.rebalance.Lock()
+	pending, paused := d.rebalance.pending, d.rebalance.paused
+	d.rebalance.Unlock()
+	if !pending || paused {
+		return
+	}
+
+	d.dagNodesLock.Lock()
+	defer d.dagNodesLock.Unlock()
+	// wait for the migration, the slots are checked again after it
+	if d.state == StateMigrating {
+		return
+	}
+	migrated, err := d.balanceSlots(rebalanceThreshold)
+
+	d.rebalance.Lock()
+	defer d.rebalance.Unlock()
+	if err != nil {
+		log.Warnw("rebalance error", "error", err)
+		d.rebalance.lastError = err.Error()
+		return
+	}
+	d.rebalance.lastError = ""
+	d.rebalance.pending = migrated
+}
+
+// drainDagNode migrates the slots of the dag node to the other dag nodes, the dag node is removed after that
+func (d *dagPoolService) drainDagNode(dagNodeName string) (*config.DagNodeConfig, error) {
+	nd := d.dagNodesMap[dagNodeName]
+	if d.draining[dagNodeName] {
+		return nd.GetConfig(), nil
+	}
+	hasOther := false
+	for name := range d.dagNodesMap {
+		if name != dagNodeName && !d.draining[name] {
+			hasOther = true
+			break
+		}
+	}
+	if !hasOther {
+		return nil, ErrNoDagNodeToDrain
+	}
+	if err := d.slotMigrateRepo.SetDrain(dagNodeName); err != nil {
+		return nil, err
+	}
+	d.draining[dagNodeName] = true
+	log.Infow("drain dagnode", "dagnode", dagNodeName, "slots", nd.GetNumSlots())
+	d.rebalance.request()
+	return nd.GetConfig(), nil
+}
+
+// finishDrains removes the draining dag nodes whose slots are all migrated
+func (d *dagPoolService) finishDrains() {
+	drains, err := d.slotMigrateRepo.Drains(d.parentCtx)
+	if err != nil {
+		log.Errorw("load drains error", "error", err)
+		return
+	}
+	d.dagNodesLock.Lock()
+	defer d.dagNodesLock.Unlock()
+	for _, name := range drains {
+		if nd, ok := d.dagNodesMap[name]; ok {
+			if nd.GetNumSlots() != 0 {
+				continue
+			}
+			if _, err = d.removeDagNode(name); err != nil {
+				log.Errorw("remove drained dagnode error", "dagnode", name, "error", err)
+				continue
+			}
+		}
+		if err = d.slotMigrateRepo.RemoveDrain(name); err != nil {
+			log.Errorw("remove drain error", "dagnode", name, "error", err)
+			continue
+		}
+		delete(d.draining, name)
+		log.Infow("drain finished", "dagnode", name)
+	}
+}
+
+// leavingDagNodes returns the dag nodes which are draining or restriped, they are expected to own no slot
+func (d *dagPoolService) leavingDagNodes() (map[string]bool, error) {
+	restripes, err := d.slotMigrateRepo.Restripes(d.parentCtx)
+	if err != nil {
+		return nil, err
+	}
+	leaving := make(map[string]bool, len(d.draining)+len(restripes))
+	for name := range d.draining {
+		leaving[name] = true
+	}
+	for from := range restripes {
+		leaving[from] = true
+	}
+	return leaving, nil
+}
+
+// slotWeights returns the weights of the dag nodes, which are the configured weights or else the raw capacity
+// of their data nodes in GiB if the automatic rebalancing is enabled. All the dag nodes weigh the same
+// if no weight is configured and the weight of some dag node is unknown
+func (d *dagPoolService) slotWeights(nameList []string) ([]uint64, error) {
+	weights := make([]uint64, len(nameList))
+	configured := false
+	unknown := ""
+	for i, name := range nameList {
+		node := d.dagNodesMap[name]
+		if w := node.GetConfig().Weight; w > 0 {
+			if w > maxSlotWeight {
+				return nil, fmt.Errorf("the weight of dagnode[%v] exceeds %d", name, uint64(maxSlotWeight))
+			}
+			weights[i] = w
+			configured = true
+			continue
+		}
+		// the capacity weighs the dag nodes only if it is opted in by the automatic rebalancing
+		if !d.autoBalance {
+			unknown = name
+			continue
+		}
+		capacity, ok := rawCapacity(node)
+		if !ok {
+			unknown = name
+			continue
+		}
+		weights[i] = capacity
+	}
+	if unknown != "" {
+		if configured {
+			if !d.autoBalance {
+				return nil, fmt.Errorf("the weight of dagnode[%v] is not configured, please configure the weights of all the dagnodes", unknown)
+			}
+			return nil, fmt.Errorf("the capacity of dagnode[%v] is unknown, please configure its weight", unknown)
+		}
+		for i := range weights {
+			weights[i] = 1
+		}
+	}
+	return weights, nil
+}
+
+// rawCapacity sums the disk capacity of the data nodes in GiB, it is unknown until all the data nodes report
+func rawCapacity(node *dagnode.DagNode) (uint64, bool) {
+	var total uint64
+	for _, sn := range node.Nodes() {
+		stat := sn.DiskStat()
+		if stat.UpdatedAt.IsZero() {
+			return 0, false
+		}
+		total += stat.Total
+	}
+	if total < gib {
+		return 1, true
+	}
+	if total/gib > maxSlotWeight {
+		return maxSlotWeight, true
+	}
+	return total / gib, true
+}
+
+// statusWeights returns the weights and the expected slots of the dag nodes reported by Status,
+// they are missing if the weights can not be calculated
+func (d *dagPoolService) statusWeights() (map[string]uint64, map[string]int) {
+	weights := make(map[string]uint64)
+	leaving, err := d.leavingDagNodes()
+	if err != nil {
+		return weights, nil
+	}
+	var nameList []string
+	for name := range d.dagNodesMap {
+		if !leaving[name] {
+			nameList = append(nameList, name)
+		}
+	}
+	sort.Strings(nameList)
+	ws, err := d.slotWeights(nameList)
+	if err != nil {
+		return weights, nil
+	}
+	for i, name := range nameList {
+		weights[name] = ws[i]
+	}
+	return weights, expectedSlots(nameList, ws)
+}
+
+// expectedSlots divides the slots by the weights using the largest remainder method,
+// the dag nodes earlier in the name list win the ties
+func expectedSlots(nameList []string, weights []uint64) map[string]int {
+	var total uint64
+	for _, w := range weights {
+		total += w
+	}
+	expected := make(map[string]int, len(nameList))
+	if total == 0 {
+		return expected
+	}
+	remainders := make([]uint64, len(nameList))
+	left := slotsmgr.ClusterSlots
+	for i, name := range nameList {
+		share := uint64(slotsmgr.ClusterSlots) * weights[i]
+		expected[name] = int(share / total)
+		remainders[i] = share % total
+		left -= expected[name]
+	}
+	order := make([]int, len(nameList))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return remainders[order[i]] > remainders[order[j]]
+	})
+	for _, i := range order[:left] {
+		expected[nameList[i]]++
+	}
+	return expected
+}
@@ -0,0 +1,109 @@
+package poolservice
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/filedag-project/filedag-storage/dag/config"
+	"github.com/filedag-project/filedag-storage/dag/node/dagnode"
+	"github.com/filedag-project/filedag-storage/dag/slotsmgr"
+)
+
+func TestExpectedSlots(t *testing.T) {
+	names := []string{"a", "b", "c"}
+	// the same weights split the slots as evenly as before, the first dag nodes get the remainder
+	expected := expectedSlots(names, []uint64{1, 1, 1})
+	if expected["a"] != 5462 || expected["b"] != 5461 || expected["c"] != 5461 {
+		t.Fatalf("unexpected even split %v", expected)
+	}
+
+	expected = expectedSlots(names, []uint64{1000, 3000, 4000})
+	if expected["a"] != 2048 || expected["b"] != 6144 || expected["c"] != 8192 {
+		t.Fatalf("unexpected weighted split %v", expected)
+	}
+
+	expected = expectedSlots(names, []uint64{3, 3, 1})
+	sum := 0
+	for _, n := range expected {
+		sum += n
+	}
+	if sum != slotsmgr.ClusterSlots {
+		t.Fatalf("all the slots should be expected, got %d", sum)
+	}
+	if expected["a"] != expected["b"] || expected["c"] > expected["a"]/3+1 {
+		t.Fatalf("unexpected weighted split %v", expected)
+	}
+}
+
+func TestSlotWeights(t *testing.T) {
+	d := &dagPoolService{dagNodesMap: make(map[string]*dagnode.DagNode)}
+	for _, name := range []string{"a", "b"} {
+		nd, err := dagnode.NewDagNode(config.DagNodeConfig{
+			Name: name, Nodes: []string{"127.0.0.1:1"}, DataBlocks: 1,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		d.dagNodesMap[name] = nd
+	}
+	names := []string{"a", "b"}
+	// the dag nodes without weights share the slots equally
+	for _, autoBalance := range []bool{false, true} {
+		d.autoBalance = autoBalance
+		weights, err := d.slotWeights(names)
+		if err != nil || weights[0] != 1 || weights[1] != 1 {
+			t.Fatalf("expect the equal weights with autoBalance %v, got %v, err: %v", autoBalance, weights, err)
+		}
+	}
+
+	cfg := d.dagNodesMap["a"].GetConfig()
+	cfg.Weight = 3
+	d.dagNodesMap["a"], _ = dagnode.NewDagNode(*cfg)
+	d.autoBalance = false
+	if _, err := d.slotWeights(names); err == nil {
+		t.Fatal("the weights of all the dag nodes should be configured without the automatic rebalancing")
+	}
+	weights, err := d.slotWeights([]string{"a"})
+	if err != nil || weights[0] != 3 {
+		t.Fatalf("expect the configured weight, got %v, err: %v", weights, err)
+	}
+}
+
+func TestMigrationGate(t *testing.T) {
+	g := newMigrationGate()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := g.wait(ctx); err != nil {
+		t.Fatalf("the gate should be open: %v", err)
+	}
+
+	g.pause("a")
+	g.pause("b")
+	done := make(chan error, 1)
+	go func() {
+		done <- g.wait(ctx)
+	}()
+	g.resume("a")
+	select {
+	case err := <-done:
+		t.Fatalf("the gate is still paused by b, wait returned %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	g.resume("b")
+	if err := <-done; err != nil {
+		t.Fatalf("the gate should be resumed: %v", err)
+	}
+	if g.paused() {
+		t.Fatal("the gate should not be paused")
+	}
+	// resuming twice is harmless
+	g.resume("b")
+
+	g.pause("a")
+	shortCtx, shortCancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer shortCancel()
+	if err := g.wait(shortCtx); err == nil {
+		t.Fatal("the paused gate should block until the context is done")
+	}
+}
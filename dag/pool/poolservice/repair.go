@@ -2,10 +2,14 @@ package poolservice
 
 import (
 	"context"
+	"errors"
 	"github.com/filedag-project/filedag-storage/dag/node/dagnode"
+	"github.com/filedag-project/filedag-storage/dag/proto"
+	"sort"
+	"time"
 )
 
-func (d *dagPoolService) RepairDataNode(ctx context.Context, dagNodeName string, fromNodeIndex int, repairNodeIndex int) error {
+func (d *dagPoolService) RepairDataNode(ctx context.Context, dagNodeName string, fromNodeIndex int, repairNodeIndex int, startAfter string) error {
 	node, ok := func() (*dagnode.DagNode, bool) {
 		d.dagNodesLock.RLock()
 		defer d.dagNodesLock.RUnlock()
@@ -16,5 +20,198 @@ func (d *dagPoolService) RepairDataNode(ctx context.Context, dagNodeName string,
 		return ErrDagNodeNotFound
 	}
 
-	return node.RepairDataNode(ctx, fromNodeIndex, repairNodeIndex)
+	return node.RepairDataNode(ctx, fromNodeIndex, repairNodeIndex, startAfter, nil)
+}
+
+// ReplaceDataNode replaces the data node at the index of the dag node with a new address,
+// the shards of the new data node are rebuilt in the background
+func (d *dagPoolService) ReplaceDataNode(ctx context.Context, dagNodeName string, index int, rpcAddress string) error {
+	d.dagNodesLock.Lock()
+	defer d.dagNodesLock.Unlock()
+
+	node, ok := d.dagNodesMap[dagNodeName]
+	if !ok {
+		return ErrDagNodeNotFound
+	}
+	cfg, err := d.loadConfig()
+	if err != nil {
+		return err
+	}
+	if index < 0 || index >= len(node.GetConfig().Nodes) {
+		return errors.New("index greater than max index of nodes")
+	}
+	oldAddress := node.GetConfig().Nodes[index]
+
+	// update local config
+	oldVersion := cfg.Version
+	cfg.Version += 1
+	for i := range cfg.Cluster {
+		if cfg.Cluster[i].Config.Name == dagNodeName {
+			cfg.Cluster[i].Config.Nodes[index] = rpcAddress
+			break
+		}
+	}
+	if err = d.saveConfig(cfg); err != nil {
+		return err
+	}
+	rollback := func() {
+		cfg.Version = oldVersion
+		for i := range cfg.Cluster {
+			if cfg.Cluster[i].Config.Name == dagNodeName {
+				cfg.Cluster[i].Config.Nodes[index] = oldAddress
+				break
+			}
+		}
+		if rerr := d.saveConfig(cfg); rerr != nil {
+			log.Errorw("rollback cluster config error", "dagnode", dagNodeName, "index", index, "error", rerr)
+		}
+	}
+	if err = d.repairRepo.SetRebuild(dagNodeName, index); err != nil {
+		rollback()
+		return err
+	}
+	if err = node.ReplaceDataNode(ctx, index, rpcAddress); err != nil {
+		rollback()
+		if rerr := d.repairRepo.RemoveRebuild(dagNodeName, index); rerr != nil {
+			log.Errorw("rollback rebuild error", "dagnode", dagNodeName, "index", index, "error", rerr)
+		}
+		return err
+	}
+	log.Infow("replace data node", "dagnode", dagNodeName, "index", index, "old", oldAddress, "new", rpcAddress)
+	go d.rebuildDataNode(node, index, "")
+	return nil
+}
+
+// rebuildDataNode rebuilds the replaced data node after the cursor, it retries until success or the pool is closed.
+// The progress is saved, so that the rebuilding resumes where it stopped after a restart.
+func (d *dagPoolService) rebuildDataNode(node *dagnode.DagNode, index int, cursor string) {
+	name := node.GetConfig().Name
+	onProgress := func(c string) {
+		cursor = c
+		if err := d.repairRepo.SetRebuildCursor(name, index, c); err != nil {
+			log.Warnw("save rebuild progress error", "dagnode", name, "index", index, "error", err)
+		}
+	}
+	for {
+		err := node.RebuildDataNode(d.parentCtx, index, cursor, onProgress)
+		if err == nil {
+			if err = d.repairRepo.RemoveRebuild(name, index); err != nil {
+				log.Errorw("remove rebuild error", "dagnode", name, "index", index, "error", err)
+			}
+			return
+		}
+		log.Errorw("rebuild data node error", "dagnode", name, "index", index, "error", err)
+		select {
+		case <-d.parentCtx.Done():
+			return
+		case <-time.After(time.Minute):
+		}
+	}
+}
+
+// resumeRebuilds restarts the rebuilding of the data nodes which were interrupted
+func (d *dagPoolService) resumeRebuilds() error {
+	entries, err := d.repairRepo.Rebuilds(d.parentCtx)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		node, ok := d.dagNodesMap[entry.DagNodeName]
+		if !ok || entry.Index >= len(node.GetConfig().Nodes) {
+			log.Warnw("the rebuilding data node no longer exists", "dagnode", entry.DagNodeName, "index", entry.Index)
+			if err = d.repairRepo.RemoveRebuild(entry.DagNodeName, entry.Index); err != nil {
+				return err
+			}
+			continue
+		}
+		go d.rebuildDataNode(node, entry.Index, entry.Cursor)
+	}
+	return nil
+}
+
+// removeRepairTask removes the repair task of the block which is no longer stored in the dag node
+func (d *dagPoolService) removeRepairTask(node *dagnode.DagNode, key string) {
+	if err := d.repairRepo.Remove(node.GetConfig().Name, key); err != nil {
+		log.Warnw("remove repair task error", "dagnode", node.GetConfig().Name, "key", key, "error", err)
+	}
+}
+
+// repairDagNodes returns the dag nodes with the name, or all the dag nodes if the name is empty
+func (d *dagPoolService) repairDagNodes(dagNodeName string) ([]*dagnode.DagNode, error) {
+	d.dagNodesLock.RLock()
+	defer d.dagNodesLock.RUnlock()
+	if dagNodeName != "" {
+		nd, ok := d.dagNodesMap[dagNodeName]
+		if !ok {
+			return nil, ErrDagNodeNotFound
+		}
+		return []*dagnode.DagNode{nd}, nil
+	}
+	var names []string
+	for name := range d.dagNodesMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	nodes := make([]*dagnode.DagNode, 0, len(names))
+	for _, name := range names {
+		nodes = append(nodes, d.dagNodesMap[name])
+	}
+	return nodes, nil
+}
+
+// ListRepairTasks lists the pending repair tasks in priority order, at most limit tasks are returned if limit is positive
+func (d *dagPoolService) ListRepairTasks(ctx context.Context, dagNodeName string, limit int) (*proto.ListRepairTasksReply, error) {
+	nodes, err := d.repairDagNodes(dagNodeName)
+	if err != nil {
+		return nil, err
+	}
+	reply := &proto.ListRepairTasksReply{}
+	for _, nd := range nodes {
+		name := nd.GetConfig().Name
+		tasks, err := d.repairRepo.RepairTasks(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for task := range tasks {
+			reply.Total++
+			if limit > 0 && len(reply.Tasks) >= limit {
+				continue
+			}
+			reply.Tasks = append(reply.Tasks, &proto.RepairTask{
+				DagNodeName: name,
+				Key:         task.Key,
+				LostShards:  int32(task.LostShards),
+				Attempts:    int32(task.Attempts),
+				NextAttempt: task.NextAttempt / int64(time.Second),
+				LastError:   task.LastError,
+			})
+		}
+	}
+	return reply, ctx.Err()
+}
+
+// DrainRepairTasks runs all the pending repair tasks at once, or removes them if discard is set
+func (d *dagPoolService) DrainRepairTasks(ctx context.Context, dagNodeName string, discard bool) (*proto.DrainRepairTasksReply, error) {
+	nodes, err := d.repairDagNodes(dagNodeName)
+	if err != nil {
+		return nil, err
+	}
+	reply := &proto.DrainRepairTasksReply{}
+	for _, nd := range nodes {
+		if !discard {
+			repaired, failed, err := nd.DrainRepairTasks(ctx)
+			reply.Repaired += int64(repaired)
+			reply.Failed += int64(failed)
+			if err != nil {
+				return reply, err
+			}
+			continue
+		}
+		discarded, err := nd.DiscardRepairTasks(ctx)
+		reply.Discarded += int64(discarded)
+		if err != nil {
+			return reply, err
+		}
+	}
+	return reply, ctx.Err()
 }
@@ -0,0 +1,204 @@
+package repairrepo
+
+import (
+	"context"
+	"fmt"
+	"github.com/filedag-project/filedag-storage/dag/node/dagnode"
+	"github.com/filedag-project/filedag-storage/objectservice/objmetadb"
+	"github.com/syndtr/goleveldb/leveldb"
+	"sync"
+)
+
+const (
+	// RepairPrefix is the prefix of the tasks, ordered by priority
+	RepairPrefix = "repair/"
+	// RepairKeyPrefix is the prefix of the index from the key to the number of lost shards
+	RepairKeyPrefix = "repairkey/"
+	// RebuildPrefix is the prefix of the data nodes being rebuilt
+	RebuildPrefix = "rebuild/"
+
+	maxPriority = 999
+)
+
+// RepairRepo saves the repair tasks of the dag nodes.
+// The tasks are stored as repair/<dagnode>/<priority>/<key>, the smaller priority is repaired first.
+type RepairRepo struct {
+	db objmetadb.ObjStoreMetaDBAPI
+	lk sync.Mutex
+}
+
+func NewRepairRepo(db objmetadb.ObjStoreMetaDBAPI) *RepairRepo {
+	return &RepairRepo{db: db}
+}
+
+func taskKey(dagNodeName string, lostShards int, key string) string {
+	priority := maxPriority - lostShards
+	if priority < 0 {
+		priority = 0
+	}
+	return fmt.Sprintf("%s%s/%03d/%s", RepairPrefix, dagNodeName, priority, key)
+}
+
+func indexKey(dagNodeName string, key string) string {
+	return fmt.Sprintf("%s%s/%s", RepairKeyPrefix, dagNodeName, key)
+}
+
+// PushRepairTask adds a task, the task of the same key is merged and keeps the larger number of lost shards
+func (r *RepairRepo) PushRepairTask(dagNodeName string, key string, lostShards int) error {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	var oldLost int
+	err := r.db.Get(indexKey(dagNodeName, key), &oldLost)
+	if err != nil && err != leveldb.ErrNotFound {
+		return err
+	}
+	task := &dagnode.RepairTask{Key: key, LostShards: lostShards}
+	batch := new(objmetadb.Batch)
+	if err == nil {
+		if oldLost >= lostShards {
+			return nil
+		}
+		if err = r.db.Get(taskKey(dagNodeName, oldLost, key), task); err != nil && err != leveldb.ErrNotFound {
+			return err
+		}
+		batch.Delete(taskKey(dagNodeName, oldLost, key))
+		task.LostShards = lostShards
+	}
+	if err = batch.Put(taskKey(dagNodeName, lostShards, key), task); err != nil {
+		return err
+	}
+	if err = batch.Put(indexKey(dagNodeName, key), lostShards); err != nil {
+		return err
+	}
+	return r.db.Write(batch)
+}
+
+// UpdateRepairTask saves the retry state of the task
+func (r *RepairRepo) UpdateRepairTask(dagNodeName string, task *dagnode.RepairTask) error {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	var lost int
+	if err := r.db.Get(indexKey(dagNodeName, task.Key), &lost); err != nil {
+		if err == leveldb.ErrNotFound {
+			// removed meanwhile
+			return nil
+		}
+		return err
+	}
+	if lost != task.LostShards {
+		// pushed again with more lost shards, keep the new one
+		return nil
+	}
+	return r.db.Put(taskKey(dagNodeName, task.LostShards, task.Key), task)
+}
+
+// RemoveRepairTask removes the task
+func (r *RepairRepo) RemoveRepairTask(dagNodeName string, task *dagnode.RepairTask) error {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	var lost int
+	if err := r.db.Get(indexKey(dagNodeName, task.Key), &lost); err != nil {
+		if err == leveldb.ErrNotFound {
+			return nil
+		}
+		return err
+	}
+	if lost != task.LostShards {
+		// pushed again with more lost shards, it must be repaired again
+		return nil
+	}
+	return r.removeTask(dagNodeName, lost, task.Key)
+}
+
+// Remove removes the task of the key if exists
+func (r *RepairRepo) Remove(dagNodeName string, key string) error {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	var lost int
+	if err := r.db.Get(indexKey(dagNodeName, key), &lost); err != nil {
+		if err == leveldb.ErrNotFound {
+			return nil
+		}
+		return err
+	}
+	return r.removeTask(dagNodeName, lost, key)
+}
+
+// removeTask removes the task and its index at once
+func (r *RepairRepo) removeTask(dagNodeName string, lostShards int, key string) error {
+	batch := new(objmetadb.Batch)
+	batch.Delete(taskKey(dagNodeName, lostShards, key))
+	batch.Delete(indexKey(dagNodeName, key))
+	return r.db.Write(batch)
+}
+
+// RepairTasks lists the tasks of the dag node, the tasks which lost more shards come first
+func (r *RepairRepo) RepairTasks(ctx context.Context, dagNodeName string) (<-chan *dagnode.RepairTask, error) {
+	all, err := r.db.ReadAllChan(ctx, fmt.Sprintf("%s%s/", RepairPrefix, dagNodeName), "")
+	if err != nil {
+		return nil, err
+	}
+	kc := make(chan *dagnode.RepairTask)
+	go func() {
+		defer close(kc)
+		for entry := range all {
+			task := &dagnode.RepairTask{}
+			if err := entry.UnmarshalValue(task); err != nil {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case kc <- task:
+			}
+		}
+	}()
+	return kc, nil
+}
+
+// RebuildEntry is a data node being rebuilt
+type RebuildEntry struct {
+	DagNodeName string
+	Index       int
+	// Cursor is the last rebuilt key, the rebuilding resumes after it
+	Cursor string
+}
+
+// SetRebuild records that the data node at the index of the dag node is being rebuilt
+func (r *RepairRepo) SetRebuild(dagNodeName string, index int) error {
+	return r.db.Put(fmt.Sprintf("%s%s/%d", RebuildPrefix, dagNodeName, index), &RebuildEntry{
+		DagNodeName: dagNodeName,
+		Index:       index,
+	})
+}
+
+// SetRebuildCursor records the progress of the rebuilding data node
+func (r *RepairRepo) SetRebuildCursor(dagNodeName string, index int, cursor string) error {
+	return r.db.Put(fmt.Sprintf("%s%s/%d", RebuildPrefix, dagNodeName, index), &RebuildEntry{
+		DagNodeName: dagNodeName,
+		Index:       index,
+		Cursor:      cursor,
+	})
+}
+
+// RemoveRebuild removes the record of the rebuilt data node
+func (r *RepairRepo) RemoveRebuild(dagNodeName string, index int) error {
+	return r.db.Delete(fmt.Sprintf("%s%s/%d", RebuildPrefix, dagNodeName, index))
+}
+
+// Rebuilds lists the data nodes being rebuilt
+func (r *RepairRepo) Rebuilds(ctx context.Context) ([]*RebuildEntry, error) {
+	all, err := r.db.ReadAllChan(ctx, RebuildPrefix, "")
+	if err != nil {
+		return nil, err
+	}
+	var entries []*RebuildEntry
+	for entry := range all {
+		en := &RebuildEntry{}
+		if err = entry.UnmarshalValue(en); err != nil {
+			return nil, err
+		}
+		entries = append(entries, en)
+	}
+	return entries, ctx.Err()
+}
@@ -0,0 +1,85 @@
+package poolservice
+
+import (
+	"fmt"
+	"github.com/filedag-project/filedag-storage/dag/config"
+	"github.com/filedag-project/filedag-storage/dag/node/dagnode"
+)
+
+// RestripeDagNode moves all the data of the dag node to a new dag node with another erasure layout,
+// such as from 2+1 to 4+2. A layout with one data block, such as 1+2, is plain replication,
+// the new dag node stores a full replica of each block on all its data nodes.
+// The blocks are re-encoded in the background by migrating the slots, the blocks not migrated yet
+// are still read from the old dag node, which is removed after all the slots are migrated.
+func (d *dagPoolService) RestripeDagNode(fromDagNodeName string, toConfig *config.DagNodeConfig) error {
+	d.dagNodesLock.Lock()
+	defer d.dagNodesLock.Unlock()
+
+	if d.state != StateOk {
+		if d.state == StateMigrating {
+			return ErrClusterMigrating
+		}
+		return ErrClusterAvailable
+	}
+	fromNode, ok := d.dagNodesMap[fromDagNodeName]
+	if !ok {
+		return ErrDagNodeNotFound
+	}
+	if fromNode.GetNumSlots() == 0 {
+		return fmt.Errorf("dagnode[%v] has no slot", fromDagNodeName)
+	}
+	if _, err := dagnode.NewErasure(toConfig.DataBlocks, toConfig.ParityBlocks, 1); err != nil {
+		return fmt.Errorf("invalid layout %d+%d, at least one data block and one parity block or extra replica are needed: %v",
+			toConfig.DataBlocks, toConfig.ParityBlocks, err)
+	}
+
+	toNode, err := d.startNewDagNode(toConfig)
+	if err != nil {
+		return err
+	}
+	if err = d.slotMigrateRepo.SetRestripe(fromDagNodeName, toConfig.Name); err != nil {
+		// rollback
+		delete(d.dagNodesMap, toConfig.Name)
+		toNode.Close()
+		return err
+	}
+	// the new dag node is saved in the cluster config with the migrating slots
+	if err = d.migrateSlotsByName(fromDagNodeName, toConfig.Name, fromNode.GetSlotPairs()); err != nil {
+		// rollback
+		if rerr := d.slotMigrateRepo.RemoveRestripe(fromDagNodeName); rerr != nil {
+			log.Errorw("rollback restripe error", "dagnode", fromDagNodeName, "error", rerr)
+		}
+		delete(d.dagNodesMap, toConfig.Name)
+		toNode.Close()
+		return err
+	}
+	log.Infow("restripe dagnode", "from", fromDagNodeName, "to", toConfig.Name,
+		"dataBlocks", toConfig.DataBlocks, "parityBlocks", toConfig.ParityBlocks)
+
+	// start to migrate data
+	select {
+	case d.migratingCh <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// finishRestripes removes the dag nodes whose data have been moved to the new dag nodes
+func (d *dagPoolService) finishRestripes() {
+	restripes, err := d.slotMigrateRepo.Restripes(d.parentCtx)
+	if err != nil {
+		log.Errorw("load restripes error", "error", err)
+		return
+	}
+	for from, to := range restripes {
+		if _, err = d.RemoveDagNode(from); err != nil && err != ErrDagNodeNotFound {
+			log.Errorw("remove restriped dagnode error", "dagnode", from, "error", err)
+			continue
+		}
+		if err = d.slotMigrateRepo.RemoveRestripe(from); err != nil {
+			log.Errorw("remove restripe error", "dagnode", from, "error", err)
+			continue
+		}
+		log.Infow("restripe finished", "from", from, "to", to)
+	}
+}
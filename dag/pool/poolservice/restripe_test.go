@@ -0,0 +1,199 @@
+package poolservice
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/filedag-project/filedag-storage/dag/config"
+	"github.com/filedag-project/filedag-storage/dag/node/dagnode"
+	"github.com/filedag-project/filedag-storage/dag/node/datanode"
+	blocks "github.com/ipfs/go-block-format"
+)
+
+func TestRestripeDagNode(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	user, pass := "dagpool", "dagpool"
+	service, err := NewDagPoolService(ctx, config.PoolConfig{
+		LeveldbPath:  t.TempDir(),
+		RootUser:     user,
+		RootPassword: pass,
+		GcPeriod:     time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go service.GC(ctx)
+	defer service.Close()
+	if err = service.AddDagNode(&config.DagNodeConfig{
+		Name:         "dagnode1",
+		Nodes:        startTestDataNodes(t, 3),
+		DataBlocks:   2,
+		ParityBlocks: 1,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err = service.BalanceSlots(); err != nil {
+		t.Fatal(err)
+	}
+	waitDagNodeOnline(t, service, "dagnode1")
+
+	var blks []blocks.Block
+	for i := 0; i < 20; i++ {
+		b := blocks.NewBlock(bytes.Repeat([]byte(fmt.Sprintf("restripe block %d ", i)), 100*(i+1)))
+		if err = service.Add(ctx, b, user, pass, true); err != nil {
+			t.Fatal(err)
+		}
+		blks = append(blks, b)
+	}
+
+	// hold the migration, so the blocks are still stored in the old layout
+	if err = service.PauseRebalance(); err != nil {
+		t.Fatal(err)
+	}
+	if err = service.RestripeDagNode("dagnode1", &config.DagNodeConfig{
+		Name:         "dagnode2",
+		Nodes:        startTestDataNodes(t, 5),
+		DataBlocks:   3,
+		ParityBlocks: 2,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	waitDagNodeOnline(t, service, "dagnode2")
+	oldNode, newNode := testDagNode(service, "dagnode1"), testDagNode(service, "dagnode2")
+	for _, b := range blks {
+		checkTestBlock(t, service, b)
+		got, err := oldNode.Get(ctx, b.Cid())
+		if err != nil || !bytes.Equal(got.RawData(), b.RawData()) {
+			t.Fatalf("read block %v in the old layout: %v", b.Cid(), err)
+		}
+		if _, err = newNode.Get(ctx, b.Cid()); err == nil {
+			t.Fatalf("block %v is migrated while the migration is paused", b.Cid())
+		}
+	}
+
+	if err = service.ResumeRebalance(); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Minute)
+	for testDagNode(service, "dagnode1") != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("the restripe is not finished")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	for _, b := range blks {
+		checkTestBlock(t, service, b)
+		got, err := newNode.Get(ctx, b.Cid())
+		if err != nil || !bytes.Equal(got.RawData(), b.RawData()) {
+			t.Fatalf("read block %v in the new layout: %v", b.Cid(), err)
+		}
+	}
+	if cfg := newNode.GetConfig(); cfg.DataBlocks != 3 || cfg.ParityBlocks != 2 || newNode.GetNumSlots() == 0 {
+		t.Fatalf("unexpected restriped dagnode: %d+%d with %d slots", cfg.DataBlocks, cfg.ParityBlocks, newNode.GetNumSlots())
+	}
+
+	// restripe to plain replication
+	if err = service.RestripeDagNode("dagnode2", &config.DagNodeConfig{
+		Name:         "dagnode3",
+		Nodes:        startTestDataNodes(t, 3),
+		DataBlocks:   1,
+		ParityBlocks: 2,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	waitDagNodeOnline(t, service, "dagnode3")
+	replicaNode := testDagNode(service, "dagnode3")
+	deadline = time.Now().Add(time.Minute)
+	for testDagNode(service, "dagnode2") != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("the restripe to replicas is not finished")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	for _, b := range blks {
+		checkTestBlock(t, service, b)
+		for _, sn := range replicaNode.Nodes() {
+			_, got, err := sn.Client.GetStream(ctx, b.Cid().String())
+			if err != nil || !bytes.Equal(got, b.RawData()) {
+				t.Fatalf("data node %s should keep a full replica of block %v: %v", sn.RpcAddress, b.Cid(), err)
+			}
+		}
+	}
+	if err = service.RestripeDagNode("dagnode3", &config.DagNodeConfig{
+		Name:         "dagnode4",
+		Nodes:        startTestDataNodes(t, 1),
+		DataBlocks:   1,
+		ParityBlocks: 0,
+	}); err == nil {
+		t.Fatal("a single replica should be rejected")
+	}
+}
+
+// startTestDataNodes starts the data nodes on free ports, and returns their addresses once they are listening
+func startTestDataNodes(t *testing.T, n int) []string {
+	var addrs []string
+	for i := 0; i < n; i++ {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		addr := lis.Addr().String()
+		lis.Close()
+		go datanode.StartDataNodeServer(datanode.ServerConfig{Listen: addr, KVType: datanode.KVMutcask, DataDirs: []string{t.TempDir()}})
+		addrs = append(addrs, addr)
+	}
+	for _, addr := range addrs {
+		deadline := time.Now().Add(10 * time.Second)
+		for {
+			conn, err := net.Dial("tcp", addr)
+			if err == nil {
+				conn.Close()
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("data node %s is not started: %v", addr, err)
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+	return addrs
+}
+
+func testDagNode(d *dagPoolService, name string) *dagnode.DagNode {
+	d.dagNodesLock.RLock()
+	defer d.dagNodesLock.RUnlock()
+	return d.dagNodesMap[name]
+}
+
+// waitDagNodeOnline waits for the first heartbeat of the data nodes of the dag node
+func waitDagNodeOnline(t *testing.T, d *dagPoolService, name string) {
+	deadline := time.Now().Add(10 * time.Second)
+	for _, sn := range testDagNode(d, name).Nodes() {
+		for {
+			hb := sn.Heartbeat()
+			if !hb.CheckedAt.IsZero() && hb.FailedAt.IsZero() {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("data node %s is offline: %s", sn.RpcAddress, hb.LastError)
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+}
+
+// checkTestBlock reads the block through the dag pool, skipping the block cache
+func checkTestBlock(t *testing.T, d *dagPoolService, b blocks.Block) {
+	got, err := d.readBlock(context.Background(), b.Cid())
+	if err != nil {
+		t.Fatalf("read block %v: %v", b.Cid(), err)
+	}
+	if !bytes.Equal(got.RawData(), b.RawData()) {
+		t.Fatalf("read block %v: the data is different", b.Cid())
+	}
+}
@@ -0,0 +1,62 @@
+package poolservice
+
+import (
+	"context"
+	"fmt"
+	"github.com/filedag-project/filedag-storage/dag/node/dagnode"
+	"github.com/filedag-project/filedag-storage/dag/slotsmgr"
+	"strconv"
+	"strings"
+)
+
+// scrubKeySource returns the keys stored in the dag node, ordered by slot and key.
+// The cursor is formatted as "<slot>/<key>".
+func (d *dagPoolService) scrubKeySource(dagNodeName string) dagnode.ScrubKeySource {
+	return func(ctx context.Context, cursor string) (<-chan *dagnode.ScrubEntry, <-chan error, error) {
+		var startSlot uint64
+		var seekKey string
+		if cursor != "" {
+			strs := strings.SplitN(cursor, "/", 2)
+			if len(strs) != 2 {
+				return nil, nil, fmt.Errorf("invalid scrub cursor: %v", cursor)
+			}
+			slot, err := strconv.ParseUint(strs[0], 10, 16)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid scrub cursor: %v", cursor)
+			}
+			startSlot, seekKey = slot, strs[1]
+		}
+		ch := make(chan *dagnode.ScrubEntry)
+		errs := make(chan error, 1)
+		go func() {
+			defer close(errs)
+			defer close(ch)
+			for slot := startSlot; slot < slotsmgr.ClusterSlots; slot++ {
+				all, err := d.slotKeyRepo.AllKeysChan(ctx, uint16(slot), seekKey)
+				if err != nil {
+					errs <- fmt.Errorf("read the keys of slot %v: %w", slot, err)
+					return
+				}
+				for entry := range all {
+					// the cursor itself has been scrubbed already
+					if entry.Key == seekKey || entry.Value != dagNodeName {
+						continue
+					}
+					select {
+					case <-ctx.Done():
+						return
+					case ch <- &dagnode.ScrubEntry{
+						Cursor: fmt.Sprintf("%v/%s", slot, entry.Key),
+						Key:    entry.Key,
+					}:
+					}
+				}
+				if ctx.Err() != nil {
+					return
+				}
+				seekKey = ""
+			}
+		}()
+		return ch, errs, nil
+	}
+}
@@ -0,0 +1,32 @@
+package scrubrepo
+
+import (
+	"fmt"
+	"github.com/filedag-project/filedag-storage/objectservice/objmetadb"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+const ScrubPrefix = "scrub/"
+
+// ScrubRepo saves the scrub progress of the dag nodes.
+type ScrubRepo struct {
+	db objmetadb.ObjStoreMetaDBAPI
+}
+
+func NewScrubRepo(db objmetadb.ObjStoreMetaDBAPI) *ScrubRepo {
+	return &ScrubRepo{db: db}
+}
+
+// LoadScrubProgress returns the cursor of the dag node, it is empty if the scrubber has never run
+func (s *ScrubRepo) LoadScrubProgress(dagNodeName string) (cursor string, err error) {
+	err = s.db.Get(fmt.Sprintf("%s%s", ScrubPrefix, dagNodeName), &cursor)
+	if err == leveldb.ErrNotFound {
+		return "", nil
+	}
+	return cursor, err
+}
+
+// SaveScrubProgress saves the cursor of the dag node
+func (s *ScrubRepo) SaveScrubProgress(dagNodeName string, cursor string) error {
+	return s.db.Put(fmt.Sprintf("%s%s", ScrubPrefix, dagNodeName), cursor)
+}
@@ -84,3 +84,57 @@ func (s *SlotMigrateRepo) AllKeysChan(ctx context.Context) (<-chan *Entry, error
 
 	return kc, nil
 }
+
+// RestripePrefix is the prefix of the dag nodes being re-encoded into another dag node
+const RestripePrefix = "restripe/"
+
+// SetRestripe records that all the data of the dag node is moving to another dag node
+func (s *SlotMigrateRepo) SetRestripe(fromDagNodeName string, toDagNodeName string) error {
+	return s.db.Put(fmt.Sprintf("%s%s", RestripePrefix, fromDagNodeName), toDagNodeName)
+}
+
+func (s *SlotMigrateRepo) RemoveRestripe(fromDagNodeName string) error {
+	return s.db.Delete(fmt.Sprintf("%s%s", RestripePrefix, fromDagNodeName))
+}
+
+// Restripes returns the dag nodes being re-encoded, mapping from the source to the target
+func (s *SlotMigrateRepo) Restripes(ctx context.Context) (map[string]string, error) {
+	all, err := s.db.ReadAllChan(ctx, RestripePrefix, "")
+	if err != nil {
+		return nil, err
+	}
+	restripes := make(map[string]string)
+	for entry := range all {
+		var val string
+		if err = entry.UnmarshalValue(&val); err != nil {
+			return nil, err
+		}
+		restripes[strings.TrimPrefix(entry.Key, RestripePrefix)] = val
+	}
+	return restripes, ctx.Err()
+}
+
+// DrainPrefix is the prefix of the dag nodes whose slots are moving to the other dag nodes before removing them
+const DrainPrefix = "drain/"
+
+// SetDrain records that the dag node is removed once its slots are migrated
+func (s *SlotMigrateRepo) SetDrain(dagNodeName string) error {
+	return s.db.Put(fmt.Sprintf("%s%s", DrainPrefix, dagNodeName), dagNodeName)
+}
+
+func (s *SlotMigrateRepo) RemoveDrain(dagNodeName string) error {
+	return s.db.Delete(fmt.Sprintf("%s%s", DrainPrefix, dagNodeName))
+}
+
+// Drains returns the names of the dag nodes being drained
+func (s *SlotMigrateRepo) Drains(ctx context.Context) ([]string, error) {
+	all, err := s.db.ReadAllChan(ctx, DrainPrefix, "")
+	if err != nil {
+		return nil, err
+	}
+	var drains []string
+	for entry := range all {
+		drains = append(drains, strings.TrimPrefix(entry.Key, DrainPrefix))
+	}
+	return drains, ctx.Err()
+}
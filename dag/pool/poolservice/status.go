@@ -1,5 +1,343 @@
 package poolservice
 
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/filedag-project/filedag-storage/dag/node/dagnode"
+	"github.com/filedag-project/filedag-storage/dag/pool/poolservice/slotkeyrepo"
+	"github.com/filedag-project/filedag-storage/dag/proto"
+	"github.com/filedag-project/filedag-storage/dag/slotsmgr"
+)
+
+const (
+	// maxStateTransitions is the number of the latest cluster state transitions kept
+	maxStateTransitions = 32
+	// keyCountInterval is how long the key counts are reported before they are counted again
+	keyCountInterval = 10 * time.Minute
+	// repairCountInterval is how long the repair task counts are reported before they are counted again
+	repairCountInterval = 30 * time.Second
+	// repairDepthTimeout bounds counting the repair tasks of a dag node
+	repairDepthTimeout = 10 * time.Second
+)
+
+type stateTransition struct {
+	from, to ClusterState
+	at       time.Time
+}
+
+// gcStats is the stats of the last finished GC run, they are kept while the next run is in progress
+type gcStats struct {
+	running         bool
+	start, finish   time.Time
+	checked         uint64
+	removed, failed uint64
+	lastError       string
+}
+
+// slotMigration is the progress of migrating a slot
+type slotMigration struct {
+	from, to         string
+	done             bool
+	migrated, failed uint64
+}
+
+// statusTracker keeps the states reported by Status which are not kept by the cluster itself
+type statusTracker struct {
+	sync.Mutex
+	transitions []stateTransition
+	gc          gcStats
+	// migrations is the progress of the slots of the latest migration
+	migrations map[uint16]*slotMigration
+	// keyCounts is the number of keys of each dag node, counted in the background at keysCountedAt
+	keyCounts     map[string]uint64
+	keysCountedAt time.Time
+	counting      bool
+	// repairDepths is the number of repair tasks of each dag node, counted in the background at repairsCountedAt
+	repairDepths     map[string]int64
+	repairsCountedAt time.Time
+	countingRepairs  bool
+}
+
+// setState changes the cluster state and records the transition, the caller holds dagNodesLock
+func (d *dagPoolService) setState(state ClusterState) {
+	if d.state == state {
+		return
+	}
+	log.Infow("cluster state changes", "from", d.state, "to", state)
+	d.status.Lock()
+	d.status.transitions = append(d.status.transitions, stateTransition{from: d.state, to: state, at: time.Now()})
+	if len(d.status.transitions) > maxStateTransitions {
+		d.status.transitions = d.status.transitions[len(d.status.transitions)-maxStateTransitions:]
+	}
+	d.status.Unlock()
+	d.state = state
+}
+
+// startMigration tracks the progress of the slots migrated from a dag node to another,
+// the progress of the former migration is dropped if reset
+func (st *statusTracker) startMigration(from, to string, pairs []slotsmgr.SlotPair, reset bool) {
+	st.Lock()
+	defer st.Unlock()
+	if reset || st.migrations == nil {
+		st.migrations = make(map[uint16]*slotMigration)
+	}
+	for _, pair := range pairs {
+		for slot := pair.Start; slot <= pair.End; slot++ {
+			st.migrations[uint16(slot)] = &slotMigration{from: from, to: to}
+		}
+	}
+}
+
+// resumeMigration tracks a slot whose migration is resumed after restarting
+func (st *statusTracker) resumeMigration(slot uint16, from, to string) {
+	st.Lock()
+	defer st.Unlock()
+	if st.migrations == nil {
+		st.migrations = make(map[uint16]*slotMigration)
+	}
+	st.migrations[slot] = &slotMigration{from: from, to: to}
+}
+
+func (st *statusTracker) migrateKey(slot uint16, ok bool) {
+	st.Lock()
+	defer st.Unlock()
+	if m := st.migrations[slot]; m != nil {
+		if ok {
+			m.migrated++
+		} else {
+			m.failed++
+		}
+	}
+}
+
+func (st *statusTracker) migrateSlotDone(slot uint16) {
+	st.Lock()
+	defer st.Unlock()
+	if m := st.migrations[slot]; m != nil {
+		m.done = true
+	}
+}
+
+func (st *statusTracker) gcStarted() {
+	st.Lock()
+	defer st.Unlock()
+	st.gc.running = true
+	st.gc.start = time.Now()
+}
+
+func (st *statusTracker) gcFinished(checked, removed, failed uint64, err error) {
+	st.Lock()
+	defer st.Unlock()
+	st.gc.running = false
+	st.gc.finish = time.Now()
+	st.gc.checked, st.gc.removed, st.gc.failed = checked, removed, failed
+	st.gc.lastError = ""
+	if err != nil {
+		st.gc.lastError = err.Error()
+	}
+}
+
+// migrationStatus groups the consecutive slots migrated between the same dag nodes
+func (st *statusTracker) migrationStatus() []*proto.MigrationStatus {
+	st.Lock()
+	defer st.Unlock()
+	slots := make([]int, 0, len(st.migrations))
+	for slot := range st.migrations {
+		slots = append(slots, int(slot))
+	}
+	sort.Ints(slots)
+	var ret []*proto.MigrationStatus
+	var last *proto.MigrationStatus
+	for _, slot := range slots {
+		m := st.migrations[uint16(slot)]
+		if last == nil || last.FromDagNode != m.from || last.ToDagNode != m.to || last.Pair.End+1 != uint32(slot) {
+			last = &proto.MigrationStatus{
+				FromDagNode: m.from,
+				ToDagNode:   m.to,
+				Pair:        &proto.SlotPair{Start: uint32(slot), End: uint32(slot)},
+			}
+			ret = append(ret, last)
+		}
+		last.Pair.End = uint32(slot)
+		if m.done {
+			last.DoneSlots++
+		}
+		last.MigratedKeys += m.migrated
+		last.FailedKeys += m.failed
+	}
+	return ret
+}
+
+func (st *statusTracker) gcStatus() *proto.GcStatus {
+	st.Lock()
+	defer st.Unlock()
+	return &proto.GcStatus{
+		Running:    st.gc.running,
+		LastStart:  unixTime(st.gc.start),
+		LastFinish: unixTime(st.gc.finish),
+		Checked:    st.gc.checked,
+		Removed:    st.gc.removed,
+		Failed:     st.gc.failed,
+		LastError:  st.gc.lastError,
+	}
+}
+
+func (st *statusTracker) stateTransitions() []*proto.StateTransition {
+	st.Lock()
+	defer st.Unlock()
+	ret := make([]*proto.StateTransition, 0, len(st.transitions))
+	for _, t := range st.transitions {
+		ret = append(ret, &proto.StateTransition{From: t.from.String(), To: t.to.String(), Time: t.at.Unix()})
+	}
+	return ret
+}
+
+// keyCounts returns the last key counts of the dag nodes, they are counted again in the background once stale
+func (d *dagPoolService) keyCounts() (map[string]uint64, time.Time) {
+	d.status.Lock()
+	defer d.status.Unlock()
+	if !d.status.counting && time.Since(d.status.keysCountedAt) > keyCountInterval {
+		d.status.counting = true
+		go d.countKeys(d.parentCtx)
+	}
+	return d.status.keyCounts, d.status.keysCountedAt
+}
+
+// countKeys counts the keys of each dag node by scanning the keys of all the slots
+func (d *dagPoolService) countKeys(ctx context.Context) {
+	counts := make(map[string]uint64)
+	var err error
+	for slot := 0; slot < slotsmgr.ClusterSlots && err == nil; slot++ {
+		var ch <-chan *slotkeyrepo.SlotKeyEntry
+		if ch, err = d.slotKeyRepo.AllKeysChan(ctx, uint16(slot), ""); err != nil {
+			break
+		}
+		for entry := range ch {
+			counts[entry.Value]++
+		}
+		err = ctx.Err()
+	}
+	d.status.Lock()
+	defer d.status.Unlock()
+	d.status.counting = false
+	if err != nil {
+		log.Warnw("count keys error", "error", err)
+		return
+	}
+	d.status.keyCounts = counts
+	d.status.keysCountedAt = time.Now()
+}
+
+func unixTime(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// genStatusDescription explains the cluster state for the operators
 func (d *dagPoolService) genStatusDescription() string {
-	return "not implemented"
+	switch d.state {
+	case StateOk:
+		return "all the slots are served"
+	case StateMigrating:
+		var pending int
+		for _, from := range d.importingSlotsFrom {
+			if from != nil {
+				pending++
+			}
+		}
+		if d.migrationGate.paused() {
+			return fmt.Sprintf("the migration is paused, %d slots are pending", pending)
+		}
+		d.migrationCtl.Lock()
+		aborting := d.migrationCtl.aborting
+		d.migrationCtl.Unlock()
+		if aborting {
+			return fmt.Sprintf("aborting the migration, %d slots are migrating back", pending)
+		}
+		return fmt.Sprintf("migrating the slots, %d slots are pending", pending)
+	case StateFail:
+		var unassigned []slotsmgr.SlotPair
+		for slot, node := range d.slots {
+			if node != nil {
+				continue
+			}
+			if n := len(unassigned); n > 0 && unassigned[n-1].End+1 == uint64(slot) {
+				unassigned[n-1].End = uint64(slot)
+			} else {
+				unassigned = append(unassigned, slotsmgr.SlotPair{Start: uint64(slot), End: uint64(slot)})
+			}
+		}
+		if len(unassigned) == 0 {
+			return "the cluster is unavailable"
+		}
+		desc := "the cluster is unavailable, the slots are not allocated:"
+		for _, pair := range unassigned {
+			desc += fmt.Sprintf(" [%s]", pair)
+		}
+		return desc
+	}
+	return "unknown"
+}
+
+// dataNodeHealth reports the heartbeats and the disk usage of the data nodes of a dag node
+func dataNodeHealth(node *dagnode.DagNode) []*proto.DataNodeHealth {
+	nodes := node.Nodes()
+	ret := make([]*proto.DataNodeHealth, 0, len(nodes))
+	for _, sn := range nodes {
+		hb := sn.Heartbeat()
+		stat := sn.DiskStat()
+		ret = append(ret, &proto.DataNodeHealth{
+			RpcAddress:    sn.RpcAddress,
+			Healthy:       sn.State,
+			LastHeartbeat: unixTime(hb.CheckedAt),
+			LastError:     hb.LastError,
+			LastErrorAt:   unixTime(hb.FailedAt),
+			Keys:          stat.Keys,
+			ReadOnly:      stat.ReadOnly,
+			Rebuilding:    sn.IsRebuilding(),
+		})
+	}
+	return ret
+}
+
+// repairQueueDepths returns the last repair task counts of the dag nodes, they are counted again in the background once stale
+func (d *dagPoolService) repairQueueDepths(nodes []*dagnode.DagNode) map[string]int64 {
+	d.status.Lock()
+	defer d.status.Unlock()
+	if !d.status.countingRepairs && time.Since(d.status.repairsCountedAt) > repairCountInterval {
+		d.status.countingRepairs = true
+		go d.countRepairTasks(nodes)
+	}
+	return d.status.repairDepths
+}
+
+// countRepairTasks counts the repair tasks of each dag node
+func (d *dagPoolService) countRepairTasks(nodes []*dagnode.DagNode) {
+	depths := make(map[string]int64, len(nodes))
+	for _, node := range nodes {
+		depths[node.GetConfig().Name] = d.repairQueueDepth(node)
+	}
+	d.status.Lock()
+	defer d.status.Unlock()
+	d.status.countingRepairs = false
+	d.status.repairDepths = depths
+	d.status.repairsCountedAt = time.Now()
+}
+
+// repairQueueDepth counts the repair tasks of a dag node, -1 if they can not be counted
+func (d *dagPoolService) repairQueueDepth(node *dagnode.DagNode) int64 {
+	ctx, cancel := context.WithTimeout(d.parentCtx, repairDepthTimeout)
+	defer cancel()
+	depth, err := node.RepairQueueDepth(ctx)
+	if err != nil {
+		log.Warnw("count repair tasks error", "dagnode", node.GetConfig().Name, "error", err)
+		return -1
+	}
+	return int64(depth)
 }
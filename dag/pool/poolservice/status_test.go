@@ -0,0 +1,57 @@
+package poolservice
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/filedag-project/filedag-storage/dag/slotsmgr"
+)
+
+func TestStatusTracker(t *testing.T) {
+	d := &dagPoolService{}
+	d.setState(StateMigrating)
+	d.setState(StateMigrating)
+	d.setState(StateOk)
+	ts := d.status.stateTransitions()
+	if len(ts) != 2 || ts[0].From != "ok" || ts[0].To != "migrating" || ts[1].To != "ok" {
+		t.Fatalf("unexpected transitions %v", ts)
+	}
+	for i := 0; i < maxStateTransitions; i++ {
+		d.setState(ClusterState(i % 3))
+	}
+	if n := len(d.status.stateTransitions()); n != maxStateTransitions {
+		t.Fatalf("transitions should be trimmed to %d, got %d", maxStateTransitions, n)
+	}
+
+	d.status.startMigration("a", "b", []slotsmgr.SlotPair{{Start: 0, End: 9}, {Start: 20, End: 29}}, true)
+	d.status.migrateKey(0, true)
+	d.status.migrateKey(0, true)
+	d.status.migrateKey(25, false)
+	d.status.migrateSlotDone(0)
+	d.status.migrateSlotDone(1)
+	// the slots of another migration are not tracked
+	d.status.migrateKey(15, true)
+	ms := d.status.migrationStatus()
+	if len(ms) != 2 {
+		t.Fatalf("the slots should be grouped into 2 ranges, got %v", ms)
+	}
+	if ms[0].Pair.Start != 0 || ms[0].Pair.End != 9 || ms[0].DoneSlots != 2 || ms[0].MigratedKeys != 2 {
+		t.Fatalf("unexpected progress %v", ms[0])
+	}
+	if ms[1].Pair.Start != 20 || ms[1].Pair.End != 29 || ms[1].DoneSlots != 0 || ms[1].FailedKeys != 1 {
+		t.Fatalf("unexpected progress %v", ms[1])
+	}
+
+	// the stats of the last run are kept while the next run is in progress
+	d.status.gcStarted()
+	d.status.gcFinished(10, 3, 1, nil)
+	d.status.gcStarted()
+	if gc := d.status.gcStatus(); !gc.Running || gc.Checked != 10 || gc.Removed != 3 || gc.Failed != 1 {
+		t.Fatalf("unexpected gc status %v", gc)
+	}
+
+	d.setState(StateFail)
+	if desc := d.genStatusDescription(); !strings.Contains(desc, "[0-16383]") {
+		t.Fatalf("the unallocated slots should be described: %s", desc)
+	}
+}
@@ -0,0 +1,99 @@
+package poolservice
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/filedag-project/filedag-storage/dag/pool/poolservice/dpuser"
+	"github.com/filedag-project/filedag-storage/dag/pool/poolservice/slotkeyrepo"
+	"github.com/ipfs/go-cid"
+	format "github.com/ipfs/go-ipld-format"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// backfillRetryInterval is how long the backfill of the usage waits after an error
+const backfillRetryInterval = time.Minute
+
+// backfillBatchSize is the number of blocks charged at a time by the backfill of the usage
+const backfillBatchSize = 100
+
+// backfillUsageTask charges the blocks stored before the usage accounting to the admin user,
+// it resumes after the last block charged until all the blocks are charged
+func (d *dagPoolService) backfillUsageTask(ctx context.Context) {
+	for {
+		err := d.backfillUsage(ctx)
+		if err == nil || ctx.Err() != nil {
+			return
+		}
+		log.Warnw("backfill usage error", "error", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backfillRetryInterval):
+		}
+	}
+}
+
+func (d *dagPoolService) backfillUsage(ctx context.Context) error {
+	cursor, done, err := d.usage.BackfillState()
+	if err != nil || done {
+		return err
+	}
+	log.Infow("backfill usage", "cursor", cursor)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	all, err := d.db.ReadAllChan(ctx, slotkeyrepo.SlotPrefix, cursor)
+	if err != nil {
+		return err
+	}
+	var batch []dpuser.BackfillBlock
+	lastKey := ""
+	for entry := range all {
+		slotKey := entry.GetKey()
+		if slotKey == cursor {
+			continue
+		}
+		key := slotKey[strings.LastIndex(slotKey, "/")+1:]
+		pins, err := d.refCounter.Get(key)
+		if err != nil && err != leveldb.ErrNotFound {
+			return err
+		}
+		cached, err := d.cacheSet.Has(key)
+		if err != nil {
+			return err
+		}
+		var size int
+		if pins > 0 || cached {
+			c, err := cid.Decode(key)
+			if err != nil {
+				return err
+			}
+			if size, err = d.readBlockSize(ctx, c); err != nil {
+				if !format.IsNotFound(err) {
+					return err
+				}
+				pins, cached = 0, false
+			}
+		}
+		batch = append(batch, dpuser.BackfillBlock{Key: key, Size: uint64(size), Pins: pins, Cached: cached})
+		lastKey = slotKey
+		if len(batch) == backfillBatchSize {
+			if err = d.usage.Backfill(ctx, batch, lastKey); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	if len(batch) > 0 {
+		if err = d.usage.Backfill(ctx, batch, lastKey); err != nil {
+			return err
+		}
+	}
+	log.Infow("backfill usage finished")
+	return d.usage.FinishBackfill()
+}
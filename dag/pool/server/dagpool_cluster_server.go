@@ -50,6 +50,17 @@ func (s *DagPoolClusterServer) MigrateSlots(ctx context.Context, req *proto.Migr
 	return &emptypb.Empty{}, nil
 }
 
+func (s *DagPoolClusterServer) RestripeDagNode(ctx context.Context, req *proto.RestripeDagNodeReq) (*emptypb.Empty, error) {
+	if req.ToDagNode == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "the new dag node is required")
+	}
+	cfg := utils.ToDagNodeConfig(req.ToDagNode)
+	if err := s.Cluster.RestripeDagNode(req.FromDagNodeName, cfg); err != nil {
+		return nil, status.Errorf(codes.Unknown, err.Error())
+	}
+	return &emptypb.Empty{}, nil
+}
+
 func (s *DagPoolClusterServer) BalanceSlots(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
 	if err := s.Cluster.BalanceSlots(); err != nil {
 		return nil, status.Errorf(codes.Unknown, err.Error())
@@ -57,6 +68,48 @@ func (s *DagPoolClusterServer) BalanceSlots(context.Context, *emptypb.Empty) (*e
 	return &emptypb.Empty{}, nil
 }
 
+func (s *DagPoolClusterServer) PauseRebalance(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
+	if err := s.Cluster.PauseRebalance(); err != nil {
+		return nil, status.Errorf(codes.Unknown, err.Error())
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *DagPoolClusterServer) ResumeRebalance(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
+	if err := s.Cluster.ResumeRebalance(); err != nil {
+		return nil, status.Errorf(codes.Unknown, err.Error())
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *DagPoolClusterServer) SetMigrationRate(ctx context.Context, req *proto.MigrationRateReq) (*emptypb.Empty, error) {
+	if err := s.Cluster.SetMigrationRate(req.BytesPerSecond, req.KeysPerSecond); err != nil {
+		return nil, status.Errorf(codes.Unknown, err.Error())
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *DagPoolClusterServer) PauseMigration(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
+	if err := s.Cluster.PauseMigration(); err != nil {
+		return nil, status.Errorf(codes.Unknown, err.Error())
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *DagPoolClusterServer) ResumeMigration(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
+	if err := s.Cluster.ResumeMigration(); err != nil {
+		return nil, status.Errorf(codes.Unknown, err.Error())
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *DagPoolClusterServer) AbortMigration(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
+	if err := s.Cluster.AbortMigration(); err != nil {
+		return nil, status.Errorf(codes.Unknown, err.Error())
+	}
+	return &emptypb.Empty{}, nil
+}
+
 func (s *DagPoolClusterServer) Status(context.Context, *emptypb.Empty) (*proto.StatusReply, error) {
 	st, err := s.Cluster.Status()
 	if err != nil {
@@ -66,8 +119,31 @@ func (s *DagPoolClusterServer) Status(context.Context, *emptypb.Empty) (*proto.S
 }
 
 func (s *DagPoolClusterServer) RepairDataNode(ctx context.Context, req *proto.RepairDataNodeReq) (*emptypb.Empty, error) {
-	if err := s.Cluster.RepairDataNode(ctx, req.DagNodeName, int(req.FromNodeIndex), int(req.RepairNodeIndex)); err != nil {
+	if err := s.Cluster.RepairDataNode(ctx, req.DagNodeName, int(req.FromNodeIndex), int(req.RepairNodeIndex), req.StartAfter); err != nil {
 		return nil, status.Errorf(codes.Unknown, err.Error())
 	}
 	return &emptypb.Empty{}, nil
 }
+
+func (s *DagPoolClusterServer) ReplaceDataNode(ctx context.Context, req *proto.ReplaceDataNodeReq) (*emptypb.Empty, error) {
+	if err := s.Cluster.ReplaceDataNode(ctx, req.DagNodeName, int(req.NodeIndex), req.RpcAddress); err != nil {
+		return nil, status.Errorf(codes.Unknown, err.Error())
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *DagPoolClusterServer) ListRepairTasks(ctx context.Context, req *proto.ListRepairTasksReq) (*proto.ListRepairTasksReply, error) {
+	reply, err := s.Cluster.ListRepairTasks(ctx, req.DagNodeName, int(req.Limit))
+	if err != nil {
+		return nil, status.Errorf(codes.Unknown, err.Error())
+	}
+	return reply, nil
+}
+
+func (s *DagPoolClusterServer) DrainRepairTasks(ctx context.Context, req *proto.DrainRepairTasksReq) (*proto.DrainRepairTasksReply, error) {
+	reply, err := s.Cluster.DrainRepairTasks(ctx, req.DagNodeName, req.Discard)
+	if err != nil {
+		return nil, status.Errorf(codes.Unknown, err.Error())
+	}
+	return reply, nil
+}
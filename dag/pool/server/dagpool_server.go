@@ -11,6 +11,8 @@ import (
 	"github.com/ipfs/go-cid"
 	logging "github.com/ipfs/go-log/v2"
 	"golang.org/x/xerrors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var log = logging.Logger("dag-pool-server")
@@ -27,6 +29,9 @@ func (s *DagPoolServer) Add(ctx context.Context, in *proto.AddReq) (*proto.AddRe
 	data := blocks.NewBlock(in.GetBlock())
 	err := s.DagPool.Add(ctx, data, in.User.User, in.User.Password, in.Pin)
 	if err != nil {
+		if xerrors.Is(err, dpuser.ErrQuotaExceeded) {
+			return &proto.AddReply{Cid: cid.Undef.String()}, status.Error(codes.ResourceExhausted, err.Error())
+		}
 		return &proto.AddReply{Cid: cid.Undef.String()}, err
 	}
 	return &proto.AddReply{Cid: data.Cid().String()}, nil
@@ -104,7 +109,7 @@ func (s *DagPoolServer) QueryUser(ctx context.Context, in *proto.QueryUserReq) (
 	if err != nil {
 		return &proto.QueryUserReply{}, err
 	}
-	return &proto.QueryUserReply{Username: user.Username, Policy: string(user.Policy), Capacity: user.Capacity}, nil
+	return &proto.QueryUserReply{Username: user.Username, Policy: string(user.Policy), Capacity: user.Capacity, Used: user.Used}, nil
 }
 
 //UpdateUser is used to update a user from the dag pool server
@@ -368,6 +368,16 @@ type DagPoolClusterClient interface {
 	BalanceSlots(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	Status(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*StatusReply, error)
 	RepairDataNode(ctx context.Context, in *RepairDataNodeReq, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ListRepairTasks(ctx context.Context, in *ListRepairTasksReq, opts ...grpc.CallOption) (*ListRepairTasksReply, error)
+	DrainRepairTasks(ctx context.Context, in *DrainRepairTasksReq, opts ...grpc.CallOption) (*DrainRepairTasksReply, error)
+	ReplaceDataNode(ctx context.Context, in *ReplaceDataNodeReq, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	RestripeDagNode(ctx context.Context, in *RestripeDagNodeReq, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	PauseRebalance(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ResumeRebalance(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	SetMigrationRate(ctx context.Context, in *MigrationRateReq, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	PauseMigration(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ResumeMigration(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	AbortMigration(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
 }
 
 type dagPoolClusterClient struct {
@@ -441,6 +451,96 @@ func (c *dagPoolClusterClient) RepairDataNode(ctx context.Context, in *RepairDat
 	return out, nil
 }
 
+func (c *dagPoolClusterClient) ListRepairTasks(ctx context.Context, in *ListRepairTasksReq, opts ...grpc.CallOption) (*ListRepairTasksReply, error) {
+	out := new(ListRepairTasksReply)
+	err := c.cc.Invoke(ctx, "/proto.DagPoolCluster/ListRepairTasks", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dagPoolClusterClient) DrainRepairTasks(ctx context.Context, in *DrainRepairTasksReq, opts ...grpc.CallOption) (*DrainRepairTasksReply, error) {
+	out := new(DrainRepairTasksReply)
+	err := c.cc.Invoke(ctx, "/proto.DagPoolCluster/DrainRepairTasks", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dagPoolClusterClient) ReplaceDataNode(ctx context.Context, in *ReplaceDataNodeReq, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/proto.DagPoolCluster/ReplaceDataNode", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dagPoolClusterClient) RestripeDagNode(ctx context.Context, in *RestripeDagNodeReq, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/proto.DagPoolCluster/RestripeDagNode", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dagPoolClusterClient) PauseRebalance(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/proto.DagPoolCluster/PauseRebalance", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dagPoolClusterClient) ResumeRebalance(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/proto.DagPoolCluster/ResumeRebalance", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dagPoolClusterClient) SetMigrationRate(ctx context.Context, in *MigrationRateReq, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/proto.DagPoolCluster/SetMigrationRate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dagPoolClusterClient) PauseMigration(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/proto.DagPoolCluster/PauseMigration", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dagPoolClusterClient) ResumeMigration(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/proto.DagPoolCluster/ResumeMigration", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dagPoolClusterClient) AbortMigration(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/proto.DagPoolCluster/AbortMigration", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DagPoolClusterServer is the server API for DagPoolCluster service.
 // All implementations must embed UnimplementedDagPoolClusterServer
 // for forward compatibility
@@ -452,6 +552,16 @@ type DagPoolClusterServer interface {
 	BalanceSlots(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
 	Status(context.Context, *emptypb.Empty) (*StatusReply, error)
 	RepairDataNode(context.Context, *RepairDataNodeReq) (*emptypb.Empty, error)
+	ListRepairTasks(context.Context, *ListRepairTasksReq) (*ListRepairTasksReply, error)
+	DrainRepairTasks(context.Context, *DrainRepairTasksReq) (*DrainRepairTasksReply, error)
+	ReplaceDataNode(context.Context, *ReplaceDataNodeReq) (*emptypb.Empty, error)
+	RestripeDagNode(context.Context, *RestripeDagNodeReq) (*emptypb.Empty, error)
+	PauseRebalance(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
+	ResumeRebalance(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
+	SetMigrationRate(context.Context, *MigrationRateReq) (*emptypb.Empty, error)
+	PauseMigration(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
+	ResumeMigration(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
+	AbortMigration(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
 	mustEmbedUnimplementedDagPoolClusterServer()
 }
 
@@ -480,6 +590,36 @@ func (UnimplementedDagPoolClusterServer) Status(context.Context, *emptypb.Empty)
 func (UnimplementedDagPoolClusterServer) RepairDataNode(context.Context, *RepairDataNodeReq) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RepairDataNode not implemented")
 }
+func (UnimplementedDagPoolClusterServer) ListRepairTasks(context.Context, *ListRepairTasksReq) (*ListRepairTasksReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRepairTasks not implemented")
+}
+func (UnimplementedDagPoolClusterServer) DrainRepairTasks(context.Context, *DrainRepairTasksReq) (*DrainRepairTasksReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DrainRepairTasks not implemented")
+}
+func (UnimplementedDagPoolClusterServer) ReplaceDataNode(context.Context, *ReplaceDataNodeReq) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReplaceDataNode not implemented")
+}
+func (UnimplementedDagPoolClusterServer) RestripeDagNode(context.Context, *RestripeDagNodeReq) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestripeDagNode not implemented")
+}
+func (UnimplementedDagPoolClusterServer) PauseRebalance(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PauseRebalance not implemented")
+}
+func (UnimplementedDagPoolClusterServer) ResumeRebalance(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResumeRebalance not implemented")
+}
+func (UnimplementedDagPoolClusterServer) SetMigrationRate(context.Context, *MigrationRateReq) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetMigrationRate not implemented")
+}
+func (UnimplementedDagPoolClusterServer) PauseMigration(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PauseMigration not implemented")
+}
+func (UnimplementedDagPoolClusterServer) ResumeMigration(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResumeMigration not implemented")
+}
+func (UnimplementedDagPoolClusterServer) AbortMigration(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AbortMigration not implemented")
+}
 func (UnimplementedDagPoolClusterServer) mustEmbedUnimplementedDagPoolClusterServer() {}
 
 // UnsafeDagPoolClusterServer may be embedded to opt out of forward compatibility for this service.
@@ -619,6 +759,186 @@ func _DagPoolCluster_RepairDataNode_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _DagPoolCluster_ListRepairTasks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRepairTasksReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DagPoolClusterServer).ListRepairTasks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.DagPoolCluster/ListRepairTasks",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DagPoolClusterServer).ListRepairTasks(ctx, req.(*ListRepairTasksReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DagPoolCluster_DrainRepairTasks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DrainRepairTasksReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DagPoolClusterServer).DrainRepairTasks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.DagPoolCluster/DrainRepairTasks",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DagPoolClusterServer).DrainRepairTasks(ctx, req.(*DrainRepairTasksReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DagPoolCluster_ReplaceDataNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReplaceDataNodeReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DagPoolClusterServer).ReplaceDataNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.DagPoolCluster/ReplaceDataNode",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DagPoolClusterServer).ReplaceDataNode(ctx, req.(*ReplaceDataNodeReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DagPoolCluster_RestripeDagNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestripeDagNodeReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DagPoolClusterServer).RestripeDagNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.DagPoolCluster/RestripeDagNode",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DagPoolClusterServer).RestripeDagNode(ctx, req.(*RestripeDagNodeReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DagPoolCluster_PauseRebalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DagPoolClusterServer).PauseRebalance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.DagPoolCluster/PauseRebalance",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DagPoolClusterServer).PauseRebalance(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DagPoolCluster_ResumeRebalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DagPoolClusterServer).ResumeRebalance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.DagPoolCluster/ResumeRebalance",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DagPoolClusterServer).ResumeRebalance(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DagPoolCluster_SetMigrationRate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MigrationRateReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DagPoolClusterServer).SetMigrationRate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.DagPoolCluster/SetMigrationRate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DagPoolClusterServer).SetMigrationRate(ctx, req.(*MigrationRateReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DagPoolCluster_PauseMigration_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DagPoolClusterServer).PauseMigration(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.DagPoolCluster/PauseMigration",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DagPoolClusterServer).PauseMigration(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DagPoolCluster_ResumeMigration_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DagPoolClusterServer).ResumeMigration(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.DagPoolCluster/ResumeMigration",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DagPoolClusterServer).ResumeMigration(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DagPoolCluster_AbortMigration_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DagPoolClusterServer).AbortMigration(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.DagPoolCluster/AbortMigration",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DagPoolClusterServer).AbortMigration(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // DagPoolCluster_ServiceDesc is the grpc.ServiceDesc for DagPoolCluster service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -654,6 +974,46 @@ var DagPoolCluster_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "RepairDataNode",
 			Handler:    _DagPoolCluster_RepairDataNode_Handler,
 		},
+		{
+			MethodName: "ListRepairTasks",
+			Handler:    _DagPoolCluster_ListRepairTasks_Handler,
+		},
+		{
+			MethodName: "DrainRepairTasks",
+			Handler:    _DagPoolCluster_DrainRepairTasks_Handler,
+		},
+		{
+			MethodName: "ReplaceDataNode",
+			Handler:    _DagPoolCluster_ReplaceDataNode_Handler,
+		},
+		{
+			MethodName: "RestripeDagNode",
+			Handler:    _DagPoolCluster_RestripeDagNode_Handler,
+		},
+		{
+			MethodName: "PauseRebalance",
+			Handler:    _DagPoolCluster_PauseRebalance_Handler,
+		},
+		{
+			MethodName: "ResumeRebalance",
+			Handler:    _DagPoolCluster_ResumeRebalance_Handler,
+		},
+		{
+			MethodName: "SetMigrationRate",
+			Handler:    _DagPoolCluster_SetMigrationRate_Handler,
+		},
+		{
+			MethodName: "PauseMigration",
+			Handler:    _DagPoolCluster_PauseMigration_Handler,
+		},
+		{
+			MethodName: "ResumeMigration",
+			Handler:    _DagPoolCluster_ResumeMigration_Handler,
+		},
+		{
+			MethodName: "AbortMigration",
+			Handler:    _DagPoolCluster_AbortMigration_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "dagpool.proto",
@@ -29,7 +29,13 @@ type DataNodeClient interface {
 	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	Size(ctx context.Context, in *SizeRequest, opts ...grpc.CallOption) (*SizeResponse, error)
 	DeleteMany(ctx context.Context, in *DeleteManyRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
-	AllKeysChan(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (DataNode_AllKeysChanClient, error)
+	AllKeysChan(ctx context.Context, in *AllKeysChanRequest, opts ...grpc.CallOption) (DataNode_AllKeysChanClient, error)
+	PutStream(ctx context.Context, opts ...grpc.CallOption) (DataNode_PutStreamClient, error)
+	GetStream(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (DataNode_GetStreamClient, error)
+	PutMany(ctx context.Context, in *PutManyRequest, opts ...grpc.CallOption) (*PutManyResponse, error)
+	GetMany(ctx context.Context, in *GetManyRequest, opts ...grpc.CallOption) (*GetManyResponse, error)
+	GetMetaMany(ctx context.Context, in *GetManyRequest, opts ...grpc.CallOption) (*GetMetaManyResponse, error)
+	Stat(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*StatResponse, error)
 }
 
 type dataNodeClient struct {
@@ -94,7 +100,7 @@ func (c *dataNodeClient) DeleteMany(ctx context.Context, in *DeleteManyRequest,
 	return out, nil
 }
 
-func (c *dataNodeClient) AllKeysChan(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (DataNode_AllKeysChanClient, error) {
+func (c *dataNodeClient) AllKeysChan(ctx context.Context, in *AllKeysChanRequest, opts ...grpc.CallOption) (DataNode_AllKeysChanClient, error) {
 	stream, err := c.cc.NewStream(ctx, &DataNode_ServiceDesc.Streams[0], "/proto.DataNode/AllKeysChan", opts...)
 	if err != nil {
 		return nil, err
@@ -126,6 +132,108 @@ func (x *dataNodeAllKeysChanClient) Recv() (*AllKeysChanResponse, error) {
 	return m, nil
 }
 
+func (c *dataNodeClient) PutStream(ctx context.Context, opts ...grpc.CallOption) (DataNode_PutStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &DataNode_ServiceDesc.Streams[1], "/proto.DataNode/PutStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &dataNodePutStreamClient{stream}
+	return x, nil
+}
+
+type DataNode_PutStreamClient interface {
+	Send(*PutStreamRequest) error
+	CloseAndRecv() (*emptypb.Empty, error)
+	grpc.ClientStream
+}
+
+type dataNodePutStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *dataNodePutStreamClient) Send(m *PutStreamRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *dataNodePutStreamClient) CloseAndRecv() (*emptypb.Empty, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(emptypb.Empty)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *dataNodeClient) GetStream(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (DataNode_GetStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &DataNode_ServiceDesc.Streams[2], "/proto.DataNode/GetStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &dataNodeGetStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type DataNode_GetStreamClient interface {
+	Recv() (*GetStreamResponse, error)
+	grpc.ClientStream
+}
+
+type dataNodeGetStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *dataNodeGetStreamClient) Recv() (*GetStreamResponse, error) {
+	m := new(GetStreamResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *dataNodeClient) PutMany(ctx context.Context, in *PutManyRequest, opts ...grpc.CallOption) (*PutManyResponse, error) {
+	out := new(PutManyResponse)
+	err := c.cc.Invoke(ctx, "/proto.DataNode/PutMany", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dataNodeClient) GetMany(ctx context.Context, in *GetManyRequest, opts ...grpc.CallOption) (*GetManyResponse, error) {
+	out := new(GetManyResponse)
+	err := c.cc.Invoke(ctx, "/proto.DataNode/GetMany", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dataNodeClient) GetMetaMany(ctx context.Context, in *GetManyRequest, opts ...grpc.CallOption) (*GetMetaManyResponse, error) {
+	out := new(GetMetaManyResponse)
+	err := c.cc.Invoke(ctx, "/proto.DataNode/GetMetaMany", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dataNodeClient) Stat(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*StatResponse, error) {
+	out := new(StatResponse)
+	err := c.cc.Invoke(ctx, "/proto.DataNode/Stat", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DataNodeServer is the server API for DataNode service.
 // All implementations must embed UnimplementedDataNodeServer
 // for forward compatibility
@@ -136,7 +244,13 @@ type DataNodeServer interface {
 	Delete(context.Context, *DeleteRequest) (*emptypb.Empty, error)
 	Size(context.Context, *SizeRequest) (*SizeResponse, error)
 	DeleteMany(context.Context, *DeleteManyRequest) (*emptypb.Empty, error)
-	AllKeysChan(*emptypb.Empty, DataNode_AllKeysChanServer) error
+	AllKeysChan(*AllKeysChanRequest, DataNode_AllKeysChanServer) error
+	PutStream(DataNode_PutStreamServer) error
+	GetStream(*GetRequest, DataNode_GetStreamServer) error
+	PutMany(context.Context, *PutManyRequest) (*PutManyResponse, error)
+	GetMany(context.Context, *GetManyRequest) (*GetManyResponse, error)
+	GetMetaMany(context.Context, *GetManyRequest) (*GetMetaManyResponse, error)
+	Stat(context.Context, *emptypb.Empty) (*StatResponse, error)
 	mustEmbedUnimplementedDataNodeServer()
 }
 
@@ -162,9 +276,27 @@ func (UnimplementedDataNodeServer) Size(context.Context, *SizeRequest) (*SizeRes
 func (UnimplementedDataNodeServer) DeleteMany(context.Context, *DeleteManyRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteMany not implemented")
 }
-func (UnimplementedDataNodeServer) AllKeysChan(*emptypb.Empty, DataNode_AllKeysChanServer) error {
+func (UnimplementedDataNodeServer) AllKeysChan(*AllKeysChanRequest, DataNode_AllKeysChanServer) error {
 	return status.Errorf(codes.Unimplemented, "method AllKeysChan not implemented")
 }
+func (UnimplementedDataNodeServer) PutStream(DataNode_PutStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method PutStream not implemented")
+}
+func (UnimplementedDataNodeServer) GetStream(*GetRequest, DataNode_GetStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetStream not implemented")
+}
+func (UnimplementedDataNodeServer) PutMany(context.Context, *PutManyRequest) (*PutManyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PutMany not implemented")
+}
+func (UnimplementedDataNodeServer) GetMany(context.Context, *GetManyRequest) (*GetManyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMany not implemented")
+}
+func (UnimplementedDataNodeServer) GetMetaMany(context.Context, *GetManyRequest) (*GetMetaManyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMetaMany not implemented")
+}
+func (UnimplementedDataNodeServer) Stat(context.Context, *emptypb.Empty) (*StatResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Stat not implemented")
+}
 func (UnimplementedDataNodeServer) mustEmbedUnimplementedDataNodeServer() {}
 
 // UnsafeDataNodeServer may be embedded to opt out of forward compatibility for this service.
@@ -287,7 +419,7 @@ func _DataNode_DeleteMany_Handler(srv interface{}, ctx context.Context, dec func
 }
 
 func _DataNode_AllKeysChan_Handler(srv interface{}, stream grpc.ServerStream) error {
-	m := new(emptypb.Empty)
+	m := new(AllKeysChanRequest)
 	if err := stream.RecvMsg(m); err != nil {
 		return err
 	}
@@ -307,6 +439,125 @@ func (x *dataNodeAllKeysChanServer) Send(m *AllKeysChanResponse) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _DataNode_PutStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(DataNodeServer).PutStream(&dataNodePutStreamServer{stream})
+}
+
+type DataNode_PutStreamServer interface {
+	SendAndClose(*emptypb.Empty) error
+	Recv() (*PutStreamRequest, error)
+	grpc.ServerStream
+}
+
+type dataNodePutStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *dataNodePutStreamServer) SendAndClose(m *emptypb.Empty) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *dataNodePutStreamServer) Recv() (*PutStreamRequest, error) {
+	m := new(PutStreamRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _DataNode_GetStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DataNodeServer).GetStream(m, &dataNodeGetStreamServer{stream})
+}
+
+type DataNode_GetStreamServer interface {
+	Send(*GetStreamResponse) error
+	grpc.ServerStream
+}
+
+type dataNodeGetStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *dataNodeGetStreamServer) Send(m *GetStreamResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _DataNode_PutMany_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PutManyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DataNodeServer).PutMany(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.DataNode/PutMany",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DataNodeServer).PutMany(ctx, req.(*PutManyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DataNode_GetMany_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetManyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DataNodeServer).GetMany(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.DataNode/GetMany",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DataNodeServer).GetMany(ctx, req.(*GetManyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DataNode_GetMetaMany_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetManyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DataNodeServer).GetMetaMany(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.DataNode/GetMetaMany",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DataNodeServer).GetMetaMany(ctx, req.(*GetManyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DataNode_Stat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DataNodeServer).Stat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.DataNode/Stat",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DataNodeServer).Stat(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // DataNode_ServiceDesc is the grpc.ServiceDesc for DataNode service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -338,6 +589,22 @@ var DataNode_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteMany",
 			Handler:    _DataNode_DeleteMany_Handler,
 		},
+		{
+			MethodName: "PutMany",
+			Handler:    _DataNode_PutMany_Handler,
+		},
+		{
+			MethodName: "GetMany",
+			Handler:    _DataNode_GetMany_Handler,
+		},
+		{
+			MethodName: "GetMetaMany",
+			Handler:    _DataNode_GetMetaMany_Handler,
+		},
+		{
+			MethodName: "Stat",
+			Handler:    _DataNode_Stat_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -345,6 +612,16 @@ var DataNode_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _DataNode_AllKeysChan_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "PutStream",
+			Handler:       _DataNode_PutStream_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "GetStream",
+			Handler:       _DataNode_GetStream_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "datanode.proto",
 }
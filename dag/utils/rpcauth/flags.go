@@ -0,0 +1,39 @@
+package rpcauth
+
+import "github.com/urfave/cli/v2"
+
+// EnvAuthToken is the environment variable of the shared token
+const EnvAuthToken = "FILEDAG_AUTH_TOKEN"
+
+// Flags returns the command line flags of the security configuration
+func Flags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "tls-cert",
+			Usage: "the certificate file of this process, enables TLS",
+		},
+		&cli.StringFlag{
+			Name:  "tls-key",
+			Usage: "the private key file of the certificate",
+		},
+		&cli.StringFlag{
+			Name:  "tls-ca",
+			Usage: "the CA file to verify the peers, enables mTLS on the servers",
+		},
+		&cli.StringFlag{
+			Name:    "auth-token",
+			Usage:   "the shared token between the servers and the clients, empty disables it",
+			EnvVars: []string{EnvAuthToken},
+		},
+	}
+}
+
+// ConfigFromCLI loads the security configuration from the flags
+func ConfigFromCLI(cctx *cli.Context) Config {
+	return Config{
+		CertFile: cctx.String("tls-cert"),
+		KeyFile:  cctx.String("tls-key"),
+		CAFile:   cctx.String("tls-ca"),
+		Token:    cctx.String("auth-token"),
+	}
+}
@@ -0,0 +1,175 @@
+package rpcauth
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"io/ioutil"
+	"strings"
+)
+
+const (
+	// authorizationKey is the metadata key of the token
+	authorizationKey = "authorization"
+	bearerPrefix     = "Bearer "
+)
+
+var errUnauthenticated = status.Error(codes.Unauthenticated, "invalid or missing token")
+
+// Config is the security configuration of the grpc servers and clients, the zero value keeps them insecure
+type Config struct {
+	// CertFile and KeyFile are the certificate of this process,
+	// used as the server certificate and as the client certificate of mTLS
+	CertFile string `json:"cert_file,omitempty"`
+	KeyFile  string `json:"key_file,omitempty"`
+	// CAFile is the CA to verify the peers, the servers require the client certificates if it is set
+	CAFile string `json:"ca_file,omitempty"`
+	// Token is the shared secret sent by the clients and checked by the servers, empty disables it
+	Token string `json:"token,omitempty"`
+}
+
+// TLSEnabled returns whether the connections are secured by TLS
+func (c Config) TLSEnabled() bool {
+	return c.CertFile != "" || c.CAFile != ""
+}
+
+func (c Config) loadCA() (*x509.CertPool, error) {
+	if c.CAFile == "" {
+		return nil, nil
+	}
+	pem, err := ioutil.ReadFile(c.CAFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificate found in %s", c.CAFile)
+	}
+	return pool, nil
+}
+
+func (c Config) loadCert() ([]tls.Certificate, error) {
+	if c.CertFile == "" && c.KeyFile == "" {
+		return nil, nil
+	}
+	if c.CertFile == "" || c.KeyFile == "" {
+		return nil, errors.New("both the certificate and the key are required")
+	}
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return []tls.Certificate{cert}, nil
+}
+
+// ServerOptions returns the options of the grpc server which enable TLS and check the token
+func (c Config) ServerOptions() ([]grpc.ServerOption, error) {
+	var opts []grpc.ServerOption
+	if c.TLSEnabled() {
+		certs, err := c.loadCert()
+		if err != nil {
+			return nil, err
+		}
+		if len(certs) == 0 {
+			return nil, errors.New("the server certificate is required to enable TLS")
+		}
+		cas, err := c.loadCA()
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig := &tls.Config{
+			Certificates: certs,
+			MinVersion:   tls.VersionTLS12,
+		}
+		if cas != nil {
+			tlsConfig.ClientCAs = cas
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	if c.Token != "" {
+		opts = append(opts,
+			grpc.ChainUnaryInterceptor(c.unaryInterceptor),
+			grpc.ChainStreamInterceptor(c.streamInterceptor))
+	}
+	return opts, nil
+}
+
+// DialOptions returns the options of the grpc client which enable TLS and send the token
+func (c Config) DialOptions() ([]grpc.DialOption, error) {
+	var opts []grpc.DialOption
+	if c.TLSEnabled() {
+		certs, err := c.loadCert()
+		if err != nil {
+			return nil, err
+		}
+		cas, err := c.loadCA()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+			Certificates: certs,
+			RootCAs:      cas,
+			MinVersion:   tls.VersionTLS12,
+		})))
+	} else {
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+	if c.Token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(&tokenCredentials{token: c.Token, secure: c.TLSEnabled()}))
+	}
+	return opts, nil
+}
+
+// checkToken checks the token in the metadata of the request
+func (c Config) checkToken(ctx context.Context) error {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return errUnauthenticated
+	}
+	for _, v := range md.Get(authorizationKey) {
+		if strings.HasPrefix(v, bearerPrefix) &&
+			subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(v, bearerPrefix)), []byte(c.Token)) == 1 {
+			return nil
+		}
+	}
+	return errUnauthenticated
+}
+
+func (c Config) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := c.checkToken(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (c Config) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := c.checkToken(ss.Context()); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// tokenCredentials sends the token with every request
+type tokenCredentials struct {
+	token  string
+	secure bool
+}
+
+func (t *tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{authorizationKey: bearerPrefix + t.token}, nil
+}
+
+// RequireTransportSecurity allows the token over the insecure connections when TLS is not configured
+func (t *tokenCredentials) RequireTransportSecurity() bool {
+	return t.secure
+}
@@ -0,0 +1,157 @@
+package rpcauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// serve starts a health server with the config and returns a client dialed with the other config
+func serve(t *testing.T, serverCfg, clientCfg Config) healthpb.HealthClient {
+	sopts, err := serverCfg.ServerOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer(sopts...)
+	healthpb.RegisterHealthServer(s, health.NewServer())
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	dopts, err := clientCfg.DialOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dopts = append(dopts, grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) {
+		return lis.Dial()
+	}))
+	conn, err := grpc.Dial("localhost", dopts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return healthpb.NewHealthClient(conn)
+}
+
+// check calls the unary and the stream rpc and returns their codes
+func check(t *testing.T, cli healthpb.HealthClient) (codes.Code, codes.Code) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := cli.Check(ctx, &healthpb.HealthCheckRequest{})
+	unary := status.Code(err)
+	stream, err := cli.Watch(ctx, &healthpb.HealthCheckRequest{})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	return unary, status.Code(err)
+}
+
+func TestToken(t *testing.T) {
+	testcases := []struct {
+		name        string
+		serverToken string
+		clientToken string
+		want        codes.Code
+	}{
+		{"disabled", "", "", codes.OK},
+		{"valid token", "secret", "secret", codes.OK},
+		{"missing token", "secret", "", codes.Unauthenticated},
+		{"wrong token", "secret", "guess", codes.Unauthenticated},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cli := serve(t, Config{Token: tc.serverToken}, Config{Token: tc.clientToken})
+			unary, stream := check(t, cli)
+			if unary != tc.want || stream != tc.want {
+				t.Errorf("got codes %v and %v, want %v", unary, stream, tc.want)
+			}
+		})
+	}
+}
+
+// writeCert writes a certificate signed by the parent, or a self-signed CA if the parent is nil
+func writeCert(t *testing.T, dir, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, Config) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage = x509.KeyUsageCertSign
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := Config{
+		CertFile: filepath.Join(dir, name+".crt"),
+		KeyFile:  filepath.Join(dir, name+".key"),
+	}
+	if err = ioutil.WriteFile(cfg.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(cfg.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key, cfg
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey, caCfg := writeCert(t, dir, "ca", nil, nil)
+	_, _, serverCfg := writeCert(t, dir, "server", ca, caKey)
+	_, _, clientCfg := writeCert(t, dir, "client", ca, caKey)
+	serverCfg.CAFile = caCfg.CertFile
+	serverCfg.Token = "secret"
+	clientCfg.CAFile = caCfg.CertFile
+	clientCfg.Token = "secret"
+
+	cli := serve(t, serverCfg, clientCfg)
+	if unary, stream := check(t, cli); unary != codes.OK || stream != codes.OK {
+		t.Fatalf("the client with a valid certificate is rejected: %v %v", unary, stream)
+	}
+	// the server requires the client certificate
+	cli = serve(t, serverCfg, Config{CAFile: caCfg.CertFile, Token: "secret"})
+	if unary, _ := check(t, cli); unary == codes.OK {
+		t.Fatal("the client without certificate is accepted")
+	}
+	// the client doesn't trust the server without the CA
+	cli = serve(t, serverCfg, Config{CertFile: clientCfg.CertFile, KeyFile: clientCfg.KeyFile, Token: "secret"})
+	if unary, _ := check(t, cli); unary == codes.OK {
+		t.Fatal("the server with an untrusted certificate is accepted")
+	}
+}
@@ -12,10 +12,12 @@ func ToDagNodeConfig(node *proto.DagNodeInfo) *config.DagNodeConfig {
 		dataNodes = append(dataNodes, nd.RpcAddress)
 	}
 	cfg := &config.DagNodeConfig{
-		Name:         node.Name,
-		Nodes:        dataNodes,
-		DataBlocks:   int(node.DataBlocks),
-		ParityBlocks: int(node.ParityBlocks),
+		Name:             node.Name,
+		Nodes:            dataNodes,
+		DataBlocks:       int(node.DataBlocks),
+		ParityBlocks:     int(node.ParityBlocks),
+		ReplicaThreshold: int(node.ReplicaThreshold),
+		Weight:           node.Weight,
 	}
 	return cfg
 }
@@ -28,10 +30,12 @@ func ToProtoDagNodeInfo(node *config.DagNodeConfig) *proto.DagNodeInfo {
 		})
 	}
 	nodeInfo := &proto.DagNodeInfo{
-		Name:         node.Name,
-		Nodes:        dataNodes,
-		DataBlocks:   int32(node.DataBlocks),
-		ParityBlocks: int32(node.ParityBlocks),
+		Name:             node.Name,
+		Nodes:            dataNodes,
+		DataBlocks:       int32(node.DataBlocks),
+		ParityBlocks:     int32(node.ParityBlocks),
+		ReplicaThreshold: int32(node.ReplicaThreshold),
+		Weight:           node.Weight,
 	}
 	return nodeInfo
 }
@@ -31,5 +31,10 @@ func main() {
 	}
 }
 func run(host, port, path string) {
-	datanode.StartDataNodeServer(fmt.Sprintf("%s:%s", host, port), datanode.KVBadge, path)
+	datanode.StartDataNodeServer(datanode.ServerConfig{
+		Listen:       fmt.Sprintf("%s:%s", host, port),
+		KVType:       datanode.KVBadge,
+		DataDirs:     []string{path},
+		ChecksumType: datanode.ChecksumCRC32C,
+	})
 }
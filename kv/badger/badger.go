@@ -23,6 +23,14 @@ type badgerDb struct {
 	db *badger.DB
 }
 
+// convertErr converts the not found error of badger to kv.ErrNotFound
+func convertErr(err error) error {
+	if err == badger.ErrKeyNotFound {
+		return kv.ErrNotFound
+	}
+	return err
+}
+
 func (b *badgerDb) Put(key string, value []byte) error {
 	wb := b.db.NewWriteBatch()
 	defer wb.Cancel()
@@ -36,7 +44,10 @@ func (b *badgerDb) Put(key string, value []byte) error {
 func (b *badgerDb) Delete(key string) error {
 	wb := b.db.NewWriteBatch()
 	defer wb.Cancel()
-	return wb.Delete([]byte(key))
+	if err := wb.Delete([]byte(key)); err != nil {
+		return err
+	}
+	return wb.Flush()
 }
 
 func (b *badgerDb) Get(key string) ([]byte, error) {
@@ -44,7 +55,7 @@ func (b *badgerDb) Get(key string) ([]byte, error) {
 	err := b.db.View(func(txn *badger.Txn) error {
 		item, err := txn.Get([]byte(key))
 		if err != nil {
-			return err
+			return convertErr(err)
 		}
 		ival, err = item.ValueCopy(nil)
 		return err
@@ -52,12 +63,31 @@ func (b *badgerDb) Get(key string) ([]byte, error) {
 	return ival, err
 }
 
+func (b *badgerDb) GetRange(key string, offset, length int) ([]byte, error) {
+	var ival []byte
+	err := b.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(key))
+		if err != nil {
+			return convertErr(err)
+		}
+		return item.Value(func(val []byte) error {
+			end, err := kv.ClipRange(len(val), offset, length)
+			if err != nil {
+				return err
+			}
+			ival = append([]byte{}, val[offset:end]...)
+			return nil
+		})
+	})
+	return ival, err
+}
+
 func (b *badgerDb) Size(key string) (int, error) {
 	size := 0
 	err := b.db.View(func(txn *badger.Txn) error {
 		item, err := txn.Get([]byte(key))
 		if err != nil {
-			return err
+			return convertErr(err)
 		}
 		size = int(item.ValueSize())
 		return err
@@ -80,8 +110,7 @@ func (b *badgerDb) AllKeysChan(ctx context.Context) (<-chan string, error) {
 				select {
 				case <-ctx.Done():
 					return nil
-				default:
-					kc <- string(k)
+				case kc <- string(k):
 				}
 			}
 			return nil
@@ -90,6 +119,33 @@ func (b *badgerDb) AllKeysChan(ctx context.Context) (<-chan string, error) {
 	return kc, nil
 }
 
+// ListKeys lists the keys in a read-only transaction, which sees a snapshot of the db
+func (b *badgerDb) ListKeys(ctx context.Context, opts kv.ListOptions) ([]string, string, error) {
+	var keys []string
+	err := b.db.View(func(txn *badger.Txn) error {
+		iopts := badger.DefaultIteratorOptions
+		iopts.PrefetchValues = false
+		it := txn.NewIterator(iopts)
+		defer it.Close()
+		prefix := []byte(opts.Prefix)
+		for it.Seek([]byte(opts.Seek())); it.ValidForPrefix(prefix) && !opts.Full(len(keys)); it.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			key := string(it.Item().Key())
+			if opts.Match(key) {
+				keys = append(keys, key)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	keys, cursor := kv.SortPage(keys, opts.Limit, false)
+	return keys, cursor, nil
+}
+
 func (b *badgerDb) Close() error {
 	return b.db.Close()
 }
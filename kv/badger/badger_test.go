@@ -0,0 +1,17 @@
+package badger
+
+import (
+	"github.com/filedag-project/filedag-storage/kv"
+	"github.com/filedag-project/filedag-storage/kv/kvtest"
+	"testing"
+)
+
+func TestConformance(t *testing.T) {
+	kvtest.Run(t, func(t *testing.T) kv.KVDB {
+		db, err := NewBadger(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return db
+	})
+}
@@ -23,7 +23,7 @@ const maxCacheDags = 4096
 const blockpath = "blocks"
 
 var (
-	ErrNotFound        = xerrors.New("diskv: not found")
+	ErrNotFound        = xerrors.Errorf("diskv: %w", kv.ErrNotFound)
 	ErrUnknowOperation = xerrors.New("diskv: unknow operation")
 )
 
@@ -35,6 +35,7 @@ const (
 	opread optype = iota
 	opwrite
 	opdelete
+	opreadrange
 )
 
 func (o optype) String() string {
@@ -45,6 +46,8 @@ func (o optype) String() string {
 		return "opwrite"
 	case opdelete:
 		return "opdelete"
+	case opreadrange:
+		return "opreadrange"
 	default:
 		return "unknow"
 	}
@@ -59,7 +62,10 @@ type op struct {
 	Type  optype
 	Key   string
 	Value []byte
-	Res   chan *opres
+	// Offset and Length are the range to read of opreadrange
+	Offset int
+	Length int
+	Res    chan *opres
 }
 
 type DisKV struct {
@@ -141,6 +147,8 @@ func (di *DisKV) acceptTasks() {
 					switch opt.Type {
 					case opread:
 						di.opread(opt)
+					case opreadrange:
+						di.opreadrange(opt)
 					default:
 						opt.Res <- &opres{
 							Err: ErrUnknowOperation,
@@ -170,11 +178,11 @@ func (di *DisKV) opread(opt *op) {
 	}
 	// link-dag keep data in refdb, no need retrive data from disk
 	if ref.Type == RefLink {
+		// update cache
+		di.cache.Set(opt.Key, ref.Data)
 		opt.Res <- &opres{
 			Data: ref.Data,
 		}
-		// update cache
-		di.cache.Set(opt.Key, ref.Data)
 		return
 	}
 	_, p, err := di.pathByKey(opt.Key)
@@ -209,19 +217,73 @@ func (di *DisKV) opread(opt *op) {
 		}
 		return
 	}
+	// update cache
+	di.cache.Set(opt.Key, d)
 	opt.Res <- &opres{
 		Data: d,
 	}
-	// update cache
-	di.cache.Set(opt.Key, ref.Data)
+}
+
+// opreadrange reads a range of the value, the data dags are read from the disk without loading the whole file
+func (di *DisKV) opreadrange(opt *op) {
+	data, err := di.readRange(opt.Key, opt.Offset, opt.Length)
+	opt.Res <- &opres{
+		Data: data,
+		Err:  err,
+	}
+}
+
+func (di *DisKV) readRange(key string, offset, length int) ([]byte, error) {
+	if v, err := di.cache.Get(key); err == nil {
+		data := v.([]byte)
+		end, err := kv.ClipRange(len(data), offset, length)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{}, data[offset:end]...), nil
+	}
+	ref, err := di.getRef(key)
+	if err != nil {
+		return nil, err
+	}
+	end, err := kv.ClipRange(ref.Size, offset, length)
+	if err != nil {
+		return nil, err
+	}
+	if ref.Type == RefLink {
+		return append([]byte{}, ref.Data[offset:end]...), nil
+	}
+	_, p, err := di.pathByKey(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(p, os.O_RDONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	// wait to get read lock
+	if err = syscall.Flock(int(f.Fd()), syscall.LOCK_SH); err != nil {
+		return nil, err
+	}
+	defer syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+	buf := make([]byte, end-offset)
+	if _, err = f.ReadAt(buf, int64(offset)); err != nil {
+		return nil, err
+	}
+	return buf, nil
 }
 
 func (di *DisKV) opwrite(opt *op) {
+	// the cache is updated before replying, so that the following reads never get the stale value
 	if len(opt.Value) <= di.Cfg.MaxLinkDagSize {
+		err := di.putRef(opt.Key, opt.Value, true)
+		if err == nil {
+			di.cache.Set(opt.Key, opt.Value)
+		}
 		opt.Res <- &opres{
-			Err: di.putRef(opt.Key, opt.Value, true),
+			Err: err,
 		}
-		di.cache.Set(opt.Key, opt.Value)
 		return
 	}
 
@@ -278,9 +340,9 @@ func (di *DisKV) opwrite(opt *op) {
 			Err: err,
 		}
 	} else {
-		opt.Res <- &opres{}
 		// update cache
 		di.cache.Set(opt.Key, opt.Value)
+		opt.Res <- &opres{}
 	}
 }
 
@@ -295,8 +357,12 @@ func (di *DisKV) opdelete(opt *op) {
 	}
 	// link-dag - delete entry in refdb
 	if ref.Type == RefLink {
+		err = di.Ref.Delete(opt.Key)
+		if err == nil {
+			di.cache.Remove(opt.Key)
+		}
 		opt.Res <- &opres{
-			Err: di.Ref.Delete(opt.Key),
+			Err: err,
 		}
 		return
 	}
@@ -335,9 +401,9 @@ func (di *DisKV) opdelete(opt *op) {
 			Err: err,
 		}
 	} else {
-		opt.Res <- &opres{}
 		// update cache
 		di.cache.Remove(opt.Key)
+		opt.Res <- &opres{}
 	}
 }
 
@@ -391,6 +457,19 @@ func (di *DisKV) Get(key string) ([]byte, error) {
 	return res.Data, res.Err
 }
 
+func (di *DisKV) GetRange(key string, offset, length int) ([]byte, error) {
+	resc := make(chan *opres)
+	di.oprchan <- &op{
+		Type:   opreadrange,
+		Key:    key,
+		Offset: offset,
+		Length: length,
+		Res:    resc,
+	}
+	res := <-resc
+	return res.Data, res.Err
+}
+
 func (di *DisKV) Size(key string) (int, error) {
 	ref, err := di.getRef(key)
 	if err != nil {
@@ -403,9 +482,13 @@ func (di *DisKV) AllKeysChan(ctx context.Context) (<-chan string, error) {
 	return di.Ref.AllKeysChan(ctx)
 }
 
+func (di *DisKV) ListKeys(ctx context.Context, opts kv.ListOptions) ([]string, string, error) {
+	return di.Ref.ListKeys(ctx, opts)
+}
+
 func (di *DisKV) Close() error {
 	di.close()
-	return nil
+	return di.Ref.Close()
 }
 
 func (di *DisKV) getRef(key string) (*DagRef, error) {
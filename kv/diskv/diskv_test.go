@@ -6,6 +6,9 @@ import (
 	"io/ioutil"
 	"sync"
 	"testing"
+
+	"github.com/filedag-project/filedag-storage/kv"
+	"github.com/filedag-project/filedag-storage/kv/kvtest"
 )
 
 func TestConcurrentWriteSameKey(t *testing.T) {
@@ -203,3 +206,13 @@ func tmpdirpath(t *testing.T) string {
 	}
 	return tmpdir
 }
+
+func TestConformance(t *testing.T) {
+	kvtest.Run(t, func(t *testing.T) kv.KVDB {
+		db, err := NewDisKV(DirConf(t.TempDir()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return db
+	})
+}
@@ -2,11 +2,14 @@ package diskv
 
 import (
 	"context"
+	"strings"
 
+	"github.com/filedag-project/filedag-storage/kv"
 	"github.com/fxamacker/cbor/v2"
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/errors"
 	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 const refdb_path = "refdb"
@@ -63,13 +66,41 @@ func (ref *Refdb) AllKeysChan(ctx context.Context) (chan string, error) {
 			if !iter.Next() {
 				return
 			}
-			out <- string(iter.Key())
+			select {
+			case <-ctx.Done():
+				return
+			case out <- string(iter.Key()):
+			}
 		}
 		// Todo: log if has iter.Error()
 	}(iter, out)
 	return out, nil
 }
 
+// ListKeys lists the keys by a leveldb iterator, which sees a snapshot of the db
+func (ref *Refdb) ListKeys(ctx context.Context, opts kv.ListOptions) ([]string, string, error) {
+	iter := ref.db.NewIterator(&util.Range{Start: []byte(opts.Seek())}, nil)
+	defer iter.Release()
+	var keys []string
+	for iter.Next() && !opts.Full(len(keys)) {
+		if err := ctx.Err(); err != nil {
+			return nil, "", err
+		}
+		key := string(iter.Key())
+		if !strings.HasPrefix(key, opts.Prefix) {
+			break
+		}
+		if opts.Match(key) {
+			keys = append(keys, key)
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return nil, "", err
+	}
+	keys, cursor := kv.SortPage(keys, opts.Limit, false)
+	return keys, cursor, nil
+}
+
 func (ref *Refdb) Close() error {
 	return ref.db.Close()
 }
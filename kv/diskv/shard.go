@@ -11,14 +11,30 @@ const defaultShardLevel = 3
 
 type ShardFun func(key string) (parent, path string, err error)
 
+var defaultShardFun = NewShardFun(defaultShardBytes, defaultShardLevel)
+
 func DefaultShardFun(key string) (parent, path string, err error) {
-	keyLen := len(key)
-	if keyLen < defaultShardBytes*defaultShardLevel {
-		return "", "", xerrors.New("key is too short")
+	return defaultShardFun(key)
+}
+
+// NewShardFun returns a ShardFun which shards the keys into levels of directories,
+// each is named by shardBytes bytes of the key from the tail. The non-positive arguments use the defaults.
+func NewShardFun(shardBytes, levels int) ShardFun {
+	if shardBytes <= 0 {
+		shardBytes = defaultShardBytes
+	}
+	if levels <= 0 {
+		levels = defaultShardLevel
 	}
-	for i := 0; i < defaultShardLevel; i++ {
-		parent = filepath.Join(parent, key[keyLen-(i+1)*defaultShardBytes:keyLen-i*defaultShardBytes])
+	return func(key string) (parent, path string, err error) {
+		keyLen := len(key)
+		if keyLen < shardBytes*levels {
+			return "", "", xerrors.New("key is too short")
+		}
+		for i := 0; i < levels; i++ {
+			parent = filepath.Join(parent, key[keyLen-(i+1)*shardBytes:keyLen-i*shardBytes])
+		}
+		path = filepath.Join(parent, key)
+		return
 	}
-	path = filepath.Join(parent, key)
-	return
 }
@@ -25,3 +25,17 @@ func TestDefaultShardFun(t *testing.T) {
 		}
 	}
 }
+
+func TestNewShardFun(t *testing.T) {
+	key := "QmbuSHXN9RANoN46sGYdxHyG6SEEcvdEJfXuXfD6EtfTUw"
+	pp, p, err := NewShardFun(3, 2)(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pp != "TUw/Etf" || p != "TUw/Etf/"+key {
+		t.Fatalf("unmatched output %s %s", pp, p)
+	}
+	if _, _, err = NewShardFun(3, 2)("short"); err == nil {
+		t.Fatal("the short key is sharded")
+	}
+}
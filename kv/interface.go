@@ -2,18 +2,86 @@ package kv
 
 import (
 	"context"
+	"sort"
+	"strings"
 
 	"golang.org/x/xerrors"
 )
 
 var ErrNotFound = xerrors.New("kv: key not found")
 
+// ErrInvalidRange is returned when the range to read is out of the value
+var ErrInvalidRange = xerrors.New("kv: invalid range")
+
 type KVDB interface {
 	Put(string, []byte) error
 	Delete(string) error
 	Get(string) ([]byte, error)
+	// GetRange reads length bytes of the value from offset, the range is truncated at the end of the value
+	GetRange(key string, offset, length int) ([]byte, error)
 	Size(string) (int, error)
 
 	AllKeysChan(context.Context) (<-chan string, error)
+	// ListKeys lists the keys in ascending order, and returns the cursor to pass as StartAfter for the next page,
+	// the cursor is empty when all the keys are listed. It is safe against concurrent writes,
+	// the keys written or deleted during the listing may or may not be listed.
+	ListKeys(ctx context.Context, opts ListOptions) (keys []string, cursor string, err error)
 	Close() error
 }
+
+// ListOptions selects the keys listed by ListKeys
+type ListOptions struct {
+	// Prefix filters the keys by the prefix
+	Prefix string
+	// StartAfter lists the keys greater than it, usually the cursor returned by the last page
+	StartAfter string
+	// Limit is the max number of keys of the page, 0 means no limit
+	Limit int
+}
+
+// Match reports whether the key is selected by the options
+func (o ListOptions) Match(key string) bool {
+	return key > o.StartAfter && strings.HasPrefix(key, o.Prefix)
+}
+
+// Seek returns the smallest key from which the sorted keys should be scanned
+func (o ListOptions) Seek() string {
+	if o.StartAfter > o.Prefix {
+		return o.StartAfter
+	}
+	return o.Prefix
+}
+
+// Full reports whether the page already holds more keys than the limit,
+// the iterators stop at one key over the limit so that SortPage knows there are more keys
+func (o ListOptions) Full(n int) bool {
+	return o.Limit > 0 && n > o.Limit
+}
+
+// SortPage sorts the keys and cuts them to the limit. The cursor is the last key of the page
+// if some keys are cut or more is set, otherwise it is empty.
+func SortPage(keys []string, limit int, more bool) ([]string, string) {
+	if !sort.StringsAreSorted(keys) {
+		sort.Strings(keys)
+	}
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+		more = true
+	}
+	if !more || len(keys) == 0 {
+		return keys, ""
+	}
+	return keys, keys[len(keys)-1]
+}
+
+// ClipRange checks the range against the size of the value, and returns the end of the range
+func ClipRange(size, offset, length int) (int, error) {
+	if offset < 0 || length < 0 || offset > size {
+		return 0, ErrInvalidRange
+	}
+	end := offset + length
+	if end > size || end < offset {
+		end = size
+	}
+	return end, nil
+}
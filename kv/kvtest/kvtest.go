@@ -0,0 +1,512 @@
+// Package kvtest is a conformance suite that runs every kv.KVDB implementation through the same behavioral tests.
+package kvtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/filedag-project/filedag-storage/kv"
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Opener opens an empty kvdb, the suite closes it
+type Opener func(t *testing.T) kv.KVDB
+
+// testKey returns a key which looks like a cid, some kvdbs shard the keys by their tail
+func testKey(i int) string {
+	return fmt.Sprintf("QmConformanceTestKey%08d", i)
+}
+
+func randBytes(size int) []byte {
+	b := make([]byte, size)
+	rand.Read(b)
+	return b
+}
+
+// Run runs all the conformance tests against the kvdb opened by open
+func Run(t *testing.T, open Opener) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, db kv.KVDB)
+	}{
+		{"PutGet", testPutGet},
+		{"Overwrite", testOverwrite},
+		{"Delete", testDelete},
+		{"NotFound", testNotFound},
+		{"GetRange", testGetRange},
+		{"PutStream", testPutStream},
+		{"AllKeysChan", testAllKeysChan},
+		{"AllKeysChanCancel", testAllKeysChanCancel},
+		{"ListKeys", testListKeys},
+		{"ListKeysConcurrentWrites", testListKeysConcurrentWrites},
+		{"Concurrent", testConcurrent},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db := open(t)
+			defer db.Close()
+			tc.fn(t, db)
+		})
+	}
+}
+
+func testPutGet(t *testing.T, db kv.KVDB) {
+	// the sizes cover the small values kept in the index and the large ones kept in files
+	for i, size := range []int{0, 1, 100, 8 << 10, 8<<10 + 1, 1 << 20} {
+		key := testKey(i)
+		value := randBytes(size)
+		if err := db.Put(key, value); err != nil {
+			t.Fatalf("put %d bytes: %v", size, err)
+		}
+		// read twice, the second read may be served by a cache
+		for j := 0; j < 2; j++ {
+			got, err := db.Get(key)
+			if err != nil {
+				t.Fatalf("get %d bytes: %v", size, err)
+			}
+			if !bytes.Equal(got, value) {
+				t.Fatalf("get %d bytes: got %d bytes of different data", size, len(got))
+			}
+		}
+		n, err := db.Size(key)
+		if err != nil {
+			t.Fatalf("size of %d bytes: %v", size, err)
+		}
+		if n != size {
+			t.Fatalf("size: got %d, want %d", n, size)
+		}
+	}
+}
+
+func testOverwrite(t *testing.T, db kv.KVDB) {
+	key := testKey(0)
+	// grow and shrink the value across the small and large sizes
+	for _, size := range []int{10, 1 << 20, 100, 16 << 10, 0} {
+		value := randBytes(size)
+		if err := db.Put(key, value); err != nil {
+			t.Fatal(err)
+		}
+		got, err := db.Get(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, value) {
+			t.Fatalf("overwrite with %d bytes: got %d bytes of different data", size, len(got))
+		}
+		if n, err := db.Size(key); err != nil || n != size {
+			t.Fatalf("overwrite with %d bytes: size %d, err %v", size, n, err)
+		}
+	}
+}
+
+func testDelete(t *testing.T, db kv.KVDB) {
+	for i, size := range []int{10, 1 << 20} {
+		key := testKey(i)
+		if err := db.Put(key, randBytes(size)); err != nil {
+			t.Fatal(err)
+		}
+		// read once so that the value may be cached
+		if _, err := db.Get(key); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Delete(key); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Get(key); !errors.Is(err, kv.ErrNotFound) {
+			t.Fatalf("get deleted %d bytes: got %v, want ErrNotFound", size, err)
+		}
+		// deleting again is not an error
+		if err := db.Delete(key); err != nil {
+			t.Fatalf("delete twice: %v", err)
+		}
+		// the key can be written again
+		value := randBytes(size)
+		if err := db.Put(key, value); err != nil {
+			t.Fatal(err)
+		}
+		got, err := db.Get(key)
+		if err != nil || !bytes.Equal(got, value) {
+			t.Fatalf("put after delete: err %v", err)
+		}
+	}
+}
+
+func testNotFound(t *testing.T, db kv.KVDB) {
+	key := testKey(404)
+	if _, err := db.Get(key); !errors.Is(err, kv.ErrNotFound) {
+		t.Errorf("get: got %v, want ErrNotFound", err)
+	}
+	if _, err := db.GetRange(key, 0, 1); !errors.Is(err, kv.ErrNotFound) {
+		t.Errorf("get range: got %v, want ErrNotFound", err)
+	}
+	if _, err := db.Size(key); !errors.Is(err, kv.ErrNotFound) {
+		t.Errorf("size: got %v, want ErrNotFound", err)
+	}
+	if err := db.Delete(key); err != nil {
+		t.Errorf("delete: got %v, want nil", err)
+	}
+}
+
+func testGetRange(t *testing.T, db kv.KVDB) {
+	for i, size := range []int{100, 1 << 20} {
+		key := testKey(i)
+		value := randBytes(size)
+		if err := db.Put(key, value); err != nil {
+			t.Fatal(err)
+		}
+		cases := []struct {
+			offset, length int
+			want           []byte
+		}{
+			{0, size, value},
+			{0, 0, value[:0]},
+			{10, 20, value[10:30]},
+			{size - 5, 100, value[size-5:]},
+			{size, 1, value[:0]},
+		}
+		for _, c := range cases {
+			got, err := db.GetRange(key, c.offset, c.length)
+			if err != nil {
+				t.Fatalf("range %d+%d of %d bytes: %v", c.offset, c.length, size, err)
+			}
+			if !bytes.Equal(got, c.want) {
+				t.Fatalf("range %d+%d of %d bytes: got %d bytes of different data", c.offset, c.length, size, len(got))
+			}
+		}
+		for _, c := range [][2]int{{-1, 1}, {0, -1}, {size + 1, 1}} {
+			if _, err := db.GetRange(key, c[0], c[1]); !errors.Is(err, kv.ErrInvalidRange) {
+				t.Fatalf("range %d+%d of %d bytes: got %v, want ErrInvalidRange", c[0], c[1], size, err)
+			}
+		}
+	}
+}
+
+func testPutStream(t *testing.T, db kv.KVDB) {
+	for i, size := range []int{0, 100, 1<<20 + 7} {
+		key := testKey(i)
+		value := randBytes(size)
+		// an overwritten value is replaced by the streamed one
+		if err := db.Put(key, randBytes(10)); err != nil {
+			t.Fatal(err)
+		}
+		w, err := kv.NewValueWriter(db, key, size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the head is written last, after the rest of the value
+		head := 0
+		if size > 0 {
+			head = 8
+			if _, err = w.Write(make([]byte, head)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for rest := value[head:]; len(rest) > 0; {
+			n := len(rest)
+			if n > 64<<10 {
+				n = 64 << 10
+			}
+			if _, err = w.Write(rest[:n]); err != nil {
+				t.Fatal(err)
+			}
+			rest = rest[n:]
+		}
+		if _, err = w.WriteAt(value[:head], 0); err != nil {
+			t.Fatal(err)
+		}
+		if err = w.Commit(); err != nil {
+			t.Fatalf("commit %d bytes: %v", size, err)
+		}
+		got, err := db.Get(key)
+		if err != nil || !bytes.Equal(got, value) {
+			t.Fatalf("get %d streamed bytes: got %d bytes, err %v", size, len(got), err)
+		}
+		if n, err := db.Size(key); err != nil || n != size {
+			t.Fatalf("size of %d streamed bytes: size %d, err %v", size, n, err)
+		}
+	}
+
+	// an aborted value is not stored, and the writes go on after it
+	key := testKey(100)
+	w, err := kv.NewValueWriter(db, key, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.Write(randBytes(50)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.Write(randBytes(51)); err == nil {
+		t.Fatal("expected an error writing over the value size")
+	}
+	w.Abort()
+	if err = w.Commit(); err == nil {
+		t.Fatal("expected an error committing an aborted value")
+	}
+	if _, err = db.Get(key); !errors.Is(err, kv.ErrNotFound) {
+		t.Fatalf("get aborted value: got %v, want ErrNotFound", err)
+	}
+	value := randBytes(100)
+	if err = db.Put(key, value); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := db.Get(key); err != nil || !bytes.Equal(got, value) {
+		t.Fatalf("put after abort: err %v", err)
+	}
+
+	// a value missing some bytes is not committed
+	w, err = kv.NewValueWriter(db, testKey(101), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.Write(randBytes(99)); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Commit(); !errors.Is(err, kv.ErrValueSize) {
+		t.Fatalf("commit short value: got %v, want ErrValueSize", err)
+	}
+	if _, err = db.Get(testKey(101)); !errors.Is(err, kv.ErrNotFound) {
+		t.Fatalf("get short value: got %v, want ErrNotFound", err)
+	}
+}
+
+func testAllKeysChan(t *testing.T, db kv.KVDB) {
+	var want []string
+	for i := 0; i < 100; i++ {
+		key := testKey(i)
+		size := 10
+		if i%10 == 0 {
+			size = 16 << 10
+		}
+		if err := db.Put(key, randBytes(size)); err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, key)
+	}
+	if err := db.Delete(want[0]); err != nil {
+		t.Fatal(err)
+	}
+	want = want[1:]
+
+	ch, err := db.AllKeysChan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for key := range ch {
+		got = append(got, key)
+	}
+	sort.Strings(got)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("got %d keys, want %d keys", len(got), len(want))
+	}
+}
+
+func testAllKeysChanCancel(t *testing.T, db kv.KVDB) {
+	for i := 0; i < 100; i++ {
+		if err := db.Put(testKey(i), randBytes(10)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := db.AllKeysChan(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := <-ch; !ok {
+		t.Fatal("the channel is closed before any key")
+	}
+	cancel()
+	// the channel must be closed soon after the cancellation, while the consumer drains it
+	timeout := time.After(10 * time.Second)
+	n := 1
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				if n >= 100 {
+					t.Logf("all the keys are listed before the cancellation took effect")
+				}
+				return
+			}
+			n++
+		case <-timeout:
+			t.Fatal("the channel is not closed after the cancellation")
+		}
+	}
+}
+
+// listAll lists all the keys page by page
+func listAll(t *testing.T, db kv.KVDB, opts kv.ListOptions) []string {
+	var all []string
+	for {
+		keys, cursor, err := db.ListKeys(context.Background(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opts.Limit > 0 && len(keys) > opts.Limit {
+			t.Fatalf("got %d keys over the limit %d", len(keys), opts.Limit)
+		}
+		if !sort.StringsAreSorted(keys) {
+			t.Fatal("the keys are not sorted")
+		}
+		all = append(all, keys...)
+		if cursor == "" {
+			return all
+		}
+		if cursor <= opts.StartAfter {
+			t.Fatalf("the cursor %q does not move forward from %q", cursor, opts.StartAfter)
+		}
+		opts.StartAfter = cursor
+	}
+}
+
+func testListKeys(t *testing.T, db kv.KVDB) {
+	var want, wantPrefix []string
+	for i := 0; i < 120; i++ {
+		// two groups of keys to test the prefix
+		key := testKey(i)
+		if i%2 == 0 {
+			key = "QmAnother" + key[2:]
+			wantPrefix = append(wantPrefix, key)
+		}
+		if err := db.Put(key, randBytes(10)); err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, key)
+	}
+	sort.Strings(want)
+	sort.Strings(wantPrefix)
+
+	keys, cursor, err := db.ListKeys(context.Background(), kv.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cursor != "" || fmt.Sprint(keys) != fmt.Sprint(want) {
+		t.Fatalf("list without limit: got %d keys and cursor %q, want %d keys", len(keys), cursor, len(want))
+	}
+	for _, limit := range []int{1, 7, 60, 120, 1000} {
+		if got := listAll(t, db, kv.ListOptions{Limit: limit}); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Fatalf("list by limit %d: got %d keys, want %d keys", limit, len(got), len(want))
+		}
+		got := listAll(t, db, kv.ListOptions{Prefix: "QmAnother", Limit: limit})
+		if fmt.Sprint(got) != fmt.Sprint(wantPrefix) {
+			t.Fatalf("list prefix by limit %d: got %d keys, want %d keys", limit, len(got), len(wantPrefix))
+		}
+	}
+	// resume from a key in the middle, the key itself is excluded
+	if got := listAll(t, db, kv.ListOptions{StartAfter: want[49], Limit: 10}); fmt.Sprint(got) != fmt.Sprint(want[50:]) {
+		t.Fatalf("list after the 50th key: got %d keys, want %d keys", len(got), len(want)-50)
+	}
+	// resume from a key which does not exist
+	if got := listAll(t, db, kv.ListOptions{StartAfter: want[9] + "0"}); fmt.Sprint(got) != fmt.Sprint(want[10:]) {
+		t.Fatalf("list after a missing key: got %d keys, want %d keys", len(got), len(want)-10)
+	}
+	if got := listAll(t, db, kv.ListOptions{StartAfter: want[len(want)-1]}); len(got) != 0 {
+		t.Fatalf("list after the last key: got %d keys", len(got))
+	}
+	if err = db.Delete(want[0]); err != nil {
+		t.Fatal(err)
+	}
+	if got := listAll(t, db, kv.ListOptions{Limit: 10}); fmt.Sprint(got) != fmt.Sprint(want[1:]) {
+		t.Fatalf("list after delete: got %d keys, want %d keys", len(got), len(want)-1)
+	}
+}
+
+func testListKeysConcurrentWrites(t *testing.T, db kv.KVDB) {
+	var stable []string
+	for i := 0; i < 200; i++ {
+		key := testKey(i * 2)
+		if err := db.Put(key, randBytes(10)); err != nil {
+			t.Fatal(err)
+		}
+		stable = append(stable, key)
+	}
+	// write and delete the other keys while listing, the stable keys must all be listed exactly once
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; ctx.Err() == nil; i++ {
+			key := testKey((i%200)*2 + 1)
+			if err := db.Put(key, randBytes(10)); err != nil {
+				t.Error(err)
+				return
+			}
+			if i%3 == 0 {
+				if err := db.Delete(key); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}
+	}()
+	got := listAll(t, db, kv.ListOptions{Limit: 9})
+	cancel()
+	<-done
+	seen := make(map[string]int)
+	for _, key := range got {
+		seen[key]++
+	}
+	for _, key := range stable {
+		if seen[key] != 1 {
+			t.Fatalf("the stable key %s is listed %d times", key, seen[key])
+		}
+	}
+}
+
+func testConcurrent(t *testing.T, db kv.KVDB) {
+	const workers = 8
+	const keysPerWorker = 50
+	var wg sync.WaitGroup
+	errCh := make(chan error, workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < keysPerWorker; i++ {
+				key := testKey(w*keysPerWorker + i)
+				value := randBytes(10 + (i%3)*(8<<10))
+				if err := db.Put(key, value); err != nil {
+					errCh <- err
+					return
+				}
+				got, err := db.Get(key)
+				if err != nil {
+					errCh <- err
+					return
+				}
+				if !bytes.Equal(got, value) {
+					errCh <- fmt.Errorf("key %s got different data", key)
+					return
+				}
+				if i%2 == 0 {
+					if err = db.Delete(key); err != nil {
+						errCh <- err
+						return
+					}
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errCh)
+	for err := range errCh {
+		t.Fatal(err)
+	}
+	for w := 0; w < workers; w++ {
+		for i := 0; i < keysPerWorker; i++ {
+			_, err := db.Size(testKey(w*keysPerWorker + i))
+			if i%2 == 0 && !errors.Is(err, kv.ErrNotFound) {
+				t.Fatalf("deleted key: got %v, want ErrNotFound", err)
+			}
+			if i%2 == 1 && err != nil {
+				t.Fatalf("kept key: %v", err)
+			}
+		}
+	}
+}
@@ -4,19 +4,26 @@ import (
 	"encoding/binary"
 	"fmt"
 	"hash/crc32"
+	"math"
 	"os"
 	"sync"
 
 	"github.com/filedag-project/filedag-storage/kv"
 )
 
-const MaxKeySize = 128
+const MaxKeySize = math.MaxUint16
+
+// LegacyMaxKeySize is the max key size of the legacy hint logs
+const LegacyMaxKeySize = 128
 
 // max key size 128 byte +  1 byte which record the key size + 1 byte delete flag
-const HintKeySize = MaxKeySize + 1 + 1
+const legacyHintKeySize = LegacyMaxKeySize + 1 + 1
+
+// legacyHintKeySize + 8 bytes value offset + 4 bytes value size
+const legacyHintSize = legacyHintKeySize + 8 + 4
 
-// HintKeySize + 8 bytes value offset + 4 bytes value size
-const HintEncodeSize = HintKeySize + 8 + 4
+// crc32 + delete flag + key size + value offset + value size
+const hintFixedSize = 4 + 1 + 2 + 8 + 8
 
 const (
 	HintDeletedFlag = byte(1)
@@ -26,43 +33,73 @@ type Hint struct {
 	Key     string
 	KOffset uint64
 	VOffset uint64
-	VSize   uint32
+	VSize   uint64
 	Deleted bool
 }
 
+// EncodedSize returns the size of the encoded hint, which is the same for every hint of the key,
+// so the hint can always be rewritten in place
+func (h *Hint) EncodedSize() uint64 {
+	return hintFixedSize + uint64(len(h.Key))
+}
+
 /**
-		key		:	value offset	:	value size
-		128+1   :   8   			:   4
+		crc32	:	flag	:	key size	:	value offset	:	value size	:	key
+		4		:	1		:	2			:	8				:	8			:	xxxx
 **/
 func (h *Hint) Encode() (ret []byte, err error) {
 	kl := len(h.Key)
 	if kl > MaxKeySize {
 		return nil, ErrKeySizeTooLong
 	}
-	ret = make([]byte, HintEncodeSize)
+	ret = make([]byte, h.EncodedSize())
 	if h.Deleted {
-		ret[0] = HintDeletedFlag
+		ret[4] = HintDeletedFlag
 	}
-	ret[1] = uint8(kl)
-	copy(ret[2:HintKeySize], []byte(h.Key))
-	binary.LittleEndian.PutUint64(ret[HintKeySize:HintKeySize+8], h.VOffset)
-	binary.LittleEndian.PutUint32(ret[HintKeySize+8:], h.VSize)
+	binary.LittleEndian.PutUint16(ret[5:7], uint16(kl))
+	binary.LittleEndian.PutUint64(ret[7:15], h.VOffset)
+	binary.LittleEndian.PutUint64(ret[15:hintFixedSize], h.VSize)
+	copy(ret[hintFixedSize:], h.Key)
+	binary.LittleEndian.PutUint32(ret[:4], crc32.ChecksumIEEE(ret[4:]))
 	return
 }
 
+// From decodes an encoded hint, ErrHintFormat is returned if its crc does not match
 func (h *Hint) From(buf []byte) (err error) {
-	if len(buf) != HintEncodeSize {
+	if len(buf) < hintFixedSize || len(buf) != hintFixedSize+int(binary.LittleEndian.Uint16(buf[5:7])) {
+		return ErrHintFormat
+	}
+	if binary.LittleEndian.Uint32(buf[:4]) != crc32.ChecksumIEEE(buf[4:]) {
+		return ErrHintFormat
+	}
+	h.Deleted = buf[4] == HintDeletedFlag
+	h.VOffset = binary.LittleEndian.Uint64(buf[7:15])
+	h.VSize = binary.LittleEndian.Uint64(buf[15:hintFixedSize])
+	h.Key = string(buf[hintFixedSize:])
+	return
+}
+
+/**
+		key		:	value offset	:	value size
+		128+1   :   8   			:   4
+**/
+// fromLegacy decodes a fixed size hint of the legacy hint logs
+func (h *Hint) fromLegacy(buf []byte) (err error) {
+	if len(buf) != legacyHintSize {
 		return ErrHintFormat
 	}
 	if buf[0] == HintDeletedFlag {
 		h.Deleted = true
 	}
 	keylen := uint8(buf[1])
+	if keylen > LegacyMaxKeySize {
+		return ErrHintFormat
+	}
 	key := make([]byte, keylen)
 	copy(key, buf[2:2+keylen])
 	h.Key = string(key)
-	h.VOffset = binary.LittleEndian.Uint64(buf[HintKeySize : HintKeySize+8])
-	h.VSize = binary.LittleEndian.Uint32(buf[HintKeySize+8:])
+	h.VOffset = binary.LittleEndian.Uint64(buf[legacyHintKeySize : legacyHintKeySize+8])
+	h.VSize = uint64(binary.LittleEndian.Uint32(buf[legacyHintKeySize+8:]))
 	return
 }
 
@@ -79,7 +116,7 @@ func EncodeValue(v []byte) (ret []byte) {
 }
 
 func DecodeValue(buf []byte, verify bool) (v []byte, err error) {
-	if len(buf) <= 4 {
+	if len(buf) < 4 {
 		return nil, ErrValueFormat
 	}
 	if verify {
@@ -100,6 +137,13 @@ const (
 	opread = iota
 	opwrite
 	opdelete
+	opstat
+	opsnapshot
+	opswap
+	opreadrange
+	opsync
+	opmergeabort
+	opwritestream
 )
 
 type action struct {
@@ -107,12 +151,17 @@ type action struct {
 	hint     *Hint
 	key      string
 	value    []byte
+	offset   int
+	length   int
+	merge    *mergeState
+	writer   *caskWriter
 	retvchan chan retv
 }
 
 type retv struct {
-	err  error
-	data []byte
+	err   error
+	data  []byte
+	stats CaskStats
 }
 
 type Cask struct {
@@ -124,7 +173,18 @@ type Cask struct {
 	vLogSize    uint64
 	hintLog     *os.File
 	hintLogSize uint64
-	keyMap      *KeyMap
+	// liveSize is the total size of the vlog records still referenced by an undeleted hint
+	liveSize uint64
+	keyMap   keyIndex
+	// merging is set while a merge of this cask is in progress
+	merging int32
+	// vLogVersion is the format of the vlog, the records of the legacy one carry no key
+	vLogVersion uint32
+	cfg         *Config
+	// changed holds the keys written or deleted since the snapshot of the merge in progress
+	changed map[string]struct{}
+	// dirty is set when there are writes not synced yet
+	dirty bool
 }
 
 func NewCask(id uint32) *Cask {
@@ -152,10 +212,24 @@ func NewCask(id uint32) *Cask {
 				switch act.optype {
 				case opread:
 					cask.doread(act)
+				case opreadrange:
+					cask.doreadrange(act)
 				case opdelete:
 					cask.dodelete(act)
 				case opwrite:
 					cask.dowrite(act)
+				case opstat:
+					cask.dostat(act)
+				case opsnapshot:
+					cask.dosnapshot(act)
+				case opswap:
+					cask.doswap(act)
+				case opsync:
+					cask.dosync(act)
+				case opmergeabort:
+					cask.domergeabort(act)
+				case opwritestream:
+					cask.dowritestream(act)
 				default:
 					fmt.Printf("unkown op type %d\n", act.optype)
 				}
@@ -169,6 +243,9 @@ func NewCask(id uint32) *Cask {
 
 func (c *Cask) Close() {
 	c.close()
+	if c.keyMap != nil {
+		c.keyMap.close()
+	}
 	if c.hintLog != nil {
 		c.hintLog.Close()
 	}
@@ -178,16 +255,31 @@ func (c *Cask) Close() {
 }
 
 func (c *Cask) Put(key string, value []byte) (err error) {
-	retvc := make(chan retv)
-	c.actChan <- &action{
-		optype:   opwrite,
-		key:      key,
-		value:    value,
-		retvchan: retvc,
+	if len(key) > MaxKeySize {
+		return ErrKeySizeTooLong
 	}
-	ret := <-retvc
+	_, err = c.send(&action{
+		optype: opwrite,
+		key:    key,
+		value:  value,
+	})
+	return
+}
 
-	return ret.err
+// PutStream writes the value of the size in chunks through the returned writer,
+// the cask serves no other operation until the value is committed or aborted
+func (c *Cask) PutStream(key string, size int) (kv.ValueWriter, error) {
+	if len(key) > MaxKeySize {
+		return nil, ErrKeySizeTooLong
+	}
+	if size < 0 {
+		return nil, kv.ErrValueSize
+	}
+	w := &caskWriter{c: c, key: key, size: int64(size), release: make(chan struct{})}
+	if _, err := c.send(&action{optype: opwritestream, key: key, writer: w}); err != nil {
+		return nil, err
+	}
+	return w, nil
 }
 
 func (c *Cask) Delete(key string) (err error) {
@@ -195,16 +287,12 @@ func (c *Cask) Delete(key string) (err error) {
 	if !has || hint.Deleted {
 		return nil
 	}
-	retvc := make(chan retv)
-	c.actChan <- &action{
-		optype:   opdelete,
-		key:      key,
-		hint:     hint,
-		retvchan: retvc,
-	}
-	ret := <-retvc
-
-	return ret.err
+	_, err = c.send(&action{
+		optype: opdelete,
+		key:    key,
+		hint:   hint,
+	})
+	return
 }
 
 func (c *Cask) Read(key string) (v []byte, err error) {
@@ -213,20 +301,38 @@ func (c *Cask) Read(key string) (v []byte, err error) {
 		return nil, kv.ErrNotFound
 	}
 
-	retvc := make(chan retv)
-	c.actChan <- &action{
-		optype:   opread,
-		hint:     hint,
-		retvchan: retvc,
-	}
-	ret := <-retvc
-	if ret.err != nil {
-		return nil, ret.err
+	ret, err := c.send(&action{
+		optype: opread,
+		key:    key,
+		hint:   hint,
+	})
+	if err != nil {
+		return nil, err
 	}
 	return ret.data, nil
 
 }
 
+// ReadRange reads a range of the value, the crc of the value is not verified
+func (c *Cask) ReadRange(key string, offset, length int) (v []byte, err error) {
+	hint, has := c.keyMap.Get(key)
+	if !has || hint.Deleted {
+		return nil, kv.ErrNotFound
+	}
+
+	ret, err := c.send(&action{
+		optype: opreadrange,
+		key:    key,
+		hint:   hint,
+		offset: offset,
+		length: length,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret.data, nil
+}
+
 func (c *Cask) Size(key string) (int, error) {
 	hint, has := c.keyMap.Get(key)
 	if !has || hint.Deleted {
@@ -235,6 +341,131 @@ func (c *Cask) Size(key string) (int, error) {
 	return int(hint.VSize - 4), nil
 }
 
+// Sync flushes the writes of the cask to the disk
+func (c *Cask) Sync() error {
+	_, err := c.send(&action{optype: opsync})
+	return err
+}
+
+// recordSize returns the vlog space taken by the record of the hint
+func (c *Cask) recordSize(h *Hint) uint64 {
+	if c.vLogVersion == legacyVLogVersion {
+		return h.VSize
+	}
+	return recordLayoutOf(c.vLogVersion).headerSize(h.Key) + h.VSize
+}
+
+// dataStart returns the offset of the first record in the vlog
+func (c *Cask) dataStart() uint64 {
+	if c.vLogVersion == legacyVLogVersion {
+		return 0
+	}
+	return vLogHeaderSize
+}
+
+// appendVLog appends the encoded value to the vlog and returns the offset the hint should point at
+func (c *Cask) appendVLog(key string, deleted bool, encValue []byte) (uint64, error) {
+	record := encValue
+	if c.vLogVersion != legacyVLogVersion {
+		var err error
+		if record, err = encodeRecord(c.vLogVersion, key, deleted, encValue); err != nil {
+			return 0, err
+		}
+	}
+	offset := c.vLogSize
+	if _, err := c.vLog.WriteAt(record, int64(offset)); err != nil {
+		return 0, err
+	}
+	// operations for one cask actually did in a sync style, so there is no need to use actomic
+	c.vLogSize += uint64(len(record))
+	// the vlog is synced before the hint is written, so a synced hint never points at a lost record
+	if err := c.syncWrite(c.vLog); err != nil {
+		return 0, err
+	}
+	return offset + uint64(len(record)-len(encValue)), nil
+}
+
+// putHint points the hint of the key at the encoded value appended to the vlog
+func (c *Cask) putHint(key string, voffset, vsize uint64) error {
+	var hint = &Hint{}
+	var isAddNew bool
+	// size of the live record which is going to be overwritten
+	var replaced uint64
+	if h, has := c.keyMap.Get(key); has {
+		hint = h
+		if !h.Deleted {
+			replaced = c.recordSize(h)
+		}
+	} else {
+		isAddNew = true
+		hint.KOffset = c.hintLogSize
+	}
+
+	hint.Key = key
+	hint.VOffset = voffset
+	hint.VSize = vsize
+	hint.Deleted = false
+
+	encHintBytes, err := hint.Encode()
+	if err != nil {
+		return err
+	}
+	_, err = c.hintLog.WriteAt(encHintBytes, int64(hint.KOffset))
+	if err != nil {
+		return err
+	}
+	if err = c.syncWrite(c.hintLog); err != nil {
+		return err
+	}
+
+	if isAddNew {
+		// update hint log file size
+		// atomic.AddUint64(&c.hintLogSize, hint.EncodedSize())
+		c.hintLogSize += hint.EncodedSize()
+	}
+	c.liveSize = c.liveSize - replaced + c.recordSize(hint)
+
+	fmt.Printf("update key map for %d\n", c.id)
+	c.keyMap.Add(hint.Key, hint)
+	c.markChanged(hint.Key)
+	return nil
+}
+
+// syncWrite syncs the written file right away in SyncAlways mode, otherwise it is left to dosync
+func (c *Cask) syncWrite(f *os.File) error {
+	if c.cfg.SyncMode == SyncAlways {
+		return f.Sync()
+	}
+	c.dirty = true
+	return nil
+}
+
+func (c *Cask) dosync(act *action) {
+	if !c.dirty {
+		act.retvchan <- retv{}
+		return
+	}
+	if err := c.vLog.Sync(); err != nil {
+		act.retvchan <- retv{err: err}
+		return
+	}
+	if err := c.hintLog.Sync(); err != nil {
+		act.retvchan <- retv{err: err}
+		return
+	}
+	c.dirty = false
+	act.retvchan <- retv{}
+}
+
+func (c *Cask) dostat(act *action) {
+	act.retvchan <- retv{stats: CaskStats{
+		ID:        c.id,
+		LiveBytes: c.liveSize,
+		DeadBytes: c.vLogSize - c.dataStart() - c.liveSize,
+		Version:   c.vLogVersion,
+	}}
+}
+
 func (c *Cask) doread(act *action) {
 	var err error
 	defer func() {
@@ -242,8 +473,14 @@ func (c *Cask) doread(act *action) {
 			act.retvchan <- retv{err: err}
 		}
 	}()
-	buf := make([]byte, act.hint.VSize)
-	_, err = c.vLog.ReadAt(buf, int64(act.hint.VOffset))
+	// the hint may have been replaced by a merge since it was looked up
+	hint, has := c.keyMap.Get(act.key)
+	if !has || hint.Deleted {
+		err = kv.ErrNotFound
+		return
+	}
+	buf := make([]byte, hint.VSize)
+	_, err = c.vLog.ReadAt(buf, int64(hint.VOffset))
 	if err != nil {
 		return
 	}
@@ -254,6 +491,32 @@ func (c *Cask) doread(act *action) {
 	act.retvchan <- retv{data: v}
 }
 
+func (c *Cask) doreadrange(act *action) {
+	var err error
+	defer func() {
+		if err != nil {
+			act.retvchan <- retv{err: err}
+		}
+	}()
+	// the hint may have been replaced by a merge since it was looked up
+	hint, has := c.keyMap.Get(act.key)
+	if !has || hint.Deleted {
+		err = kv.ErrNotFound
+		return
+	}
+	end, err := kv.ClipRange(int(hint.VSize)-4, act.offset, act.length)
+	if err != nil {
+		return
+	}
+	buf := make([]byte, end-act.offset)
+	// skip the crc code
+	_, err = c.vLog.ReadAt(buf, int64(hint.VOffset)+4+int64(act.offset))
+	if err != nil {
+		return
+	}
+	act.retvchan <- retv{data: buf}
+}
+
 func (c *Cask) dodelete(act *action) {
 	var err error
 	defer func() {
@@ -261,17 +524,40 @@ func (c *Cask) dodelete(act *action) {
 			act.retvchan <- retv{err: err}
 		}
 	}()
+	// the hint may have been replaced by a merge since it was looked up
+	hint, has := c.keyMap.Get(act.key)
+	if !has || hint.Deleted {
+		act.retvchan <- retv{}
+		return
+	}
+	act.hint = hint
+	// the tombstone keeps the delete when the hint log is rebuilt from the vlog
+	if c.vLogVersion != legacyVLogVersion {
+		if _, err = c.appendVLog(act.key, true, EncodeValue(nil)); err != nil {
+			return
+		}
+	}
 	// operations for one cask actually did in a sync style, so there is no need to use actomic
 	fsize := c.hintLogSize // atomic.LoadUint64(&c.hintLogSize)
-	//fmt.Printf("%d | %s hint offset: %d, %d, file size: %d\n", c.id, act.key, act.hint.KOffset, act.hint.KOffset+HintEncodeSize, fsize)
-	if act.hint.KOffset+HintEncodeSize > fsize {
+	//fmt.Printf("%d | %s hint offset: %d, %d, file size: %d\n", c.id, act.key, act.hint.KOffset, act.hint.KOffset+act.hint.EncodedSize(), fsize)
+	if act.hint.KOffset+act.hint.EncodedSize() > fsize {
 		err = ErrReadHintBeyondRange
 		return
 	}
-	_, err = c.hintLog.WriteAt([]byte{HintDeletedFlag}, int64(act.hint.KOffset))
+	// the whole hint is rewritten since the crc covers the flag
+	deleted := *act.hint
+	deleted.Deleted = true
+	encHintBytes, err := deleted.Encode()
+	if err != nil {
+		return
+	}
+	_, err = c.hintLog.WriteAt(encHintBytes, int64(act.hint.KOffset))
 	if err != nil {
 		return
 	}
+	if err = c.syncWrite(c.hintLog); err != nil {
+		return
+	}
 	// h := &Hint{
 	// 	Deleted: true,
 	// 	Key:     act.hint.Key,
@@ -280,7 +566,9 @@ func (c *Cask) dodelete(act *action) {
 	// 	VSize:   act.hint.VSize,
 	// }
 	act.hint.Deleted = true
+	c.liveSize -= c.recordSize(act.hint)
 	c.keyMap.Add(act.key, act.hint)
+	c.markChanged(act.key)
 	// truncate the last hint
 	act.retvchan <- retv{}
 }
@@ -293,11 +581,10 @@ func (c *Cask) dowrite(act *action) {
 		}
 	}()
 
-	var hint = &Hint{}
-	var isAddNew bool
 	// check if key value already been saved
-	if h, has := c.keyMap.Get(act.key); has {
-		hint = h
+	// a value written again after deleted is appended as a new record,
+	// so that it comes after the tombstone in the vlog
+	if h, has := c.keyMap.Get(act.key); has && !h.Deleted {
 		// the crc code
 		buf := make([]byte, 4)
 		_, err = c.vLog.ReadAt(buf, int64(h.VOffset))
@@ -308,70 +595,24 @@ func (c *Cask) dowrite(act *action) {
 		crcRecord := binary.LittleEndian.Uint32(buf)
 		crcv := crc32.ChecksumIEEE(act.value)
 		// value has same crc code
-		if crcRecord == crcv && !h.Deleted {
-			act.retvchan <- retv{}
-			return
-		}
-		// value has same crc code but in deleted state
-		// should just update hint to undeleted state
-		if crcRecord == crcv && h.Deleted {
-			if hint.KOffset+HintEncodeSize > c.hintLogSize {
-				err = ErrReadHintBeyondRange
-				return
-			}
-			//_, err = c.hintLog.WriteAt([]byte{HintDeletedFlag}, int64(hint.KOffset))
-			_, err = c.hintLog.WriteAt([]byte{byte(0)}, int64(hint.KOffset))
-			if err != nil {
-				return
-			}
-			hint.Deleted = false
-			c.keyMap.Add(hint.Key, hint)
+		if crcRecord == crcv {
 			act.retvchan <- retv{}
 			return
 		}
-	} else {
-		isAddNew = true
-		hint.KOffset = c.hintLogSize
 	}
 
-	// record file size as value offset
-	voffset := c.vLogSize
 	// encode value
 	encbytes := EncodeValue(act.value)
 	// record encoded value size
-	vsize := uint32(len(encbytes))
+	vsize := uint64(len(encbytes))
 	// write to vlog file
-	_, err = c.vLog.WriteAt(encbytes, int64(voffset))
-	if err != nil {
-		return
-	}
-	// operations for one cask actually did in a sync style, so there is no need to use actomic
-	// update vlog file size
-	//atomic.AddUint64(&c.vLogSize, uint64(vsize))
-	c.vLogSize += uint64(vsize)
-
-	hint.Key = act.key
-	hint.VOffset = voffset
-	hint.VSize = vsize
-	hint.Deleted = false
-
-	encHintBytes, err := hint.Encode()
+	voffset, err := c.appendVLog(act.key, false, encbytes)
 	if err != nil {
 		return
 	}
-	_, err = c.hintLog.WriteAt(encHintBytes, int64(hint.KOffset))
-	if err != nil {
+	if err = c.putHint(act.key, voffset, vsize); err != nil {
 		return
 	}
-
-	if isAddNew {
-		// update hint log file size
-		// atomic.AddUint64(&c.hintLogSize, HintEncodeSize)
-		c.hintLogSize += HintEncodeSize
-	}
-
-	fmt.Printf("update key map for %d\n", c.id)
-	c.keyMap.Add(hint.Key, hint)
 	act.retvchan <- retv{}
 	fmt.Printf("put %s = %s\n", act.key, act.value)
 }
@@ -2,6 +2,7 @@ package mutcask
 
 import (
 	"bytes"
+	"encoding/binary"
 	"testing"
 )
 
@@ -23,6 +24,34 @@ func TestHintEncode(t *testing.T) {
 	if h1.Key != h2.Key || h1.VOffset != h2.VOffset || h1.VSize != h2.VSize {
 		t.Fatal()
 	}
+	bs[len(bs)-1] ^= 0xff
+	if err = h2.From(bs); err != ErrHintFormat {
+		t.Fatal(err)
+	}
+}
+
+// encodeLegacyHint encodes the hint in the fixed size format of the legacy hint logs
+func encodeLegacyHint(h *Hint) []byte {
+	ret := make([]byte, legacyHintSize)
+	if h.Deleted {
+		ret[0] = HintDeletedFlag
+	}
+	ret[1] = uint8(len(h.Key))
+	copy(ret[2:legacyHintKeySize], h.Key)
+	binary.LittleEndian.PutUint64(ret[legacyHintKeySize:legacyHintKeySize+8], h.VOffset)
+	binary.LittleEndian.PutUint32(ret[legacyHintKeySize+8:], uint32(h.VSize))
+	return ret
+}
+
+func TestLegacyHintDecode(t *testing.T) {
+	h1 := &Hint{Key: "QmYs2ezGBk63nzf3vD4EHejWfN5ZkDfTVroS7rwY2JTbnQ", VOffset: 4 << 10, VSize: 512, Deleted: true}
+	h2 := &Hint{}
+	if err := h2.fromLegacy(encodeLegacyHint(h1)); err != nil {
+		t.Fatal(err)
+	}
+	if *h1 != *h2 {
+		t.Fatalf("expected %+v, got %+v", h1, h2)
+	}
 }
 
 func TestValueEncodeDecode(t *testing.T) {
@@ -7,10 +7,19 @@ var (
 	ErrValueFormat         = xerrors.New("mutcask: invalid value format")
 	ErrDataRotted          = xerrors.New("mutcask: data may be rotted")
 	ErrKeySizeTooLong      = xerrors.New("mutcask: key size is too long")
+	ErrValueSizeTooLarge   = xerrors.New("mutcask: value size is too large")
 	ErrHintFormat          = xerrors.New("mutcask: invalid hint format")
 	ErrPathUndefined       = xerrors.New("mutcask: should define path within config")
 	ErrPath                = xerrors.New("mutcask: path should be directory not file")
 	ErrHintLogBroken       = xerrors.New("mutcask: hint log broken")
 	ErrReadHintBeyondRange = xerrors.New("mutcask: read hint out of file range")
 	ErrRepoLocked          = xerrors.New("mutcask: repo has been locked")
+	ErrCaskClosed          = xerrors.New("mutcask: cask has been closed")
+	ErrMergeInProgress     = xerrors.New("mutcask: merge already in progress")
+	ErrRecordBroken        = xerrors.New("mutcask: vlog record broken")
+	ErrSyncMode            = xerrors.New("mutcask: unknown sync mode")
+	ErrIndexMode           = xerrors.New("mutcask: unknown index mode")
+	ErrIndexBroken         = xerrors.New("mutcask: index file broken")
+	ErrRecoverMode         = xerrors.New("mutcask: unknown recover mode")
+	ErrNoHintLog           = xerrors.New("mutcask: hint log missing and the vlog can not be scanned")
 )
@@ -1,6 +1,9 @@
 package mutcask
 
 import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
 	"io"
 	"os"
 	"path/filepath"
@@ -8,12 +11,34 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/filedag-project/filedag-storage/kv"
 	"golang.org/x/xerrors"
 )
 
 const vLogSuffix = ".vlog"
 const hintLogSuffix = ".hint"
 
+// mergeSuffix marks the files being written by a merge which is still in progress
+const mergeSuffix = ".merge"
+
+// mergedSuffix marks a merged hint log which has been completely written but not yet swapped in
+const mergedSuffix = ".merged"
+
+// hintLogMagic starts the hint logs of variable size hints, the legacy hint logs hold fixed size hints only
+var hintLogMagic = []byte("MCHT")
+
+const (
+	legacyHintLogVersion  = uint32(0)
+	currentHintLogVersion = uint32(1)
+)
+
+const hintLogHeaderSize = fileHeaderSize
+
+// initHintLog writes the header of the current version into an empty hint log
+func initHintLog(f *os.File) error {
+	return writeFileHeader(f, hintLogMagic, currentHintLogVersion)
+}
+
 type CaskMap struct {
 	sync.Mutex
 	m map[uint32]*Cask
@@ -26,12 +51,23 @@ func (cm *CaskMap) Add(id uint32, cask *Cask) {
 }
 
 func (cm *CaskMap) Get(id uint32) (c *Cask, b bool) {
-	// cm.Lock()
-	// defer cm.Unlock()
+	cm.Lock()
+	defer cm.Unlock()
 	c, b = cm.m[id]
 	return
 }
 
+// casks returns all the casks
+func (cm *CaskMap) casks() []*Cask {
+	cm.Lock()
+	defer cm.Unlock()
+	ret := make([]*Cask, 0, len(cm.m))
+	for _, cask := range cm.m {
+		ret = append(ret, cask)
+	}
+	return ret
+}
+
 func (cm *CaskMap) CloseAll() {
 	for _, cask := range cm.m {
 		if cask != nil {
@@ -46,59 +82,215 @@ type KeyMap struct {
 }
 
 func (km *KeyMap) Add(key string, hint *Hint) {
-	// km.Lock()
-	// defer km.Unlock()
+	km.Lock()
+	defer km.Unlock()
 	km.m[key] = hint
 }
 
 func (km *KeyMap) Get(key string) (h *Hint, b bool) {
-	// km.Lock()
-	// defer km.Unlock()
+	km.Lock()
+	defer km.Unlock()
 	h, b = km.m[key]
 	return
 }
 
+// Range calls fn with every hint under the lock, so the key map must not be changed in fn
+func (km *KeyMap) Range(fn func(h *Hint) bool) error {
+	km.Lock()
+	defer km.Unlock()
+	for _, h := range km.m {
+		if !fn(h) {
+			break
+		}
+	}
+	return nil
+}
+
+// keys returns the undeleted keys selected by the options, unsorted. Only the smallest opts.Limit+1 keys
+// are kept in a heap if the limit is set, so that a page does not sort all the keys of the cask
+func (km *KeyMap) keys(opts kv.ListOptions) ([]string, error) {
+	km.Lock()
+	defer km.Unlock()
+	keys := &maxKeyHeap{}
+	for key, h := range km.m {
+		if h.Deleted || !opts.Match(key) {
+			continue
+		}
+		if !opts.Full(keys.Len()) {
+			heap.Push(keys, key)
+		} else if key < (*keys)[0] {
+			(*keys)[0] = key
+			heap.Fix(keys, 0)
+		}
+	}
+	return *keys, nil
+}
+
+// maxKeyHeap keeps the largest key at the top
+type maxKeyHeap []string
+
+func (kh maxKeyHeap) Len() int            { return len(kh) }
+func (kh maxKeyHeap) Less(i, j int) bool  { return kh[i] > kh[j] }
+func (kh maxKeyHeap) Swap(i, j int)       { kh[i], kh[j] = kh[j], kh[i] }
+func (kh *maxKeyHeap) Push(x interface{}) { *kh = append(*kh, x.(string)) }
+func (kh *maxKeyHeap) Pop() interface{} {
+	old := *kh
+	x := old[len(old)-1]
+	*kh = old[:len(old)-1]
+	return x
+}
+
+// snapshot copies the undeleted hints
+func (km *KeyMap) snapshot() (indexSnapshot, error) {
+	km.Lock()
+	defer km.Unlock()
+	s := make(hintSnapshot, 0, len(km.m))
+	for _, h := range km.m {
+		if !h.Deleted {
+			s = append(s, *h)
+		}
+	}
+	return s, nil
+}
+
+// replace swaps in the key map built by a merge
+func (km *KeyMap) replace(idx keyIndex) {
+	nkm := idx.(*KeyMap)
+	km.Lock()
+	defer km.Unlock()
+	km.m = nkm.m
+}
+
+func (km *KeyMap) close() error {
+	return nil
+}
+
 // func (km *KeyMap) Remove(key string) {
 // 	km.Lock()
 // 	defer km.Unlock()
 // 	delete(km.m, key)
 // }
 
-func buildKeyMap(hint *os.File) (*KeyMap, error) {
-	finfo, err := hint.Stat()
+// scanHints calls fn with the hints of a hint log of the current version in the order they are written.
+// It returns the offset where the complete hints end, which is less than size if the tail is torn,
+// and the hints whose crc does not match, which are passed to fn as well.
+func scanHints(hint *os.File, size uint64, fn func(h *Hint) error) (end uint64, broken []*Hint, err error) {
+	r := bufio.NewReader(io.NewSectionReader(hint, hintLogHeaderSize, int64(size-hintLogHeaderSize)))
+	offset := uint64(hintLogHeaderSize)
+	fixed := make([]byte, hintFixedSize)
+	for offset < size {
+		if _, err = io.ReadFull(r, fixed); err != nil {
+			if err == io.ErrUnexpectedEOF {
+				return offset, broken, nil
+			}
+			return 0, nil, err
+		}
+		buf := make([]byte, hintFixedSize+int(binary.LittleEndian.Uint16(fixed[5:7])))
+		copy(buf, fixed)
+		if _, err = io.ReadFull(r, buf[hintFixedSize:]); err != nil {
+			if err == io.ErrUnexpectedEOF || err == io.EOF {
+				return offset, broken, nil
+			}
+			return 0, nil, err
+		}
+		h := &Hint{KOffset: offset}
+		if err = h.From(buf); err != nil {
+			// a hint is only rewritten for the same key, so the broken one still tells its key and size
+			h.Key = string(buf[hintFixedSize:])
+			h.Deleted = true
+			broken = append(broken, h)
+		}
+		if err = fn(h); err != nil {
+			return 0, nil, err
+		}
+		offset += uint64(len(buf))
+	}
+	return offset, broken, nil
+}
+
+// buildIndex reads the hints of the hint log into a key index of the configured mode,
+// of the hints with the same key the last one written wins. The index file is kept beside the hint log path.
+func buildIndex(cfg *Config, hintPath string, hint *os.File, size uint64) (idx keyIndex, end uint64, broken []*Hint, err error) {
+	indexPath := strings.TrimSuffix(hintPath, hintLogSuffix) + indexSuffix
+	if cfg.IndexMode == IndexMemory {
+		// the index file left by the disk mode is stale
+		if err = os.Remove(indexPath); err != nil && !os.IsNotExist(err) {
+			return nil, 0, nil, err
+		}
+		km := &KeyMap{m: make(map[string]*Hint)}
+		if end, broken, err = scanHints(hint, size, func(h *Hint) error {
+			km.m[h.Key] = h
+			return nil
+		}); err != nil {
+			return nil, 0, nil, err
+		}
+		return km, end, broken, nil
+	}
+	b := newIndexBuilder(indexPath, cfg)
+	if end, broken, err = scanHints(hint, size, b.add); err != nil {
+		b.removeRuns()
+		return nil, 0, nil, err
+	}
+	if idx, err = b.finish(); err != nil {
+		return nil, 0, nil, err
+	}
+	return idx, end, broken, nil
+}
+
+// migrateHintLog rewrites a legacy hint log of fixed size hints in the current version, the torn tail is dropped
+func migrateHintLog(hintPath string) error {
+	old, err := os.Open(hintPath)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	if finfo.Size()%HintEncodeSize != 0 {
-		return nil, ErrHintLogBroken
+	defer old.Close()
+	size, err := fileSize(old)
+	if err != nil {
+		return err
 	}
-	km := &KeyMap{}
-	km.m = make(map[string]*Hint)
-	hint.Seek(0, 0)
-	offset := uint64(0)
-	buf := make([]byte, HintEncodeSize)
-	for {
-		n, err := hint.Read(buf)
-		if err != nil {
-			if err != io.EOF {
-				return nil, err
-			}
-			// read end file
-			break
-		}
-		// should never happened
-		if n != HintEncodeSize {
-			return nil, xerrors.Errorf("read hint failed, expected %d bytes, read %d bytes", HintEncodeSize, n)
+	// the unfinished migration is cleaned up by recoverMerge like an unfinished merge
+	f, err := os.OpenFile(hintPath+mergeSuffix, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err = initHintLog(f); err != nil {
+		return err
+	}
+	if _, err = f.Seek(hintLogHeaderSize, io.SeekStart); err != nil {
+		return err
+	}
+	r := bufio.NewReader(old)
+	w := bufio.NewWriter(f)
+	buf := make([]byte, legacyHintSize)
+	for i := uint64(0); i < size/legacyHintSize; i++ {
+		if _, err = io.ReadFull(r, buf); err != nil {
+			return err
 		}
 		h := &Hint{}
-		if err = h.From(buf); err != nil {
-			return nil, err
+		if err = h.fromLegacy(buf); err != nil {
+			return err
+		}
+		enc, err := h.Encode()
+		if err != nil {
+			return err
+		}
+		if _, err = w.Write(enc); err != nil {
+			return err
 		}
-		h.KOffset = offset
-		offset += HintEncodeSize
-		km.Add(h.Key, h)
 	}
-	return km, nil
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		return err
+	}
+	if err = os.Rename(hintPath+mergeSuffix, hintPath); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(hintPath))
+	log.Infof("migrated the legacy hint log %s with %d hints", hintPath, size/legacyHintSize)
+	return nil
 }
 
 func buildCaskMap(cfg *Config) (*CaskMap, error) {
@@ -115,36 +307,38 @@ func buildCaskMap(cfg *Config) (*CaskMap, error) {
 		}
 	}()
 
-	for _, ent := range dirents {
-		if !ent.IsDir() && strings.HasSuffix(ent.Name(), hintLogSuffix) {
-			name := strings.TrimSuffix(ent.Name(), hintLogSuffix)
-			id, err := strconv.ParseUint(name, 10, 32)
-			if err != nil {
-				return nil, err
-			}
-			cask := NewCask(uint32(id))
-			cm.Add(uint32(id), cask)
-			cask.hintLog, err = os.OpenFile(filepath.Join(cfg.Path, ent.Name()), os.O_RDWR, 0644)
-			if err != nil {
-				return nil, err
-			}
-			cask.hintLogSize, err = fileSize(cask.hintLog)
-			if err != nil {
-				return nil, err
-			}
-			cask.keyMap, err = buildKeyMap(cask.hintLog)
-			if err != nil {
-				return nil, err
-			}
+	// finish or discard the merges interrupted by last shutdown before loading the casks
+	if err = recoverMerge(cfg.Path, dirents); err != nil {
+		return nil, err
+	}
+	dirents, err = os.ReadDir(cfg.Path)
+	if err != nil {
+		return nil, err
+	}
 
-			cask.vLog, err = os.OpenFile(filepath.Join(cfg.Path, name+vLogSuffix), os.O_RDWR, 0644)
-			if err != nil {
-				return nil, err
-			}
-			cask.vLogSize, err = fileSize(cask.vLog)
-			if err != nil {
-				return nil, err
-			}
+	// a cask is made of a vlog and a hint log, the hint log may be missing if it is going to be rebuilt
+	names := make(map[string]struct{})
+	for _, ent := range dirents {
+		if ent.IsDir() {
+			continue
+		}
+		if strings.HasSuffix(ent.Name(), hintLogSuffix) {
+			names[strings.TrimSuffix(ent.Name(), hintLogSuffix)] = struct{}{}
+		} else if strings.HasSuffix(ent.Name(), vLogSuffix) {
+			names[strings.TrimSuffix(ent.Name(), vLogSuffix)] = struct{}{}
+		}
+	}
+	for name := range names {
+		var id uint64
+		id, err = strconv.ParseUint(name, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		cask := NewCask(uint32(id))
+		cask.cfg = cfg
+		cm.Add(uint32(id), cask)
+		if err = cask.load(cfg, name); err != nil {
+			return nil, xerrors.Errorf("load cask %s: %w", name, err)
 		}
 	}
 
@@ -0,0 +1,647 @@
+package mutcask
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bluele/gcache"
+	"github.com/filedag-project/filedag-storage/kv"
+	"github.com/syndtr/goleveldb/leveldb/filter"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+const indexSuffix = ".index"
+
+// indexMagic starts the index files, which are rebuilt from the hint logs whenever the casks are loaded
+var indexMagic = []byte("MCIX")
+
+const indexVersion = uint32(1)
+
+const indexHeaderSize = fileHeaderSize
+
+// key size + value offset + value size + hint offset + delete flag
+const indexEntryFixedSize = 2 + 8 + 8 + 8 + 1
+
+// indexBlockKeys is the number of entries between two keys of the sparse index kept in memory
+const indexBlockKeys = 64
+
+// sortRunKeys is the max number of hints sorted in memory when an index file is built,
+// the sorted runs are spilled to disk and merged
+var sortRunKeys = 1 << 20
+
+const bloomBitsPerKey = 10
+
+// allKeysPageKeys is the number of keys read from a disk index at once by AllKeysChan
+var allKeysPageKeys = 1000
+
+var bloom = filter.NewBloomFilter(bloomBitsPerKey)
+
+// keyIndex maps the keys of a cask to their hints, a hint got from it must be added back once changed
+type keyIndex interface {
+	Get(key string) (*Hint, bool)
+	Add(key string, hint *Hint)
+	// Range calls fn with every hint until fn returns false, the index must not be changed in fn
+	Range(fn func(h *Hint) bool) error
+	// keys returns the undeleted keys selected by the options, unsorted,
+	// all of them or at least the smallest opts.Limit+1 ones
+	keys(opts kv.ListOptions) ([]string, error)
+	// snapshot freezes the hints for a merge
+	snapshot() (indexSnapshot, error)
+	// replace swaps in the index built from the merged hint log
+	replace(idx keyIndex)
+	close() error
+}
+
+// allKeysPage returns the page size of listing all the keys of the index, 0 lists them at once.
+// The keys of a disk index are paged so that they are not all loaded into memory and the index is not locked
+// for the whole file scan, while the keys of a memory index are in memory anyway
+func allKeysPage(idx keyIndex) int {
+	if _, ok := idx.(*diskIndex); ok {
+		return allKeysPageKeys
+	}
+	return 0
+}
+
+// indexSnapshot is a frozen view of the hints of a cask
+type indexSnapshot interface {
+	Range(fn func(h *Hint) bool) error
+	close()
+}
+
+// liveSize returns the total record size of the undeleted hints
+func liveSize(idx keyIndex, recordSize func(h *Hint) uint64) (size uint64, err error) {
+	err = idx.Range(func(h *Hint) bool {
+		if !h.Deleted {
+			size += recordSize(h)
+		}
+		return true
+	})
+	return
+}
+
+// hintSnapshot is a copy of the hints in memory
+type hintSnapshot []Hint
+
+func (s hintSnapshot) Range(fn func(h *Hint) bool) error {
+	for i := range s {
+		if !fn(&s[i]) {
+			break
+		}
+	}
+	return nil
+}
+
+func (s hintSnapshot) close() {}
+
+/**
+		key size	:	value offset	:	value size	:	hint offset	:	flag	:	key
+		2			:	8				:	8			:	8			:	1		:	xxxx
+**/
+// writeIndexEntry writes the entry of a hint, the entries are sorted by key in the index file
+func writeIndexEntry(w *bufio.Writer, h *Hint) (int, error) {
+	buf := make([]byte, indexEntryFixedSize+len(h.Key))
+	binary.LittleEndian.PutUint16(buf[0:2], uint16(len(h.Key)))
+	binary.LittleEndian.PutUint64(buf[2:10], h.VOffset)
+	binary.LittleEndian.PutUint64(buf[10:18], h.VSize)
+	binary.LittleEndian.PutUint64(buf[18:26], h.KOffset)
+	if h.Deleted {
+		buf[26] = HintDeletedFlag
+	}
+	copy(buf[indexEntryFixedSize:], h.Key)
+	return w.Write(buf)
+}
+
+// readIndexEntry reads the next entry, io.EOF is returned at the end of the entries
+func readIndexEntry(r *bufio.Reader) (*Hint, error) {
+	fixed := make([]byte, indexEntryFixedSize)
+	if _, err := io.ReadFull(r, fixed); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, ErrIndexBroken
+		}
+		return nil, err
+	}
+	key := make([]byte, binary.LittleEndian.Uint16(fixed[0:2]))
+	if _, err := io.ReadFull(r, key); err != nil {
+		return nil, ErrIndexBroken
+	}
+	return &Hint{
+		Key:     string(key),
+		VOffset: binary.LittleEndian.Uint64(fixed[2:10]),
+		VSize:   binary.LittleEndian.Uint64(fixed[10:18]),
+		KOffset: binary.LittleEndian.Uint64(fixed[18:26]),
+		Deleted: fixed[26] == HintDeletedFlag,
+	}, nil
+}
+
+// indexBlock is a key of the sparse index and the offset of its entry in the index file
+type indexBlock struct {
+	key    string
+	offset uint64
+}
+
+// indexFile is an immutable index file with its sparse index and bloom filter
+type indexFile struct {
+	f      *os.File
+	size   uint64
+	blocks []indexBlock
+	filter []byte
+}
+
+// lookup finds the entry of the key in the block which may hold it
+func (x *indexFile) lookup(key string) (*Hint, error) {
+	if len(x.blocks) == 0 || !bloom.Contains(x.filter, []byte(key)) {
+		return nil, nil
+	}
+	i := sort.Search(len(x.blocks), func(i int) bool { return x.blocks[i].key > key }) - 1
+	if i < 0 {
+		return nil, nil
+	}
+	end := x.size
+	if i+1 < len(x.blocks) {
+		end = x.blocks[i+1].offset
+	}
+	r := bufio.NewReader(io.NewSectionReader(x.f, int64(x.blocks[i].offset), int64(end-x.blocks[i].offset)))
+	for {
+		h, err := readIndexEntry(r)
+		if err == io.EOF {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if h.Key == key {
+			return h, nil
+		}
+		if h.Key > key {
+			return nil, nil
+		}
+	}
+}
+
+// reader returns a reader of the entries from the block which may hold the seek key
+func (x *indexFile) reader(seek string) *bufio.Reader {
+	start := uint64(indexHeaderSize)
+	if i := sort.Search(len(x.blocks), func(i int) bool { return x.blocks[i].key > seek }) - 1; i >= 0 {
+		start = x.blocks[i].offset
+	}
+	return bufio.NewReader(io.NewSectionReader(x.f, int64(start), int64(x.size-start)))
+}
+
+// indexWriter writes the sorted hints into an index file, of the hints with the same key the last one is kept
+type indexWriter struct {
+	path    string
+	f       *os.File
+	w       *bufio.Writer
+	offset  uint64
+	count   int
+	blocks  []indexBlock
+	filter  filter.FilterGenerator
+	pending *Hint
+}
+
+// newIndexWriter writes the index file aside, it is moved to the path when finished
+func newIndexWriter(path string) (*indexWriter, error) {
+	f, err := os.OpenFile(path+mergeSuffix, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err = writeFileHeader(f, indexMagic, indexVersion); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, err = f.Seek(indexHeaderSize, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &indexWriter{
+		path:   path,
+		f:      f,
+		w:      bufio.NewWriter(f),
+		offset: indexHeaderSize,
+		filter: bloom.NewGenerator(),
+	}, nil
+}
+
+func (iw *indexWriter) add(h *Hint) error {
+	if iw.pending != nil && iw.pending.Key != h.Key {
+		if err := iw.write(iw.pending); err != nil {
+			return err
+		}
+	}
+	iw.pending = h
+	return nil
+}
+
+func (iw *indexWriter) write(h *Hint) error {
+	if iw.count%indexBlockKeys == 0 {
+		iw.blocks = append(iw.blocks, indexBlock{key: h.Key, offset: iw.offset})
+	}
+	n, err := writeIndexEntry(iw.w, h)
+	if err != nil {
+		return err
+	}
+	iw.filter.Add([]byte(h.Key))
+	iw.offset += uint64(n)
+	iw.count++
+	return nil
+}
+
+func (iw *indexWriter) finish() (*indexFile, error) {
+	if iw.pending != nil {
+		if err := iw.write(iw.pending); err != nil {
+			iw.abort()
+			return nil, err
+		}
+	}
+	if err := iw.w.Flush(); err != nil {
+		iw.abort()
+		return nil, err
+	}
+	// the index file is rebuilt at every load, so it is not synced
+	if err := os.Rename(iw.path+mergeSuffix, iw.path); err != nil {
+		iw.abort()
+		return nil, err
+	}
+	buf := &util.Buffer{}
+	iw.filter.Generate(buf)
+	return &indexFile{f: iw.f, size: iw.offset, blocks: iw.blocks, filter: buf.Bytes()}, nil
+}
+
+func (iw *indexWriter) abort() {
+	iw.f.Close()
+	os.Remove(iw.path + mergeSuffix)
+}
+
+// indexBuilder sorts the hints of a hint log into an index file,
+// at most sortRunKeys hints are held in memory and the sorted runs are spilled to disk
+type indexBuilder struct {
+	path string
+	cfg  *Config
+	buf  []*Hint
+	runs []string
+}
+
+func newIndexBuilder(path string, cfg *Config) *indexBuilder {
+	return &indexBuilder{path: path, cfg: cfg}
+}
+
+// add takes the hints in the order of the hint log
+func (b *indexBuilder) add(h *Hint) error {
+	b.buf = append(b.buf, h)
+	if len(b.buf) >= sortRunKeys {
+		return b.spill()
+	}
+	return nil
+}
+
+// sortHints sorts the hints by key, the hints with the same key stay in the order of the hint log
+func sortHints(hints []*Hint) {
+	sort.SliceStable(hints, func(i, j int) bool { return hints[i].Key < hints[j].Key })
+}
+
+func (b *indexBuilder) spill() error {
+	sortHints(b.buf)
+	// the runs left by a crash are cleaned up by recoverMerge like an unfinished merge
+	path := fmt.Sprintf("%s.%d%s", b.path, len(b.runs), mergeSuffix)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	b.runs = append(b.runs, path)
+	w := bufio.NewWriter(f)
+	for _, h := range b.buf {
+		if _, err = writeIndexEntry(w, h); err != nil {
+			return err
+		}
+	}
+	b.buf = b.buf[:0]
+	return w.Flush()
+}
+
+func (b *indexBuilder) removeRuns() {
+	for _, path := range b.runs {
+		os.Remove(path)
+	}
+}
+
+// finish writes the index file and opens the disk index on it
+func (b *indexBuilder) finish() (*diskIndex, error) {
+	defer b.removeRuns()
+	iw, err := newIndexWriter(b.path)
+	if err != nil {
+		return nil, err
+	}
+	if len(b.runs) == 0 {
+		sortHints(b.buf)
+		for _, h := range b.buf {
+			if err = iw.add(h); err != nil {
+				iw.abort()
+				return nil, err
+			}
+		}
+	} else {
+		if err = b.spill(); err != nil {
+			iw.abort()
+			return nil, err
+		}
+		if err = b.mergeRuns(iw); err != nil {
+			iw.abort()
+			return nil, err
+		}
+	}
+	x, err := iw.finish()
+	if err != nil {
+		return nil, err
+	}
+	return newDiskIndex(b.path, x, b.cfg), nil
+}
+
+// runHead is the next hint of a sorted run
+type runHead struct {
+	h   *Hint
+	run int
+	r   *bufio.Reader
+}
+
+// runHeap orders the heads by key, and the runs spilled later come later for the same key
+type runHeap []*runHead
+
+func (rh runHeap) Len() int { return len(rh) }
+func (rh runHeap) Less(i, j int) bool {
+	if rh[i].h.Key != rh[j].h.Key {
+		return rh[i].h.Key < rh[j].h.Key
+	}
+	return rh[i].run < rh[j].run
+}
+func (rh runHeap) Swap(i, j int)       { rh[i], rh[j] = rh[j], rh[i] }
+func (rh *runHeap) Push(x interface{}) { *rh = append(*rh, x.(*runHead)) }
+func (rh *runHeap) Pop() interface{} {
+	old := *rh
+	x := old[len(old)-1]
+	*rh = old[:len(old)-1]
+	return x
+}
+
+func (b *indexBuilder) mergeRuns(iw *indexWriter) error {
+	hp := &runHeap{}
+	for i, path := range b.runs {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		head := &runHead{run: i, r: bufio.NewReader(f)}
+		if head.h, err = readIndexEntry(head.r); err == io.EOF {
+			continue
+		} else if err != nil {
+			return err
+		}
+		heap.Push(hp, head)
+	}
+	for hp.Len() > 0 {
+		head := (*hp)[0]
+		if err := iw.add(head.h); err != nil {
+			return err
+		}
+		h, err := readIndexEntry(head.r)
+		if err == io.EOF {
+			heap.Pop(hp)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		head.h = h
+		heap.Fix(hp, 0)
+	}
+	return nil
+}
+
+// diskIndex keeps the hints in a sorted index file, only the sparse index, the bloom filter,
+// the hints changed since the file was written and the hot hints are kept in memory.
+// The changed hints are written into a new index file once there are too many of them.
+type diskIndex struct {
+	sync.Mutex
+	path string
+	cfg  *Config
+	file *indexFile
+	// delta holds the hints changed since the index file was written
+	delta map[string]*Hint
+	hot   gcache.Cache
+}
+
+func newDiskIndex(path string, x *indexFile, cfg *Config) *diskIndex {
+	d := &diskIndex{
+		path:  path,
+		cfg:   cfg,
+		file:  x,
+		delta: make(map[string]*Hint),
+	}
+	if cfg.HotKeys > 0 {
+		d.hot = gcache.New(cfg.HotKeys).LRU().Build()
+	}
+	return d
+}
+
+func (d *diskIndex) Get(key string) (*Hint, bool) {
+	d.Lock()
+	defer d.Unlock()
+	if h, has := d.delta[key]; has {
+		return h, true
+	}
+	if d.hot != nil {
+		if v, err := d.hot.Get(key); err == nil {
+			return v.(*Hint), true
+		}
+	}
+	h, err := d.file.lookup(key)
+	if err != nil {
+		log.Errorf("lookup index %s failed: %v", d.path, err)
+		return nil, false
+	}
+	if h == nil {
+		return nil, false
+	}
+	if d.hot != nil {
+		d.hot.Set(key, h)
+	}
+	return h, true
+}
+
+func (d *diskIndex) Add(key string, hint *Hint) {
+	d.Lock()
+	defer d.Unlock()
+	d.delta[key] = hint
+	if d.hot != nil {
+		d.hot.Remove(key)
+	}
+	if len(d.delta) >= d.cfg.IndexDeltaKeys {
+		// the changed hints stay in memory if they can not be written
+		if err := d.compact(); err != nil {
+			log.Errorf("rewrite index %s failed: %v", d.path, err)
+		}
+	}
+}
+
+// iterate calls fn with the hints from the seek key on in key order, the changed hints take the place of the written ones
+func (d *diskIndex) iterate(seek string, fn func(h *Hint) bool) error {
+	var changed []*Hint
+	for key, h := range d.delta {
+		if key >= seek {
+			changed = append(changed, h)
+		}
+	}
+	sortHints(changed)
+	r := d.file.reader(seek)
+	next := func() (*Hint, error) {
+		for {
+			h, err := readIndexEntry(r)
+			if err == io.EOF {
+				return nil, nil
+			}
+			if err != nil || h.Key >= seek {
+				return h, err
+			}
+		}
+	}
+	written, err := next()
+	if err != nil {
+		return err
+	}
+	for written != nil || len(changed) > 0 {
+		var h *Hint
+		switch {
+		case written == nil || (len(changed) > 0 && changed[0].Key < written.Key):
+			h, changed = changed[0], changed[1:]
+		case len(changed) > 0 && changed[0].Key == written.Key:
+			h, changed = changed[0], changed[1:]
+			if written, err = next(); err != nil {
+				return err
+			}
+		default:
+			h = written
+			if written, err = next(); err != nil {
+				return err
+			}
+		}
+		if !fn(h) {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (d *diskIndex) Range(fn func(h *Hint) bool) error {
+	d.Lock()
+	defer d.Unlock()
+	return d.iterate("", fn)
+}
+
+func (d *diskIndex) keys(opts kv.ListOptions) ([]string, error) {
+	d.Lock()
+	defer d.Unlock()
+	var keys []string
+	err := d.iterate(opts.Seek(), func(h *Hint) bool {
+		// the sorted keys after the prefix range can not match
+		if !strings.HasPrefix(h.Key, opts.Prefix) && h.Key > opts.Prefix {
+			return false
+		}
+		if !h.Deleted && opts.Match(h.Key) {
+			keys = append(keys, h.Key)
+		}
+		return !opts.Full(len(keys))
+	})
+	return keys, err
+}
+
+// compact writes the changed hints into a new index file
+func (d *diskIndex) compact() error {
+	iw, err := newIndexWriter(d.path)
+	if err != nil {
+		return err
+	}
+	if err = d.iterate("", func(h *Hint) bool {
+		err = iw.add(h)
+		return err == nil
+	}); err != nil {
+		iw.abort()
+		return err
+	}
+	x, err := iw.finish()
+	if err != nil {
+		return err
+	}
+	d.file.f.Close()
+	d.file = x
+	d.delta = make(map[string]*Hint)
+	return nil
+}
+
+// snapshot writes the changed hints into the index file, and reads the file by its own handle,
+// which keeps reading the same file after it is replaced
+func (d *diskIndex) snapshot() (indexSnapshot, error) {
+	d.Lock()
+	defer d.Unlock()
+	if len(d.delta) > 0 {
+		if err := d.compact(); err != nil {
+			return nil, err
+		}
+	}
+	f, err := os.Open(d.path)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSnapshot{f: f, size: d.file.size}, nil
+}
+
+func (d *diskIndex) replace(idx keyIndex) {
+	nd := idx.(*diskIndex)
+	d.Lock()
+	defer d.Unlock()
+	d.file.f.Close()
+	d.file = nd.file
+	d.delta = nd.delta
+	if d.hot != nil {
+		d.hot.Purge()
+	}
+}
+
+func (d *diskIndex) close() error {
+	d.Lock()
+	defer d.Unlock()
+	return d.file.f.Close()
+}
+
+// fileSnapshot reads the hints from an index file
+type fileSnapshot struct {
+	f    *os.File
+	size uint64
+}
+
+func (s *fileSnapshot) Range(fn func(h *Hint) bool) error {
+	r := bufio.NewReader(io.NewSectionReader(s.f, indexHeaderSize, int64(s.size-indexHeaderSize)))
+	for {
+		h, err := readIndexEntry(r)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !fn(h) {
+			return nil
+		}
+	}
+}
+
+func (s *fileSnapshot) close() {
+	s.f.Close()
+}
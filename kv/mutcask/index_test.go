@@ -0,0 +1,244 @@
+package mutcask
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/filedag-project/filedag-storage/kv"
+	"github.com/filedag-project/filedag-storage/kv/kvtest"
+)
+
+func diskIndexOptions() []Option {
+	return []Option{IndexModeConf(IndexDisk), HotKeysConf(8), IndexDeltaKeysConf(16)}
+}
+
+func TestDiskIndexConformance(t *testing.T) {
+	kvtest.Run(t, func(t *testing.T) kv.KVDB {
+		db, err := NewMutcask(append([]Option{PathConf(t.TempDir()), CaskNumConf(6)}, diskIndexOptions()...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return db
+	})
+}
+
+func TestDiskIndex(t *testing.T) {
+	dir := t.TempDir()
+	mutc := openOneCask(t, dir, diskIndexOptions()...)
+	expected := make(map[string]string)
+	for i := 0; i < 500; i++ {
+		key := fmt.Sprintf("key-%03d", i)
+		expected[key] = fmt.Sprintf("value-%d", i)
+		if err := mutc.Put(key, []byte(expected[key])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 500; i += 3 {
+		key := fmt.Sprintf("key-%03d", i)
+		expected[key] = ""
+		if err := mutc.Delete(key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 1; i < 500; i += 7 {
+		key := fmt.Sprintf("key-%03d", i)
+		expected[key] = fmt.Sprintf("new-%d", i)
+		if err := mutc.Put(key, []byte(expected[key])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkValues(t, mutc, expected)
+	// the keys read again are served by the hot hints
+	checkValues(t, mutc, expected)
+	if _, err := mutc.Get("missing"); err != kv.ErrNotFound {
+		t.Fatalf("expected %v, got %v", kv.ErrNotFound, err)
+	}
+
+	keys, cursor, err := mutc.ListKeys(context.Background(), kv.ListOptions{Prefix: "key-1", StartAfter: "key-120", Limit: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(keys) != "[key-121 key-122 key-124 key-125 key-127]" || cursor != "key-127" {
+		t.Fatalf("unexpected page: %v, cursor %s", keys, cursor)
+	}
+	// all the keys are listed page by page
+	oldPage := allKeysPageKeys
+	allKeysPageKeys = 7
+	kc, err := mutc.AllKeysChan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var all []string
+	for key := range kc {
+		all = append(all, key)
+	}
+	allKeysPageKeys = oldPage
+	live := 0
+	for _, v := range expected {
+		if v != "" {
+			live++
+		}
+	}
+	for i, key := range all {
+		if expected[key] == "" || (i > 0 && key <= all[i-1]) {
+			t.Fatalf("unexpected key %s at %d", key, i)
+		}
+	}
+	if len(all) != live {
+		t.Fatalf("expected %d keys, got %d", live, len(all))
+	}
+
+	if err = mutc.Merge(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	checkValues(t, mutc, expected)
+	stats, err := mutc.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mutc.Close()
+
+	mutc = openOneCask(t, dir, diskIndexOptions()...)
+	checkValues(t, mutc, expected)
+	reopened, err := mutc.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened[0] != stats[0] {
+		t.Fatalf("stats changed after reopen: %+v, %+v", stats[0], reopened[0])
+	}
+	mutc.Close()
+
+	// the memory mode ignores and removes the index file
+	mutc = openOneCask(t, dir)
+	defer mutc.Close()
+	checkValues(t, mutc, expected)
+	matches, err := filepath.Glob(filepath.Join(dir, "*"+indexSuffix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 0 {
+		t.Fatalf("stale index files left: %v", matches)
+	}
+}
+
+func TestDiskIndexMergeWithWrites(t *testing.T) {
+	mutc := openOneCask(t, t.TempDir(), diskIndexOptions()...)
+	defer mutc.Close()
+	for i := 0; i < 200; i++ {
+		if err := mutc.Put(fmt.Sprintf("key-%d", i), []byte(fmt.Sprintf("value-%d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	done := make(chan error, 1)
+	go func() {
+		for i := 0; i < 200; i++ {
+			key := fmt.Sprintf("key-%d", i)
+			var err error
+			if i%2 == 0 {
+				err = mutc.Delete(key)
+			} else {
+				err = mutc.Put(key, []byte(fmt.Sprintf("merged-%d", i)))
+			}
+			if err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+	if err := mutc.Merge(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 200; i++ {
+		v, err := mutc.Get(fmt.Sprintf("key-%d", i))
+		if i%2 == 0 {
+			if err != kv.ErrNotFound {
+				t.Fatalf("key-%d should be deleted, got %s, %v", i, v, err)
+			}
+		} else if err != nil || string(v) != fmt.Sprintf("merged-%d", i) {
+			t.Fatalf("unexpected value of key-%d: %s, %v", i, v, err)
+		}
+	}
+}
+
+func TestIndexBuilderRuns(t *testing.T) {
+	defer func(n int) { sortRunKeys = n }(sortRunKeys)
+	sortRunKeys = 10
+
+	path := filepath.Join(t.TempDir(), "00000000"+indexSuffix)
+	b := newIndexBuilder(path, &Config{HotKeys: 0, IndexDeltaKeys: 1 << 10})
+	// the later hints of a key win, even across the runs
+	for round := 0; round < 3; round++ {
+		for i := 0; i < 45; i++ {
+			h := &Hint{Key: fmt.Sprintf("key-%02d", i), VOffset: uint64(round), VSize: uint64(i)}
+			if err := b.add(h); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	idx, err := b.finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.close()
+	if len(b.runs) == 0 {
+		t.Fatal("expected spilled runs")
+	}
+	for _, run := range b.runs {
+		if _, err = os.Stat(run); !os.IsNotExist(err) {
+			t.Fatalf("run %s left: %v", run, err)
+		}
+	}
+	count := 0
+	if err = idx.Range(func(h *Hint) bool {
+		if h.Key != fmt.Sprintf("key-%02d", count) || h.VOffset != 2 || h.VSize != uint64(count) {
+			t.Fatalf("unexpected hint %+v at %d", h, count)
+		}
+		count++
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if count != 45 {
+		t.Fatalf("expected 45 hints, got %d", count)
+	}
+	for i := 0; i < 45; i++ {
+		if h, has := idx.Get(fmt.Sprintf("key-%02d", i)); !has || h.VOffset != 2 {
+			t.Fatalf("unexpected hint of key-%02d: %+v, %v", i, h, has)
+		}
+	}
+	if _, has := idx.Get("key-45"); has {
+		t.Fatal("key-45 should not be found")
+	}
+}
+
+func TestKeyMapKeysLimit(t *testing.T) {
+	km := &KeyMap{m: make(map[string]*Hint)}
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key-%03d", i)
+		km.Add(key, &Hint{Key: key, Deleted: i%10 == 0})
+	}
+	keys, err := km.keys(kv.ListOptions{StartAfter: "key-050", Limit: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// only the smallest limit+1 keys are kept
+	page, cursor := kv.SortPage(keys, 3, false)
+	if len(keys) != 4 || fmt.Sprint(page) != "[key-051 key-052 key-053]" || cursor != "key-053" {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+	keys, err = km.keys(kv.ListOptions{StartAfter: "key-095"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 4 {
+		t.Fatalf("expected all the 4 keys after key-095, got %v", keys)
+	}
+}
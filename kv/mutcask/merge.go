@@ -0,0 +1,332 @@
+package mutcask
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+)
+
+// CaskStats describes the space usage of one cask's vlog
+type CaskStats struct {
+	ID uint32
+	// LiveBytes is the size of the records still referenced by an undeleted key
+	LiveBytes uint64
+	// DeadBytes is the size of the records which were deleted or overwritten
+	DeadBytes uint64
+	// Version is the format version of the vlog, the casks of old versions are upgraded by a merge
+	Version uint32
+}
+
+// DeadRatio returns the ratio of dead bytes to the whole vlog size
+func (s CaskStats) DeadRatio() float64 {
+	total := s.LiveBytes + s.DeadBytes
+	if total == 0 {
+		return 0
+	}
+	return float64(s.DeadBytes) / float64(total)
+}
+
+// LiveRatio returns the ratio of live bytes to the whole vlog size
+func (s CaskStats) LiveRatio() float64 {
+	total := s.LiveBytes + s.DeadBytes
+	if total == 0 {
+		return 0
+	}
+	return float64(s.LiveBytes) / float64(total)
+}
+
+// mergeState holds the files written by a merge
+type mergeState struct {
+	vLogPath    string
+	hintLogPath string
+	vLog        *os.File
+	vLogSize    uint64
+	hintLog     *os.File
+	hintLogSize uint64
+	// snapshot keeps the hints at the time the merge started
+	snapshot indexSnapshot
+	// committed is set once the merged files must be swapped in, even after a restart
+	committed bool
+}
+
+func (s *mergeState) close() {
+	if s.snapshot != nil {
+		s.snapshot.close()
+		s.snapshot = nil
+	}
+	if s.vLog != nil {
+		s.vLog.Close()
+	}
+	if s.hintLog != nil {
+		s.hintLog.Close()
+	}
+}
+
+func (s *mergeState) remove() {
+	s.close()
+	os.Remove(s.vLogPath + mergeSuffix)
+	os.Remove(s.hintLogPath + mergeSuffix)
+}
+
+// appendRecord copies an encoded value into the merged files and appends its hint,
+// the merged vlog is always written in the current version. The keys changed during the merge are appended again,
+// and the last hint of a key wins when the merged hint log is loaded.
+func (s *mergeState) appendRecord(key string, encValue []byte, deleted bool) error {
+	record, err := encodeRecord(currentVLogVersion, key, deleted, encValue)
+	if err != nil {
+		return err
+	}
+	if _, err = s.vLog.WriteAt(record, int64(s.vLogSize)); err != nil {
+		return err
+	}
+	h := &Hint{Key: key, KOffset: s.hintLogSize, Deleted: deleted}
+	// a tombstone carries no value to point at
+	if !deleted {
+		h.VOffset = s.vLogSize + uint64(len(record)-len(encValue))
+		h.VSize = uint64(len(encValue))
+	}
+	enc, err := h.Encode()
+	if err != nil {
+		return err
+	}
+	if _, err = s.hintLog.WriteAt(enc, int64(h.KOffset)); err != nil {
+		return err
+	}
+	s.vLogSize += uint64(len(record))
+	s.hintLogSize += h.EncodedSize()
+	return nil
+}
+
+// Stats returns the space usage of the cask
+func (c *Cask) Stats() (CaskStats, error) {
+	ret, err := c.send(&action{optype: opstat})
+	if err != nil {
+		return CaskStats{}, err
+	}
+	return ret.stats, nil
+}
+
+// send passes the action to the cask goroutine and waits for the result,
+// it gives up if the cask has been closed
+func (c *Cask) send(act *action) (retv, error) {
+	act.retvchan = make(chan retv, 1)
+	select {
+	case <-c.closeChan:
+		return retv{}, ErrCaskClosed
+	case c.actChan <- act:
+	}
+	ret := <-act.retvchan
+	return ret, ret.err
+}
+
+// Merge rewrites the live records of the cask into fresh vlog and hint files and swaps them in.
+//
+// The live records are copied in the background while the cask keeps serving reads and writes,
+// the changes made during the copy are applied when the new files are swapped in.
+func (c *Cask) Merge(ctx context.Context) (err error) {
+	if !atomic.CompareAndSwapInt32(&c.merging, 0, 1) {
+		return ErrMergeInProgress
+	}
+	defer atomic.StoreInt32(&c.merging, 0)
+
+	st := &mergeState{}
+	if _, err = c.send(&action{optype: opsnapshot, merge: st}); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil && !st.committed {
+			st.remove()
+			c.send(&action{optype: opmergeabort})
+		}
+	}()
+	if st.vLog, err = os.OpenFile(st.vLogPath+mergeSuffix, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644); err != nil {
+		return err
+	}
+	if err = initVLog(st.vLog); err != nil {
+		return err
+	}
+	st.vLogSize = vLogHeaderSize
+	if st.hintLog, err = os.OpenFile(st.hintLogPath+mergeSuffix, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644); err != nil {
+		return err
+	}
+	if err = initHintLog(st.hintLog); err != nil {
+		return err
+	}
+	st.hintLogSize = hintLogHeaderSize
+	var copyErr error
+	if err = st.snapshot.Range(func(h *Hint) bool {
+		if h.Deleted {
+			return true
+		}
+		if copyErr = ctx.Err(); copyErr != nil {
+			return false
+		}
+		// the records below the snapshot size are never rewritten, so it is safe to read them here
+		buf := make([]byte, h.VSize)
+		if _, copyErr = c.vLog.ReadAt(buf, int64(h.VOffset)); copyErr != nil {
+			return false
+		}
+		copyErr = st.appendRecord(h.Key, buf, false)
+		return copyErr == nil
+	}); err != nil {
+		return err
+	}
+	if err = copyErr; err != nil {
+		return err
+	}
+	_, err = c.send(&action{optype: opswap, merge: st})
+	return err
+}
+
+func (c *Cask) dosnapshot(act *action) {
+	st := act.merge
+	st.vLogPath = c.vLog.Name()
+	st.hintLogPath = c.hintLog.Name()
+	snapshot, err := c.keyMap.snapshot()
+	if err != nil {
+		act.retvchan <- retv{err: err}
+		return
+	}
+	st.snapshot = snapshot
+	c.changed = make(map[string]struct{})
+	act.retvchan <- retv{}
+}
+
+// domergeabort stops tracking the changed keys of a failed merge
+func (c *Cask) domergeabort(act *action) {
+	c.changed = nil
+	act.retvchan <- retv{}
+}
+
+// markChanged records a key written or deleted while a merge is in progress
+func (c *Cask) markChanged(key string) {
+	if c.changed != nil {
+		c.changed[key] = struct{}{}
+	}
+}
+
+// doswap applies the changes made since the snapshot to the merged files and swaps them in
+func (c *Cask) doswap(act *action) {
+	var err error
+	st := act.merge
+	defer func() {
+		if err != nil {
+			act.retvchan <- retv{err: err}
+		}
+	}()
+	for key := range c.changed {
+		h, has := c.keyMap.Get(key)
+		if !has {
+			continue
+		}
+		// the key may have been copied from the snapshot, so the tombstone is appended after it
+		if h.Deleted {
+			if err = st.appendRecord(key, EncodeValue(nil), true); err != nil {
+				return
+			}
+			continue
+		}
+		// the record was written after the snapshot
+		buf := make([]byte, h.VSize)
+		if _, err = c.vLog.ReadAt(buf, int64(h.VOffset)); err != nil {
+			return
+		}
+		if err = st.appendRecord(key, buf, false); err != nil {
+			return
+		}
+	}
+	if err = st.vLog.Sync(); err != nil {
+		return
+	}
+	if err = st.hintLog.Sync(); err != nil {
+		return
+	}
+	idx, _, _, err := buildIndex(c.cfg, st.hintLogPath, st.hintLog, st.hintLogSize)
+	if err != nil {
+		return
+	}
+	// renaming the merged hint log is the commit point, an interrupted swap is finished by recoverMerge
+	if err = os.Rename(st.hintLogPath+mergeSuffix, st.hintLogPath+mergedSuffix); err != nil {
+		idx.close()
+		return
+	}
+	c.changed = nil
+	st.committed = true
+	st.close()
+	// the original files may be gone from now on, so the cask stops serving if the swap fails,
+	// the merge will be finished by recoverMerge at next start
+	if err = commitMerge(st.vLogPath, st.hintLogPath); err != nil {
+		idx.close()
+		c.close()
+		return
+	}
+	vLog, err := os.OpenFile(st.vLogPath, os.O_RDWR, 0644)
+	if err != nil {
+		idx.close()
+		c.close()
+		return
+	}
+	hintLog, err := os.OpenFile(st.hintLogPath, os.O_RDWR, 0644)
+	if err != nil {
+		vLog.Close()
+		idx.close()
+		c.close()
+		return
+	}
+	c.vLog.Close()
+	c.hintLog.Close()
+	c.vLog, c.vLogSize = vLog, st.vLogSize
+	c.hintLog, c.hintLogSize = hintLog, st.hintLogSize
+	c.vLogVersion = currentVLogVersion
+	c.keyMap.replace(idx)
+	if c.liveSize, err = liveSize(c.keyMap, c.recordSize); err != nil {
+		return
+	}
+	act.retvchan <- retv{}
+}
+
+// commitMerge moves the merged files over the original ones
+func commitMerge(vLogPath, hintLogPath string) error {
+	if _, err := os.Stat(vLogPath + mergeSuffix); err == nil {
+		if err = os.Rename(vLogPath+mergeSuffix, vLogPath); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(hintLogPath+mergedSuffix, hintLogPath); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(hintLogPath))
+	return nil
+}
+
+// recoverMerge finishes the merges which were committed and removes the files of the unfinished ones
+func recoverMerge(dir string, dirents []os.DirEntry) error {
+	for _, ent := range dirents {
+		if !ent.IsDir() && strings.HasSuffix(ent.Name(), hintLogSuffix+mergedSuffix) {
+			id := strings.TrimSuffix(ent.Name(), hintLogSuffix+mergedSuffix)
+			if err := commitMerge(filepath.Join(dir, id+vLogSuffix), filepath.Join(dir, id+hintLogSuffix)); err != nil {
+				return err
+			}
+		}
+	}
+	// the merge files left now belong to merges which were never committed
+	for _, ent := range dirents {
+		if !ent.IsDir() && strings.HasSuffix(ent.Name(), mergeSuffix) {
+			if err := os.Remove(filepath.Join(dir, ent.Name())); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	defer d.Close()
+	d.Sync()
+}
@@ -7,12 +7,16 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/filedag-project/filedag-storage/kv"
 	fslock "github.com/ipfs/go-fs-lock"
+	logging "github.com/ipfs/go-log/v2"
 	"golang.org/x/xerrors"
 )
 
+var log = logging.Logger("mutcask")
+
 const lockFileName = "repo.lock"
 
 var _ kv.KVDB = (*mutcask)(nil)
@@ -24,6 +28,7 @@ type mutcask struct {
 	createCaskChan chan *createCaskRequst
 	close          func()
 	closeChan      chan struct{}
+	mergeWg        sync.WaitGroup
 }
 
 func NewMutcask(opts ...Option) (*mutcask, error) {
@@ -39,6 +44,9 @@ func NewMutcask(opts ...Option) (*mutcask, error) {
 	if repoPath == "" {
 		return nil, ErrPathUndefined
 	}
+	if err := m.cfg.validate(); err != nil {
+		return nil, err
+	}
 	repo, err := os.Stat(repoPath)
 	if err == nil && !repo.IsDir() {
 		return nil, ErrPath
@@ -73,13 +81,84 @@ func NewMutcask(opts ...Option) (*mutcask, error) {
 	m.close = func() {
 		once.Do(func() {
 			close(m.closeChan)
+			// wait for the running merge before closing the casks
+			m.mergeWg.Wait()
+			if m.cfg.SyncMode != SyncNone {
+				if err := m.Sync(); err != nil {
+					log.Warnf("sync on close failed: %v", err)
+				}
+			}
+			m.caskMap.CloseAll()
 			unlockRepo.Close()
 		})
 	}
 	m.handleCreateCask()
+	if m.cfg.MergeInterval > 0 {
+		m.handleMerge()
+	}
+	if m.cfg.SyncMode == SyncInterval && m.cfg.SyncInterval > 0 {
+		m.handleSync()
+	}
 	return m, nil
 }
 
+// handleSync periodically syncs the casks written since last sync
+func (m *mutcask) handleSync() {
+	go func(m *mutcask) {
+		ticker := time.NewTicker(m.cfg.SyncInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.closeChan:
+				return
+			case <-ticker.C:
+				if err := m.Sync(); err != nil {
+					log.Warnf("sync casks failed: %v", err)
+				}
+			}
+		}
+	}(m)
+}
+
+// handleMerge periodically merges the casks which have too many dead bytes
+func (m *mutcask) handleMerge() {
+	m.mergeWg.Add(1)
+	go func(m *mutcask) {
+		defer m.mergeWg.Done()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			select {
+			case <-m.closeChan:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		ticker := time.NewTicker(m.cfg.MergeInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.closeChan:
+				return
+			case <-ticker.C:
+				for _, cask := range m.caskMap.casks() {
+					stats, err := cask.Stats()
+					if err != nil {
+						continue
+					}
+					if stats.DeadBytes < m.cfg.MergeMinDeadBytes || stats.DeadRatio() < m.cfg.MergeRatio {
+						continue
+					}
+					log.Infof("merge cask %d, live bytes: %d, dead bytes: %d", stats.ID, stats.LiveBytes, stats.DeadBytes)
+					if err = cask.Merge(ctx); err != nil {
+						log.Warnf("merge cask %d failed: %v", stats.ID, err)
+					}
+				}
+			}
+		}
+	}(m)
+}
+
 func (m *mutcask) handleCreateCask() {
 	go func(m *mutcask) {
 		ids := []uint32{}
@@ -95,6 +174,7 @@ func (m *mutcask) handleCreateCask() {
 						return
 					}
 					cask := NewCask(req.id)
+					cask.cfg = m.cfg
 					var err error
 					// create vlog file
 					cask.vLog, err = os.OpenFile(filepath.Join(m.cfg.Path, m.vLogName(req.id)), os.O_RDWR|os.O_CREATE, 0644)
@@ -102,12 +182,27 @@ func (m *mutcask) handleCreateCask() {
 						req.done <- err
 						return
 					}
+					if err = initVLog(cask.vLog); err != nil {
+						req.done <- err
+						return
+					}
+					cask.vLogSize = vLogHeaderSize
+					cask.vLogVersion = currentVLogVersion
 					// create hintlog file
 					cask.hintLog, err = os.OpenFile(filepath.Join(m.cfg.Path, m.hintLogName(req.id)), os.O_RDWR|os.O_CREATE, 0644)
 					if err != nil {
 						req.done <- err
 						return
 					}
+					if err = initHintLog(cask.hintLog); err != nil {
+						req.done <- err
+						return
+					}
+					cask.hintLogSize = hintLogHeaderSize
+					if cask.keyMap, _, _, err = buildIndex(m.cfg, cask.hintLog.Name(), cask.hintLog, cask.hintLogSize); err != nil {
+						req.done <- err
+						return
+					}
 					m.caskMap.Add(req.id, cask)
 					ids = append(ids, req.id)
 					req.done <- ErrNone
@@ -126,10 +221,27 @@ func (m *mutcask) hintLogName(id uint32) string {
 }
 
 func (m *mutcask) Put(key string, value []byte) (err error) {
+	cask, err := m.openCask(key)
+	if err != nil {
+		return err
+	}
+	return cask.Put(key, value)
+}
+
+// PutStream writes the value of the size by key in chunks, the cask of the key is held until the writer
+// is committed or aborted
+func (m *mutcask) PutStream(key string, size int) (kv.ValueWriter, error) {
+	cask, err := m.openCask(key)
+	if err != nil {
+		return nil, err
+	}
+	return cask.PutStream(key, size)
+}
+
+// openCask returns the cask of the key, it is created if missing
+func (m *mutcask) openCask(key string) (*Cask, error) {
 	id := m.fileID(key)
-	var cask *Cask
-	var has bool
-	cask, has = m.caskMap.Get(id)
+	cask, has := m.caskMap.Get(id)
 	if !has {
 		done := make(chan error)
 		m.createCaskChan <- &createCaskRequst{
@@ -137,12 +249,11 @@ func (m *mutcask) Put(key string, value []byte) (err error) {
 			done: done,
 		}
 		if err := <-done; err != ErrNone {
-			return err
+			return nil, err
 		}
 		cask, _ = m.caskMap.Get(id)
 	}
-
-	return cask.Put(key, value)
+	return cask, nil
 }
 
 func (m *mutcask) Delete(key string) error {
@@ -165,6 +276,15 @@ func (m *mutcask) Get(key string) ([]byte, error) {
 	return cask.Read(key)
 }
 
+func (m *mutcask) GetRange(key string, offset, length int) ([]byte, error) {
+	id := m.fileID(key)
+	cask, has := m.caskMap.Get(id)
+	if !has {
+		return nil, kv.ErrNotFound
+	}
+	return cask.ReadRange(key, offset, length)
+}
+
 func (m *mutcask) Size(key string) (int, error) {
 	id := m.fileID(key)
 	cask, has := m.caskMap.Get(id)
@@ -175,31 +295,122 @@ func (m *mutcask) Size(key string) (int, error) {
 }
 
 func (m *mutcask) Close() error {
-	m.caskMap.CloseAll()
 	m.close()
 	return nil
 }
+
+// Sync flushes the writes of every cask to the disk
+func (m *mutcask) Sync() error {
+	for _, cask := range m.caskMap.casks() {
+		if err := cask.Sync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stats returns the space usage of every cask
+func (m *mutcask) Stats() ([]CaskStats, error) {
+	var ret []CaskStats
+	for _, cask := range m.caskMap.casks() {
+		stats, err := cask.Stats()
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, stats)
+	}
+	return ret, nil
+}
+
+// Merge merges every cask whose dead bytes ratio is not less than the given ratio,
+// the casks without dead bytes are skipped
+func (m *mutcask) Merge(ctx context.Context, ratio float64) error {
+	for _, cask := range m.caskMap.casks() {
+		stats, err := cask.Stats()
+		if err != nil {
+			return err
+		}
+		if stats.DeadBytes == 0 || stats.DeadRatio() < ratio {
+			continue
+		}
+		if err = cask.Merge(ctx); err != nil && err != ErrMergeInProgress {
+			return err
+		}
+	}
+	return nil
+}
+
+// Upgrade merges the casks whose vlogs are of old versions, which rewrites them in the current version.
+// The legacy vlogs can not rebuild their hint logs, and the old versions limit the key and value sizes.
+func (m *mutcask) Upgrade(ctx context.Context) error {
+	for _, cask := range m.caskMap.casks() {
+		stats, err := cask.Stats()
+		if err != nil {
+			return err
+		}
+		if stats.Version == currentVLogVersion {
+			continue
+		}
+		log.Infof("upgrade cask %d from vlog version %d", stats.ID, stats.Version)
+		if err = cask.Merge(ctx); err != nil && err != ErrMergeInProgress {
+			return err
+		}
+	}
+	return nil
+}
+
+// AllKeysChan streams the keys of the casks one by one. The keys of a memory index are copied under its lock,
+// a disk index is listed in pages of sorted keys and its lock is only held for a page
 func (m *mutcask) AllKeysChan(ctx context.Context) (<-chan string, error) {
 	kc := make(chan string)
 	go func(ctx context.Context, m *mutcask) {
 		defer close(kc)
-		for _, cask := range m.caskMap.m {
-			for key, h := range cask.keyMap.m {
-				if h.Deleted {
-					continue
-				}
-				select {
-				case <-ctx.Done():
+		for _, cask := range m.caskMap.casks() {
+			opts := kv.ListOptions{Limit: allKeysPage(cask.keyMap)}
+			for {
+				keys, err := cask.keyMap.keys(opts)
+				if err != nil {
+					log.Errorf("list keys of cask %d failed: %v", cask.id, err)
 					return
-				default:
-					kc <- key
 				}
+				cursor := ""
+				if opts.Limit > 0 {
+					keys, cursor = kv.SortPage(keys, opts.Limit, false)
+				}
+				for _, key := range keys {
+					select {
+					case <-ctx.Done():
+						return
+					case kc <- key:
+					}
+				}
+				if cursor == "" {
+					break
+				}
+				opts.StartAfter = cursor
 			}
 		}
 	}(ctx, m)
 	return kc, nil
 }
 
+// ListKeys collects the smallest selected keys of every cask and sorts them, at most opts.Limit+1 keys of a cask
+func (m *mutcask) ListKeys(ctx context.Context, opts kv.ListOptions) ([]string, string, error) {
+	var keys []string
+	for _, cask := range m.caskMap.casks() {
+		if err := ctx.Err(); err != nil {
+			return nil, "", err
+		}
+		ckeys, err := cask.keyMap.keys(opts)
+		if err != nil {
+			return nil, "", err
+		}
+		keys = append(keys, ckeys...)
+	}
+	keys, cursor := kv.SortPage(keys, opts.Limit, false)
+	return keys, cursor, nil
+}
+
 func (m *mutcask) fileID(key string) uint32 {
 	crc := crc32.ChecksumIEEE([]byte(key))
 	return crc % m.cfg.CaskNum
@@ -5,10 +5,12 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"strings"
 	"sync"
 	"testing"
 
 	"github.com/filedag-project/filedag-storage/kv"
+	"github.com/filedag-project/filedag-storage/kv/kvtest"
 )
 
 func TestMutcask(t *testing.T) {
@@ -147,3 +149,212 @@ type kvt struct {
 	Key   string
 	Value []byte
 }
+
+func TestMutcaskMerge(t *testing.T) {
+	dir := tmpdirpath(t)
+	mutc, err := NewMutcask(PathConf(dir), CaskNumConf(2), MergeIntervalConf(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		keys = append(keys, key)
+		if err = mutc.Put(key, []byte(fmt.Sprintf("value-%d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// overwrite half of the keys and delete the others
+	for i, key := range keys {
+		if i%2 == 0 {
+			err = mutc.Put(key, []byte(fmt.Sprintf("new-value-%d", i)))
+		} else {
+			err = mutc.Delete(key)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	stats, err := mutc.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range stats {
+		if s.DeadBytes == 0 || s.DeadRatio() == 0 {
+			t.Fatalf("cask %d should have dead bytes", s.ID)
+		}
+	}
+	if err = mutc.Merge(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	stats, err = mutc.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range stats {
+		if s.DeadBytes != 0 || s.LiveRatio() != 1 {
+			t.Fatalf("cask %d should have no dead bytes after merge, got %d", s.ID, s.DeadBytes)
+		}
+	}
+	check := func(mutc *mutcask, from int) {
+		for i := from; i < len(keys); i++ {
+			key := keys[i]
+			v, err := mutc.Get(key)
+			if i%2 == 0 {
+				if err != nil || string(v) != fmt.Sprintf("new-value-%d", i) {
+					t.Fatalf("unexpected value of %s: %s, %v", key, v, err)
+				}
+			} else if err != kv.ErrNotFound {
+				t.Fatalf("%s should be deleted, got %v", key, err)
+			}
+		}
+	}
+	check(mutc, 0)
+	// the cask keeps working after merge
+	if err = mutc.Put(keys[1], []byte("value-back")); err != nil {
+		t.Fatal(err)
+	}
+	if err = mutc.Delete(keys[0]); err != nil {
+		t.Fatal(err)
+	}
+	mutc.Close()
+
+	mutc, err = NewMutcask(PathConf(dir), CaskNumConf(2), MergeIntervalConf(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mutc.Close()
+	if v, err := mutc.Get(keys[1]); err != nil || string(v) != "value-back" {
+		t.Fatalf("unexpected value of %s: %s, %v", keys[1], v, err)
+	}
+	if _, err := mutc.Get(keys[0]); err != kv.ErrNotFound {
+		t.Fatalf("%s should be deleted, got %v", keys[0], err)
+	}
+	check(mutc, 2)
+}
+
+func TestMutcaskMergeWithWrites(t *testing.T) {
+	mutc, err := NewMutcask(PathConf(tmpdirpath(t)), CaskNumConf(1), MergeIntervalConf(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mutc.Close()
+	for i := 0; i < 200; i++ {
+		if err = mutc.Put(fmt.Sprintf("key-%d", i), []byte(fmt.Sprintf("value-%d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// make sure there are dead bytes to merge
+	if err = mutc.Delete("key-0"); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			if err := mutc.Put(fmt.Sprintf("key-%d", i), []byte(fmt.Sprintf("merged-%d", i))); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	if err = mutc.Merge(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+	for i := 0; i < 200; i++ {
+		v, err := mutc.Get(fmt.Sprintf("key-%d", i))
+		if err != nil || string(v) != fmt.Sprintf("merged-%d", i) {
+			t.Fatalf("unexpected value of key-%d: %s, %v", i, v, err)
+		}
+	}
+}
+
+func TestConformance(t *testing.T) {
+	kvtest.Run(t, func(t *testing.T) kv.KVDB {
+		db, err := NewMutcask(PathConf(t.TempDir()), CaskNumConf(6))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return db
+	})
+}
+
+func TestMutcaskLongKey(t *testing.T) {
+	dir := t.TempDir()
+	mutc, err := NewMutcask(PathConf(dir), CaskNumConf(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := strings.Repeat("k", 1000)
+	if err = mutc.Put(key, []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	if err = mutc.Put(strings.Repeat("k", MaxKeySize+1), []byte("value")); err != ErrKeySizeTooLong {
+		t.Fatalf("expected %v, got %v", ErrKeySizeTooLong, err)
+	}
+	mutc.Close()
+
+	mutc, err = NewMutcask(PathConf(dir), CaskNumConf(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mutc.Close()
+	if v, err := mutc.Get(key); err != nil || string(v) != "value" {
+		t.Fatalf("unexpected value: %s, %v", v, err)
+	}
+}
+
+func TestMutcaskPutStreamReopen(t *testing.T) {
+	dir := t.TempDir()
+	mutc, err := NewMutcask(PathConf(dir), CaskNumConf(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	value := bytes.Repeat([]byte("streamed"), 1<<17)
+	w, err := mutc.PutStream("streamed", len(value))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the other operations of the cask wait for the writer
+	putDone := make(chan error, 1)
+	go func() {
+		putDone <- mutc.Put("other", []byte("other"))
+	}()
+	for i := 0; i < len(value); i += 1 << 16 {
+		if _, err = w.Write(value[i : i+1<<16]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = w.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err = <-putDone; err != nil {
+		t.Fatal(err)
+	}
+	// the aborted value leaves nothing behind
+	w, err = mutc.PutStream("aborted", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.Write(make([]byte, 50)); err != nil {
+		t.Fatal(err)
+	}
+	w.Abort()
+	mutc.Close()
+
+	mutc, err = NewMutcask(PathConf(dir), CaskNumConf(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mutc.Close()
+	if v, err := mutc.Get("streamed"); err != nil || !bytes.Equal(v, value) {
+		t.Fatalf("unexpected streamed value of %d bytes: %v", len(v), err)
+	}
+	if v, err := mutc.Get("other"); err != nil || string(v) != "other" {
+		t.Fatalf("unexpected value: %s, %v", v, err)
+	}
+	if _, err = mutc.Get("aborted"); err != kv.ErrNotFound {
+		t.Fatalf("expected %v, got %v", kv.ErrNotFound, err)
+	}
+}
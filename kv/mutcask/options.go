@@ -1,14 +1,96 @@
 package mutcask
 
+import "time"
+
 type Config struct {
 	Path    string
 	CaskNum uint32
+	// MergeInterval is how often the casks are checked for merging, 0 disables the background merge
+	MergeInterval time.Duration
+	// MergeRatio is the dead bytes ratio from which a cask will be merged
+	MergeRatio float64
+	// MergeMinDeadBytes is the dead bytes size below which a cask will never be merged in background
+	MergeMinDeadBytes uint64
+	// SyncMode is when the writes are flushed to the disk
+	SyncMode SyncMode
+	// SyncInterval is how often the written casks are synced in SyncInterval mode
+	SyncInterval time.Duration
+	// RecoverMode is how the casks are checked when they are loaded
+	RecoverMode RecoverMode
+	// IndexMode is where the hints of the keys are kept
+	IndexMode IndexMode
+	// HotKeys is the max number of hints read from the disk index kept in memory for each cask
+	HotKeys int
+	// IndexDeltaKeys is the max number of changed hints kept in memory for each cask before the disk index is rewritten
+	IndexDeltaKeys int
 }
 
+// SyncMode is when the writes of a cask are flushed to the disk
+type SyncMode string
+
+const (
+	// SyncAlways syncs the vlog and then the hint log before every write returns
+	SyncAlways SyncMode = "always"
+	// SyncInterval syncs the written casks every SyncInterval
+	SyncInterval SyncMode = "interval"
+	// SyncNone leaves the flushing to the operating system
+	SyncNone SyncMode = "none"
+)
+
+// RecoverMode is how the hints of a cask are checked against its vlog when the cask is loaded
+type RecoverMode string
+
+const (
+	// RecoverTail verifies the records at the tail of the vlog only, which are the ones a crash may tear
+	RecoverTail RecoverMode = "tail"
+	// RecoverFull verifies the crc of the record of every hint
+	RecoverFull RecoverMode = "full"
+	// RecoverRebuild rebuilds the hint logs from the vlogs
+	RecoverRebuild RecoverMode = "rebuild"
+)
+
+// IndexMode is where the hints of a cask are kept
+type IndexMode string
+
+const (
+	// IndexMemory keeps all the hints in a map
+	IndexMemory IndexMode = "memory"
+	// IndexDisk keeps the hints in a sorted index file with a bloom filter, only the hot and the changed hints stay in memory
+	IndexDisk IndexMode = "disk"
+)
+
 func defaultConfig() *Config {
 	return &Config{
-		CaskNum: 256,
+		CaskNum:           256,
+		MergeInterval:     10 * time.Minute,
+		MergeRatio:        0.5,
+		MergeMinDeadBytes: 64 << 20,
+		SyncMode:          SyncInterval,
+		SyncInterval:      time.Second,
+		RecoverMode:       RecoverTail,
+		IndexMode:         IndexMemory,
+		HotKeys:           4096,
+		IndexDeltaKeys:    64 << 10,
+	}
+}
+
+func (cfg *Config) validate() error {
+	switch cfg.SyncMode {
+	case SyncAlways, SyncInterval, SyncNone:
+	default:
+		return ErrSyncMode
+	}
+	switch cfg.RecoverMode {
+	case RecoverTail, RecoverFull, RecoverRebuild:
+	default:
+		return ErrRecoverMode
+	}
+	switch cfg.IndexMode {
+	case IndexMemory, IndexDisk:
+	default:
+		return ErrIndexMode
 	}
+	return nil
 }
 
 type Option func(cfg *Config)
@@ -24,3 +106,57 @@ func PathConf(dir string) Option {
 		cfg.Path = dir
 	}
 }
+
+func MergeIntervalConf(interval time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.MergeInterval = interval
+	}
+}
+
+func MergeRatioConf(ratio float64) Option {
+	return func(cfg *Config) {
+		cfg.MergeRatio = ratio
+	}
+}
+
+func MergeMinDeadBytesConf(size uint64) Option {
+	return func(cfg *Config) {
+		cfg.MergeMinDeadBytes = size
+	}
+}
+
+func SyncModeConf(mode SyncMode) Option {
+	return func(cfg *Config) {
+		cfg.SyncMode = mode
+	}
+}
+
+func SyncIntervalConf(interval time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.SyncInterval = interval
+	}
+}
+
+func RecoverModeConf(mode RecoverMode) Option {
+	return func(cfg *Config) {
+		cfg.RecoverMode = mode
+	}
+}
+
+func IndexModeConf(mode IndexMode) Option {
+	return func(cfg *Config) {
+		cfg.IndexMode = mode
+	}
+}
+
+func HotKeysConf(n int) Option {
+	return func(cfg *Config) {
+		cfg.HotKeys = n
+	}
+}
+
+func IndexDeltaKeysConf(n int) Option {
+	return func(cfg *Config) {
+		cfg.IndexDeltaKeys = n
+	}
+}
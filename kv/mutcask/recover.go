@@ -0,0 +1,264 @@
+package mutcask
+
+import (
+	"os"
+	"path/filepath"
+
+	"golang.org/x/xerrors"
+)
+
+// recoverTailBytes is the size of the vlog tail whose records are verified in RecoverTail mode,
+// which holds the writes a crash may have torn
+const recoverTailBytes = 64 << 20
+
+// load opens the files of the cask and recovers them from what a crash may leave behind:
+// torn tails, hints pointing at records which never reached the disk, and records whose hints were lost
+func (c *Cask) load(cfg *Config, name string) (err error) {
+	hintPath := filepath.Join(cfg.Path, name+hintLogSuffix)
+	_, err = os.Stat(hintPath)
+	noHint := os.IsNotExist(err)
+	if err != nil && !noHint {
+		return err
+	}
+	if c.vLog, err = os.OpenFile(filepath.Join(cfg.Path, name+vLogSuffix), os.O_RDWR, 0644); err != nil {
+		return err
+	}
+	if c.vLogSize, err = fileSize(c.vLog); err != nil {
+		return err
+	}
+	// a vlog without any record is started over in the current version
+	if c.vLogSize == 0 {
+		if err = initVLog(c.vLog); err != nil {
+			return err
+		}
+		c.vLogSize = vLogHeaderSize
+	}
+	if c.vLogVersion, err = readFileVersion(c.vLog, c.vLogSize, vLogMagic); err != nil {
+		return err
+	}
+	if c.vLogVersion > currentVLogVersion {
+		return xerrors.Errorf("unsupported vlog version %d", c.vLogVersion)
+	}
+
+	if c.vLogVersion != legacyVLogVersion && (noHint || cfg.RecoverMode == RecoverRebuild) {
+		if err = c.rebuildHintLog(cfg, hintPath); err != nil {
+			return err
+		}
+	} else {
+		if noHint {
+			return ErrNoHintLog
+		}
+		if err = c.openHintLog(hintPath); err != nil {
+			return err
+		}
+		var end uint64
+		var broken []*Hint
+		if c.keyMap, end, broken, err = buildIndex(cfg, hintPath, c.hintLog, c.hintLogSize); err != nil {
+			return err
+		}
+		// a torn hint at the tail belongs to a new key whose write never returned
+		if end < c.hintLogSize {
+			log.Warnf("truncate %d bytes of torn hint log tail of cask %s", c.hintLogSize-end, name)
+			if err = c.hintLog.Truncate(int64(end)); err != nil {
+				return err
+			}
+			c.hintLogSize = end
+		}
+		if len(broken) > 0 && c.vLogVersion != legacyVLogVersion {
+			log.Warnf("%d hints of cask %s are broken, rebuild the hint log", len(broken), name)
+			c.keyMap.close()
+			c.hintLog.Close()
+			if err = c.rebuildHintLog(cfg, hintPath); err != nil {
+				return err
+			}
+		} else {
+			for _, h := range broken {
+				log.Warnf("the hint of key %s in cask %s is broken, the key is deleted", h.Key, name)
+				h.VOffset, h.VSize = 0, 0
+				if err = c.writeHint(h); err != nil {
+					return err
+				}
+				// a later hint of the key written by a merge wins over the broken one
+				if cur, has := c.keyMap.Get(h.Key); has && cur.KOffset == h.KOffset {
+					c.keyMap.Add(h.Key, h)
+				}
+			}
+			if err = c.recoverHints(cfg.RecoverMode == RecoverFull); err != nil {
+				return err
+			}
+		}
+	}
+	if err = c.vLog.Sync(); err != nil {
+		return err
+	}
+	if err = c.hintLog.Sync(); err != nil {
+		return err
+	}
+	c.liveSize, err = liveSize(c.keyMap, c.recordSize)
+	return err
+}
+
+// openHintLog opens the hint log, a legacy one is migrated to the current version first
+func (c *Cask) openHintLog(hintPath string) (err error) {
+	for {
+		if c.hintLog, err = os.OpenFile(hintPath, os.O_RDWR, 0644); err != nil {
+			return err
+		}
+		if c.hintLogSize, err = fileSize(c.hintLog); err != nil {
+			return err
+		}
+		version, err := readFileVersion(c.hintLog, c.hintLogSize, hintLogMagic)
+		if err != nil {
+			return err
+		}
+		switch version {
+		case currentHintLogVersion:
+			return nil
+		case legacyHintLogVersion:
+			c.hintLog.Close()
+			if err = migrateHintLog(hintPath); err != nil {
+				return xerrors.Errorf("migrate hint log: %w", err)
+			}
+		default:
+			return xerrors.Errorf("unsupported hint log version %d", version)
+		}
+	}
+}
+
+// recoverHints deletes the hints whose records are broken, applies the records written after the last hinted one,
+// and truncates the torn vlog tail. Only the hints pointing into the vlog tail are verified unless verifyAll is set.
+func (c *Cask) recoverHints(verifyAll bool) error {
+	// end is where the last record referenced by a valid hint ends
+	end := c.dataStart()
+	// the index must not be changed while it is ranged, so the broken hints are deleted afterwards
+	var broken []*Hint
+	var readErr error
+	if err := c.keyMap.Range(func(h *Hint) bool {
+		hend := h.VOffset + h.VSize
+		ok := h.VOffset >= c.dataStart() && hend <= c.vLogSize
+		if ok && !h.Deleted && (verifyAll || hend+recoverTailBytes > c.vLogSize) {
+			buf := make([]byte, h.VSize)
+			if _, readErr = c.vLog.ReadAt(buf, int64(h.VOffset)); readErr != nil {
+				return false
+			}
+			_, err := DecodeValue(buf, true)
+			ok = err == nil
+		}
+		if !ok {
+			if !h.Deleted {
+				broken = append(broken, h)
+			}
+			return true
+		}
+		if hend > end {
+			end = hend
+		}
+		return true
+	}); err != nil {
+		return err
+	}
+	if readErr != nil {
+		return readErr
+	}
+	for _, h := range broken {
+		log.Warnf("the record of key %s in cask %d is broken, the key is deleted", h.Key, c.id)
+		h.Deleted = true
+		if err := c.writeHint(h); err != nil {
+			return err
+		}
+		c.keyMap.Add(h.Key, h)
+	}
+
+	// the legacy records carry no key, so the bytes after the last hinted record can only be dropped
+	if c.vLogVersion == legacyVLogVersion {
+		return c.truncateVLog(end)
+	}
+	scanned, err := scanVLog(c.vLog, c.vLogVersion, end, c.vLogSize, c.applyRecord)
+	if err != nil {
+		return err
+	}
+	return c.truncateVLog(scanned)
+}
+
+// rebuildHintLog writes a new hint log from the records of the vlog,
+// the records after a broken one are not indexed but left in the vlog.
+// The hints are collected in memory during the rebuild, the disk index is built from the new hint log afterwards.
+func (c *Cask) rebuildHintLog(cfg *Config, hintPath string) (err error) {
+	km := &KeyMap{m: make(map[string]*Hint)}
+	c.keyMap = km
+	// the unfinished rebuild is cleaned up by recoverMerge like an unfinished merge
+	if c.hintLog, err = os.OpenFile(hintPath+mergeSuffix, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644); err != nil {
+		return err
+	}
+	if err = initHintLog(c.hintLog); err != nil {
+		return err
+	}
+	c.hintLogSize = hintLogHeaderSize
+	end, err := scanVLog(c.vLog, c.vLogVersion, vLogHeaderSize, c.vLogSize, c.applyRecord)
+	if err != nil {
+		return err
+	}
+	if end < c.vLogSize {
+		log.Warnf("%d bytes from offset %d in the vlog of cask %d can not be read", c.vLogSize-end, end, c.id)
+	}
+	if err = c.hintLog.Sync(); err != nil {
+		return err
+	}
+	if err = os.Rename(hintPath+mergeSuffix, hintPath); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(hintPath))
+	log.Infof("rebuilt the hint log of cask %d with %d keys", c.id, len(km.m))
+	if cfg.IndexMode == IndexDisk {
+		c.keyMap, _, _, err = buildIndex(cfg, hintPath, c.hintLog, c.hintLogSize)
+	}
+	return err
+}
+
+// applyRecord points the hint of the key at a record found in the vlog
+func (c *Cask) applyRecord(rh recordHeader, voffset uint64) error {
+	h, has := c.keyMap.Get(rh.key)
+	if rh.deleted {
+		// the hint of a deleted key keeps pointing at its last value
+		if !has || h.Deleted {
+			return nil
+		}
+		h.Deleted = true
+	} else {
+		if !has {
+			h = &Hint{Key: rh.key, KOffset: c.hintLogSize}
+		}
+		h.VOffset = voffset
+		h.VSize = rh.vsize
+		h.Deleted = false
+	}
+	if err := c.writeHint(h); err != nil {
+		return err
+	}
+	if !has {
+		c.hintLogSize += h.EncodedSize()
+	}
+	c.keyMap.Add(rh.key, h)
+	return nil
+}
+
+func (c *Cask) writeHint(h *Hint) error {
+	enc, err := h.Encode()
+	if err != nil {
+		return err
+	}
+	_, err = c.hintLog.WriteAt(enc, int64(h.KOffset))
+	return err
+}
+
+func (c *Cask) truncateVLog(size uint64) error {
+	if size >= c.vLogSize {
+		return nil
+	}
+	log.Warnf("truncate %d bytes of torn vlog tail of cask %d", c.vLogSize-size, c.id)
+	if err := c.vLog.Truncate(int64(size)); err != nil {
+		return err
+	}
+	c.vLogSize = size
+	return nil
+}
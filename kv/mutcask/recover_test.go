@@ -0,0 +1,377 @@
+package mutcask
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/filedag-project/filedag-storage/kv"
+)
+
+func openOneCask(t *testing.T, dir string, opts ...Option) *mutcask {
+	opts = append([]Option{PathConf(dir), CaskNumConf(1), MergeIntervalConf(0)}, opts...)
+	mutc, err := NewMutcask(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mutc
+}
+
+func checkValues(t *testing.T, mutc *mutcask, expected map[string]string) {
+	for key, value := range expected {
+		v, err := mutc.Get(key)
+		if value == "" {
+			if err != kv.ErrNotFound {
+				t.Fatalf("%s should not be found, got %s, %v", key, v, err)
+			}
+			continue
+		}
+		if err != nil || string(v) != value {
+			t.Fatalf("unexpected value of %s: %s, %v", key, v, err)
+		}
+	}
+}
+
+func appendFile(t *testing.T, path string, data []byte) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err = f.Write(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRecoverTornTails(t *testing.T) {
+	dir := t.TempDir()
+	mutc := openOneCask(t, dir, SyncModeConf(SyncAlways))
+	expected := make(map[string]string)
+	for i := 0; i < 10; i++ {
+		key, value := fmt.Sprintf("key-%d", i), fmt.Sprintf("value-%d", i)
+		if err := mutc.Put(key, []byte(value)); err != nil {
+			t.Fatal(err)
+		}
+		expected[key] = value
+	}
+	mutc.Close()
+
+	vLogPath := filepath.Join(dir, mutc.vLogName(0))
+	hintPath := filepath.Join(dir, mutc.hintLogName(0))
+	vinfo, _ := os.Stat(vLogPath)
+	hinfo, _ := os.Stat(hintPath)
+	// a record and a hint which were being written when the process crashed
+	record, err := encodeRecord(currentVLogVersion, "key-torn", false, EncodeValue([]byte("torn value")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hint, err := (&Hint{Key: "key-torn", VOffset: uint64(vinfo.Size()), VSize: uint64(len(record))}).Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, vLogPath, record[:len(record)-3])
+	appendFile(t, hintPath, hint[:len(hint)-3])
+
+	mutc = openOneCask(t, dir)
+	expected["key-torn"] = ""
+	checkValues(t, mutc, expected)
+	stats, err := mutc.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats[0].DeadBytes != 0 {
+		t.Fatalf("the torn tail should be truncated, got %d dead bytes", stats[0].DeadBytes)
+	}
+	mutc.Close()
+	if info, _ := os.Stat(vLogPath); info.Size() != vinfo.Size() {
+		t.Fatalf("vlog size should be %d, got %d", vinfo.Size(), info.Size())
+	}
+	if info, _ := os.Stat(hintPath); info.Size() != hinfo.Size() {
+		t.Fatalf("hint log size should be %d, got %d", hinfo.Size(), info.Size())
+	}
+}
+
+func TestRecoverLostHints(t *testing.T) {
+	dir := t.TempDir()
+	mutc := openOneCask(t, dir)
+	for i := 0; i < 5; i++ {
+		if err := mutc.Put(fmt.Sprintf("key-%d", i), []byte(fmt.Sprintf("value-%d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mutc.Close()
+	hintPath := filepath.Join(dir, mutc.hintLogName(0))
+	hints, err := ioutil.ReadFile(hintPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mutc = openOneCask(t, dir)
+	if err = mutc.Put("key-0", []byte("new-value-0")); err != nil {
+		t.Fatal(err)
+	}
+	if err = mutc.Delete("key-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err = mutc.Put("key-5", []byte("value-5")); err != nil {
+		t.Fatal(err)
+	}
+	mutc.Close()
+	// the vlog reached the disk but the hint log did not
+	if err = ioutil.WriteFile(hintPath, hints, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mutc = openOneCask(t, dir)
+	defer mutc.Close()
+	checkValues(t, mutc, map[string]string{
+		"key-0": "new-value-0",
+		"key-1": "",
+		"key-2": "value-2",
+		"key-4": "value-4",
+		"key-5": "value-5",
+	})
+}
+
+func TestRecoverHintsPastVLog(t *testing.T) {
+	dir := t.TempDir()
+	mutc := openOneCask(t, dir)
+	for i := 0; i < 5; i++ {
+		if err := mutc.Put(fmt.Sprintf("key-%d", i), []byte(fmt.Sprintf("value-%d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mutc.Close()
+	vLogPath := filepath.Join(dir, mutc.vLogName(0))
+	vinfo, _ := os.Stat(vLogPath)
+
+	mutc = openOneCask(t, dir)
+	if err := mutc.Put("key-5", []byte("value-5")); err != nil {
+		t.Fatal(err)
+	}
+	if err := mutc.Put("key-0", []byte("new-value-0")); err != nil {
+		t.Fatal(err)
+	}
+	mutc.Close()
+	// the hint log reached the disk but the vlog did not
+	if err := os.Truncate(vLogPath, vinfo.Size()); err != nil {
+		t.Fatal(err)
+	}
+
+	mutc = openOneCask(t, dir)
+	checkValues(t, mutc, map[string]string{
+		"key-0": "",
+		"key-1": "value-1",
+		"key-5": "",
+	})
+	if err := mutc.Put("key-5", []byte("value-5-again")); err != nil {
+		t.Fatal(err)
+	}
+	mutc.Close()
+
+	mutc = openOneCask(t, dir)
+	defer mutc.Close()
+	checkValues(t, mutc, map[string]string{"key-5": "value-5-again"})
+}
+
+func TestRecoverBrokenRecord(t *testing.T) {
+	dir := t.TempDir()
+	mutc := openOneCask(t, dir)
+	if err := mutc.Put("key-0", []byte("value-0")); err != nil {
+		t.Fatal(err)
+	}
+	if err := mutc.Put("key-1", []byte("value-1")); err != nil {
+		t.Fatal(err)
+	}
+	mutc.Close()
+	// rot the last byte of the value of key-1
+	vLogPath := filepath.Join(dir, mutc.vLogName(0))
+	data, err := ioutil.ReadFile(vLogPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-1] ^= 0xff
+	if err = ioutil.WriteFile(vLogPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mutc = openOneCask(t, dir, RecoverModeConf(RecoverFull))
+	defer mutc.Close()
+	checkValues(t, mutc, map[string]string{
+		"key-0": "value-0",
+		"key-1": "",
+	})
+}
+
+func TestRebuildHintLog(t *testing.T) {
+	dir := t.TempDir()
+	mutc := openOneCask(t, dir)
+	expected := make(map[string]string)
+	for i := 0; i < 20; i++ {
+		key, value := fmt.Sprintf("key-%d", i), fmt.Sprintf("value-%d", i)
+		if err := mutc.Put(key, []byte(value)); err != nil {
+			t.Fatal(err)
+		}
+		expected[key] = value
+	}
+	for i := 0; i < 20; i += 2 {
+		key := fmt.Sprintf("key-%d", i)
+		if err := mutc.Delete(key); err != nil {
+			t.Fatal(err)
+		}
+		expected[key] = ""
+	}
+	for i := 0; i < 20; i += 4 {
+		key, value := fmt.Sprintf("key-%d", i), fmt.Sprintf("value-%d-again", i)
+		if err := mutc.Put(key, []byte(value)); err != nil {
+			t.Fatal(err)
+		}
+		expected[key] = value
+	}
+	stats, err := mutc.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mutc.Close()
+
+	// the hint log is rebuilt from the vlog alone
+	if err = os.Remove(filepath.Join(dir, mutc.hintLogName(0))); err != nil {
+		t.Fatal(err)
+	}
+	mutc = openOneCask(t, dir)
+	checkValues(t, mutc, expected)
+	rebuilt, err := mutc.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rebuilt[0] != stats[0] {
+		t.Fatalf("stats should be %+v after rebuild, got %+v", stats[0], rebuilt[0])
+	}
+	mutc.Close()
+
+	mutc = openOneCask(t, dir, RecoverModeConf(RecoverRebuild))
+	defer mutc.Close()
+	checkValues(t, mutc, expected)
+}
+
+func TestLegacyVLog(t *testing.T) {
+	dir := t.TempDir()
+	// a cask written before the vlog records carried their keys
+	var vlog, hints []byte
+	for i := 0; i < 3; i++ {
+		enc := EncodeValue([]byte(fmt.Sprintf("value-%d", i)))
+		h := &Hint{Key: fmt.Sprintf("key-%d", i), VOffset: uint64(len(vlog)), VSize: uint64(len(enc))}
+		vlog = append(vlog, enc...)
+		hints = append(hints, encodeLegacyHint(h)...)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "00000000"+vLogSuffix), vlog, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "00000000"+hintLogSuffix), hints, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// the legacy hint log is migrated when opened
+	mutc := openOneCask(t, dir)
+	checkValues(t, mutc, map[string]string{"key-0": "value-0", "key-1": "value-1", "key-2": "value-2"})
+	if err := mutc.Put("key-1", []byte("new-value-1")); err != nil {
+		t.Fatal(err)
+	}
+	// the legacy vlog records carry no key, so the long keys fit in it
+	longKey := strings.Repeat("k", LegacyMaxKeySize+1)
+	if err := mutc.Put(longKey, []byte("long")); err != nil {
+		t.Fatal(err)
+	}
+	if err := mutc.Delete("key-2"); err != nil {
+		t.Fatal(err)
+	}
+	mutc.Close()
+
+	mutc = openOneCask(t, dir)
+	expected := map[string]string{"key-0": "value-0", "key-1": "new-value-1", "key-2": "", longKey: "long"}
+	checkValues(t, mutc, expected)
+	if stats, err := mutc.Stats(); err != nil || stats[0].Version != legacyVLogVersion {
+		t.Fatalf("the vlog should be of the legacy version, got %+v, %v", stats, err)
+	}
+	// an upgrade rewrites the cask in the current version
+	if err := mutc.Upgrade(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if stats, err := mutc.Stats(); err != nil || stats[0].Version != currentVLogVersion {
+		t.Fatalf("the vlog should be of the current version, got %+v, %v", stats, err)
+	}
+	mutc.Close()
+
+	if err := os.Remove(filepath.Join(dir, "00000000"+hintLogSuffix)); err != nil {
+		t.Fatal(err)
+	}
+	mutc = openOneCask(t, dir)
+	defer mutc.Close()
+	checkValues(t, mutc, expected)
+}
+
+func TestRecoverBrokenHint(t *testing.T) {
+	dir := t.TempDir()
+	mutc := openOneCask(t, dir)
+	expected := make(map[string]string)
+	for i := 0; i < 5; i++ {
+		key, value := fmt.Sprintf("key-%d", i), fmt.Sprintf("value-%d", i)
+		if err := mutc.Put(key, []byte(value)); err != nil {
+			t.Fatal(err)
+		}
+		expected[key] = value
+	}
+	mutc.Close()
+	// tear the value offset of the first hint, which was being rewritten in place
+	hintPath := filepath.Join(dir, mutc.hintLogName(0))
+	data, err := ioutil.ReadFile(hintPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[hintLogHeaderSize+8] ^= 0xff
+	if err = ioutil.WriteFile(hintPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mutc = openOneCask(t, dir)
+	defer mutc.Close()
+	checkValues(t, mutc, expected)
+}
+
+func TestVLogVersion1(t *testing.T) {
+	dir := t.TempDir()
+	// a vlog of version 1 without its hint log
+	vlog := make([]byte, vLogHeaderSize)
+	copy(vlog, vLogMagic)
+	binary.LittleEndian.PutUint32(vlog[4:], vLogVersion1)
+	for i := 0; i < 3; i++ {
+		record, err := encodeRecord(vLogVersion1, fmt.Sprintf("key-%d", i), false, EncodeValue([]byte(fmt.Sprintf("value-%d", i))))
+		if err != nil {
+			t.Fatal(err)
+		}
+		vlog = append(vlog, record...)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "00000000"+vLogSuffix), vlog, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mutc := openOneCask(t, dir)
+	defer mutc.Close()
+	checkValues(t, mutc, map[string]string{"key-0": "value-0", "key-1": "value-1", "key-2": "value-2"})
+	longKey := strings.Repeat("k", 256)
+	if err := mutc.Put(longKey, []byte("long")); err != ErrKeySizeTooLong {
+		t.Fatalf("the key is too long for version 1, got %v", err)
+	}
+	if err := mutc.Upgrade(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := mutc.Put(longKey, []byte("long")); err != nil {
+		t.Fatal(err)
+	}
+	checkValues(t, mutc, map[string]string{"key-0": "value-0", longKey: "long"})
+}
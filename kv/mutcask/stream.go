@@ -0,0 +1,131 @@
+package mutcask
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+
+	"github.com/filedag-project/filedag-storage/kv"
+)
+
+// streamReadSize is the size of the chunks read back to compute the crc of a streamed value
+const streamReadSize = 1 << 20
+
+// caskWriter writes a value to the end of the vlog in chunks. The cask is lent to the writer
+// by dowritestream, so the writer uses the files of the cask until it is committed or aborted
+type caskWriter struct {
+	c       *Cask
+	key     string
+	size    int64
+	written int64
+	// voffset is the offset of the encoded value in the vlog, the value follows its 4 bytes crc
+	voffset uint64
+	release chan struct{}
+	done    bool
+}
+
+// dowritestream writes the header of the streamed record at the end of the vlog,
+// then it waits until the writer is committed or aborted
+func (c *Cask) dowritestream(act *action) {
+	w := act.writer
+	w.voffset = c.vLogSize
+	if c.vLogVersion != legacyVLogVersion {
+		header, err := encodeRecordHeader(c.vLogVersion, act.key, false, uint64(4+w.size))
+		if err == nil {
+			_, err = c.vLog.WriteAt(header, int64(c.vLogSize))
+		}
+		if err != nil {
+			act.retvchan <- retv{err: err}
+			return
+		}
+		w.voffset += uint64(len(header))
+	}
+	act.retvchan <- retv{}
+	select {
+	case <-w.release:
+	case <-c.closeChan:
+	}
+}
+
+func (w *caskWriter) Write(p []byte) (int, error) {
+	n, err := w.WriteAt(p, w.written)
+	w.written += int64(n)
+	return n, err
+}
+
+func (w *caskWriter) WriteAt(p []byte, off int64) (int, error) {
+	if w.done {
+		return 0, kv.ErrWriterDone
+	}
+	if off < 0 || off > w.written || off+int64(len(p)) > w.size {
+		return 0, kv.ErrValueSize
+	}
+	return w.c.vLog.WriteAt(p, int64(w.voffset)+4+off)
+}
+
+// Commit computes the crc of the value from the vlog, since the written bytes may be overwritten,
+// and then points the hint of the key at the record
+func (w *caskWriter) Commit() error {
+	if w.done {
+		return kv.ErrWriterDone
+	}
+	err := w.commit()
+	if err != nil {
+		w.truncate()
+	}
+	w.finish()
+	return err
+}
+
+func (w *caskWriter) commit() error {
+	if w.written != w.size {
+		return kv.ErrValueSize
+	}
+	c := w.c
+	crc := uint32(0)
+	bufSize := w.size
+	if bufSize > streamReadSize {
+		bufSize = streamReadSize
+	}
+	buf := make([]byte, bufSize)
+	for off := int64(0); off < w.size; {
+		n := w.size - off
+		if n > streamReadSize {
+			n = streamReadSize
+		}
+		if _, err := c.vLog.ReadAt(buf[:n], int64(w.voffset)+4+off); err != nil {
+			return err
+		}
+		crc = crc32.Update(crc, crc32.IEEETable, buf[:n])
+		off += n
+	}
+	crcBuf := make([]byte, 4)
+	binary.LittleEndian.PutUint32(crcBuf, crc)
+	if _, err := c.vLog.WriteAt(crcBuf, int64(w.voffset)); err != nil {
+		return err
+	}
+	c.vLogSize = w.voffset + 4 + uint64(w.size)
+	// the vlog is synced before the hint is written, so a synced hint never points at a lost record
+	if err := c.syncWrite(c.vLog); err != nil {
+		return err
+	}
+	return c.putHint(w.key, w.voffset, uint64(4+w.size))
+}
+
+func (w *caskWriter) Abort() {
+	if w.done {
+		return
+	}
+	w.truncate()
+	w.finish()
+}
+
+// truncate drops the bytes written after the last record, the recovery would drop them anyway
+func (w *caskWriter) truncate() {
+	_ = w.c.vLog.Truncate(int64(w.c.vLogSize))
+}
+
+// finish gives the cask back to its actor
+func (w *caskWriter) finish() {
+	w.done = true
+	close(w.release)
+}
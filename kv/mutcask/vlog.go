@@ -0,0 +1,228 @@
+package mutcask
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"math"
+	"os"
+)
+
+// vLogMagic starts the vlog files whose records carry their keys, so the hints can be rebuilt from them,
+// the vlog files written before hold bare values only
+var vLogMagic = []byte("MCVL")
+
+const (
+	// legacyVLogVersion is the version of the vlog files without a header
+	legacyVLogVersion = uint32(0)
+	// vLogVersion1 records the key size in 1 byte and the value size in 4 bytes
+	vLogVersion1 = uint32(1)
+	// currentVLogVersion records the key size in 2 bytes and the value size in 8 bytes
+	currentVLogVersion = uint32(2)
+)
+
+// magic + version
+const fileHeaderSize = 4 + 4
+
+const vLogHeaderSize = fileHeaderSize
+
+// recordLayout is the width of the size fields in the record headers of a vlog version
+type recordLayout struct {
+	keySizeLen   int
+	valueSizeLen int
+}
+
+func recordLayoutOf(version uint32) recordLayout {
+	if version == vLogVersion1 {
+		return recordLayout{keySizeLen: 1, valueSizeLen: 4}
+	}
+	return recordLayout{keySizeLen: 2, valueSizeLen: 8}
+}
+
+// flag + key size + value size + header crc
+func (l recordLayout) fixedSize() uint64 {
+	return uint64(1 + l.keySizeLen + l.valueSizeLen + 4)
+}
+
+func (l recordLayout) headerSize(key string) uint64 {
+	return l.fixedSize() + uint64(len(key))
+}
+
+func (l recordLayout) maxKeySize() int {
+	return 1<<(8*l.keySizeLen) - 1
+}
+
+func (l recordLayout) maxValueSize() uint64 {
+	if l.valueSizeLen == 4 {
+		return math.MaxUint32
+	}
+	return math.MaxUint64
+}
+
+// recordHeader describes a record of a vlog file which carries its key
+type recordHeader struct {
+	key     string
+	deleted bool
+	// vsize is the size of the encoded value following the header
+	vsize uint64
+	// size is the size of the header itself
+	size uint64
+}
+
+/**
+		flag	:	key size	:	value size	:	key		:	crc32	:	encoded value
+		1		:	1 or 2		:	4 or 8		:	xxxx	:	4		:	xxxx
+**/
+// encodeRecord prefixes the encoded value with a header, the hint of the record points at the encoded value
+func encodeRecord(version uint32, key string, deleted bool, encValue []byte) ([]byte, error) {
+	header, err := encodeRecordHeader(version, key, deleted, uint64(len(encValue)))
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]byte, len(header)+len(encValue))
+	copy(ret, header)
+	copy(ret[len(header):], encValue)
+	return ret, nil
+}
+
+// encodeRecordHeader encodes the header of a record whose encoded value is vsize bytes
+func encodeRecordHeader(version uint32, key string, deleted bool, vsize uint64) ([]byte, error) {
+	l := recordLayoutOf(version)
+	if len(key) > l.maxKeySize() {
+		return nil, ErrKeySizeTooLong
+	}
+	if vsize > l.maxValueSize() {
+		return nil, ErrValueSizeTooLarge
+	}
+	hs := l.headerSize(key)
+	ret := make([]byte, hs)
+	if deleted {
+		ret[0] = HintDeletedFlag
+	}
+	p := 1
+	putUint(ret[p:p+l.keySizeLen], uint64(len(key)))
+	p += l.keySizeLen
+	putUint(ret[p:p+l.valueSizeLen], vsize)
+	p += l.valueSizeLen
+	copy(ret[p:], key)
+	binary.LittleEndian.PutUint32(ret[hs-4:hs], crc32.ChecksumIEEE(ret[:hs-4]))
+	return ret, nil
+}
+
+// readRecordHeader reads the header of the record at the offset, ErrRecordBroken is returned if there is no valid one
+func readRecordHeader(f *os.File, version uint32, offset, size uint64) (rh recordHeader, err error) {
+	l := recordLayoutOf(version)
+	if offset+l.fixedSize() > size {
+		return rh, ErrRecordBroken
+	}
+	sizes := make([]byte, l.fixedSize()-4)
+	if _, err = f.ReadAt(sizes, int64(offset)); err != nil {
+		return rh, err
+	}
+	if sizes[0] != 0 && sizes[0] != HintDeletedFlag {
+		return rh, ErrRecordBroken
+	}
+	kl := getUint(sizes[1 : 1+l.keySizeLen])
+	hs := l.fixedSize() + kl
+	if offset+hs > size {
+		return rh, ErrRecordBroken
+	}
+	buf := make([]byte, hs)
+	copy(buf, sizes)
+	if _, err = f.ReadAt(buf[len(sizes):], int64(offset)+int64(len(sizes))); err != nil {
+		return rh, err
+	}
+	if crc32.ChecksumIEEE(buf[:hs-4]) != binary.LittleEndian.Uint32(buf[hs-4:]) {
+		return rh, ErrRecordBroken
+	}
+	rh.deleted = buf[0] == HintDeletedFlag
+	rh.vsize = getUint(buf[1+l.keySizeLen : len(sizes)])
+	rh.key = string(buf[len(sizes) : hs-4])
+	rh.size = hs
+	return rh, nil
+}
+
+// scanVLog calls fn with every valid record from the offset on, in the order they were written.
+// It returns the offset where the valid records end, which is less than size if a broken record was met.
+func scanVLog(f *os.File, version uint32, offset, size uint64, fn func(rh recordHeader, voffset uint64) error) (uint64, error) {
+	for offset < size {
+		rh, err := readRecordHeader(f, version, offset, size)
+		if err == ErrRecordBroken {
+			return offset, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		voffset := offset + rh.size
+		if voffset+rh.vsize > size {
+			return offset, nil
+		}
+		buf := make([]byte, rh.vsize)
+		if _, err = f.ReadAt(buf, int64(voffset)); err != nil {
+			return 0, err
+		}
+		if _, err = DecodeValue(buf, true); err != nil {
+			return offset, nil
+		}
+		if err = fn(rh, voffset); err != nil {
+			return 0, err
+		}
+		offset = voffset + rh.vsize
+	}
+	return offset, nil
+}
+
+// writeFileHeader writes the magic and the version at the head of a vlog or hint log file
+func writeFileHeader(f *os.File, magic []byte, version uint32) error {
+	buf := make([]byte, fileHeaderSize)
+	copy(buf, magic)
+	binary.LittleEndian.PutUint32(buf[4:], version)
+	_, err := f.WriteAt(buf, 0)
+	return err
+}
+
+// readFileVersion returns the version written at the head of the file, 0 if the file starts without the magic
+func readFileVersion(f *os.File, size uint64, magic []byte) (uint32, error) {
+	if size < fileHeaderSize {
+		return 0, nil
+	}
+	buf := make([]byte, fileHeaderSize)
+	if _, err := f.ReadAt(buf, 0); err != nil {
+		return 0, err
+	}
+	if !bytes.Equal(buf[:4], magic) {
+		return 0, nil
+	}
+	return binary.LittleEndian.Uint32(buf[4:]), nil
+}
+
+// initVLog writes the header of the current version into an empty vlog file
+func initVLog(f *os.File) error {
+	return writeFileHeader(f, vLogMagic, currentVLogVersion)
+}
+
+func putUint(buf []byte, v uint64) {
+	switch len(buf) {
+	case 1:
+		buf[0] = uint8(v)
+	case 2:
+		binary.LittleEndian.PutUint16(buf, uint16(v))
+	case 4:
+		binary.LittleEndian.PutUint32(buf, uint32(v))
+	default:
+		binary.LittleEndian.PutUint64(buf, v)
+	}
+}
+
+func getUint(buf []byte) uint64 {
+	switch len(buf) {
+	case 1:
+		return uint64(buf[0])
+	case 2:
+		return uint64(binary.LittleEndian.Uint16(buf))
+	case 4:
+		return uint64(binary.LittleEndian.Uint32(buf))
+	default:
+		return binary.LittleEndian.Uint64(buf)
+	}
+}
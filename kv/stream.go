@@ -0,0 +1,86 @@
+package kv
+
+import (
+	"golang.org/x/xerrors"
+)
+
+// ErrValueSize is returned when the bytes written to a ValueWriter do not match the size of the value
+var ErrValueSize = xerrors.New("kv: the written bytes do not match the value size")
+
+// ErrWriterDone is returned when a ValueWriter is used after it is committed or aborted
+var ErrWriterDone = xerrors.New("kv: the value writer is committed or aborted")
+
+// ValueWriter writes a value in chunks, the value is stored once it is committed
+type ValueWriter interface {
+	// Write appends the chunk to the value
+	Write(p []byte) (int, error)
+	// WriteAt overwrites the bytes of the value already written, such as a header known after the rest of the value
+	WriteAt(p []byte, off int64) (int, error)
+	// Commit stores the value, all the bytes of the value must be written
+	Commit() error
+	// Abort drops the value, it does nothing after Commit
+	Abort()
+}
+
+// StreamPutter is implemented by the kvdbs which write a value in chunks without holding all of it in memory
+type StreamPutter interface {
+	// PutStream starts writing the value of the size by key, the writer must be committed or aborted
+	PutStream(key string, size int) (ValueWriter, error)
+}
+
+// NewValueWriter writes the value in chunks if the kvdb is a StreamPutter,
+// otherwise the value is buffered and put on commit
+func NewValueWriter(db KVDB, key string, size int) (ValueWriter, error) {
+	if sp, ok := db.(StreamPutter); ok {
+		return sp.PutStream(key, size)
+	}
+	if size < 0 {
+		return nil, ErrValueSize
+	}
+	return &bufferedWriter{db: db, key: key, buf: make([]byte, 0, size)}, nil
+}
+
+// bufferedWriter holds the value in memory until it is committed
+type bufferedWriter struct {
+	db   KVDB
+	key  string
+	buf  []byte
+	done bool
+}
+
+func (w *bufferedWriter) Write(p []byte) (int, error) {
+	if w.done {
+		return 0, ErrWriterDone
+	}
+	if len(w.buf)+len(p) > cap(w.buf) {
+		return 0, ErrValueSize
+	}
+	w.buf = append(w.buf, p...)
+	return len(p), nil
+}
+
+func (w *bufferedWriter) WriteAt(p []byte, off int64) (int, error) {
+	if w.done {
+		return 0, ErrWriterDone
+	}
+	if off < 0 || off+int64(len(p)) > int64(len(w.buf)) {
+		return 0, ErrValueSize
+	}
+	return copy(w.buf[off:], p), nil
+}
+
+func (w *bufferedWriter) Commit() error {
+	if w.done {
+		return ErrWriterDone
+	}
+	w.done = true
+	if len(w.buf) != cap(w.buf) {
+		return ErrValueSize
+	}
+	return w.db.Put(w.key, w.buf)
+}
+
+func (w *bufferedWriter) Abort() {
+	w.done = true
+	w.buf = nil
+}
@@ -70,6 +70,19 @@ func (l *objStoreMetaData) Delete(key string) error {
 	return l.DB.Delete([]byte(key), nil)
 }
 
+// Write applies the puts and deletes of the batch atomically
+func (l *objStoreMetaData) Write(batch *Batch) error {
+	b := new(leveldb.Batch)
+	for _, op := range batch.Ops {
+		if op.Delete {
+			b.Delete([]byte(op.Key))
+		} else {
+			b.Put([]byte(op.Key), op.Value)
+		}
+	}
+	return l.DB.Write(b, nil)
+}
+
 // NewIterator /**
 func (l *objStoreMetaData) NewIterator(slice *util.Range, ro *opt.ReadOptions) iterator.Iterator {
 	return l.DB.NewIterator(slice, ro)
@@ -5,6 +5,7 @@ import (
 	"github.com/syndtr/goleveldb/leveldb/iterator"
 	"github.com/syndtr/goleveldb/leveldb/opt"
 	"github.com/syndtr/goleveldb/leveldb/util"
+	"github.com/vmihailenco/msgpack/v5"
 )
 
 // ObjStoreMetaDBAPI object service data store API
@@ -13,6 +14,39 @@ type ObjStoreMetaDBAPI interface {
 	Put(key string, value interface{}) error
 	Get(key string, value interface{}) error
 	Delete(key string) error
+	Write(batch *Batch) error
 	NewIterator(slice *util.Range, ro *opt.ReadOptions) iterator.Iterator
 	ReadAllChan(ctx context.Context, prefix string, seekKey string) (<-chan *entry, error)
 }
+
+// Batch is a group of puts and deletes which Write applies atomically
+type Batch struct {
+	Ops []BatchOp
+}
+
+// BatchOp is a put or a delete of a batch, the value of a put is marshaled already
+type BatchOp struct {
+	Delete bool
+	Key    string
+	Value  []byte
+}
+
+// Put adds the put of the key to the batch
+func (b *Batch) Put(key string, value interface{}) error {
+	data, err := msgpack.Marshal(value)
+	if err != nil {
+		return err
+	}
+	b.Ops = append(b.Ops, BatchOp{Key: key, Value: data})
+	return nil
+}
+
+// Delete adds the delete of the key to the batch
+func (b *Batch) Delete(key string) {
+	b.Ops = append(b.Ops, BatchOp{Delete: true, Key: key})
+}
+
+// Len returns the number of the writes in the batch
+func (b *Batch) Len() int {
+	return len(b.Ops)
+}